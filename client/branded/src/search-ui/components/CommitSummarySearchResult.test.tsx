@@ -0,0 +1,45 @@
+import { cleanup, getByTestId } from '@testing-library/react'
+import { spy } from 'sinon'
+import { afterAll, describe, expect, it } from 'vitest'
+
+import { renderWithBrandedContext } from '@sourcegraph/wildcard/src/testing'
+
+import { CommitSummarySearchResult } from './CommitSummarySearchResult'
+
+describe('CommitSummarySearchResult', () => {
+    afterAll(cleanup)
+
+    it('renders commit authors', () => {
+        const { container } = renderWithBrandedContext(
+            <CommitSummarySearchResult
+                index={0}
+                onSelect={spy()}
+                result={{
+                    type: 'commitAuthor',
+                    repository: 'github.com/sourcegraph/sourcegraph',
+                    authorName: 'Alice',
+                    authorEmail: 'alice@example.com',
+                }}
+            />
+        )
+        expect(getByTestId(container, 'result-container')).toBeVisible()
+        expect(getByTestId(container, 'search-commit-summary-result')).toHaveTextContent('Alice <alice@example.com>')
+    })
+
+    it('renders commit groups', () => {
+        const { container } = renderWithBrandedContext(
+            <CommitSummarySearchResult
+                index={0}
+                onSelect={spy()}
+                result={{
+                    type: 'commitGroup',
+                    repository: 'github.com/sourcegraph/sourcegraph',
+                    commitCount: 1,
+                    diffCount: 2,
+                    query: 'repo:^github\\.com/sourcegraph/sourcegraph$ type:diff foo',
+                }}
+            />
+        )
+        expect(getByTestId(container, 'search-commit-summary-result')).toHaveTextContent('1 commit, 2 diffs.')
+    })
+})
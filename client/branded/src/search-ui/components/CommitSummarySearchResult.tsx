@@ -0,0 +1,83 @@
+import React from 'react'
+
+import classNames from 'classnames'
+
+import { pluralize } from '@sourcegraph/common'
+import { displayRepoName } from '@sourcegraph/shared/src/components/RepoLink'
+import { SearchPatternType } from '@sourcegraph/shared/src/graphql-operations'
+import type { CommitSummaryMatch } from '@sourcegraph/shared/src/search/stream'
+import { buildSearchURLQuery } from '@sourcegraph/shared/src/util/url'
+import { Link, Text } from '@sourcegraph/wildcard'
+
+import { ResultContainer } from './ResultContainer'
+
+import styles from './SearchResult.module.scss'
+
+export interface CommitSummarySearchResultProps {
+    result: CommitSummaryMatch
+    onSelect: () => void
+    containerClassName?: string
+    as?: React.ElementType
+    index: number
+}
+
+/**
+ * Renders a match summarizing the commits of a repository, such as a commit
+ * author for `select:commit.author`.
+ */
+export const CommitSummarySearchResult: React.FunctionComponent<CommitSummarySearchResultProps> = ({
+    result,
+    onSelect,
+    containerClassName,
+    as,
+    index,
+}) => {
+    const title = (
+        <div className={styles.title}>
+            <span className={classNames('test-search-result-label', styles.titleInner)}>
+                <Link to={'/' + encodeURI(result.repository)} data-selectable-search-result="true">
+                    {displayRepoName(result.repository)}
+                </Link>
+            </span>
+        </div>
+    )
+
+    return (
+        <ResultContainer
+            index={index}
+            title={title}
+            resultType="commit"
+            onResultClicked={onSelect}
+            repoName={result.repository}
+            repoStars={result.repoStars}
+            className={containerClassName}
+            repoLastFetched={result.repoLastFetched}
+            as={as}
+        >
+            <div data-testid="search-commit-summary-result" className={classNames(styles.searchResultMatch, 'p-3')}>
+                {result.type === 'commitGroup' ? (
+                    <Text className="mb-0">
+                        {result.commitCount} {pluralize('commit', result.commitCount)}, {result.diffCount}{' '}
+                        {pluralize('diff', result.diffCount)}.{' '}
+                        <Link to={`/search?${buildSearchURLQuery(result.query, SearchPatternType.standard, false)}`}>
+                            Show matches
+                        </Link>
+                    </Text>
+                ) : (
+                    <Text className="mb-0">
+                        {result.authorName !== undefined && (
+                            <>
+                                {result.authorName}
+                                {result.authorEmail && (
+                                    <span className="text-muted"> &lt;{result.authorEmail}&gt;</span>
+                                )}
+                            </>
+                        )}
+                        {result.date !== undefined && <>Commits authored on {result.date}</>}
+                    </Text>
+                )}
+            </div>
+        </ResultContainer>
+    )
+}
+
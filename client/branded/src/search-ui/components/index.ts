@@ -6,6 +6,7 @@ export * from './CodeExcerpt'
 export * from './CodeHostIcon'
 export * from './CommitSearchResult'
 export * from './CommitSearchResultMatch'
+export * from './CommitSummarySearchResult'
 export * from './CopyPathAction'
 export * from './FileContentSearchResult'
 export * from './LastSyncedIcon'
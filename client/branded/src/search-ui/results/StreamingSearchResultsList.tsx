@@ -15,6 +15,7 @@ import {
     type AggregateStreamingSearchResults,
     getMatchUrl,
     getRevision,
+    isCommitSummaryMatch,
     type SearchMatch,
 } from '@sourcegraph/shared/src/search/stream'
 import type { SettingsCascadeProps } from '@sourcegraph/shared/src/settings/settings'
@@ -22,6 +23,7 @@ import type { TelemetryProps } from '@sourcegraph/shared/src/telemetry/telemetry
 
 import {
     CommitSearchResult,
+    CommitSummarySearchResult,
     FileContentSearchResult,
     FilePathSearchResult,
     RepoSearchResult,
@@ -120,7 +122,7 @@ export const StreamingSearchResultsList: React.FunctionComponent<
 
     const renderResult = useCallback(
         (result: SearchMatch, index: number): JSX.Element => {
-            function renderResultContent(): JSX.Element {
+            function renderResultContent(): JSX.Element | null {
                 switch (result.type) {
                     case 'content':
                     case 'symbol':
@@ -219,6 +221,23 @@ export const StreamingSearchResultsList: React.FunctionComponent<
                             />
                         )
                     }
+                    default: {
+                        // The stream also sends matches that aren't part of
+                        // SearchMatch, e.g. for select:commit.author.
+                        const match = result as { type: string }
+                        if (isCommitSummaryMatch(match)) {
+                            return (
+                                <CommitSummarySearchResult
+                                    index={index}
+                                    result={match}
+                                    onSelect={() => logSearchResultClicked?.(index, match.type, resultsNumber)}
+                                    containerClassName={resultClassName}
+                                    as="li"
+                                />
+                            )
+                        }
+                        return null
+                    }
                 }
             }
 
@@ -311,5 +330,12 @@ function itemKey(item: SearchMatch): string {
     if (item.type === 'symbol') {
         return `file:${getMatchUrl(item)}`
     }
+    const match = item as { type: string }
+    if (isCommitSummaryMatch(match)) {
+        // A later summary of the same repository has larger counts.
+        return match.type === 'commitGroup'
+            ? `commitGroup:${match.repository}:${match.commitCount}:${match.diffCount}`
+            : `commitAuthor:${match.repository}:${match.authorName}:${match.authorEmail}:${match.date}`
+    }
     return getMatchUrl(item)
 }
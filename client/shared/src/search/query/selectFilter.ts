@@ -49,6 +49,8 @@ export const SELECTORS: Access[] = [
     },
     {
         name: 'commit',
+        // commit.author and commit.date are not offered: their results are only
+        // returned by the stream API and aren't displayed by the web app.
         fields: [{ name: 'diff', fields: [{ name: 'added' }, { name: 'removed' }] }],
     },
 ]
@@ -147,6 +147,98 @@ export interface CommitMatch {
     content: MarkdownText
     // Array of [line, character, length] triplets
     ranges: number[][]
+    // Number of modified files per detected language
+    languages?: Record<string, number>
+    // URL of the commit in the format of git format-patch. Only set if
+    // requested with the `pu` parameter.
+    patchURL?: string
+    // Structured form of the diff in content. Only set for diff matches if
+    // requested with the `sd` parameter.
+    diff?: DiffFile[]
+}
+
+export interface DiffFile {
+    // /dev/null for added and deleted files respectively
+    origName: string
+    newName: string
+    hunks: DiffHunk[]
+}
+
+export interface DiffHunk {
+    oldStart: number
+    oldCount: number
+    newStart: number
+    newCount: number
+    header?: string
+    lines: DiffLine[]
+    // Symbols enclosing the changes of the hunk. Only set if the symbols of
+    // the changed file were resolved.
+    symbols?: DiffSymbol[]
+}
+
+export interface DiffSymbol {
+    name: string
+    // The kind of the symbol as used by select:symbol, e.g. 'function'
+    kind: string
+}
+
+export interface DiffLine {
+    kind: 'added' | 'removed' | 'context'
+    // The line without its '+', '-' or ' ' prefix
+    content: string
+    // Array of [character, length] pairs relative to content
+    ranges?: number[][]
+}
+
+/**
+ * Summarizes the commit and diff matches of a repository. Only sent instead of
+ * commit matches when requested with the `gr` parameter. The web app never
+ * sets it, so this is not part of SearchMatch, but it is rendered if it is
+ * received. A later summary for the same repository replaces the earlier one.
+ */
+export interface CommitGroupMatch {
+    type: 'commitGroup'
+    repository: string
+    repoStars?: number
+    repoLastFetched?: string
+    commitCount: number
+    diffCount: number
+    // Query listing the grouped matches
+    query: string
+}
+
+/**
+ * The author or the authoring day of commits in a repository. Only sent for
+ * queries with `select:commit.author` or `select:commit.date`. These selectors
+ * are primarily meant for API clients and aren't suggested by the web app,
+ * which is why this is not part of SearchMatch. The web app still renders
+ * these matches for queries that use the selectors.
+ */
+export interface CommitAuthorMatch {
+    type: 'commitAuthor'
+    repository: string
+    repoStars?: number
+    repoLastFetched?: string
+    // Set for select:commit.author
+    authorName?: string
+    authorEmail?: string
+    // UTC day in the format YYYY-MM-DD, set for select:commit.date
+    date?: string
+}
+
+/**
+ * Matches summarizing the commits of a repository. They're sent over the stream
+ * but aren't part of SearchMatch, see isCommitSummaryMatch.
+ */
+export type CommitSummaryMatch = CommitGroupMatch | CommitAuthorMatch
+
+/**
+ * Returns true if the given match received over the stream is a
+ * CommitSummaryMatch. Since those aren't part of SearchMatch, code switching
+ * over the type of a SearchMatch has to check for them separately.
+ */
+export function isCommitSummaryMatch(match: { type: string }): match is CommitSummaryMatch {
+    return match.type === 'commitGroup' || match.type === 'commitAuthor'
 }
 
 export interface RepositoryMatch {
@@ -33,6 +33,7 @@ type ExternalServicesService interface {
 	ValidateConnection(ctx context.Context, svc *types.ExternalService) error
 	ListNamespaces(ctx context.Context, externalServiceID *int64, kind string, config string) ([]*types.ExternalServiceNamespace, error)
 	DiscoverRepos(ctx context.Context, externalServiceID *int64, kind string, config string, first int32, query string, excludeRepos []string) ([]*types.ExternalServiceRepository, error)
+	PreviewPackagesSync(ctx context.Context, externalServiceID *int64, kind string, config string, sampleSize int) (*internalrepos.PackagesSyncPreview, error)
 	ExcludeRepoFromExternalServices(context.Context, []int64, api.RepoID) error
 }
 
@@ -249,6 +250,61 @@ func (e *externalServices) DiscoverRepos(ctx context.Context, externalServiceID
 	return repositories, nil
 }
 
+// PreviewPackagesSync reports which repositories a sync of the given package
+// host connection would add, change and remove, without writing anything. When
+// externalServiceID is nil the connection is described by kind and config and
+// is assumed to not own any repositories yet.
+//
+// 🚨 SECURITY: Callers must check that the current user is a site admin.
+func (e *externalServices) PreviewPackagesSync(ctx context.Context, externalServiceID *int64, kind string, config string, sampleSize int) (*internalrepos.PackagesSyncPreview, error) {
+	var externalSvc *types.ExternalService
+	if externalServiceID != nil {
+		var err error
+		externalSvc, err = e.db.ExternalServices().GetByID(ctx, *externalServiceID)
+		if err != nil {
+			return nil, err
+		}
+		if config != "" {
+			externalSvc.Config = extsvc.NewUnencryptedConfig(config)
+		}
+	} else {
+		externalSvc = &types.ExternalService{
+			Kind:   kind,
+			Config: extsvc.NewUnencryptedConfig(config),
+		}
+	}
+
+	var (
+		genericSrc internalrepos.Source
+		err        error
+	)
+	if e.mockSourcer != nil {
+		genericSrc, err = e.mockSourcer(ctx, externalSvc)
+	} else {
+		genericSrc, err = newGenericSourcer(log.Scoped("externalservice.packagessyncpreview"), e.db)(ctx, externalSvc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	packagesSrc, ok := genericSrc.(*internalrepos.PackagesSource)
+	if !ok {
+		return nil, errors.Newf("external service kind %q is not a package host", externalSvc.Kind)
+	}
+
+	var existing types.Repos
+	if externalServiceID != nil {
+		existing, err = e.db.Repos().List(ctx, database.ReposListOptions{
+			ExternalServiceIDs: []int64{*externalServiceID},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return packagesSrc.PreviewSync(ctx, existing, sampleSize)
+}
+
 // ExcludeRepoFromExternalServices excludes given repo from given external service config.
 //
 // Function is pretty beefy, what it does is:
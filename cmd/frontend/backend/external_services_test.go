@@ -683,3 +683,17 @@ func (t testSource) WithAuthenticator(_ auth.Authenticator) (internalrepos.Sourc
 func (t testSource) ValidateAuthenticator(_ context.Context) error {
 	return t.fn()
 }
+
+func TestExternalService_PreviewPackagesSync_NotPackageHost(t *testing.T) {
+	githubConnection := `{"url": "https://github.com", "token": "secret-token"}`
+	src := internalrepos.NewFakeSource(&types.ExternalService{
+		Kind:   extsvc.KindGitHub,
+		Config: extsvc.NewUnencryptedConfig(githubConnection),
+	}, nil)
+
+	e := NewMockExternalServices(logtest.Scoped(t), nil, internalrepos.NewFakeSourcer(nil, src))
+	_, err := e.PreviewPackagesSync(context.Background(), nil, extsvc.KindGitHub, githubConnection, 0)
+	if have, want := fmt.Sprint(err), "is not a package host"; !strings.Contains(have, want) {
+		t.Fatalf("have err: %q, want: %q", have, want)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	api "github.com/sourcegraph/sourcegraph/internal/api"
 	database "github.com/sourcegraph/sourcegraph/internal/database"
 	inventory "github.com/sourcegraph/sourcegraph/internal/inventory"
+	repos "github.com/sourcegraph/sourcegraph/internal/repos"
 	types "github.com/sourcegraph/sourcegraph/internal/types"
 )
 
@@ -32,6 +33,9 @@ type MockExternalServicesService struct {
 	// ListNamespacesFunc is an instance of a mock function object
 	// controlling the behavior of the method ListNamespaces.
 	ListNamespacesFunc *ExternalServicesServiceListNamespacesFunc
+	// PreviewPackagesSyncFunc is an instance of a mock function object
+	// controlling the behavior of the method PreviewPackagesSync.
+	PreviewPackagesSyncFunc *ExternalServicesServicePreviewPackagesSyncFunc
 	// ValidateConnectionFunc is an instance of a mock function object
 	// controlling the behavior of the method ValidateConnection.
 	ValidateConnectionFunc *ExternalServicesServiceValidateConnectionFunc
@@ -57,6 +61,11 @@ func NewMockExternalServicesService() *MockExternalServicesService {
 				return
 			},
 		},
+		PreviewPackagesSyncFunc: &ExternalServicesServicePreviewPackagesSyncFunc{
+			defaultHook: func(context.Context, *int64, string, string, int) (r0 *repos.PackagesSyncPreview, r1 error) {
+				return
+			},
+		},
 		ValidateConnectionFunc: &ExternalServicesServiceValidateConnectionFunc{
 			defaultHook: func(context.Context, *types.ExternalService) (r0 error) {
 				return
@@ -85,6 +94,11 @@ func NewStrictMockExternalServicesService() *MockExternalServicesService {
 				panic("unexpected invocation of MockExternalServicesService.ListNamespaces")
 			},
 		},
+		PreviewPackagesSyncFunc: &ExternalServicesServicePreviewPackagesSyncFunc{
+			defaultHook: func(context.Context, *int64, string, string, int) (*repos.PackagesSyncPreview, error) {
+				panic("unexpected invocation of MockExternalServicesService.PreviewPackagesSync")
+			},
+		},
 		ValidateConnectionFunc: &ExternalServicesServiceValidateConnectionFunc{
 			defaultHook: func(context.Context, *types.ExternalService) error {
 				panic("unexpected invocation of MockExternalServicesService.ValidateConnection")
@@ -107,6 +121,9 @@ func NewMockExternalServicesServiceFrom(i ExternalServicesService) *MockExternal
 		ListNamespacesFunc: &ExternalServicesServiceListNamespacesFunc{
 			defaultHook: i.ListNamespaces,
 		},
+		PreviewPackagesSyncFunc: &ExternalServicesServicePreviewPackagesSyncFunc{
+			defaultHook: i.PreviewPackagesSync,
+		},
 		ValidateConnectionFunc: &ExternalServicesServiceValidateConnectionFunc{
 			defaultHook: i.ValidateConnection,
 		},
@@ -472,6 +489,127 @@ func (c ExternalServicesServiceListNamespacesFuncCall) Results() []interface{} {
 	return []interface{}{c.Result0, c.Result1}
 }
 
+// ExternalServicesServicePreviewPackagesSyncFunc describes the behavior when the
+// PreviewPackagesSync method of the parent MockExternalServicesService instance
+// is invoked.
+type ExternalServicesServicePreviewPackagesSyncFunc struct {
+	defaultHook func(context.Context, *int64, string, string, int) (*repos.PackagesSyncPreview, error)
+	hooks       []func(context.Context, *int64, string, string, int) (*repos.PackagesSyncPreview, error)
+	history     []ExternalServicesServicePreviewPackagesSyncFuncCall
+	mutex       sync.Mutex
+}
+
+// PreviewPackagesSync delegates to the next hook function in the queue and
+// stores the parameter and result values of this invocation.
+func (m *MockExternalServicesService) PreviewPackagesSync(v0 context.Context, v1 *int64, v2 string, v3 string, v4 int) (*repos.PackagesSyncPreview, error) {
+	r0, r1 := m.PreviewPackagesSyncFunc.nextHook()(v0, v1, v2, v3, v4)
+	m.PreviewPackagesSyncFunc.appendCall(ExternalServicesServicePreviewPackagesSyncFuncCall{v0, v1, v2, v3, v4, r0, r1})
+	return r0, r1
+}
+
+// SetDefaultHook sets function that is called when the PreviewPackagesSync
+// method of the parent MockExternalServicesService instance is invoked and
+// the hook queue is empty.
+func (f *ExternalServicesServicePreviewPackagesSyncFunc) SetDefaultHook(hook func(context.Context, *int64, string, string, int) (*repos.PackagesSyncPreview, error)) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// PreviewPackagesSync method of the parent MockExternalServicesService instance
+// invokes the hook at the front of the queue and discards it. After the
+// queue is empty, the default hook function is invoked for any future
+// action.
+func (f *ExternalServicesServicePreviewPackagesSyncFunc) PushHook(hook func(context.Context, *int64, string, string, int) (*repos.PackagesSyncPreview, error)) {
+	f.mutex.Lock()
+	f.hooks = append(f.hooks, hook)
+	f.mutex.Unlock()
+}
+
+// SetDefaultReturn calls SetDefaultHook with a function that returns the
+// given values.
+func (f *ExternalServicesServicePreviewPackagesSyncFunc) SetDefaultReturn(r0 *repos.PackagesSyncPreview, r1 error) {
+	f.SetDefaultHook(func(context.Context, *int64, string, string, int) (*repos.PackagesSyncPreview, error) {
+		return r0, r1
+	})
+}
+
+// PushReturn calls PushHook with a function that returns the given values.
+func (f *ExternalServicesServicePreviewPackagesSyncFunc) PushReturn(r0 *repos.PackagesSyncPreview, r1 error) {
+	f.PushHook(func(context.Context, *int64, string, string, int) (*repos.PackagesSyncPreview, error) {
+		return r0, r1
+	})
+}
+
+func (f *ExternalServicesServicePreviewPackagesSyncFunc) nextHook() func(context.Context, *int64, string, string, int) (*repos.PackagesSyncPreview, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+func (f *ExternalServicesServicePreviewPackagesSyncFunc) appendCall(r0 ExternalServicesServicePreviewPackagesSyncFuncCall) {
+	f.mutex.Lock()
+	f.history = append(f.history, r0)
+	f.mutex.Unlock()
+}
+
+// History returns a sequence of
+// ExternalServicesServicePreviewPackagesSyncFuncCall objects describing the
+// invocations of this function.
+func (f *ExternalServicesServicePreviewPackagesSyncFunc) History() []ExternalServicesServicePreviewPackagesSyncFuncCall {
+	f.mutex.Lock()
+	history := make([]ExternalServicesServicePreviewPackagesSyncFuncCall, len(f.history))
+	copy(history, f.history)
+	f.mutex.Unlock()
+
+	return history
+}
+
+// ExternalServicesServicePreviewPackagesSyncFuncCall is an object that describes
+// an invocation of method PreviewPackagesSync on an instance of
+// MockExternalServicesService.
+type ExternalServicesServicePreviewPackagesSyncFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 context.Context
+	// Arg1 is the value of the 2nd argument passed to this method
+	// invocation.
+	Arg1 *int64
+	// Arg2 is the value of the 3rd argument passed to this method
+	// invocation.
+	Arg2 string
+	// Arg3 is the value of the 4th argument passed to this method
+	// invocation.
+	Arg3 string
+	// Arg4 is the value of the 5th argument passed to this method
+	// invocation.
+	Arg4 int
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 *repos.PackagesSyncPreview
+	// Result1 is the value of the 2nd result returned from this method
+	// invocation.
+	Result1 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c ExternalServicesServicePreviewPackagesSyncFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0, c.Arg1, c.Arg2, c.Arg3, c.Arg4}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c ExternalServicesServicePreviewPackagesSyncFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0, c.Result1}
+}
+
 // ExternalServicesServiceValidateConnectionFunc describes the behavior when
 // the ValidateConnection method of the parent MockExternalServicesService
 // instance is invoked.
@@ -2,6 +2,8 @@ package graphqlbackend
 
 import (
 	"context"
+
+	"github.com/sourcegraph/sourcegraph/internal/gqlutil"
 )
 
 type ComputeArgs struct {
@@ -10,6 +12,14 @@ type ComputeArgs struct {
 
 type ComputeResolver interface {
 	Compute(ctx context.Context, args *ComputeArgs) ([]ComputeResultResolver, error)
+	SearchQuotaUsage(ctx context.Context) ([]SearchQuotaUsageResolver, error)
+}
+
+type SearchQuotaUsageResolver interface {
+	Kind() string
+	Used() int32
+	Limit() int32
+	ResetsAt() *gqlutil.DateTime
 }
 
 type ComputeResultResolver interface {
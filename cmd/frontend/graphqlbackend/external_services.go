@@ -19,6 +19,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
 	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/auth"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/database"
@@ -621,6 +622,94 @@ type externalServiceRepositoryConnectionResolver struct {
 	err   error
 }
 
+type externalServicePackagesSyncPreviewArgs struct {
+	ID         *graphql.ID
+	Kind       string
+	Config     *string
+	SampleSize *int32
+}
+
+func (r *schemaResolver) ExternalServicePackagesSyncPreview(ctx context.Context, args *externalServicePackagesSyncPreviewArgs) (*externalServicePackagesSyncPreviewResolver, error) {
+	// 🚨 SECURITY: Only site admins may preview syncs, the preview reads the
+	// connection's configuration and lists private package repos.
+	if auth.CheckCurrentUserIsSiteAdmin(ctx, r.db) != nil {
+		return nil, auth.ErrMustBeSiteAdmin
+	}
+
+	externalServiceID, err := TryUnmarshalExternalServiceID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var config string
+	if args.Config != nil {
+		config = *args.Config
+	}
+	if externalServiceID == nil && config == "" {
+		return nil, errors.New("either id or config must be given")
+	}
+
+	var sampleSize int
+	if args.SampleSize != nil {
+		sampleSize = int(*args.SampleSize)
+	}
+
+	e := newExternalServices(log.Scoped("graphql.externalservicepackagessyncpreview"), r.db)
+	preview, err := e.PreviewPackagesSync(ctx, externalServiceID, args.Kind, config, sampleSize)
+	if err != nil {
+		return nil, err
+	}
+	return &externalServicePackagesSyncPreviewResolver{preview: preview}, nil
+}
+
+type externalServicePackagesSyncPreviewResolver struct {
+	preview *repos.PackagesSyncPreview
+}
+
+func (r *externalServicePackagesSyncPreviewResolver) Scheme() string {
+	return r.preview.Scheme
+}
+
+func (r *externalServicePackagesSyncPreviewResolver) NewCount() int32 {
+	return int32(r.preview.New)
+}
+
+func (r *externalServicePackagesSyncPreviewResolver) ChangedCount() int32 {
+	return int32(r.preview.Changed)
+}
+
+func (r *externalServicePackagesSyncPreviewResolver) RemovedCount() int32 {
+	return int32(r.preview.Removed)
+}
+
+func (r *externalServicePackagesSyncPreviewResolver) UnchangedCount() int32 {
+	return int32(r.preview.Unchanged)
+}
+
+func (r *externalServicePackagesSyncPreviewResolver) NewSample() []string {
+	return repoNamesToStrings(r.preview.NewSample)
+}
+
+func (r *externalServicePackagesSyncPreviewResolver) ChangedSample() []string {
+	return repoNamesToStrings(r.preview.ChangedSample)
+}
+
+func (r *externalServicePackagesSyncPreviewResolver) RemovedSample() []string {
+	return repoNamesToStrings(r.preview.RemovedSample)
+}
+
+func (r *externalServicePackagesSyncPreviewResolver) Truncated() bool {
+	return r.preview.Truncated
+}
+
+func repoNamesToStrings(names []api.RepoName) []string {
+	strs := make([]string, len(names))
+	for i, name := range names {
+		strs[i] = string(name)
+	}
+	return strs
+}
+
 // NewSourceConfiguration returns a configuration string for defining a Source for discovery.
 // Only external service kinds that implement source discovery functions are returned.
 func NewSourceConfiguration(kind, url, token string) (string, error) {
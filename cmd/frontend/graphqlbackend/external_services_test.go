@@ -29,6 +29,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/repos"
 	"github.com/sourcegraph/sourcegraph/internal/timeutil"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 	"github.com/sourcegraph/sourcegraph/schema"
@@ -1798,3 +1799,112 @@ func (h handle) Transact(context.Context) (basestore.TransactableHandle, error)
 func (handle) InTransaction() bool {
 	return false
 }
+
+func TestExternalServicePackagesSyncPreview(t *testing.T) {
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+
+	query := `query ExternalServicePackagesSyncPreview($id: ID, $kind: ExternalServiceKind!, $config: String, $sampleSize: Int) {
+		externalServicePackagesSyncPreview(id: $id, kind: $kind, config: $config, sampleSize: $sampleSize) {
+			scheme
+			newCount
+			changedCount
+			removedCount
+			unchangedCount
+			newSample
+			changedSample
+			removedSample
+			truncated
+		}
+	}`
+
+	mockPreview := func(t *testing.T) *backend.MockExternalServicesService {
+		t.Helper()
+
+		es := backend.NewStrictMockExternalServicesService()
+		es.PreviewPackagesSyncFunc.SetDefaultReturn(&repos.PackagesSyncPreview{
+			Scheme:        "npm",
+			New:           2,
+			Changed:       1,
+			Unchanged:     3,
+			NewSample:     []api.RepoName{"npm/left-pad"},
+			ChangedSample: []api.RepoName{"npm/right-pad"},
+			Truncated:     true,
+		}, nil)
+
+		mockExternalServicesService = es
+		t.Cleanup(func() { mockExternalServicesService = nil })
+		return es
+	}
+
+	t.Run("as an admin", func(t *testing.T) {
+		users := dbmocks.NewMockUserStore()
+		users.GetByCurrentAuthUserFunc.SetDefaultReturn(&types.User{SiteAdmin: true}, nil)
+
+		db := dbmocks.NewMockDB()
+		db.UsersFunc.SetDefaultReturn(users)
+
+		es := mockPreview(t)
+
+		RunTest(t, &Test{
+			Schema: mustParseGraphQLSchema(t, db),
+			Query:  query,
+			ExpectedResult: `{ "externalServicePackagesSyncPreview": {
+				"scheme": "npm",
+				"newCount": 2,
+				"changedCount": 1,
+				"removedCount": 0,
+				"unchangedCount": 3,
+				"newSample": ["npm/left-pad"],
+				"changedSample": ["npm/right-pad"],
+				"removedSample": [],
+				"truncated": true
+			}}`,
+			Context: ctx,
+			Variables: map[string]any{
+				"id":         string(MarshalExternalServiceID(1)),
+				"kind":       extsvc.KindNpmPackages,
+				"sampleSize": 5,
+			},
+		})
+
+		history := es.PreviewPackagesSyncFunc.History()
+		if len(history) != 1 {
+			t.Fatalf("expected 1 preview call, got %d", len(history))
+		}
+		if call := history[0]; call.Arg1 == nil || *call.Arg1 != 1 || call.Arg3 != "" || call.Arg4 != 5 {
+			t.Fatalf("unexpected preview call arguments: %v", call.Args())
+		}
+	})
+
+	t.Run("as a non-admin", func(t *testing.T) {
+		users := dbmocks.NewMockUserStore()
+		users.GetByCurrentAuthUserFunc.SetDefaultReturn(&types.User{SiteAdmin: false}, nil)
+
+		db := dbmocks.NewMockDB()
+		db.UsersFunc.SetDefaultReturn(users)
+
+		es := mockPreview(t)
+
+		RunTest(t, &Test{
+			Schema:         mustParseGraphQLSchema(t, db),
+			Query:          query,
+			ExpectedResult: `null`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{
+					Path:          []any{"externalServicePackagesSyncPreview"},
+					Message:       auth.ErrMustBeSiteAdmin.Error(),
+					ResolverError: auth.ErrMustBeSiteAdmin,
+				},
+			},
+			Context: ctx,
+			Variables: map[string]any{
+				"id":   string(MarshalExternalServiceID(1)),
+				"kind": extsvc.KindNpmPackages,
+			},
+		})
+
+		if len(es.PreviewPackagesSyncFunc.History()) != 0 {
+			t.Fatal("expected no preview for a non-admin")
+		}
+	})
+}
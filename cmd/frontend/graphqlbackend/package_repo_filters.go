@@ -63,7 +63,7 @@ func (r *schemaResolver) PackageRepoReferencesMatchingFilter(ctx context.Context
 	depsService := dependencies.NewService(observation.NewContext(r.logger), r.db)
 
 	matchingPkgs, totalCount, hasMore, err := depsService.PackagesOrVersionsMatchingFilter(ctx, shared.MinimalPackageFilter{
-		PackageScheme: externalServiceToPackageSchemeMap[args.Kind],
+		PackageScheme: externalServiceToPackageScheme(args.Kind),
 		NameFilter:    args.Filter.NameFilter,
 		VersionFilter: args.Filter.VersionFilter,
 	}, limit, after)
@@ -104,7 +104,7 @@ func (r *schemaResolver) PackageRepoFilters(ctx context.Context, args struct {
 	}
 
 	if args.Kind != nil {
-		opts.PackageScheme = externalServiceToPackageSchemeMap[*args.Kind]
+		opts.PackageScheme = externalServiceToPackageScheme(*args.Kind)
 	}
 
 	depsService := dependencies.NewService(observation.NewContext(r.logger), r.db)
@@ -138,7 +138,7 @@ func (r *packageRepoFilterResolver) Behaviour() string {
 }
 
 func (r *packageRepoFilterResolver) Kind() string {
-	return packageSchemeToExternalServiceMap[r.filter.PackageScheme]
+	return packageSchemeToExternalService(r.filter.PackageScheme)
 }
 
 func (r *packageRepoFilterResolver) NameFilter() *packageRepoNameFilterResolver {
@@ -198,7 +198,7 @@ func (r *schemaResolver) AddPackageRepoFilter(ctx context.Context, args struct {
 
 	filter := shared.MinimalPackageFilter{
 		Behaviour:     &args.Behaviour,
-		PackageScheme: externalServiceToPackageSchemeMap[args.Kind],
+		PackageScheme: externalServiceToPackageScheme(args.Kind),
 		NameFilter:    args.Filter.NameFilter,
 		VersionFilter: args.Filter.VersionFilter,
 	}
@@ -232,7 +232,7 @@ func (r *schemaResolver) UpdatePackageRepoFilter(ctx context.Context, args *stru
 	return &EmptyResponse{}, depsService.UpdatePackageRepoFilter(ctx, shared.PackageRepoFilter{
 		ID:            filterID,
 		Behaviour:     args.Behaviour,
-		PackageScheme: externalServiceToPackageSchemeMap[args.Kind],
+		PackageScheme: externalServiceToPackageScheme(args.Kind),
 		NameFilter:    args.Filter.NameFilter,
 		VersionFilter: args.Filter.VersionFilter,
 	})
@@ -12,7 +12,6 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/dependencies"
 	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
 	"github.com/sourcegraph/sourcegraph/internal/database"
-	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/observation"
 	"github.com/sourcegraph/sourcegraph/internal/types"
@@ -24,24 +23,23 @@ type PackageRepoReferenceConnectionArgs struct {
 	After *string
 	Kind  *string
 	Name  *string
+	Fuzzy bool
 }
 
-var externalServiceToPackageSchemeMap = map[string]string{
-	extsvc.KindJVMPackages:    dependencies.JVMPackagesScheme,
-	extsvc.KindNpmPackages:    dependencies.NpmPackagesScheme,
-	extsvc.KindGoPackages:     dependencies.GoPackagesScheme,
-	extsvc.KindPythonPackages: dependencies.PythonPackagesScheme,
-	extsvc.KindRustPackages:   dependencies.RustPackagesScheme,
-	extsvc.KindRubyPackages:   dependencies.RubyPackagesScheme,
+// externalServiceToPackageScheme returns the package scheme synced by external
+// services of the given kind, or the empty string if the kind doesn't sync
+// package repos.
+func externalServiceToPackageScheme(kind string) string {
+	scheme, _ := dependencies.LookupPackageSchemeByExternalServiceKind(kind)
+	return scheme.Name
 }
 
-var packageSchemeToExternalServiceMap = map[string]string{
-	dependencies.JVMPackagesScheme:    extsvc.KindJVMPackages,
-	dependencies.NpmPackagesScheme:    extsvc.KindNpmPackages,
-	dependencies.GoPackagesScheme:     extsvc.KindGoPackages,
-	dependencies.PythonPackagesScheme: extsvc.KindPythonPackages,
-	dependencies.RustPackagesScheme:   extsvc.KindRustPackages,
-	dependencies.RubyPackagesScheme:   extsvc.KindRubyPackages,
+// packageSchemeToExternalService returns the kind of the external service that
+// syncs package repos of the given scheme, or the empty string if the scheme
+// isn't supported.
+func packageSchemeToExternalService(scheme string) string {
+	packageScheme, _ := dependencies.LookupPackageScheme(scheme)
+	return packageScheme.ExternalServiceKind
 }
 
 func (r *schemaResolver) PackageRepoReferences(ctx context.Context, args *PackageRepoReferenceConnectionArgs) (_ *packageRepoReferenceConnectionResolver, err error) {
@@ -52,15 +50,16 @@ func (r *schemaResolver) PackageRepoReferences(ctx context.Context, args *Packag
 	}
 
 	if args.Kind != nil {
-		packageScheme, ok := externalServiceToPackageSchemeMap[*args.Kind]
+		packageScheme, ok := dependencies.LookupPackageSchemeByExternalServiceKind(*args.Kind)
 		if !ok {
 			return nil, errors.Errorf("unknown package scheme %q", *args.Kind)
 		}
-		opts.Scheme = packageScheme
+		opts.Scheme = packageScheme.Name
 	}
 
 	if args.Name != nil {
 		opts.Name = reposource.PackageName(*args.Name)
+		opts.Fuzzy = args.Fuzzy
 	}
 
 	opts.Limit = int(args.GetFirst())
@@ -79,6 +78,46 @@ func (r *schemaResolver) PackageRepoReferences(ctx context.Context, args *Packag
 	return &packageRepoReferenceConnectionResolver{r.db, deps, hasMore, total}, err
 }
 
+type ResolvePackageRepoReferenceVersionArgs struct {
+	Kind         string
+	Name         string
+	Version      string
+	AllowNearest bool
+}
+
+func (r *schemaResolver) ResolvePackageRepoReferenceVersion(ctx context.Context, args *ResolvePackageRepoReferenceVersionArgs) (*resolvedPackageRepoReferenceVersionResolver, error) {
+	packageScheme, ok := dependencies.LookupPackageSchemeByExternalServiceKind(args.Kind)
+	if !ok {
+		return nil, errors.Errorf("unknown package scheme %q", args.Kind)
+	}
+
+	depsService := dependencies.NewService(observation.NewContext(r.logger), r.db)
+	resolved, found, err := depsService.ResolvePackageRepoRefVersion(ctx, dependencies.ResolvePackageRepoRefVersionOpts{
+		Scheme:       packageScheme.Name,
+		Name:         reposource.PackageName(args.Name),
+		Version:      args.Version,
+		AllowNearest: args.AllowNearest,
+		Gitserver:    r.gitserverClient,
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &resolvedPackageRepoReferenceVersionResolver{resolved}, nil
+}
+
+type resolvedPackageRepoReferenceVersionResolver struct {
+	resolved dependencies.ResolvedPackageRepoRefVersion
+}
+
+func (r *resolvedPackageRepoReferenceVersionResolver) Version() *packageRepoReferenceVersionResolver {
+	return &packageRepoReferenceVersionResolver{r.resolved.PackageRepoRefVersion}
+}
+
+func (r *resolvedPackageRepoReferenceVersionResolver) Approximate() bool {
+	return r.resolved.Approximate
+}
+
 type packageRepoReferenceConnectionResolver struct {
 	db      database.DB
 	deps    []dependencies.PackageRepoReference
@@ -173,7 +212,7 @@ func (r *packageRepoReferenceResolver) ID() graphql.ID {
 }
 
 func (r *packageRepoReferenceResolver) Kind() string {
-	return packageSchemeToExternalServiceMap[r.dep.Scheme]
+	return packageSchemeToExternalService(r.dep.Scheme)
 }
 
 func (r *packageRepoReferenceResolver) Name() string {
@@ -226,33 +265,10 @@ func (r *packageRepoReferenceVersionResolver) Version() string {
 	return r.version.Version
 }
 
-func dependencyRepoToRepoName(dep dependencies.PackageRepoReference) (repoName api.RepoName, _ error) {
-	switch dep.Scheme {
-	case "python":
-		repoName = reposource.ParsePythonPackageFromName(dep.Name).RepoName()
-	case "scip-ruby":
-		repoName = reposource.ParseRubyPackageFromName(dep.Name).RepoName()
-	case "semanticdb":
-		pkg, err := reposource.ParseMavenPackageFromName(dep.Name)
-		if err != nil {
-			return "", err
-		}
-		repoName = pkg.RepoName()
-	case "npm":
-		pkg, err := reposource.ParseNpmPackageFromPackageSyntax(dep.Name)
-		if err != nil {
-			return "", err
-		}
-		repoName = pkg.RepoName()
-	case "rust-analyzer":
-		repoName = reposource.ParseRustPackageFromName(dep.Name).RepoName()
-	case "go":
-		pkg, err := reposource.ParseGoDependencyFromName(dep.Name)
-		if err != nil {
-			return "", err
-		}
-		repoName = pkg.RepoName()
+func dependencyRepoToRepoName(dep dependencies.PackageRepoReference) (api.RepoName, error) {
+	scheme, ok := dependencies.LookupPackageScheme(dep.Scheme)
+	if !ok {
+		return "", nil
 	}
-
-	return repoName, nil
+	return scheme.RepoName(dep.Name)
 }
@@ -49,6 +49,17 @@ func (a searchAlertResolver) ProposedQueries() *[]*searchQueryDescriptionResolve
 	return &proposedQueries
 }
 
+func (a searchAlertResolver) GeneratedQueries() *[]*searchQueryDescriptionResolver {
+	if len(a.alert.GeneratedQueries) == 0 {
+		return nil
+	}
+	generatedQueries := make([]*searchQueryDescriptionResolver, 0, len(a.alert.GeneratedQueries))
+	for _, q := range a.alert.GeneratedQueries {
+		generatedQueries = append(generatedQueries, &searchQueryDescriptionResolver{q})
+	}
+	return &generatedQueries
+}
+
 func (a searchAlertResolver) wrapSearchImplementer(db database.DB) *alertSearchImplementer {
 	return &alertSearchImplementer{
 		db:    db,
@@ -297,8 +297,8 @@ loop:
 	for _, r := range sr.Matches {
 		r := r // shadow so it doesn't change in the goroutine
 		switch m := r.(type) {
-		case *result.RepoMatch, *result.OwnerMatch:
-			// We don't care about repo or owner results here.
+		case *result.RepoMatch, *result.OwnerMatch, *result.CommitGroupMatch, *result.CommitAuthorMatch:
+			// We don't care about repo, owner, grouped commit or commit author results here.
 			continue
 		case *result.CommitMatch:
 			// Diff searches are cheap, because we implicitly have author date info.
@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/go-diff/diff"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// servePatch serves the commit of the requested revision in the format of git
+// format-patch, so that it can be applied elsewhere with git am:
+//
+//	curl http://localhost:3080/github.com/gorilla/mux@<commit>/-/patch | git am
+//
+// The diff is computed against the first parent of the commit. Files the user
+// can't read because of sub-repo permissions are omitted.
+func servePatch(db database.DB, gitserverClient gitserver.Client) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		common, err := newCommon(w, r, db, globals.Branding().BrandName, noIndex, serveError)
+		if err != nil {
+			return err
+		}
+		if common == nil {
+			return nil // request was handled
+		}
+		if common.Repo == nil {
+			// Repository is cloning.
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "Repository unavailable while cloning.")
+			return nil
+		}
+
+		ctx := r.Context()
+		commit, err := gitserverClient.GetCommit(ctx, common.Repo.Name, common.CommitID)
+		if err != nil {
+			return err
+		}
+
+		base := gitserver.DevNullSHA
+		if len(commit.Parents) > 0 {
+			base = string(commit.Parents[0])
+		}
+		iter, err := gitserverClient.Diff(ctx, gitserver.DiffOptions{
+			Repo:      common.Repo.Name,
+			Base:      base,
+			Head:      string(commit.ID),
+			RangeType: "..",
+		})
+		if err != nil {
+			return err
+		}
+		defer iter.Close()
+
+		var fileDiffs []*diff.FileDiff
+		for {
+			fileDiff, err := iter.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			fileDiffs = append(fileDiffs, fileDiff)
+		}
+
+		patch, err := formatPatch(commit, fileDiffs)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, err = w.Write(patch)
+		return err
+	}
+}
+
+// formatPatch formats a commit and its diff like git format-patch does.
+func formatPatch(commit *gitdomain.Commit, fileDiffs []*diff.FileDiff) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From %s Mon Sep 17 00:00:00 2001\n", commit.ID)
+	fmt.Fprintf(&buf, "From: %s <%s>\n", commit.Author.Name, commit.Author.Email)
+	fmt.Fprintf(&buf, "Date: %s\n", commit.Author.Date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Subject: [PATCH] %s\n\n", commit.Message.Subject())
+	if body := commit.Message.Body(); body != "" {
+		fmt.Fprintf(&buf, "%s\n", body)
+	}
+	buf.WriteString("---\n")
+
+	for _, fileDiff := range fileDiffs {
+		out, err := diff.PrintFileDiff(withPathPrefixes(fileDiff))
+		if err != nil {
+			return nil, errors.Wrapf(err, "printing diff of %q", fileDiff.NewName)
+		}
+		buf.Write(out)
+	}
+	return buf.Bytes(), nil
+}
+
+// withPathPrefixes returns a copy of fileDiff with the a/ and b/ path prefixes
+// that git am expects. Gitserver computes diffs without them.
+func withPathPrefixes(fileDiff *diff.FileDiff) *diff.FileDiff {
+	const devNull = "/dev/null"
+
+	cp := *fileDiff
+	oldPath, newPath := fileDiff.OrigName, fileDiff.NewName
+	if oldPath == devNull {
+		oldPath = newPath
+	}
+	if newPath == devNull {
+		newPath = oldPath
+	}
+
+	if cp.OrigName != "" && cp.OrigName != devNull {
+		cp.OrigName = "a/" + cp.OrigName
+	}
+	if cp.NewName != "" && cp.NewName != devNull {
+		cp.NewName = "b/" + cp.NewName
+	}
+
+	cp.Extended = make([]string, len(fileDiff.Extended))
+	for i, line := range fileDiff.Extended {
+		if strings.HasPrefix(line, "diff --git ") && oldPath != "" && newPath != "" {
+			line = fmt.Sprintf("diff --git a/%s b/%s", oldPath, newPath)
+		}
+		cp.Extended[i] = line
+	}
+	return &cp
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/go-diff/diff"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
+)
+
+func TestFormatPatch(t *testing.T) {
+	// Gitserver computes diffs without path prefixes.
+	fileDiffs, err := diff.ParseMultiFileDiff([]byte(`diff --git foo.go foo.go
+index aaaaaaa..bbbbbbb 100644
+--- foo.go
++++ foo.go
+@@ -1,2 +1,2 @@
+-old
++new
+ same
+diff --git bar.go bar.go
+new file mode 100644
+index 0000000..ccccccc
+--- /dev/null
++++ bar.go
+@@ -0,0 +1,1 @@
++bar
+`))
+	require.NoError(t, err)
+
+	commit := &gitdomain.Commit{
+		ID: "0123456789abcdef0123456789abcdef01234567",
+		Author: gitdomain.Signature{
+			Name:  "Alice",
+			Email: "alice@example.com",
+			Date:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		Message: "Fix foo\n\nLonger description.\n",
+	}
+
+	patch, err := formatPatch(commit, fileDiffs)
+	require.NoError(t, err)
+	require.Equal(t, `From 0123456789abcdef0123456789abcdef01234567 Mon Sep 17 00:00:00 2001
+From: Alice <alice@example.com>
+Date: Tue, 02 Jan 2024 03:04:05 +0000
+Subject: [PATCH] Fix foo
+
+Longer description.
+---
+diff --git a/foo.go b/foo.go
+index aaaaaaa..bbbbbbb 100644
+--- a/foo.go
++++ b/foo.go
+@@ -1,2 +1,2 @@
+-old
++new
+ same
+diff --git a/bar.go b/bar.go
+new file mode 100644
+index 0000000..ccccccc
+--- /dev/null
++++ b/bar.go
+@@ -0,0 +1,1 @@
++bar
+`, string(patch))
+
+	// The parsed diffs are not modified.
+	require.Equal(t, "foo.go", fileDiffs[0].OrigName)
+	require.Equal(t, "diff --git foo.go foo.go", fileDiffs[0].Extended[0])
+}
@@ -47,6 +47,7 @@ const (
 	routeTree             = "tree"
 	routeBlob             = "blob"
 	routeRaw              = "raw"
+	routePatch            = "patch"
 	routeSettings         = "settings"
 	routeSiteAdmin        = "site-admin"
 
@@ -277,6 +278,9 @@ func InitRouter(db database.DB) {
 	// raw
 	repoRev.Path("/raw{Path:.*}").Methods("GET", "HEAD").Name(routeRaw).Handler(handler(db, serveRaw(logger, db, gitserver.NewClient("http.raw"))))
 
+	// patch
+	repoRev.Path("/patch").Methods("GET").Name(routePatch).Handler(handler(db, servePatch(db, gitserver.NewClient("http.patch"))))
+
 	repo := r.PathPrefix(repoRevPath + "/" + routevar.RepoPathDelim).Subrouter()
 
 	repo.PathPrefix("/batch-changes").Methods("GET").Name("repo-batch-changes").Handler(brandedIndex("Batch Changes"))
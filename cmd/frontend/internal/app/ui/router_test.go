@@ -122,6 +122,13 @@ func TestRouter(t *testing.T) {
 			wantVars:  map[string]string{"Repo": "r", "Rev": "@v", "Path": "/d/f"},
 		},
 
+		// patch
+		{
+			path:      "/r@v/-/patch",
+			wantRoute: routePatch,
+			wantVars:  map[string]string{"Repo": "r", "Rev": "@v"},
+		},
+
 		// sourcegraph.com redirects
 		{
 			path:      "/about",
@@ -3,6 +3,8 @@ package resolvers
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/inconshreveable/log15" //nolint:logging // TODO move all logging to sourcegraph/log
 	"github.com/sourcegraph/log"
@@ -13,8 +15,13 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/compute"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/gqlutil"
+	"github.com/sourcegraph/sourcegraph/internal/search/limits"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/search/quota"
 	"github.com/sourcegraph/sourcegraph/internal/search/result"
 	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
 func NewResolver(logger log.Logger, db database.DB) gql.ComputeResolver {
@@ -225,6 +232,18 @@ func NewBatchComputeImplementer(ctx context.Context, logger log.Logger, db datab
 		return nil, err
 	}
 
+	// Compute results count towards the user's daily quota. Failing to read or
+	// update the quota doesn't fail the query.
+	remainingQuota, quotaLimited, err := quota.DefaultStore.Remaining(ctx, quota.KindComputeResults)
+	var quotaExceeded *quota.ExceededError
+	if errors.As(err, &quotaExceeded) {
+		return nil, quotaExceeded
+	}
+	if quotaLimited {
+		// Don't search for more matches than the quota allows us to return.
+		computeQuery.Parameters = limitCount(computeQuery.Parameters, remainingQuota)
+	}
+
 	searchQuery, err := computeQuery.ToSearchQuery()
 	if err != nil {
 		return nil, err
@@ -241,9 +260,78 @@ func NewBatchComputeImplementer(ctx context.Context, logger log.Logger, db datab
 	if err != nil {
 		return nil, err
 	}
-	return toResultResolverList(ctx, computeQuery.Command, results.Matches, db)
+	matches := results.Matches
+
+	// Other queries of the user may have used the quota while we searched, so
+	// reserve what we return before running the command. Every match produces
+	// at most one compute result, so we can drop the matches we didn't get a
+	// reservation for, and give back the rest afterwards.
+	reserved, err := quota.DefaultStore.Reserve(ctx, quota.KindComputeResults, len(matches))
+	if errors.As(err, &quotaExceeded) {
+		return nil, quotaExceeded
+	}
+	if err != nil {
+		reserved = len(matches)
+	}
+	matches = matches[:reserved]
+	resolvers, err := toResultResolverList(ctx, computeQuery.Command, matches, db)
+	_ = quota.DefaultStore.Release(context.WithoutCancel(ctx), quota.KindComputeResults, reserved-len(resolvers))
+	if err != nil {
+		return nil, err
+	}
+
+	return resolvers, nil
+}
+
+// limitCount returns nodes with the count of search results capped at limit.
+func limitCount(nodes []query.Node, limit int) []query.Node {
+	hasCount := false
+	nodes = query.MapField(nodes, query.FieldCount, func(value string, negated bool, annotation query.Annotation) query.Node {
+		hasCount = true
+		if count, err := strconv.Atoi(value); err == nil && count < limit {
+			limit = count
+		}
+		return query.Parameter{Field: query.FieldCount, Value: strconv.Itoa(limit), Negated: negated, Annotation: annotation}
+	})
+	if !hasCount && limit < limits.DefaultMaxSearchResults {
+		nodes = append(nodes, query.Parameter{Field: query.FieldCount, Value: strconv.Itoa(limit)})
+	}
+	return nodes
 }
 
 func (r *Resolver) Compute(ctx context.Context, args *gql.ComputeArgs) ([]gql.ComputeResultResolver, error) {
 	return NewBatchComputeImplementer(ctx, r.logger, r.db, args)
 }
+
+func (r *Resolver) SearchQuotaUsage(ctx context.Context) ([]gql.SearchQuotaUsageResolver, error) {
+	usages, err := quota.DefaultStore.Usage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]gql.SearchQuotaUsageResolver, 0, len(usages))
+	for _, usage := range usages {
+		resolvers = append(resolvers, &searchQuotaUsageResolver{usage: usage})
+	}
+	return resolvers, nil
+}
+
+type searchQuotaUsageResolver struct {
+	usage quota.Usage
+}
+
+func (r *searchQuotaUsageResolver) Kind() string {
+	return strings.ToUpper(string(r.usage.Kind))
+}
+
+func (r *searchQuotaUsageResolver) Used() int32 {
+	return int32(r.usage.Used)
+}
+
+func (r *searchQuotaUsageResolver) Limit() int32 {
+	return int32(r.usage.Limit)
+}
+
+func (r *searchQuotaUsageResolver) ResetsAt() *gqlutil.DateTime {
+	return gqlutil.DateTimeOrNil(r.usage.ResetsAt)
+}
@@ -55,3 +55,20 @@ func TestToResultResolverList(t *testing.T) {
 	producesNilResult := []result.Match{&result.CommitMatch{}}
 	autogold.Expect("[]").Equal(t, test("a|b", producesNilResult))
 }
+
+func TestLimitCount(t *testing.T) {
+	test := func(input string, limit int) string {
+		computeQuery, err := compute.Parse(input)
+		if err != nil {
+			return err.Error()
+		}
+		computeQuery.Parameters = limitCount(computeQuery.Parameters, limit)
+		q, _ := computeQuery.ToSearchQuery()
+		return q
+	}
+
+	autogold.Expect("(repo:foo count:5 AND bar)").Equal(t, test("repo:foo bar", 5))
+	autogold.Expect("(repo:foo AND bar)").Equal(t, test("repo:foo bar", 100))
+	autogold.Expect("(repo:foo count:5 AND bar)").Equal(t, test("repo:foo count:1000 bar", 5))
+	autogold.Expect("(repo:foo count:3 AND bar)").Equal(t, test("repo:foo count:3 bar", 5))
+}
@@ -3,6 +3,7 @@ package streaming
 import (
 	"context"
 
+	"github.com/grafana/regexp"
 	"github.com/sourcegraph/conc/stream"
 	"github.com/sourcegraph/log"
 
@@ -10,15 +11,18 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/search"
-	"github.com/sourcegraph/sourcegraph/internal/search/client"
 	"github.com/sourcegraph/sourcegraph/internal/search/result"
 	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
-	"github.com/sourcegraph/sourcegraph/lib/pointers"
+	"github.com/sourcegraph/sourcegraph/internal/symbols"
 )
 
 func toComputeResult(ctx context.Context, gitserverClient gitserver.Client, cmd compute.Command, match result.Match) (out []compute.Result, _ error) {
 	if v, ok := match.(*result.CommitMatch); ok && v.DiffPreview != nil {
+		needsSymbols := compute.NeedsSymbols(cmd)
 		for _, diffMatch := range v.CommitToDiffMatches() {
+			if needsSymbols {
+				setEnclosingSymbols(ctx, diffMatch)
+			}
 			runResult, err := cmd.Run(ctx, gitserverClient, diffMatch)
 			if err != nil {
 				return nil, err
@@ -35,9 +39,61 @@ func toComputeResult(ctx context.Context, gitserverClient gitserver.Client, cmd
 	return out, nil
 }
 
-func NewComputeStream(ctx context.Context, logger log.Logger, db database.DB, searchQuery string, computeCommand compute.Command) (<-chan Event, func() (*search.Alert, error)) {
-	gitserverClient := gitserver.NewClient("http.computestream")
+// maxSymbolsPerFile is the maximum number of symbols we fetch to find the
+// symbols enclosing the hunks of a file.
+const maxSymbolsPerFile = 10000
+
+// setEnclosingSymbols resolves the symbols enclosing the hunks of diffMatch
+// from the symbols of the file at the commit. Symbols are best effort, so
+// errors are ignored.
+func setEnclosingSymbols(ctx context.Context, diffMatch *result.CommitDiffMatch) {
+	if diffMatch.PathStatus() == result.Deleted {
+		return
+	}
+	syms, err := symbols.DefaultClient.Search(ctx, search.SymbolsParameters{
+		Repo:            diffMatch.Repo.Name,
+		CommitID:        diffMatch.Commit.ID,
+		IncludePatterns: []string{"^" + regexp.QuoteMeta(diffMatch.NewName) + "$"},
+		First:           maxSymbolsPerFile,
+	})
+	if err != nil {
+		return
+	}
+	diffMatch.SetEnclosingSymbols(syms)
+}
+
+// Ordering is the order in which a compute stream sends its results.
+type Ordering string
+
+const (
+	// OrderingNone sends results as soon as they are computed. The order of
+	// results depends on the order in which search backends respond.
+	OrderingNone Ordering = ""
+	// OrderingDeterministic sends results ordered by repository, revision,
+	// commit and path, so that reruns over the same corpus produce identical
+	// output. Results are only sent once the search completes, and only the
+	// first maxOrderedMatches matches in that order are computed.
+	OrderingDeterministic Ordering = "deterministic"
+)
+
+// maxOrderedMatches bounds the memory of deterministically ordered compute
+// streams, which hold every search match until the search completes.
+const maxOrderedMatches = 10000
+
+// NewComputeStream runs computeCommand over the results of searchQuery.
+func NewComputeStream(ctx context.Context, logger log.Logger, db database.DB, searchQuery string, computeCommand compute.Command, ordering Ordering) (<-chan Event, func() (*search.Alert, error)) {
+	var resultOrdering *search.ResultOrdering
+	if ordering == OrderingDeterministic {
+		resultOrdering = &search.ResultOrdering{Limit: maxOrderedMatches}
+	}
+	source := newSearchClientSource(logger, db, searchQuery, resultOrdering)
+	return newComputeStream(ctx, gitserver.NewClient("http.computestream"), source, computeCommand)
+}
 
+// newComputeStream runs computeCommand over the matches of source. Matches are
+// computed concurrently, but results are sent in the order in which source
+// sent the matches.
+func newComputeStream(ctx context.Context, gitserverClient gitserver.Client, source EventSource, computeCommand compute.Command) (<-chan Event, func() (*search.Alert, error)) {
 	eventsC := make(chan Event, 8)
 	errorC := make(chan error, 1)
 	s := stream.New().WithMaxGoroutines(8)
@@ -68,24 +124,6 @@ func NewComputeStream(ctx context.Context, logger log.Logger, db database.DB, se
 		}
 	})
 
-	patternType := "regexp"
-	searchClient := client.New(logger, db, gitserver.NewClient("http.compute.search"))
-	inputs, err := searchClient.Plan(
-		ctx,
-		"",
-		&patternType,
-		searchQuery,
-		search.Precise,
-		search.Streaming,
-		pointers.Ptr(int32(0)),
-	)
-	if err != nil {
-		close(eventsC)
-		close(errorC)
-
-		return eventsC, func() (*search.Alert, error) { return nil, err }
-	}
-
 	type finalResult struct {
 		alert *search.Alert
 		err   error
@@ -97,7 +135,7 @@ func NewComputeStream(ctx context.Context, logger log.Logger, db database.DB, se
 		defer close(errorC)
 		defer s.Wait()
 
-		alert, err := searchClient.Execute(ctx, stream, inputs)
+		alert, err := source.Search(ctx, stream)
 		final <- finalResult{alert: alert, err: err}
 	}()
 
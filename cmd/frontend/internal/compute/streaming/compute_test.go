@@ -0,0 +1,201 @@
+package streaming
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/grafana/regexp"
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/compute"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+)
+
+// outputRepoAndPath emits "<repo>:<path>" for every chunk a match produces.
+var outputRepoAndPath = &compute.Output{
+	SearchPattern: &compute.Regexp{Value: regexp.MustCompile(`(?s).+`)},
+	OutputPattern: "$repo:$path",
+	Separator:     "\n",
+	Kind:          "output",
+}
+
+func replayFixture(t *testing.T, name string) *ReplaySource {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	defer f.Close()
+
+	source, err := NewReplaySource(f)
+	require.NoError(t, err)
+	return source
+}
+
+type collected struct {
+	Outputs    []string
+	IsLimitHit bool
+}
+
+func runComputeStream(t *testing.T, source EventSource, cmd compute.Command) collected {
+	t.Helper()
+
+	events, done := newComputeStream(context.Background(), gitserver.NewMockClient(), source, cmd)
+
+	var c collected
+	for event := range events {
+		for _, r := range event.Results {
+			text, ok := r.(*compute.Text)
+			require.True(t, ok, "unexpected result type %T", r)
+			c.Outputs = append(c.Outputs, text.Value)
+		}
+		c.IsLimitHit = c.IsLimitHit || event.Stats.IsLimitHit
+	}
+
+	_, err := done()
+	require.NoError(t, err)
+
+	// Matches are computed concurrently, so the order of results is not stable.
+	sort.Strings(c.Outputs)
+	return c
+}
+
+func TestComputeStream_Replay(t *testing.T) {
+	for _, tc := range []struct {
+		fixture string
+		want    autogold.Value
+	}{
+		{
+			fixture: "commit.jsonl",
+			want: autogold.Expect(collected{Outputs: []string{
+				"github.com/sourcegraph/a:\n",
+				"github.com/sourcegraph/b:\n",
+			}}),
+		},
+		{
+			fixture: "diff.jsonl",
+			want: autogold.Expect(collected{Outputs: []string{
+				"github.com/sourcegraph/a:README.md\n",
+				"github.com/sourcegraph/a:cmd/main.go\n",
+			}}),
+		},
+		{
+			fixture: "file.jsonl",
+			want: autogold.Expect(collected{Outputs: []string{
+				"github.com/sourcegraph/a:cmd/main.go\ngithub.com/sourcegraph/a:cmd/main.go\n",
+				"github.com/sourcegraph/b:internal/run.go\n",
+			}}),
+		},
+		{
+			fixture: "repo.jsonl",
+			want: autogold.Expect(collected{Outputs: []string{
+				"github.com/sourcegraph/a:\n",
+				"github.com/sourcegraph/b:\n",
+			}}),
+		},
+		{
+			fixture: "mixed.jsonl",
+			want: autogold.Expect(collected{
+				Outputs: []string{
+					"github.com/sourcegraph/a:\n",
+					"github.com/sourcegraph/a:README.md\n",
+					"github.com/sourcegraph/a:cmd/main.go\n",
+					"github.com/sourcegraph/a:cmd/main.go\ngithub.com/sourcegraph/a:cmd/main.go\n",
+					"github.com/sourcegraph/b:\n",
+				},
+				IsLimitHit: true,
+			}),
+		},
+	} {
+		t.Run(tc.fixture, func(t *testing.T) {
+			tc.want.Equal(t, runComputeStream(t, replayFixture(t, tc.fixture), outputRepoAndPath))
+		})
+	}
+}
+
+func TestRecordingSource(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewRecordingSource(replayFixture(t, "mixed.jsonl"), &buf)
+	recorded := runComputeStream(t, recorder, outputRepoAndPath)
+
+	replayed := runComputeStream(t, replayFixture(t, "mixed.jsonl"), outputRepoAndPath)
+	require.Equal(t, replayed, recorded)
+
+	source, err := NewReplaySource(&buf)
+	require.NoError(t, err)
+	require.Equal(t, recorded, runComputeStream(t, source, outputRepoAndPath))
+}
+
+// shuffledSource sends the matches of recorded events one at a time in a
+// random order, like search backends responding in a different order on
+// every run. Like the search client, it sorts the matches if ordering is set.
+type shuffledSource struct {
+	events   []streaming.SearchEvent
+	rand     *rand.Rand
+	ordering *search.ResultOrdering
+}
+
+func (s *shuffledSource) Search(_ context.Context, stream streaming.Sender) (*search.Alert, error) {
+	if s.ordering != nil {
+		parent, ordered := stream, streaming.NewOrderingStream(s.ordering.Limit)
+		defer func() { parent.Send(ordered.Event()) }()
+		stream = ordered
+	}
+
+	var events []streaming.SearchEvent
+	for _, event := range s.events {
+		for _, match := range event.Results {
+			events = append(events, streaming.SearchEvent{Results: []result.Match{match}})
+		}
+		events = append(events, streaming.SearchEvent{Stats: event.Stats})
+	}
+	s.rand.Shuffle(len(events), func(i, j int) { events[i], events[j] = events[j], events[i] })
+	for _, event := range events {
+		stream.Send(event)
+	}
+	return nil, nil
+}
+
+func TestComputeStream_DeterministicOrdering(t *testing.T) {
+	fixture := replayFixture(t, "mixed.jsonl")
+
+	run := func(seed int64) []byte {
+		source := &shuffledSource{
+			events:   fixture.events,
+			rand:     rand.New(rand.NewSource(seed)),
+			ordering: &search.ResultOrdering{Limit: maxOrderedMatches},
+		}
+		events, done := newComputeStream(context.Background(), gitserver.NewMockClient(), source, outputRepoAndPath)
+
+		var out bytes.Buffer
+		for event := range events {
+			for _, r := range event.Results {
+				out.WriteString(r.(*compute.Text).Value)
+			}
+		}
+		_, err := done()
+		require.NoError(t, err)
+		return out.Bytes()
+	}
+
+	want := run(0)
+	for seed := int64(1); seed < 10; seed++ {
+		require.Equal(t, string(want), string(run(seed)), "seed %d", seed)
+	}
+
+	autogold.Expect(`github.com/sourcegraph/a:cmd/main.go
+github.com/sourcegraph/a:cmd/main.go
+github.com/sourcegraph/a:
+github.com/sourcegraph/a:cmd/main.go
+github.com/sourcegraph/a:README.md
+github.com/sourcegraph/b:
+`).Equal(t, string(want))
+}
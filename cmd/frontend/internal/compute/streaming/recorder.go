@@ -0,0 +1,194 @@
+package streaming
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// recordedEvent is the serialized form of a streaming.SearchEvent. Recordings
+// are written as one JSON encoded event per line.
+type recordedEvent struct {
+	Matches          []recordedMatch `json:"matches,omitempty"`
+	IsLimitHit       bool            `json:"isLimitHit,omitempty"`
+	BackendsMissing  int             `json:"backendsMissing,omitempty"`
+	ExcludedForks    int             `json:"excludedForks,omitempty"`
+	ExcludedArchived int             `json:"excludedArchived,omitempty"`
+}
+
+// recordedMatch holds exactly one of the match types that compute supports.
+type recordedMatch struct {
+	Commit *result.CommitMatch `json:"commit,omitempty"`
+	File   *recordedFileMatch  `json:"file,omitempty"`
+	Repo   *result.RepoMatch   `json:"repo,omitempty"`
+}
+
+// recordedFileMatch carries the repository and commit of a file match, which
+// are not serialized as part of result.FileMatch.
+type recordedFileMatch struct {
+	RepoID   api.RepoID   `json:"repoID"`
+	RepoName api.RepoName `json:"repoName"`
+	CommitID api.CommitID `json:"commitID"`
+	*result.FileMatch
+}
+
+func toRecordedEvent(event streaming.SearchEvent) (recordedEvent, error) {
+	matches := make([]recordedMatch, 0, len(event.Results))
+	for _, match := range event.Results {
+		switch v := match.(type) {
+		case *result.CommitMatch:
+			matches = append(matches, recordedMatch{Commit: v})
+		case *result.FileMatch:
+			matches = append(matches, recordedMatch{File: &recordedFileMatch{
+				RepoID:    v.Repo.ID,
+				RepoName:  v.Repo.Name,
+				CommitID:  v.CommitID,
+				FileMatch: v,
+			}})
+		case *result.RepoMatch:
+			matches = append(matches, recordedMatch{Repo: v})
+		default:
+			return recordedEvent{}, errors.Errorf("unsupported match type %T", match)
+		}
+	}
+
+	return recordedEvent{
+		Matches:          matches,
+		IsLimitHit:       event.Stats.IsLimitHit,
+		BackendsMissing:  event.Stats.BackendsMissing,
+		ExcludedForks:    event.Stats.ExcludedForks,
+		ExcludedArchived: event.Stats.ExcludedArchived,
+	}, nil
+}
+
+func (e recordedEvent) toSearchEvent() (streaming.SearchEvent, error) {
+	matches := make(result.Matches, 0, len(e.Matches))
+	for _, match := range e.Matches {
+		switch {
+		case match.Commit != nil:
+			matches = append(matches, match.Commit)
+		case match.File != nil && match.File.FileMatch != nil:
+			fm := match.File.FileMatch
+			fm.Repo = types.MinimalRepo{ID: match.File.RepoID, Name: match.File.RepoName}
+			fm.CommitID = match.File.CommitID
+			matches = append(matches, fm)
+		case match.Repo != nil:
+			matches = append(matches, match.Repo)
+		default:
+			return streaming.SearchEvent{}, errors.New("recorded match is empty")
+		}
+	}
+
+	return streaming.SearchEvent{
+		Results: matches,
+		Stats: streaming.Stats{
+			IsLimitHit:       e.IsLimitHit,
+			BackendsMissing:  e.BackendsMissing,
+			ExcludedForks:    e.ExcludedForks,
+			ExcludedArchived: e.ExcludedArchived,
+		},
+	}, nil
+}
+
+// RecordingSource is an EventSource that forwards the events of another
+// source and writes each of them to w. The recording can be replayed with
+// NewReplaySource, which is useful to capture fixtures from a real search.
+type RecordingSource struct {
+	source EventSource
+
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+func NewRecordingSource(source EventSource, w io.Writer) *RecordingSource {
+	return &RecordingSource{
+		source: source,
+		enc:    json.NewEncoder(w),
+	}
+}
+
+func (s *RecordingSource) Search(ctx context.Context, stream streaming.Sender) (*search.Alert, error) {
+	alert, err := s.source.Search(ctx, streaming.StreamFunc(func(event streaming.SearchEvent) {
+		s.record(event)
+		stream.Send(event)
+	}))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		err = s.err
+	}
+	return alert, err
+}
+
+func (s *RecordingSource) record(event streaming.SearchEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return
+	}
+
+	recorded, err := toRecordedEvent(event)
+	if err != nil {
+		s.err = errors.Wrap(err, "recording event")
+		return
+	}
+	if err := s.enc.Encode(recorded); err != nil {
+		s.err = errors.Wrap(err, "writing recorded event")
+	}
+}
+
+// ReplaySource is an EventSource that sends previously recorded events.
+type ReplaySource struct {
+	events []streaming.SearchEvent
+}
+
+// NewReplaySource reads events in the format written by RecordingSource.
+func NewReplaySource(r io.Reader) (*ReplaySource, error) {
+	var events []streaming.SearchEvent
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var recorded recordedEvent
+		if err := json.Unmarshal(line, &recorded); err != nil {
+			return nil, errors.Wrap(err, "decoding recorded event")
+		}
+		event, err := recorded.toSearchEvent()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &ReplaySource{events: events}, nil
+}
+
+func (s *ReplaySource) Search(ctx context.Context, stream streaming.Sender) (*search.Alert, error) {
+	for _, event := range s.events {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		stream.Send(event)
+	}
+	return nil, nil
+}
@@ -0,0 +1,56 @@
+package streaming
+
+import (
+	"context"
+
+	"github.com/sourcegraph/log"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/client"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/lib/pointers"
+)
+
+// EventSource produces the search events that a compute stream runs its
+// command over. The default source executes a search query, but tests can
+// substitute a source that replays recorded events instead.
+type EventSource interface {
+	Search(ctx context.Context, stream streaming.Sender) (*search.Alert, error)
+}
+
+// searchClientSource is an EventSource that plans and executes a search query
+// with a search client.
+type searchClientSource struct {
+	client   client.SearchClient
+	query    string
+	ordering *search.ResultOrdering
+}
+
+func newSearchClientSource(logger log.Logger, db database.DB, searchQuery string, ordering *search.ResultOrdering) *searchClientSource {
+	return &searchClientSource{
+		client:   client.New(logger, db, gitserver.NewClient("http.compute.search")),
+		query:    searchQuery,
+		ordering: ordering,
+	}
+}
+
+func (s *searchClientSource) Search(ctx context.Context, stream streaming.Sender) (*search.Alert, error) {
+	patternType := "regexp"
+	inputs, err := s.client.Plan(
+		ctx,
+		"",
+		&patternType,
+		s.query,
+		search.Precise,
+		search.Streaming,
+		pointers.Ptr(int32(0)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	inputs.Ordering = s.ordering
+
+	return s.client.Execute(ctx, stream, inputs)
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/compute"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/quota"
 	streamclient "github.com/sourcegraph/sourcegraph/internal/search/streaming/client"
 	streamhttp "github.com/sourcegraph/sourcegraph/internal/search/streaming/http"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
@@ -24,6 +26,10 @@ import (
 // and this is best avoided on large instances like Sourcegraph.com
 const maxRequestDuration = time.Minute
 
+// quotaReservationSize is the number of compute results reserved from the
+// user's daily quota at a time.
+const quotaReservationSize = 100
+
 // NewComputeStreamHandler is an http handler which streams back compute results.
 func NewComputeStreamHandler(logger log.Logger, db database.DB) http.Handler {
 	return &streamHandler{
@@ -90,7 +96,33 @@ func (h *streamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Log events to trace
 	eventWriter.StatHook = eventStreamTraceHook(tr.AddEvent)
 
-	events, getResults := NewComputeStream(ctx, h.logger, h.db, searchQuery, computeQuery.Command)
+	// Compute results count towards the user's daily quota. They're reserved
+	// in chunks before they're sent, so that concurrent queries of a user
+	// can't use more than the quota together, and the unused rest is given
+	// back once the query finished. We stop the query once the quota is used
+	// up. Failing to update the quota doesn't fail the query.
+	var quotaExceeded *quota.ExceededError
+	resultCount, reservedQuota, quotaReached := 0, 0, false
+	reserveQuota := func() bool {
+		n, err := quota.DefaultStore.Reserve(ctx, quota.KindComputeResults, quotaReservationSize)
+		if errors.As(err, &quotaExceeded) {
+			return false
+		}
+		if err != nil {
+			n = quotaReservationSize
+		}
+		reservedQuota += n
+		return true
+	}
+	if !reserveQuota() {
+		_ = eventWriter.Event("alert", alertEvent(quotaExceeded.Alert()))
+		return
+	}
+	defer func() {
+		_ = quota.DefaultStore.Release(context.WithoutCancel(ctx), quota.KindComputeResults, reservedQuota-resultCount)
+	}()
+
+	events, getResults := NewComputeStream(ctx, h.logger, h.db, searchQuery, computeQuery.Command, args.Ordering)
 	events = batchEvents(events, 50*time.Millisecond)
 
 	// Store marshalled matches and flush periodically or when we go over
@@ -121,6 +153,12 @@ func (h *streamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		progress.Stats.Update(&event.Stats)
 
 		for _, result := range event.Results {
+			if resultCount >= reservedQuota && !reserveQuota() {
+				quotaReached = true
+				cancel()
+				break
+			}
+			resultCount++
 			_ = matchesBuf.Append(result)
 		}
 
@@ -149,6 +187,14 @@ LOOP:
 	matchesFlush()
 
 	alert, err := getResults()
+
+	if quotaReached {
+		// We canceled the query ourselves, so we ignore the resulting error.
+		_ = eventWriter.Event("alert", alertEvent(quotaExceeded.Alert()))
+		_ = eventWriter.Event("progress", progress.Final())
+		return
+	}
+
 	if err != nil {
 		_ = eventWriter.Event("error", streamhttp.EventError{Message: err.Error()})
 		return
@@ -161,26 +207,33 @@ LOOP:
 		})
 	}
 	if alert != nil {
-		var pqs []streamhttp.QueryDescription
-		for _, pq := range alert.ProposedQueries {
-			pqs = append(pqs, streamhttp.QueryDescription{
-				Description: pq.Description,
-				Query:       pq.QueryString(),
-			})
-		}
-		_ = eventWriter.Event("alert", streamhttp.EventAlert{
-			Title:           alert.Title,
-			Description:     alert.Description,
-			ProposedQueries: pqs,
-		})
+		_ = eventWriter.Event("alert", alertEvent(alert))
 	}
 
 	_ = eventWriter.Event("progress", progress.Final())
 }
 
+func alertEvent(alert *search.Alert) streamhttp.EventAlert {
+	var pqs []streamhttp.QueryDescription
+	for _, pq := range alert.ProposedQueries {
+		pqs = append(pqs, streamhttp.QueryDescription{
+			Description: pq.Description,
+			Query:       pq.QueryString(),
+		})
+	}
+	return streamhttp.EventAlert{
+		Title:           alert.Title,
+		Description:     alert.Description,
+		ProposedQueries: pqs,
+	}
+}
+
 type args struct {
 	Query   string
 	Display int
+	// Ordering is the order of results. It is specified with the "order"
+	// parameter, e.g. `order=deterministic` for reproducible output.
+	Ordering Ordering
 }
 
 func parseURLQuery(q url.Values) (*args, error) {
@@ -206,6 +259,13 @@ func parseURLQuery(q url.Values) (*args, error) {
 		return nil, errors.Errorf("display must be an integer, got %q: %w", display, err)
 	}
 
+	switch order := Ordering(get("order", "")); order {
+	case OrderingNone, OrderingDeterministic:
+		a.Ordering = order
+	default:
+		return nil, errors.Errorf("order must be %q or empty, got %q", OrderingDeterministic, order)
+	}
+
 	return &a, nil
 }
 
@@ -15,10 +15,17 @@ func newEventWriter(inner *streamhttp.Writer) *eventWriter {
 // methods for each of the supported evens in a frontend stream.
 type eventWriter struct {
 	inner *streamhttp.Writer
+
+	// commitWatermarks, if set, are reported in the done event.
+	commitWatermarks *search.CommitWatermarks
 }
 
 func (e *eventWriter) Done() error {
-	return e.inner.Event("done", map[string]any{})
+	var done streamhttp.EventDone
+	if e.commitWatermarks != nil {
+		done.Watermarks = e.commitWatermarks.Next()
+	}
+	return e.inner.Event("done", done)
 }
 
 func (e *eventWriter) Progress(current api.Progress) error {
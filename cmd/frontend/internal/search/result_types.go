@@ -0,0 +1,165 @@
+package search
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// resultTypesFilter decides which matches of a search stream are sent to the
+// client. Clients can narrow the result types of an in-flight stream, e.g. once
+// the first filters arrive and the UI only shows diffs, so that matches the
+// client hides aren't streamed at all.
+//
+// Filtered matches still count towards the progress and the filters of the
+// stream, so the facets the client shows stay complete. Matches filtered out
+// are not resent if the result types are widened again.
+type resultTypesFilter struct {
+	mu sync.Mutex
+	// types is the set of result types to send. TypeEmpty sends all types.
+	types result.Types
+}
+
+func (f *resultTypesFilter) Set(types result.Types) {
+	f.mu.Lock()
+	f.types = types
+	f.mu.Unlock()
+}
+
+// Allows returns true if match should be sent to the client.
+func (f *resultTypesFilter) Allows(match result.Match) bool {
+	f.mu.Lock()
+	types := f.types
+	f.mu.Unlock()
+
+	if types == result.TypeEmpty {
+		return true
+	}
+	matchType := matchResultType(match)
+	return matchType == result.TypeEmpty || types.Has(matchType)
+}
+
+// matchResultType returns the result type of the given match as used by the
+// type: filter, or TypeEmpty for matches that don't correspond to a type.
+func matchResultType(match result.Match) result.Types {
+	switch m := match.(type) {
+	case *result.FileMatch:
+		switch {
+		case len(m.Symbols) > 0:
+			return result.TypeSymbol
+		case m.ChunkMatches.MatchCount() > 0:
+			return result.TypeFile
+		default:
+			return result.TypePath
+		}
+	case *result.CommitMatch:
+		if m.DiffPreview != nil {
+			return result.TypeDiff
+		}
+		return result.TypeCommit
+	case *result.CommitDiffMatch:
+		return result.TypeDiff
+	case *result.RepoMatch:
+		return result.TypeRepo
+	default:
+		return result.TypeEmpty
+	}
+}
+
+// parseResultTypes parses a comma-separated list of result types such as
+// "diff,commit". The empty string is the set of all types.
+func parseResultTypes(s string) (result.Types, error) {
+	types := result.TypeEmpty
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		t, ok := result.TypeFromString[name]
+		if !ok {
+			return result.TypeEmpty, errors.Errorf("unknown result type %q", name)
+		}
+		types = types.With(t)
+	}
+	return types, nil
+}
+
+type inflightStreamKey struct {
+	userID int32
+	id     string
+}
+
+// inflightStreams are the result type filters of the search streams served by
+// this frontend that were started with a stream id.
+var inflightStreams = struct {
+	sync.Mutex
+	m map[inflightStreamKey]*resultTypesFilter
+}{m: map[inflightStreamKey]*resultTypesFilter{}}
+
+// registerInflightStream makes the result types of the stream with the given id
+// controllable by the given user until the returned function is called.
+func registerInflightStream(userID int32, id string, filter *resultTypesFilter) (unregister func()) {
+	key := inflightStreamKey{userID: userID, id: id}
+
+	inflightStreams.Lock()
+	inflightStreams.m[key] = filter
+	inflightStreams.Unlock()
+
+	return func() {
+		inflightStreams.Lock()
+		if inflightStreams.m[key] == filter {
+			delete(inflightStreams.m, key)
+		}
+		inflightStreams.Unlock()
+	}
+}
+
+// ResultTypesHandler is an http handler which narrows the result types of an
+// in-flight search stream. It expects the id the stream was started with and
+// a comma-separated list of result types, e.g. `id=1234&types=diff`. An empty
+// list of types sends all types again.
+//
+// Only authenticated users can control their streams. Streams are only known
+// to the frontend replica serving them. Clients should fall back to restarting
+// the search with the `rt` parameter if the stream is not found.
+func ResultTypesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		id := r.Form.Get("id")
+		if id == "" {
+			http.Error(w, "no stream id found", http.StatusBadRequest)
+			return
+		}
+		types, err := parseResultTypes(r.Form.Get("types"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		a := actor.FromContext(r.Context())
+		if !a.IsAuthenticated() {
+			http.Error(w, "not authenticated", http.StatusUnauthorized)
+			return
+		}
+
+		key := inflightStreamKey{userID: a.UID, id: id}
+		inflightStreams.Lock()
+		filter, ok := inflightStreams.m[key]
+		inflightStreams.Unlock()
+		if !ok {
+			http.Error(w, "search stream not found", http.StatusNotFound)
+			return
+		}
+
+		filter.Set(types)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
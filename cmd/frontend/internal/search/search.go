@@ -5,6 +5,7 @@ package search
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -25,6 +26,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/honey"
 	searchhoney "github.com/sourcegraph/sourcegraph/internal/honey/search"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/client"
@@ -33,6 +35,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
 	streamclient "github.com/sourcegraph/sourcegraph/internal/search/streaming/client"
 	streamhttp "github.com/sourcegraph/sourcegraph/internal/search/streaming/http"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming/opensearch"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 	"github.com/sourcegraph/sourcegraph/lib/pointers"
@@ -124,6 +127,11 @@ func (h *streamHandler) serveHTTP(r *http.Request, tr trace.Trace, eventWriter *
 		// to change the code.
 		inputs.Features.ZoektSearchOptionsOverride = args.ZoektSearchOptionsOverride
 	}
+	inputs.Features.GroupCommitsByRepo = args.GroupCommitsByRepo
+	if args.AfterWatermarks != nil {
+		inputs.CommitWatermarks = search.NewCommitWatermarks(args.AfterWatermarks)
+		eventWriter.commitWatermarks = inputs.CommitWatermarks
+	}
 
 	// Display is the number of results we send down. If display is < 0 we
 	// want to send everything we find before hitting a limit. Otherwise we
@@ -134,6 +142,14 @@ func (h *streamHandler) serveHTTP(r *http.Request, tr trace.Trace, eventWriter *
 		displayLimit = limit
 	}
 
+	resultTypes := &resultTypesFilter{types: args.ResultTypes}
+	// Stream ids are keyed by user, so the streams of anonymous users can't be
+	// told apart and aren't controllable.
+	if args.StreamID != "" && actor.FromContext(ctx).IsAuthenticated() {
+		unregister := registerInflightStream(actor.FromContext(ctx).UID, args.StreamID, resultTypes)
+		defer unregister()
+	}
+
 	progress := &streamclient.ProgressAggregator{
 		Start:        start,
 		Limit:        limit,
@@ -160,6 +176,14 @@ func (h *streamHandler) serveHTTP(r *http.Request, tr trace.Trace, eventWriter *
 	// panic handler. We cannot add a panic handler because the goroutines are
 	// spawned by the go runtime.
 	alert, err := func() (*search.Alert, error) {
+		var export streaming.Sender
+		if sink, flush := h.newExportSink(ctx); sink != nil {
+			// Exporting must not hold up the response, so wait for the
+			// remaining matches to be indexed in the background.
+			defer func() { go flush() }()
+			export = sink
+		}
+
 		eventHandler := newEventHandler(
 			ctx,
 			h.logger,
@@ -170,7 +194,11 @@ func (h *streamHandler) serveHTTP(r *http.Request, tr trace.Trace, eventWriter *
 			h.pingTickerInterval,
 			displayLimit,
 			args.EnableChunkMatches,
+			args.EnablePatchURLs,
+			args.EnableStructuredDiffs,
+			resultTypes,
 			logLatency,
+			export,
 		)
 		defer eventHandler.Done()
 
@@ -191,6 +219,63 @@ func (h *streamHandler) serveHTTP(r *http.Request, tr trace.Trace, eventWriter *
 	return err
 }
 
+const (
+	// maxConcurrentExports is the number of searches whose results are
+	// exported at the same time. Searches started while as many are exporting
+	// aren't exported.
+	maxConcurrentExports = 20
+
+	// exportFlushTimeout bounds how long the remaining results of a search
+	// are indexed after the request finished.
+	exportFlushTimeout = time.Minute
+)
+
+// exportSlots limits the number of searches exporting their results across
+// all requests, since results are still indexed after requests finished.
+var exportSlots = make(chan struct{}, maxConcurrentExports)
+
+// newExportSink returns the sink streamed results are exported to, or nil if
+// exporting isn't configured or too many searches are being exported. Matches
+// are indexed after the request finished, so the sink doesn't use the request
+// context. flush must be called once the search finished.
+func (h *streamHandler) newExportSink(ctx context.Context) (_ *opensearch.Sink, flush func()) {
+	c := conf.Get().ExperimentalFeatures
+	if c == nil || c.SearchExportOpensearch == nil {
+		return nil, nil
+	}
+
+	select {
+	case exportSlots <- struct{}{}:
+	default:
+		h.logger.Warn("not exporting search results, too many searches are being exported", log.Int("maxConcurrentExports", maxConcurrentExports))
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	sink, err := opensearch.NewSink(ctx, httpcli.ExternalDoer, opensearch.OptionsFromConfig(c.SearchExportOpensearch))
+	if err != nil {
+		cancel()
+		<-exportSlots
+		h.logger.Warn("invalid search.export.opensearch configuration", log.Error(err))
+		return nil, nil
+	}
+
+	return sink, func() {
+		defer func() {
+			cancel()
+			<-exportSlots
+		}()
+
+		// Cancelling the context aborts the bulk requests still in flight.
+		timer := time.AfterFunc(exportFlushTimeout, cancel)
+		defer timer.Stop()
+
+		if err := sink.Flush(); err != nil {
+			h.logger.Warn("failed to export search results", log.Error(err))
+		}
+	}
+}
+
 func logSearch(
 	ctx context.Context,
 	logger log.Logger,
@@ -240,9 +325,23 @@ type args struct {
 	PatternType                string
 	Display                    int
 	EnableChunkMatches         bool
+	EnablePatchURLs            bool
+	EnableStructuredDiffs      bool
+	GroupCommitsByRepo         bool
 	SearchMode                 int
 	ContextLines               *int32
 	ZoektSearchOptionsOverride string
+
+	// StreamID identifies the stream for ResultTypesHandler.
+	StreamID string
+	// ResultTypes are the result types sent to the client. TypeEmpty sends all
+	// types.
+	ResultTypes result.Types
+
+	// AfterWatermarks, if set, restricts commit and diff searches to commits
+	// that are new since the search that returned these watermarks in its
+	// done event.
+	AfterWatermarks map[api.RepoID][]string
 }
 
 func parseURLQuery(q url.Values) (*args, error) {
@@ -259,6 +358,7 @@ func parseURLQuery(q url.Values) (*args, error) {
 		Version:                    get("v", "V3"),
 		PatternType:                get("t", ""),
 		ZoektSearchOptionsOverride: get("zoekt-search-opts", ""),
+		StreamID:                   get("id", ""),
 	}
 
 	if a.Query == "" {
@@ -276,6 +376,30 @@ func parseURLQuery(q url.Values) (*args, error) {
 		return nil, errors.Errorf("chunk matches must be parseable as a boolean, got %q: %w", chunkMatches, err)
 	}
 
+	patchURLs := get("pu", "f")
+	if a.EnablePatchURLs, err = strconv.ParseBool(patchURLs); err != nil {
+		return nil, errors.Errorf("patch URLs must be parseable as a boolean, got %q: %w", patchURLs, err)
+	}
+
+	structuredDiffs := get("sd", "f")
+	if a.EnableStructuredDiffs, err = strconv.ParseBool(structuredDiffs); err != nil {
+		return nil, errors.Errorf("structured diffs must be parseable as a boolean, got %q: %w", structuredDiffs, err)
+	}
+
+	groupCommits := get("gr", "f")
+	if a.GroupCommitsByRepo, err = strconv.ParseBool(groupCommits); err != nil {
+		return nil, errors.Errorf("grouping commits by repository must be parseable as a boolean, got %q: %w", groupCommits, err)
+	}
+
+	if afterWatermarks := q.Get("aw"); afterWatermarks != "" {
+		if err := json.Unmarshal([]byte(afterWatermarks), &a.AfterWatermarks); err != nil {
+			return nil, errors.Errorf("after watermarks must be a JSON object mapping repository IDs to commit hashes, got %q: %w", afterWatermarks, err)
+		}
+		if a.AfterWatermarks == nil {
+			a.AfterWatermarks = map[api.RepoID][]string{}
+		}
+	}
+
 	if contextLines := q.Get("cl"); contextLines != "" {
 		parsedContextLines, err := strconv.ParseUint(contextLines, 10, 32)
 		if err != nil {
@@ -289,6 +413,10 @@ func parseURLQuery(q url.Values) (*args, error) {
 		return nil, errors.Errorf("search mode must be integer, got %q: %w", searchMode, err)
 	}
 
+	if a.ResultTypes, err = parseResultTypes(get("rt", "")); err != nil {
+		return nil, errors.Errorf("result types must be a comma-separated list of types: %w", err)
+	}
+
 	return &a, nil
 }
 
@@ -364,7 +492,11 @@ func newEventHandler(
 	progressInterval time.Duration,
 	displayLimit int,
 	enableChunkMatches bool,
+	enablePatchURLs bool,
+	enableStructuredDiffs bool,
+	resultTypes *resultTypesFilter,
 	logLatency func(),
+	export streaming.Sender,
 ) *eventHandler {
 	// Store marshalled matches and flush periodically or when we go over
 	// 32kb. 32kb chosen to be smaller than bufio.MaxTokenSize. Note: we can
@@ -374,19 +506,23 @@ func newEventHandler(
 	})
 
 	eh := &eventHandler{
-		ctx:                ctx,
-		logger:             logger,
-		db:                 db,
-		eventWriter:        eventWriter,
-		matchesBuf:         matchesBuf,
-		filters:            &streaming.SearchFilters{},
-		flushInterval:      flushInterval,
-		progress:           progress,
-		progressInterval:   progressInterval,
-		displayRemaining:   displayLimit,
-		enableChunkMatches: enableChunkMatches,
-		first:              true,
-		logLatency:         logLatency,
+		ctx:                   ctx,
+		logger:                logger,
+		db:                    db,
+		eventWriter:           eventWriter,
+		matchesBuf:            matchesBuf,
+		filters:               &streaming.SearchFilters{},
+		flushInterval:         flushInterval,
+		progress:              progress,
+		progressInterval:      progressInterval,
+		displayRemaining:      displayLimit,
+		enableChunkMatches:    enableChunkMatches,
+		enablePatchURLs:       enablePatchURLs,
+		enableStructuredDiffs: enableStructuredDiffs,
+		resultTypes:           resultTypes,
+		first:                 true,
+		logLatency:            logLatency,
+		export:                export,
 	}
 
 	// Schedule the first flushes.
@@ -406,12 +542,18 @@ type eventHandler struct {
 	db     database.DB
 
 	// Config params
-	enableChunkMatches bool
-	flushInterval      time.Duration
-	progressInterval   time.Duration
+	enableChunkMatches    bool
+	enablePatchURLs       bool
+	enableStructuredDiffs bool
+	flushInterval         time.Duration
+	progressInterval      time.Duration
+	resultTypes           *resultTypesFilter
 
 	logLatency func()
 
+	// export, if non-nil, is sent the matches that are sent to the client.
+	export streaming.Sender
+
 	// Everything below this line is protected by the mutex
 	mu sync.Mutex
 
@@ -436,7 +578,15 @@ func (h *eventHandler) Send(event streaming.SearchEvent) {
 	h.progress.Update(event)
 	h.filters.Update(event)
 
-	h.displayRemaining = event.Results.Limit(h.displayRemaining)
+	// Drop the matches of result types the client doesn't want before
+	// limiting, so that they don't use up the display limit.
+	results := make(result.Matches, 0, len(event.Results))
+	for _, match := range event.Results {
+		if h.resultTypes.Allows(match) {
+			results = append(results, match)
+		}
+	}
+	h.displayRemaining = results.Limit(h.displayRemaining)
 
 	repoMetadata, err := getEventRepoMetadata(h.ctx, h.db, event)
 	if err != nil {
@@ -446,7 +596,8 @@ func (h *eventHandler) Send(event streaming.SearchEvent) {
 		return
 	}
 
-	for _, match := range event.Results {
+	var exported result.Matches
+	for _, match := range results {
 		repo := match.RepoName()
 
 		// Don't send matches which we cannot map to a repo the actor has access to. This
@@ -456,10 +607,29 @@ func (h *eventHandler) Send(event streaming.SearchEvent) {
 			continue
 		}
 
+		if h.export != nil {
+			exported = append(exported, match)
+		}
+
 		eventMatch := search.FromMatch(match, repoMetadata, h.enableChunkMatches)
+		if cm, ok := match.(*result.CommitMatch); ok {
+			commitEvent := eventMatch.(*streamhttp.EventCommitMatch)
+			if h.enablePatchURLs {
+				commitEvent.PatchURL = cm.PatchURL().String()
+			}
+			if h.enableStructuredDiffs {
+				commitEvent.Diff = search.FromDiffMatch(cm)
+			}
+		}
 		h.matchesBuf.Append(eventMatch)
 	}
 
+	// Only export what the client is shown, so that the export doesn't
+	// contain matches the actor can't access.
+	if len(exported) > 0 {
+		h.export.Send(streaming.SearchEvent{Results: exported})
+	}
+
 	// Instantly send results if we have not sent any yet.
 	if h.first && len(event.Results) > 0 {
 		h.first = false
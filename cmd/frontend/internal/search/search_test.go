@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"testing"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/sourcegraph/sourcegraph/internal/actor"
 	api2 "github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbmocks"
 	"github.com/sourcegraph/sourcegraph/internal/search"
@@ -22,6 +24,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/search/result"
 	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
 	"github.com/sourcegraph/sourcegraph/internal/search/streaming/api"
+	streamclient "github.com/sourcegraph/sourcegraph/internal/search/streaming/client"
 	streamhttp "github.com/sourcegraph/sourcegraph/internal/search/streaming/http"
 	"github.com/sourcegraph/sourcegraph/internal/settings"
 	"github.com/sourcegraph/sourcegraph/internal/types"
@@ -278,6 +281,166 @@ func TestDisplayLimit(t *testing.T) {
 	}
 }
 
+func TestServeStream_resultTypes(t *testing.T) {
+	settings.MockCurrentUserFinal = &schema.Settings{}
+	t.Cleanup(func() { settings.MockCurrentUserFinal = nil })
+
+	mockInput := make(chan streaming.SearchEvent)
+	mock := client.NewMockSearchClient()
+	mock.PlanFunc.SetDefaultReturn(&search.Inputs{Query: query.Q{query.Parameter{Field: "count", Value: "1000"}}}, nil)
+	mock.ExecuteFunc.SetDefaultHook(func(_ context.Context, stream streaming.Sender, _ *search.Inputs) (*search.Alert, error) {
+		for event := range mockInput {
+			stream.Send(event)
+		}
+		return nil, nil
+	})
+
+	repos := dbmocks.NewMockRepoStore()
+	repos.MetadataFunc.SetDefaultHook(func(_ context.Context, ids ...api2.RepoID) ([]*types.SearchedRepo, error) {
+		res := make([]*types.SearchedRepo, 0, len(ids))
+		for _, id := range ids {
+			res = append(res, &types.SearchedRepo{ID: id, Name: api2.RepoName(fmt.Sprintf("repo%d", id))})
+		}
+		return res, nil
+	})
+	db := dbmocks.NewMockDB()
+	db.ReposFunc.SetDefaultReturn(repos)
+
+	authenticated := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.ServeHTTP(w, r.WithContext(actor.WithActor(r.Context(), actor.FromUser(1))))
+		})
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/stream", authenticated(&streamHandler{
+		logger:              logtest.Scoped(t),
+		db:                  db,
+		flushTickerInternal: 1 * time.Millisecond,
+		pingTickerInterval:  1 * time.Millisecond,
+		searchClient:        mock,
+	}))
+	mux.Handle("/result-types", authenticated(ResultTypesHandler()))
+	mux.Handle("/anonymous/result-types", ResultTypesHandler())
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	// Filtered matches don't use up the display limit.
+	res, err := http.Get(ts.URL + "/stream?q=test&id=s1&rt=repo,commit&display=2")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	var matches []streamhttp.EventMatch
+	received := make(chan struct{}, 10)
+	decoder := streamhttp.FrontendStreamDecoder{
+		OnMatches: func(ev []streamhttp.EventMatch) {
+			matches = append(matches, ev...)
+			received <- struct{}{}
+		},
+	}
+	g := errgroup.Group{}
+	g.Go(func() error {
+		return decoder.ReadAll(res.Body)
+	})
+
+	mkFileMatch := func(id int) *result.FileMatch {
+		return &result.FileMatch{File: result.File{
+			Repo: types.MinimalRepo{ID: api2.RepoID(id), Name: api2.RepoName(fmt.Sprintf("repo%d", id))},
+			Path: "README.md",
+		}}
+	}
+
+	// Path matches are not sent from the start.
+	mockInput <- streaming.SearchEvent{Results: result.Matches{mkRepoMatch(1), mkFileMatch(1)}}
+	<-received
+
+	postResultTypes := func(path, id, types string) int {
+		resp, err := http.PostForm(ts.URL+path, url.Values{"id": {id}, "types": {types}})
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	setResultTypes := func(id, types string) int {
+		return postResultTypes("/result-types", id, types)
+	}
+	// Anonymous users share a user ID, so they can't control streams.
+	require.Equal(t, http.StatusUnauthorized, postResultTypes("/anonymous/result-types", "s1", "path"))
+	require.Equal(t, http.StatusNotFound, setResultTypes("unknown", "path"))
+	require.Equal(t, http.StatusBadRequest, setResultTypes("s1", "bogus"))
+	require.Equal(t, http.StatusNoContent, setResultTypes("s1", "path"))
+
+	mockInput <- streaming.SearchEvent{Results: result.Matches{mkRepoMatch(2), mkFileMatch(2)}}
+	close(mockInput)
+	require.NoError(t, g.Wait())
+
+	var have []string
+	for _, match := range matches {
+		switch m := match.(type) {
+		case *streamhttp.EventRepoMatch:
+			have = append(have, "repo "+m.Repository)
+		case *streamhttp.EventPathMatch:
+			have = append(have, "path "+m.Repository)
+		}
+	}
+	require.Equal(t, []string{"repo repo1", "path repo2"}, have)
+
+	// The stream can no longer be controlled once it finished.
+	require.Equal(t, http.StatusNotFound, setResultTypes("s1", ""))
+}
+
+func TestEventHandlerExport(t *testing.T) {
+	repos := dbmocks.NewMockRepoStore()
+	repos.MetadataFunc.SetDefaultHook(func(_ context.Context, ids ...api2.RepoID) ([]*types.SearchedRepo, error) {
+		res := make([]*types.SearchedRepo, 0, len(ids))
+		for _, id := range ids {
+			// The actor can't access repo2.
+			if id == 2 {
+				continue
+			}
+			res = append(res, &types.SearchedRepo{ID: id, Name: api2.RepoName(fmt.Sprintf("repo%d", id))})
+		}
+		return res, nil
+	})
+	db := dbmocks.NewMockDB()
+	db.ReposFunc.SetDefaultReturn(repos)
+
+	w, err := streamhttp.NewWriter(httptest.NewRecorder())
+	require.NoError(t, err)
+
+	var exported result.Matches
+	export := streaming.StreamFunc(func(event streaming.SearchEvent) {
+		exported = append(exported, event.Results...)
+	})
+
+	h := newEventHandler(
+		context.Background(),
+		logtest.Scoped(t),
+		db,
+		newEventWriter(w),
+		&streamclient.ProgressAggregator{},
+		time.Hour,
+		time.Hour,
+		2,
+		false,
+		false,
+		false,
+		&resultTypesFilter{types: result.TypeRepo},
+		func() {},
+		export,
+	)
+	defer h.Done()
+
+	pathMatch := &result.FileMatch{File: result.File{
+		Repo: types.MinimalRepo{ID: 1, Name: "repo1"},
+		Path: "README.md",
+	}}
+	h.Send(streaming.SearchEvent{Results: result.Matches{mkRepoMatch(1), pathMatch, mkRepoMatch(2), mkRepoMatch(3)}})
+
+	// Only the matches sent to the client are exported: the path match is
+	// filtered by its type, repo2 is inaccessible and repo3 is over the
+	// display limit.
+	require.Equal(t, result.Matches{mkRepoMatch(1)}, exported)
+}
+
 func mkRepoMatch(id int) *result.RepoMatch {
 	return &result.RepoMatch{
 		ID:   api2.RepoID(id),
@@ -143,6 +143,8 @@ func (s *Server) search(ctx context.Context, args *protocol.SearchRequest, onMat
 		Query:                mt,
 		IncludeDiff:          args.IncludeDiff,
 		IncludeModifiedFiles: args.IncludeModifiedFiles || hasDiffModifiesFile,
+		Pathspecs:            args.Pathspecs(),
+		Since:                args.Since(),
 	}
 
 	return hitLimit.Load(), searcher.Search(ctx, limitedOnMatch)
@@ -16,6 +16,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/executil"
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/git"
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/internal/gitserverfs"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/dependencies"
 	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
@@ -359,7 +360,8 @@ func (s *vcsPackagesSyncer) versions(ctx context.Context, packageName reposource
 		}
 	}
 
-	listedPackages, _, _, err := s.svc.ListPackageRepoRefs(ctx, dependencies.ListDependencyReposOpts{
+	// Private package repos are only listed for internal actors.
+	listedPackages, _, _, err := s.svc.ListPackageRepoRefs(actor.WithInternalActor(ctx), dependencies.ListDependencyReposOpts{
 		Scheme:         s.scheme,
 		Name:           packageName,
 		ExactNameOnly:  true,
@@ -377,8 +379,14 @@ func (s *vcsPackagesSyncer) versions(ctx context.Context, packageName reposource
 		return combinedVersions, nil
 	}
 
-	for _, versions := range listedPackages[0].Versions {
-		combinedVersions = append(combinedVersions, versions.Version)
+	for _, version := range listedPackages[0].Versions {
+		// Versions are stored normalized, but package hosts only know them by
+		// the spelling they were referenced by, so fetch that one if we have it.
+		if version.OriginalVersion != "" {
+			combinedVersions = append(combinedVersions, version.OriginalVersion)
+		} else {
+			combinedVersions = append(combinedVersions, version.Version)
+		}
 	}
 
 	return combinedVersions, nil
@@ -0,0 +1,169 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/sourcegraph/log"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/internal/search/exhaustive/store"
+	"github.com/sourcegraph/sourcegraph/internal/search/exhaustive/types"
+	"github.com/sourcegraph/sourcegraph/internal/txemail"
+	"github.com/sourcegraph/sourcegraph/internal/txemail/txtypes"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// notifierBatchSize is the maximum number of finished search jobs we notify
+// about per run of the notifier.
+const notifierBatchSize = 100
+
+// newExhaustiveSearchNotifier creates a background routine that periodically
+// emails the initiators of search jobs which have finished.
+func newExhaustiveSearchNotifier(
+	ctx context.Context,
+	db database.DB,
+	exhaustiveSearchStore *store.Store,
+	config config,
+) goroutine.BackgroundRoutine {
+	n := &exhaustiveSearchNotifier{
+		logger: log.Scoped("exhaustive-search-notifier"),
+		db:     db,
+		store:  exhaustiveSearchStore,
+	}
+
+	return goroutine.NewPeriodicGoroutine(
+		ctx,
+		n,
+		goroutine.WithName("exhaustive_search_notifier"),
+		goroutine.WithDescription("notifies users when their search jobs have finished"),
+		goroutine.WithInterval(config.NotifierInterval),
+	)
+}
+
+type exhaustiveSearchNotifier struct {
+	logger log.Logger
+	db     database.DB
+	store  *store.Store
+}
+
+var _ goroutine.Handler = &exhaustiveSearchNotifier{}
+
+func (n *exhaustiveSearchNotifier) Handle(ctx context.Context) error {
+	jobs, err := n.store.ListSearchJobsToNotify(ctx, notifierBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for _, job := range jobs {
+		if err := n.notify(ctx, job); err != nil {
+			errs = errors.Append(errs, errors.Wrapf(err, "notifying initiator of search job %d", job.ID))
+			continue
+		}
+
+		if err := n.store.MarkSearchJobNotified(ctx, job.ID); err != nil {
+			errs = errors.Append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// notify sends an email to the initiator of job. Initiators we cannot email are
+// skipped, so that we don't try again on every run.
+func (n *exhaustiveSearchNotifier) notify(ctx context.Context, job *types.ExhaustiveSearchJob) error {
+	if !conf.CanSendEmail() {
+		return nil
+	}
+
+	email, verified, err := n.db.UserEmails().GetPrimaryEmail(ctx, job.InitiatorID)
+	if err != nil {
+		if errcode.IsNotFound(err) {
+			n.logger.Debug("skipping notification, user has no primary email", log.Int32("userID", job.InitiatorID))
+			return nil
+		}
+		return err
+	}
+	if !verified {
+		n.logger.Debug("skipping notification, primary email is not verified", log.Int32("userID", job.InitiatorID))
+		return nil
+	}
+
+	data, err := newSearchJobFinishedTemplateData(job)
+	if err != nil {
+		return err
+	}
+
+	return txemail.Send(ctx, "search-jobs", txtypes.Message{
+		To:       []string{email},
+		Template: searchJobFinishedEmailTemplates,
+		Data:     data,
+	})
+}
+
+type searchJobFinishedTemplateData struct {
+	Query         string
+	Failed        bool
+	SearchJobsURL string
+	ResultsURL    string
+	LogsURL       string
+}
+
+func newSearchJobFinishedTemplateData(job *types.ExhaustiveSearchJob) (*searchJobFinishedTemplateData, error) {
+	externalURL := conf.Get().ExternalURL
+
+	searchJobsURL, err := url.JoinPath(externalURL, "/search-jobs")
+	if err != nil {
+		return nil, err
+	}
+	resultsURL, err := url.JoinPath(externalURL, fmt.Sprintf("/.api/search/export/%d.json", job.ID))
+	if err != nil {
+		return nil, err
+	}
+	logsURL, err := url.JoinPath(externalURL, fmt.Sprintf("/.api/search/export/%d.log", job.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &searchJobFinishedTemplateData{
+		Query:         job.Query,
+		Failed:        job.AggState == types.JobStateFailed,
+		SearchJobsURL: searchJobsURL,
+		ResultsURL:    resultsURL,
+		LogsURL:       logsURL,
+	}, nil
+}
+
+var searchJobFinishedEmailTemplates = txemail.MustValidate(txtypes.Templates{
+	Subject: `Your Sourcegraph search job {{ if .Failed }}finished with errors{{ else }}has completed{{ end }}`,
+	Text: `
+Your search job for the query
+
+  {{.Query}}
+
+{{ if .Failed }}finished, but some repositories could not be searched. The results that were found are still available.{{ else }}has completed.{{ end }}
+
+Download results: {{.ResultsURL}}
+Download logs: {{.LogsURL}}
+
+View all your search jobs: {{.SearchJobsURL}}
+`,
+	HTML: `
+<p>Your search job for the query</p>
+
+<p><code>{{.Query}}</code></p>
+
+<p>{{ if .Failed }}finished, but some repositories could not be searched. The results that were found are still available.{{ else }}has completed.{{ end }}</p>
+
+<p>
+  <a href="{{.ResultsURL}}">Download results</a> &middot; <a href="{{.LogsURL}}">Download logs</a>
+</p>
+
+<p><a href="{{.SearchJobsURL}}">View all your search jobs</a></p>
+`,
+})
@@ -8,6 +8,7 @@ import (
 	"github.com/sourcegraph/log"
 
 	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/env"
 	"github.com/sourcegraph/sourcegraph/internal/goroutine"
 	"github.com/sourcegraph/sourcegraph/internal/observation"
 	"github.com/sourcegraph/sourcegraph/internal/search/exhaustive/service"
@@ -21,6 +22,10 @@ import (
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
+// maxMatchesPerRevision bounds the number of results we persist for a single
+// revision of a repository.
+var maxMatchesPerRevision = env.MustGetInt("SRC_SEARCH_JOBS_MAX_MATCHES_PER_REVISION", 1_000_000, "Maximum number of results a search job stores for a revision of a repository.")
+
 // newExhaustiveSearchRepoRevisionWorker creates a background routine that periodically runs the exhaustive search of a revision on a repo.
 func newExhaustiveSearchRepoRevisionWorker(
 	ctx context.Context,
@@ -77,7 +82,11 @@ func (h *exhaustiveSearchRepoRevHandler) Handle(ctx context.Context, logger log.
 		return err
 	}
 
-	err = q.Search(ctx, repoRev, w)
+	err = q.Search(ctx, repoRev, service.NewLimitMatchWriter(w, maxMatchesPerRevision))
+	if errors.Is(err, service.ErrMatchLimitReached) {
+		logger.Info("stopped search of revision after reaching match limit", log.Int("limit", maxMatchesPerRevision))
+		err = nil
+	}
 	if closeErr := w.Flush(); closeErr != nil {
 		err = errors.Append(err, closeErr)
 	}
@@ -90,7 +90,8 @@ func TestExhaustiveSearch(t *testing.T) {
 	searchJob := &searchJob{
 		workerDB: db,
 		config: config{
-			WorkerInterval: 10 * time.Millisecond,
+			WorkerInterval:   10 * time.Millisecond,
+			NotifierInterval: 10 * time.Millisecond,
 		},
 	}
 
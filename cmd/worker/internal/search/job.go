@@ -24,6 +24,10 @@ import (
 type config struct {
 	// WorkerInterval sets WorkerOptions.Interval for every worker
 	WorkerInterval time.Duration
+
+	// NotifierInterval sets how often we check for finished jobs to notify
+	// their initiators about.
+	NotifierInterval time.Duration
 }
 
 type searchJob struct {
@@ -44,7 +48,8 @@ type searchJob struct {
 func NewSearchJob() job.Job {
 	return &searchJob{
 		config: config{
-			WorkerInterval: 1 * time.Second,
+			WorkerInterval:   1 * time.Second,
+			NotifierInterval: 1 * time.Minute,
 		},
 	}
 }
@@ -113,6 +118,8 @@ func (j *searchJob) newSearchJobRoutines(
 			newExhaustiveSearchRepoWorker(workCtx, observationCtx, repoWorkerStore, exhaustiveSearchStore, newSearcher, j.config),
 			newExhaustiveSearchRepoRevisionWorker(workCtx, observationCtx, revWorkerStore, exhaustiveSearchStore, newSearcher, uploadStore, j.config),
 
+			newExhaustiveSearchNotifier(workCtx, db, exhaustiveSearchStore, j.config),
+
 			// resetters
 			newExhaustiveSearchWorkerResetter(observationCtx, searchWorkerStore),
 			newExhaustiveSearchRepoWorkerResetter(observationCtx, repoWorkerStore),
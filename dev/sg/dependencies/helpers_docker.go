@@ -0,0 +1,160 @@
+package dependencies
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/dev/sg/internal/check"
+	"github.com/sourcegraph/sourcegraph/dev/sg/internal/std"
+	"github.com/sourcegraph/sourcegraph/dev/sg/internal/usershell"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+const (
+	// Minimum resources the Docker daemon needs for the containers started by
+	// 'sg start' (databases, Jaeger, Grafana, ...) alongside the executors.
+	dockerMinMemoryBytes   = 8 << 30
+	dockerMinCPUs          = 4
+	dockerMinFreeDiskBytes = 20 << 30
+
+	// dockerDiskProbeImage is the image used to measure the disk space available
+	// to the Docker daemon, which lives inside a VM on most setups.
+	dockerDiskProbeImage = "busybox:1.36"
+
+	// dockerColimaDiskGiB is the disk size requested when resizing a Colima VM.
+	// Colima can only grow disks, so this is deliberately generous.
+	dockerColimaDiskGiB = 100
+)
+
+// dockerResources are the resources allocated to the Docker daemon.
+type dockerResources struct {
+	// Name is the hostname of the machine or VM the daemon runs in.
+	Name string
+	// OperatingSystem is e.g. "Docker Desktop" or "Ubuntu 22.04.2 LTS".
+	OperatingSystem string
+	MemTotal        int64
+	NCPU            int
+	// FreeDisk is the number of bytes available for images and containers, or -1
+	// if it could not be determined.
+	FreeDisk int64
+}
+
+func checkDockerResources(ctx context.Context, out *std.Output, args CheckArgs) error {
+	resources, err := getDockerResources(ctx)
+	if err != nil {
+		return err
+	}
+
+	if problems := resources.problems(); len(problems) > 0 {
+		return errors.Newf("the Docker daemon has insufficient resources:\n%s\n\n%s",
+			strings.Join(problems, "\n"), resources.guidance())
+	}
+	return nil
+}
+
+// fixDockerResources resizes the VM for Colima-managed Docker daemons. For any
+// other setup resources have to be changed by hand.
+func fixDockerResources(ctx context.Context, cio check.IO, args CheckArgs) error {
+	resources, err := getDockerResources(ctx)
+	if err != nil {
+		return err
+	}
+	if !resources.isColima() {
+		return errors.New(resources.guidance())
+	}
+
+	cmd := fmt.Sprintf("colima stop && colima start --cpu %d --memory %d --disk %d",
+		max(resources.NCPU, dockerMinCPUs),
+		max(resources.MemTotal>>30, dockerMinMemoryBytes>>30),
+		dockerColimaDiskGiB,
+	)
+	cio.Writef("Restarting the Colima VM with more resources: %s", cmd)
+	return cmdFix(cmd)(ctx, cio, args)
+}
+
+func getDockerResources(ctx context.Context) (dockerResources, error) {
+	out, err := usershell.Command(ctx, "docker info --format '{{json .}}'").StdOut().Run().String()
+	if err != nil {
+		return dockerResources{}, errors.Wrap(err, "failed to run 'docker info', is the Docker daemon running?")
+	}
+
+	resources, err := parseDockerInfo(out)
+	if err != nil {
+		return dockerResources{}, err
+	}
+
+	resources.FreeDisk = -1
+	// The image may not be present yet, so this can take a little while the first
+	// time. If it fails, we only skip the disk check.
+	df, err := usershell.Command(ctx, fmt.Sprintf("docker run --rm --entrypoint df %s -Pk /", dockerDiskProbeImage)).StdOut().Run().String()
+	if err == nil {
+		if free, err := parseDfAvailable(df); err == nil {
+			resources.FreeDisk = free
+		}
+	}
+
+	return resources, nil
+}
+
+func parseDockerInfo(out string) (dockerResources, error) {
+	var resources dockerResources
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &resources); err != nil {
+		return dockerResources{}, errors.Wrap(err, "unexpected output from 'docker info'")
+	}
+	return resources, nil
+}
+
+// parseDfAvailable returns the available bytes reported by 'df -Pk'.
+func parseDfAvailable(out string) (int64, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) < 2 {
+		return 0, errors.Newf("unexpected output from df: %s", out)
+	}
+
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 4 {
+		return 0, errors.Newf("unexpected output from df: %s", out)
+	}
+
+	available, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unexpected output from df: %s", out)
+	}
+	return available << 10, nil
+}
+
+func (r dockerResources) problems() []string {
+	var problems []string
+	if r.MemTotal < dockerMinMemoryBytes {
+		problems = append(problems, fmt.Sprintf("- memory: %s allocated, at least %s required", formatGiB(r.MemTotal), formatGiB(dockerMinMemoryBytes)))
+	}
+	if r.NCPU < dockerMinCPUs {
+		problems = append(problems, fmt.Sprintf("- CPUs: %d allocated, at least %d required", r.NCPU, dockerMinCPUs))
+	}
+	if r.FreeDisk >= 0 && r.FreeDisk < dockerMinFreeDiskBytes {
+		problems = append(problems, fmt.Sprintf("- disk: %s free for images and containers, at least %s required", formatGiB(r.FreeDisk), formatGiB(dockerMinFreeDiskBytes)))
+	}
+	return problems
+}
+
+func (r dockerResources) isColima() bool {
+	return strings.HasPrefix(r.Name, "colima")
+}
+
+func (r dockerResources) guidance() string {
+	switch {
+	case r.isColima():
+		return "Run 'sg setup --fix' to restart the Colima VM with more resources, or run 'colima stop' and 'colima start --cpu <n> --memory <GiB> --disk <GiB>' yourself."
+	case strings.Contains(r.OperatingSystem, "Docker Desktop"):
+		return "Increase the resources in Docker Desktop under Settings > Resources, then click 'Apply & restart'. Free up disk space with 'docker system prune'."
+	default:
+		return "The Docker daemon uses the resources of this machine. Free up disk space with 'docker system prune' or by removing unused images and volumes. If the daemon runs in a VM, give the VM more memory and CPUs."
+	}
+}
+
+func formatGiB(bytes int64) string {
+	return fmt.Sprintf("%.1f GiB", float64(bytes)/(1<<30))
+}
@@ -0,0 +1,45 @@
+package dependencies
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDockerInfo(t *testing.T) {
+	resources, err := parseDockerInfo(`{"ID":"abc","NCPU":2,"MemTotal":2084024320,"Name":"colima","OperatingSystem":"Ubuntu 23.10"}` + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, dockerResources{Name: "colima", OperatingSystem: "Ubuntu 23.10", MemTotal: 2084024320, NCPU: 2}, resources)
+	assert.True(t, resources.isColima())
+
+	_, err = parseDockerInfo("Cannot connect to the Docker daemon")
+	assert.Error(t, err)
+}
+
+func TestParseDfAvailable(t *testing.T) {
+	free, err := parseDfAvailable(`Filesystem           1024-blocks    Used Available Capacity Mounted on
+overlay               61202244  40123456  18017876  69% /
+`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(18017876)<<10, free)
+
+	_, err = parseDfAvailable("df: /: No such file or directory")
+	assert.Error(t, err)
+}
+
+func TestDockerResourcesProblems(t *testing.T) {
+	enough := dockerResources{MemTotal: dockerMinMemoryBytes, NCPU: dockerMinCPUs, FreeDisk: dockerMinFreeDiskBytes}
+	assert.Empty(t, enough.problems())
+
+	unknownDisk := enough
+	unknownDisk.FreeDisk = -1
+	assert.Empty(t, unknownDisk.problems())
+
+	tooSmall := dockerResources{MemTotal: 2 << 30, NCPU: 2, FreeDisk: 5 << 30}
+	assert.Equal(t, []string{
+		"- memory: 2.0 GiB allocated, at least 8.0 GiB required",
+		"- CPUs: 2 allocated, at least 4 required",
+		"- disk: 5.0 GiB free for images and containers, at least 20.0 GiB required",
+	}, tooSmall.problems())
+}
@@ -148,6 +148,12 @@ var Mac = []category{
 					return usershell.Cmd(ctx, "open --hide --background /Applications/Docker.app").Run()
 				},
 			},
+			{
+				Name:        "Docker resources",
+				Description: "The Docker daemon needs enough memory, CPUs and disk space to run the containers started by 'sg start'.",
+				Check:       checkDockerResources,
+				Fix:         fixDockerResources,
+			},
 		},
 	},
 	categoryCloneRepositories(),
@@ -333,7 +339,9 @@ WARNING: if you just fixed (automatically or manually) this step, you must resta
 			},
 		},
 	},
-	categoryAdditionalSGConfiguration(),
+	categoryAdditionalSGConfiguration(
+		dependencyDirenv(cmdFix("brew install direnv")),
+	),
 	{
 		Name:      "Cloud services",
 		DependsOn: []string{depsHomebrew},
@@ -223,8 +223,8 @@ func categoryProgrammingLanguagesAndTools(additionalChecks ...*dependency) categ
 	return categories
 }
 
-func categoryAdditionalSGConfiguration() category {
-	return category{
+func categoryAdditionalSGConfiguration(additionalChecks ...*dependency) category {
+	categories := category{
 		Name: "Additional sg configuration",
 		Checks: []*dependency{
 			{
@@ -316,6 +316,106 @@ func categoryAdditionalSGConfiguration() category {
 			},
 		},
 	}
+	categories.Checks = append(categories.Checks, additionalChecks...)
+	return categories
+}
+
+// dependencyDirenv checks that direnv is installed, hooked into the user's
+// shell, and allowed to load the .envrc at the root of the repository if there
+// is one. The repository doesn't ship an .envrc: it is where developers keep
+// their local environment variables. install is the platform-specific command
+// that installs direnv.
+func dependencyDirenv(install check.FixAction[CheckArgs]) *dependency {
+	return &dependency{
+		Name: "direnv",
+		Description: `We use direnv to load the environment variables you declare in an .envrc at the
+root of the repository whenever you enter it: https://direnv.net`,
+		Enabled: func(ctx context.Context, args CheckArgs) error {
+			if !usershell.IsSupportedShell(ctx) {
+				return errors.New("direnv setup is only supported for bash and zsh")
+			}
+			return nil
+		},
+		Check: func(ctx context.Context, out *std.Output, args CheckArgs) error {
+			if err := check.InPath("direnv")(ctx); err != nil {
+				return err
+			}
+
+			shellConfig := usershell.ShellConfigPath(ctx)
+			conf, err := os.ReadFile(shellConfig)
+			if err != nil {
+				return err
+			}
+			if !strings.Contains(string(conf), direnvHook(usershell.ShellType(ctx))) {
+				return errors.Newf("direnv hook not found in shell config %s", shellConfig)
+			}
+
+			if ok, err := hasEnvrc(); err != nil || !ok {
+				return err
+			}
+			status, err := root.Run(usershell.Command(ctx, "direnv status")).String()
+			if err != nil {
+				return errors.Wrap(err, "direnv status")
+			}
+			if !direnvAllowedRegexp.MatchString(status) {
+				return errors.New(".envrc is not allowed, run 'direnv allow' in the repository root")
+			}
+			return nil
+		},
+		Fix: func(ctx context.Context, cio check.IO, args CheckArgs) error {
+			if err := check.InPath("direnv")(ctx); err != nil {
+				if err := install(ctx, cio, args); err != nil {
+					return errors.Wrap(err, "installing direnv")
+				}
+			}
+
+			shellConfig := usershell.ShellConfigPath(ctx)
+			if shellConfig == "" {
+				return errors.New("Failed to detect shell config path")
+			}
+			conf, err := os.ReadFile(shellConfig)
+			if err != nil {
+				return err
+			}
+			if hook := direnvHook(usershell.ShellType(ctx)); !strings.Contains(string(conf), hook) {
+				cio.Verbosef("Adding direnv hook to %s", shellConfig)
+				if err := usershell.Run(ctx,
+					"echo", run.Arg(hook), ">>", shellConfig,
+				).Wait(); err != nil {
+					return errors.Wrap(err, "adding direnv hook")
+				}
+			}
+
+			if ok, err := hasEnvrc(); err != nil || !ok {
+				return err
+			}
+			return root.Run(usershell.Command(ctx, "direnv allow")).StreamLines(cio.Verbose)
+		},
+	}
+}
+
+// hasEnvrc returns whether there is an .envrc at the root of the repository.
+func hasEnvrc() (bool, error) {
+	repoRoot, err := root.RepositoryRoot()
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(filepath.Join(repoRoot, ".envrc")); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// direnvAllowedRegexp matches the output of 'direnv status' when the .envrc
+// found is allowed. Older versions of direnv report it as 'true', newer ones
+// as 0.
+var direnvAllowedRegexp = regexp.MustCompile(`(?m)^Found RC allowed (true|0)$`)
+
+func direnvHook(shell usershell.Shell) string {
+	return fmt.Sprintf(`eval "$(direnv hook %s)"`, shell)
 }
 
 var gcloudSourceRegexp = regexp.MustCompile(`(Source \[)(?P<path>[^\]]*)(\] in your profile)`)
@@ -18,3 +18,11 @@ func TestGcloudSourceRegexp(t *testing.T) {
 	require.Greater(t, len(matches), 0)
 	assert.Equal(t, matches[gcloudSourceRegexp.SubexpIndex("path")], "/foobar/path.zsh.inc")
 }
+
+func TestDirenvAllowedRegexp(t *testing.T) {
+	assert.True(t, direnvAllowedRegexp.MatchString("Loaded RC path /foobar/.envrc\nFound RC path /foobar/.envrc\nFound RC allowed true\n"))
+	assert.True(t, direnvAllowedRegexp.MatchString("Found RC path /foobar/.envrc\nFound RC allowed 0\nFound RC allowPath /foobar/allow\n"))
+	assert.False(t, direnvAllowedRegexp.MatchString("Found RC path /foobar/.envrc\nFound RC allowed false\n"))
+	assert.False(t, direnvAllowedRegexp.MatchString("Found RC path /foobar/.envrc\nFound RC allowed 1\n"))
+	assert.False(t, direnvAllowedRegexp.MatchString("No .envrc or .env loaded\n"))
+}
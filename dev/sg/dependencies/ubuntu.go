@@ -151,6 +151,12 @@ var Ubuntu = []category{
 					return err
 				},
 			},
+			{
+				Name:        "Docker resources",
+				Description: "The Docker daemon needs enough memory, CPUs and disk space to run the containers started by 'sg start'.",
+				Check:       checkDockerResources,
+				Fix:         fixDockerResources,
+			},
 		},
 	},
 	categoryCloneRepositories(),
@@ -264,7 +270,9 @@ WARNING: if you just fixed (automatically or manually) this step, you must resta
 			},
 		},
 	},
-	categoryAdditionalSGConfiguration(),
+	categoryAdditionalSGConfiguration(
+		dependencyDirenv(aptGetInstall("direnv")),
+	),
 	{
 		Name:      "Cloud services",
 		DependsOn: []string{depsBaseUtilities},
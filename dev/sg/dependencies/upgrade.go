@@ -0,0 +1,159 @@
+package dependencies
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/sourcegraph/sourcegraph/dev/sg/internal/check"
+	"github.com/sourcegraph/sourcegraph/dev/sg/internal/std"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// versionSensitiveChecks maps files in the repository that declare tool
+// version requirements to the names of the checks that depend on them.
+var versionSensitiveChecks = map[string][]string{
+	".tool-versions": {"go", "python", "node", "rust", "asdf reshim"},
+	"go.mod":         {"go"},
+	"package.json":   {"pnpm", "node"},
+}
+
+// SetupState records the contents of the requirement files as of the last
+// successful setup, so that 'sg setup upgrade' can tell what changed since.
+type SetupState struct {
+	// Requirements maps requirement files to the SHA-256 of their contents.
+	Requirements map[string]string `json:"requirements"`
+}
+
+func setupStatePath(sgHome string) string {
+	return filepath.Join(sgHome, "setup-state.json")
+}
+
+// LoadSetupState reads the setup state stored in sgHome. It returns an empty
+// state if none was stored yet.
+func LoadSetupState(sgHome string) (*SetupState, error) {
+	data, err := os.ReadFile(setupStatePath(sgHome))
+	if errors.Is(err, os.ErrNotExist) {
+		return &SetupState{Requirements: map[string]string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state SetupState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, errors.Wrap(err, "invalid setup state")
+	}
+	if state.Requirements == nil {
+		state.Requirements = map[string]string{}
+	}
+	return &state, nil
+}
+
+// Save writes the state to sgHome.
+func (s *SetupState) Save(sgHome string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(setupStatePath(sgHome), data, 0o644)
+}
+
+// CurrentSetupState hashes the requirement files in repoRoot. Missing files are
+// left out.
+func CurrentSetupState(repoRoot string) (*SetupState, error) {
+	state := &SetupState{Requirements: map[string]string{}}
+	for file := range versionSensitiveChecks {
+		f, err := os.Open(filepath.Join(repoRoot, file))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", file)
+		}
+		state.Requirements[file] = hex.EncodeToString(h.Sum(nil))
+	}
+	return state, nil
+}
+
+// ChangedRequirements returns the sorted requirement files whose contents
+// differ between previous and s.
+func (s *SetupState) ChangedRequirements(previous *SetupState) []string {
+	var changed []string
+	for file := range versionSensitiveChecks {
+		if s.Requirements[file] != previous.Requirements[file] {
+			changed = append(changed, file)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// ChecksForRequirements returns the sorted names of the checks affected by
+// changes to the given requirement files.
+func ChecksForRequirements(files []string) []string {
+	names := map[string]struct{}{}
+	for _, file := range files {
+		for _, name := range versionSensitiveChecks[file] {
+			names[name] = struct{}{}
+		}
+	}
+
+	checks := make([]string, 0, len(names))
+	for name := range names {
+		checks = append(checks, name)
+	}
+	sort.Strings(checks)
+	return checks
+}
+
+// SetupUpgrade instantiates a runner that only checks and fixes the setup
+// dependencies with the given names.
+func SetupUpgrade(in io.Reader, out *std.Output, os OS, checkNames []string) *check.Runner[CheckArgs] {
+	categories := Ubuntu
+	if os == OSMac {
+		categories = Mac
+	}
+	return check.NewRunner(in, out, filterChecks(categories, checkNames))
+}
+
+// filterChecks returns copies of the categories that only contain the checks
+// with the given names. Categories without any of these checks are dropped.
+func filterChecks(categories []category, checkNames []string) []category {
+	wanted := make(map[string]struct{}, len(checkNames))
+	for _, name := range checkNames {
+		wanted[name] = struct{}{}
+	}
+
+	var filtered []category
+	for _, c := range categories {
+		var checks []*dependency
+		for _, d := range c.Checks {
+			if _, ok := wanted[d.Name]; ok {
+				checks = append(checks, d)
+			}
+		}
+		if len(checks) == 0 {
+			continue
+		}
+
+		c.Checks = checks
+		// The dependencies of the category were set up by the full setup
+		// that recorded the previous state, and their categories may have
+		// been dropped above.
+		c.DependsOn = nil
+		filtered = append(filtered, c)
+	}
+	return filtered
+}
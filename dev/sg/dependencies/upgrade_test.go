@@ -0,0 +1,64 @@
+package dependencies
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupState(t *testing.T) {
+	repoRoot := t.TempDir()
+	sgHome := t.TempDir()
+	write := func(file, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoRoot, file), []byte(content), 0o644))
+	}
+
+	write(".tool-versions", "golang 1.21.0\nnodejs 20.8.0\n")
+	write("go.mod", "module example\n\ngo 1.21\n")
+
+	// Without a stored state, every existing requirement file counts as
+	// changed.
+	previous, err := LoadSetupState(sgHome)
+	require.NoError(t, err)
+	current, err := CurrentSetupState(repoRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []string{".tool-versions", "go.mod"}, current.ChangedRequirements(previous))
+
+	require.NoError(t, current.Save(sgHome))
+	previous, err = LoadSetupState(sgHome)
+	require.NoError(t, err)
+	assert.Equal(t, current, previous)
+	assert.Empty(t, current.ChangedRequirements(previous))
+
+	write("go.mod", "module example\n\ngo 1.22\n")
+	write("package.json", "{}\n")
+	current, err = CurrentSetupState(repoRoot)
+	require.NoError(t, err)
+	changed := current.ChangedRequirements(previous)
+	assert.Equal(t, []string{"go.mod", "package.json"}, changed)
+	assert.Equal(t, []string{"go", "node", "pnpm"}, ChecksForRequirements(changed))
+}
+
+func TestFilterChecks(t *testing.T) {
+	categories := []category{{
+		Name:      "languages",
+		DependsOn: []string{"base"},
+		Checks:    []*dependency{{Name: "go"}, {Name: "python"}, {Name: "node"}},
+	}, {
+		Name:   "base",
+		Checks: []*dependency{{Name: "git"}},
+	}}
+
+	filtered := filterChecks(categories, []string{"go", "node"})
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "languages", filtered[0].Name)
+	assert.Empty(t, filtered[0].DependsOn)
+	assert.Equal(t, []*dependency{{Name: "go"}, {Name: "node"}}, filtered[0].Checks)
+
+	// The original categories are left untouched.
+	assert.Len(t, categories[0].Checks, 3)
+	assert.Equal(t, []string{"base"}, categories[0].DependsOn)
+}
@@ -153,6 +153,17 @@ func (r *Runner[Args]) Interactive(
 	ctx context.Context,
 	args Args,
 ) error {
+	_, err := r.InteractiveSatisfied(ctx, args)
+	return err
+}
+
+// InteractiveSatisfied is like Interactive, but additionally reports whether all
+// checks were satisfied when it returned. If the user quits early, it returns
+// false and a nil error.
+func (r *Runner[Args]) InteractiveSatisfied(
+	ctx context.Context,
+	args Args,
+) (satisfied bool, _ error) {
 	var span *analytics.Span
 	ctx, span = r.startSpan(ctx, "Interactive")
 	defer span.End()
@@ -184,9 +195,9 @@ func (r *Runner[Args]) Interactive(
 		choice, err := getChoice(r.Input, r.Output, buildChoices(results.failed))
 		if err != nil {
 			if err == io.EOF {
-				return nil
+				return false, nil
 			}
-			return err
+			return false, err
 		}
 
 		switch choice {
@@ -194,12 +205,12 @@ func (r *Runner[Args]) Interactive(
 			{
 				if everythingFixed := r.fixAllCategories(ctx, args, results); everythingFixed {
 					// evertyhing got fixed \o/
-					return nil
+					return true, nil
 				}
 			}
 		case FixQuit:
 			{
-				return nil
+				return false, nil
 			}
 		default:
 			{
@@ -212,7 +223,7 @@ func (r *Runner[Args]) Interactive(
 				err = r.presentFailedCategoryWithOptions(ctx, idx, &selectedCategory, args, results)
 				if err != nil {
 					if err == io.EOF {
-						return nil // we are done
+						return false, nil // we are done
 					}
 
 					r.Output.WriteWarningf("Encountered error while fixing: %s", err.Error())
@@ -222,7 +233,7 @@ func (r *Runner[Args]) Interactive(
 		}
 	}
 
-	return nil
+	return true, nil
 }
 
 // runAllCategoryChecksResult provides a summary of categories checks results.
@@ -297,6 +297,18 @@ func TestRunnerInteractive(t *testing.T) {
 		}
 	})
 
+	t.Run("quit unsatisfied", func(t *testing.T) {
+		var output strings.Builder
+		runner := check.NewRunner(
+			strings.NewReader(""), // EOF, as if the user quit
+			getOutput(&output),
+			getUnsatisfiableChecks(t))
+
+		satisfied, err := runner.InteractiveSatisfied(context.Background(), nil)
+		require.NoError(t, err)
+		assert.False(t, satisfied)
+	})
+
 	t.Run("manual fix", func(t *testing.T) {
 		inputs := []string{
 			"4", // fixable
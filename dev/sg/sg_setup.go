@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
 
 	"github.com/urfave/cli/v2"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/dev/sg/internal/std"
 	"github.com/sourcegraph/sourcegraph/dev/sg/root"
 	"github.com/sourcegraph/sourcegraph/lib/cliutil/exit"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
 	"github.com/sourcegraph/sourcegraph/lib/output"
 )
 
@@ -42,19 +44,25 @@ var setupCommand = &cli.Command{
 		Action: func(cmd *cli.Context) error {
 			return root.Run(run.Bash(cmd.Context, "rm .git/hooks/pre-commit || echo \"no pre-commit hook was found.\"")).Stream(os.Stdout)
 		},
+	}, {
+		Name:  "upgrade",
+		Usage: "Re-check only the dependencies whose version requirements changed since the last setup, e.g. after a git pull",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "check",
+				Aliases: []string{"c"},
+				Usage:   "Only run checks and report setup state",
+			},
+		},
+		Action: setupUpgradeAction,
 	}},
 	Action: func(cmd *cli.Context) error {
-		if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
-			std.Out.WriteLine(output.Styled(output.StyleWarning, "'sg setup' currently only supports macOS and Linux"))
-			return exit.NewEmptyExitErr(1)
-		}
-
-		currentOS := runtime.GOOS
-		if overridesOS, ok := os.LookupEnv("SG_FORCE_OS"); ok {
-			currentOS = overridesOS
+		currentOS, err := setupOS()
+		if err != nil {
+			return err
 		}
 
-		setup := dependencies.Setup(cmd.App.Reader, std.Out, dependencies.OS(currentOS))
+		setup := dependencies.Setup(cmd.App.Reader, std.Out, currentOS)
 		setup.AnalyticsCategory = "setup"
 		setup.RenderDescription = func(out *std.Output) {
 			printSgSetupWelcomeScreen(out)
@@ -69,23 +77,131 @@ var setupCommand = &cli.Command{
 			DisablePreCommits:   cmd.Bool("skip-pre-commit"),
 		}
 
+		// Check and Fix only succeed if every check passed, but Interactive
+		// also returns without error if the user quits early.
+		satisfied := true
 		switch {
 		case cmd.Bool("check"):
-			err := setup.Check(cmd.Context, args)
+			err = setup.Check(cmd.Context, args)
 			if err != nil {
 				std.Out.WriteSuggestionf("Run 'sg setup -fix' to try and automatically fix issues!")
 			}
-			return err
 
 		case cmd.Bool("fix"):
-			return setup.Fix(cmd.Context, args)
+			err = setup.Fix(cmd.Context, args)
 
 		default:
-			return setup.Interactive(cmd.Context, args)
+			satisfied, err = setup.InteractiveSatisfied(cmd.Context, args)
+		}
+		if err != nil {
+			return err
+		}
+		if !satisfied {
+			return nil
+		}
+
+		// Remember the version requirements we just set up, so that
+		// 'sg setup upgrade' only re-checks what changes from here on.
+		if err := saveSetupState(); err != nil {
+			std.Out.WriteWarningf("Failed to save setup state: %s", err)
 		}
+		return nil
 	},
 }
 
+// setupOS returns the OS to set up dependencies for.
+func setupOS() (dependencies.OS, error) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		std.Out.WriteLine(output.Styled(output.StyleWarning, "'sg setup' currently only supports macOS and Linux"))
+		return "", exit.NewEmptyExitErr(1)
+	}
+
+	currentOS := runtime.GOOS
+	if overridesOS, ok := os.LookupEnv("SG_FORCE_OS"); ok {
+		currentOS = overridesOS
+	}
+	return dependencies.OS(currentOS), nil
+}
+
+func setupUpgradeAction(cmd *cli.Context) error {
+	currentOS, err := setupOS()
+	if err != nil {
+		return err
+	}
+
+	repoRoot, err := root.RepositoryRoot()
+	if err != nil {
+		return err
+	}
+	sgHome, err := root.GetSGHomePath()
+	if err != nil {
+		return err
+	}
+
+	previous, err := dependencies.LoadSetupState(sgHome)
+	if err != nil {
+		return err
+	}
+	current, err := dependencies.CurrentSetupState(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	changed := current.ChangedRequirements(previous)
+	if len(changed) == 0 {
+		std.Out.WriteSuccessf("No version requirements changed since the last setup.")
+		return nil
+	}
+	checks := dependencies.ChecksForRequirements(changed)
+	std.Out.WriteNoticef("Version requirements changed in %s, re-checking: %s",
+		strings.Join(changed, ", "), strings.Join(checks, ", "))
+
+	setup := dependencies.SetupUpgrade(cmd.App.Reader, std.Out, currentOS, checks)
+	setup.AnalyticsCategory = "setup-upgrade"
+	setup.RunPostFixChecks = true
+
+	args := dependencies.CheckArgs{
+		ConfigFile:          configFile,
+		ConfigOverwriteFile: configOverwriteFile,
+		DisableOverwrite:    disableOverwrite,
+	}
+
+	if cmd.Bool("check") {
+		err := setup.Check(cmd.Context, args)
+		if err != nil {
+			std.Out.WriteSuggestionf("Run 'sg setup upgrade' to try and automatically fix issues!")
+		}
+		// Don't record the new state, so that the next upgrade checks again.
+		return err
+	}
+
+	if err := setup.Fix(cmd.Context, args); err != nil {
+		return err
+	}
+	return current.Save(sgHome)
+}
+
+// saveSetupState records the current version requirements as set up.
+func saveSetupState() error {
+	repoRoot, err := root.RepositoryRoot()
+	if errors.Is(err, root.ErrNotInsideSourcegraph) {
+		// Nothing version sensitive was set up.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sgHome, err := root.GetSGHomePath()
+	if err != nil {
+		return err
+	}
+	state, err := dependencies.CurrentSetupState(repoRoot)
+	if err != nil {
+		return err
+	}
+	return state.Save(sgHome)
+}
+
 func printSgSetupWelcomeScreen(out *std.Output) {
 	genLine := func(style output.Style, content string) string {
 		return fmt.Sprintf("%s%s%s", output.CombineStyles(output.StyleBold, style), content, output.StyleReset)
@@ -14,7 +14,6 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
 	"github.com/sourcegraph/sourcegraph/internal/database"
-	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/packagefilters"
 	"github.com/sourcegraph/sourcegraph/internal/workerutil"
 	"github.com/sourcegraph/sourcegraph/internal/workerutil/dbworker"
@@ -64,14 +63,6 @@ type dependencySyncSchedulerHandler struct {
 // For mocking in tests
 var autoIndexingEnabled = conf.CodeIntelAutoIndexingEnabled
 
-var schemeToExternalService = map[string]string{
-	dependencies.JVMPackagesScheme:    extsvc.KindJVMPackages,
-	dependencies.NpmPackagesScheme:    extsvc.KindNpmPackages,
-	dependencies.PythonPackagesScheme: extsvc.KindPythonPackages,
-	dependencies.RustPackagesScheme:   extsvc.KindRustPackages,
-	dependencies.RubyPackagesScheme:   extsvc.KindRubyPackages,
-}
-
 func (h *dependencySyncSchedulerHandler) Handle(ctx context.Context, logger log.Logger, job dependencySyncingJob) error {
 	if !autoIndexingEnabled() {
 		return nil
@@ -130,13 +121,14 @@ func (h *dependencySyncSchedulerHandler) Handle(ctx context.Context, logger log.
 		}
 		pkg := *pkgRef
 
-		extsvcKind, ok := schemeToExternalService[pkg.Scheme]
-		// add entry for empty string/kind here so dependencies such as lsif-go ones still get
-		// an associated dependency indexing job
-		kinds[extsvcKind] = struct{}{}
-		if !ok {
+		scheme, ok := dependencies.LookupPackageScheme(pkg.Scheme)
+		if !ok || !scheme.SyncReferenced {
+			// add entry for empty string/kind here so dependencies such as lsif-go ones still get
+			// an associated dependency indexing job
+			kinds[""] = struct{}{}
 			continue
 		}
+		kinds[scheme.ExternalServiceKind] = struct{}{}
 
 		newRepo, newVersion, err := h.insertPackageRepoRef(ctx, pkg, packageFilters, instant)
 		if err != nil {
@@ -199,7 +191,7 @@ func (h *dependencySyncSchedulerHandler) Handle(ctx context.Context, logger log.
 	}
 
 	if shouldIndex {
-		// If we saw a kind that's not in schemeToExternalService, then kinds contains an empty string key
+		// If we saw a package that isn't synced when referenced, then kinds contains an empty string key
 		for kind := range kinds {
 			if _, err := h.store.InsertDependencyIndexingJob(ctx, job.UploadID, kind, nextSync); err != nil {
 				errs = append(errs, errors.Wrap(err, "dbstore.InsertDependencyIndexingJob"))
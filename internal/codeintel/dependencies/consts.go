@@ -10,3 +10,15 @@ const (
 	RustPackagesScheme   = shared.RustPackagesScheme
 	RubyPackagesScheme   = shared.RubyPackagesScheme
 )
+
+// PackageScheme describes a package ecosystem whose packages are synced as package
+// repos.
+type PackageScheme = shared.PackageScheme
+
+// PackageSchemes are all package ecosystems supported by package repos.
+var PackageSchemes = shared.PackageSchemes
+
+var (
+	LookupPackageScheme                      = shared.LookupPackageScheme
+	LookupPackageSchemeByExternalServiceKind = shared.LookupPackageSchemeByExternalServiceKind
+)
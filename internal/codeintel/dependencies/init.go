@@ -29,5 +29,6 @@ func PackageFiltersJob(
 ) goroutine.CombinedRoutine {
 	return []goroutine.BackgroundRoutine{
 		background.NewPackagesFilterApplicator(obsctx, db),
+		background.NewPackageRepoCountsReporter(obsctx, db),
 	}
 }
@@ -0,0 +1,62 @@
+package background
+
+import (
+	"context"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/dependencies/internal/store"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+type packageRepoCountsReporterJob struct {
+	store      store.Store
+	operations *operations
+}
+
+// NewPackageRepoCountsReporter periodically exports the number of package repo
+// references and versions per scheme as gauges. A package syncer that adds
+// versions unboundedly (e.g. npm packages publishing a version per commit) shows
+// up as a steadily climbing version count for its scheme long before the size of
+// the tables becomes a problem for the database.
+func NewPackageRepoCountsReporter(
+	obsctx *observation.Context,
+	db database.DB,
+) goroutine.BackgroundRoutine {
+	job := packageRepoCountsReporterJob{
+		store:      store.New(obsctx, db),
+		operations: newOperations(obsctx),
+	}
+
+	return goroutine.NewPeriodicGoroutine(
+		actor.WithInternalActor(context.Background()),
+		goroutine.HandlerFunc(job.handle),
+		goroutine.WithName("codeintel.package-repo-counts-reporter"),
+		goroutine.WithDescription("exports the number of package repo references and versions per scheme"),
+		goroutine.WithInterval(time.Minute*5),
+	)
+}
+
+func (j *packageRepoCountsReporterJob) handle(ctx context.Context) (err error) {
+	ctx, _, endObservation := j.operations.packageRepoCountsReporter.With(ctx, &err, observation.Args{})
+	defer endObservation(1, observation.Args{})
+
+	counts, err := j.store.CountPackageRepoRefsByScheme(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to count package repos by scheme")
+	}
+
+	// Reset so that schemes whose references were all deleted stop reporting.
+	j.operations.packageRepoRefs.Reset()
+	j.operations.packageRepoRefVersions.Reset()
+
+	for _, count := range counts {
+		j.operations.packageRepoRefs.WithLabelValues(count.Scheme).Set(float64(count.Packages))
+		j.operations.packageRepoRefVersions.WithLabelValues(count.Scheme).Set(float64(count.Versions))
+	}
+
+	return nil
+}
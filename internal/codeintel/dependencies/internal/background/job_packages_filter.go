@@ -66,9 +66,10 @@ func (j *packagesFilterApplicatorJob) handle(ctx context.Context) (err error) {
 
 	for lastID := 0; ; {
 		pkgs, _, _, err := j.store.ListPackageRepoRefs(ctx, store.ListDependencyReposOpts{
-			After:          lastID,
-			Limit:          1000,
-			IncludeBlocked: true,
+			After:           lastID,
+			Limit:           1000,
+			IncludeBlocked:  true,
+			IncludeArchived: true,
 		})
 		if err != nil {
 			return errors.Wrap(err, "failed to list package repos")
@@ -11,16 +11,21 @@ import (
 )
 
 type operations struct {
-	handleCrateSyncer        *observation.Operation
-	packagesFilterApplicator *observation.Operation
+	handleCrateSyncer         *observation.Operation
+	packagesFilterApplicator  *observation.Operation
+	packageRepoCountsReporter *observation.Operation
 
 	packagesUpdated prometheus.Counter
 	versionsUpdated prometheus.Counter
+
+	packageRepoRefs        *prometheus.GaugeVec
+	packageRepoRefVersions *prometheus.GaugeVec
 }
 
 var (
 	m          = new(metrics.SingletonREDMetrics)
 	metricsMap = make(map[string]prometheus.Counter)
+	gaugesMap  = make(map[string]*prometheus.GaugeVec)
 	metricsMu  sync.Mutex
 )
 
@@ -53,6 +58,25 @@ func newOperations(observationCtx *observation.Context) *operations {
 		return counter
 	}
 
+	gauge := func(name, help string) *prometheus.GaugeVec {
+		metricsMu.Lock()
+		defer metricsMu.Unlock()
+
+		if g, ok := gaugesMap[name]; ok {
+			return g
+		}
+
+		gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: name,
+			Help: help,
+		}, []string{"scheme"})
+		observationCtx.Registerer.MustRegister(gauge)
+
+		gaugesMap[name] = gauge
+
+		return gauge
+	}
+
 	op := func(name string) *observation.Operation {
 		return observationCtx.Operation(observation.Op{
 			Name:              fmt.Sprintf("codeintel.dependencies.background.%s", name),
@@ -62,8 +86,9 @@ func newOperations(observationCtx *observation.Context) *operations {
 	}
 
 	return &operations{
-		handleCrateSyncer:        op("HandleCrateSyncer"),
-		packagesFilterApplicator: op("HandlePackagesFilterApplicator"),
+		handleCrateSyncer:         op("HandleCrateSyncer"),
+		packagesFilterApplicator:  op("HandlePackagesFilterApplicator"),
+		packageRepoCountsReporter: op("HandlePackageRepoCountsReporter"),
 
 		packagesUpdated: counter(
 			"src_codeintel_background_filtered_packages_updated",
@@ -73,5 +98,17 @@ func newOperations(observationCtx *observation.Context) *operations {
 			"src_codeintel_background_filtered_package_versions_updated",
 			"The number of package repo versions who's blocked status was updated",
 		),
+
+		// As a rule of thumb, more than 1M versions for a single scheme, or more than
+		// 100 versions per package on average, indicates a syncer that is importing far
+		// more than what is referenced and is worth alerting on.
+		packageRepoRefs: gauge(
+			"src_codeintel_dependencies_package_repo_refs",
+			"The number of package repo references per scheme",
+		),
+		packageRepoRefVersions: gauge(
+			"src_codeintel_dependencies_package_repo_ref_versions",
+			"The number of package repo reference versions per scheme",
+		),
 	}
 }
@@ -0,0 +1,238 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keegancsmith/sqlf"
+	"github.com/sourcegraph/log/logtest"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/dependencies/shared"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+)
+
+// graphFixture is a declarative description of a dependency graph and of the
+// expected outputs of the store queries over it. Fixtures live in
+// testdata/graphs and are run by TestGraphFixtures, each against a fresh
+// database.
+//
+// Repositories reference packages through the precise indexes uploaded for
+// their commits. Packages are inserted with InsertPackageRepoRefs, so they are
+// normalized and filtered the same way as packages synced in production.
+type graphFixture struct {
+	Repos []struct {
+		ID   int    `yaml:"id"`
+		Name string `yaml:"name"`
+	} `yaml:"repos"`
+
+	Uploads []struct {
+		ID      int    `yaml:"id"`
+		Repo    int    `yaml:"repo"`
+		Commit  string `yaml:"commit"`
+		Indexer string `yaml:"indexer"`
+		// State defaults to completed.
+		State      string `yaml:"state"`
+		References []struct {
+			Scheme  string `yaml:"scheme"`
+			Manager string `yaml:"manager"`
+			Name    string `yaml:"name"`
+			Version string `yaml:"version"`
+		} `yaml:"references"`
+	} `yaml:"uploads"`
+
+	Packages []struct {
+		Scheme   string `yaml:"scheme"`
+		Name     string `yaml:"name"`
+		License  string `yaml:"license"`
+		Versions []struct {
+			Version string `yaml:"version"`
+			License string `yaml:"license"`
+		} `yaml:"versions"`
+	} `yaml:"packages"`
+
+	Expect struct {
+		// PackageRepoRefs lists the packages returned by ListPackageRepoRefs,
+		// formatted as scheme:name@version.
+		PackageRepoRefs []struct {
+			Scheme  string   `yaml:"scheme"`
+			Name    string   `yaml:"name"`
+			License string   `yaml:"license"`
+			Want    []string `yaml:"want"`
+		} `yaml:"packageRepoRefs"`
+
+		// PackageDependents lists the dependents returned by PackageDependents,
+		// formatted as repo@commit version.
+		PackageDependents []struct {
+			Scheme       string   `yaml:"scheme"`
+			Name         string   `yaml:"name"`
+			VersionRange string   `yaml:"versionRange"`
+			Want         []string `yaml:"want"`
+		} `yaml:"packageDependents"`
+
+		// LicenseDependents lists the dependents returned by
+		// ListPackageLicenseDependents, formatted as repo scheme:name@version.
+		LicenseDependents []struct {
+			License string   `yaml:"license"`
+			Want    []string `yaml:"want"`
+		} `yaml:"licenseDependents"`
+	} `yaml:"expect"`
+}
+
+func TestGraphFixtures(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	paths, err := filepath.Glob(filepath.Join("testdata", "graphs", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no graph fixtures found")
+	}
+
+	for _, path := range paths {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".yaml"), func(t *testing.T) {
+			contents, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fixture graphFixture
+			if err := yaml.Unmarshal(contents, &fixture); err != nil {
+				t.Fatalf("invalid fixture: %s", err)
+			}
+
+			logger := logtest.Scoped(t)
+			ctx := context.Background()
+			db := database.NewDB(logger, dbtest.NewDB(t))
+			store := New(&observation.TestContext, db)
+
+			loadGraphFixture(t, ctx, db, store, fixture)
+			checkGraphFixture(t, ctx, store, fixture)
+		})
+	}
+}
+
+func loadGraphFixture(t *testing.T, ctx context.Context, db database.DB, store *store, fixture graphFixture) {
+	t.Helper()
+
+	bs := basestore.NewWithHandle(db.Handle())
+	for _, repo := range fixture.Repos {
+		if err := bs.Exec(ctx, sqlf.Sprintf(`INSERT INTO repo (id, name) VALUES (%s, %s)`, repo.ID, repo.Name)); err != nil {
+			t.Fatalf("inserting repo %q: %s", repo.Name, err)
+		}
+	}
+
+	for _, upload := range fixture.Uploads {
+		state := upload.State
+		if state == "" {
+			state = "completed"
+		}
+		if err := bs.Exec(ctx, sqlf.Sprintf(`
+			INSERT INTO lsif_uploads (id, repository_id, commit, indexer, num_parts, uploaded_parts, state)
+			VALUES (%s, %s, %s, %s, 1, '{}', %s)
+		`, upload.ID, upload.Repo, upload.Commit, upload.Indexer, state)); err != nil {
+			t.Fatalf("inserting upload %d: %s", upload.ID, err)
+		}
+
+		for _, ref := range upload.References {
+			if err := bs.Exec(ctx, sqlf.Sprintf(`
+				INSERT INTO lsif_references (dump_id, scheme, manager, name, version)
+				VALUES (%s, %s, %s, %s, %s)
+			`, upload.ID, ref.Scheme, ref.Manager, ref.Name, ref.Version)); err != nil {
+				t.Fatalf("inserting reference %s:%s@%s of upload %d: %s", ref.Scheme, ref.Name, ref.Version, upload.ID, err)
+			}
+		}
+	}
+
+	pkgs := make([]shared.MinimalPackageRepoRef, 0, len(fixture.Packages))
+	for _, pkg := range fixture.Packages {
+		versions := make([]shared.MinimalPackageRepoRefVersion, 0, len(pkg.Versions))
+		for _, v := range pkg.Versions {
+			versions = append(versions, shared.MinimalPackageRepoRefVersion{Version: v.Version, License: v.License})
+		}
+		pkgs = append(pkgs, shared.MinimalPackageRepoRef{
+			Scheme:   pkg.Scheme,
+			Name:     reposource.PackageName(pkg.Name),
+			License:  pkg.License,
+			Versions: versions,
+		})
+	}
+	if len(pkgs) > 0 {
+		if _, _, err := store.InsertPackageRepoRefs(ctx, pkgs); err != nil {
+			t.Fatalf("inserting packages: %s", err)
+		}
+	}
+}
+
+func checkGraphFixture(t *testing.T, ctx context.Context, store *store, fixture graphFixture) {
+	t.Helper()
+
+	for _, c := range fixture.Expect.PackageRepoRefs {
+		pkgs, _, _, err := store.ListPackageRepoRefs(ctx, ListDependencyReposOpts{
+			Scheme:         c.Scheme,
+			Name:           reposource.PackageName(c.Name),
+			Fuzziness:      FuzzinessExactMatch,
+			License:        c.License,
+			IncludeBlocked: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, pkg := range pkgs {
+			for _, v := range pkg.Versions {
+				got = append(got, pkg.Scheme+":"+string(pkg.Name)+"@"+v.Version)
+			}
+		}
+		if diff := cmp.Diff(nonNil(c.Want), got); diff != "" {
+			t.Errorf("unexpected package repo refs for scheme=%q name=%q license=%q (-want +got):\n%s", c.Scheme, c.Name, c.License, diff)
+		}
+	}
+
+	for _, c := range fixture.Expect.PackageDependents {
+		dependents, err := store.PackageDependents(ctx, c.Scheme, reposource.PackageName(c.Name), c.VersionRange)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, d := range dependents {
+			got = append(got, d.RepositoryName+"@"+d.Commit+" "+d.Version)
+		}
+		if diff := cmp.Diff(nonNil(c.Want), got); diff != "" {
+			t.Errorf("unexpected dependents of %s:%s %q (-want +got):\n%s", c.Scheme, c.Name, c.VersionRange, diff)
+		}
+	}
+
+	for _, c := range fixture.Expect.LicenseDependents {
+		dependents, err := store.ListPackageLicenseDependents(ctx, c.License)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, d := range dependents {
+			got = append(got, d.RepositoryName+" "+d.Scheme+":"+string(d.Name)+"@"+d.Version)
+		}
+		if diff := cmp.Diff(nonNil(c.Want), got); diff != "" {
+			t.Errorf("unexpected dependents for license %q (-want +got):\n%s", c.License, diff)
+		}
+	}
+}
+
+// nonNil returns an empty slice for a nil expectation, so that fixtures can
+// omit empty lists.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
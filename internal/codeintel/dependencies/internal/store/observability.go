@@ -8,10 +8,16 @@ import (
 )
 
 type operations struct {
-	listPackageRepoRefs              *observation.Operation
-	insertPackageRepoRefs            *observation.Operation
-	deletePackageRepoRefsByID        *observation.Operation
-	deletePackageRepoRefVersionsByID *observation.Operation
+	listPackageRepoRefs                 *observation.Operation
+	scanPackageRepoRefs                 *observation.Operation
+	insertPackageRepoRefs               *observation.Operation
+	deletePackageRepoRefsByID           *observation.Operation
+	deletePackageRepoRefVersionsByID    *observation.Operation
+	deletePackageRepoRefs               *observation.Operation
+	resolvePackageRepoRefVersion        *observation.Operation
+	archivePackageRepoRefsByID          *observation.Operation
+	unarchivePackageRepoRefsByID        *observation.Operation
+	updatePackageRepoRefsVisibilityByID *observation.Operation
 
 	listPackageRepoFilters  *observation.Operation
 	createPackageRepoFilter *observation.Operation
@@ -20,6 +26,12 @@ type operations struct {
 
 	shouldRefilterPackageRepoRefs *observation.Operation
 	updateAllBlockedStatuses      *observation.Operation
+
+	countPackageRepoRefsByScheme *observation.Operation
+	stats                        *observation.Operation
+
+	listPackageLicenseDependents *observation.Operation
+	packageDependents            *observation.Operation
 }
 
 var m = new(metrics.SingletonREDMetrics)
@@ -43,10 +55,16 @@ func newOperations(observationCtx *observation.Context) *operations {
 	}
 
 	return &operations{
-		listPackageRepoRefs:              op("ListDependencyRepos"),
-		insertPackageRepoRefs:            op("InsertDependencyRepos"),
-		deletePackageRepoRefsByID:        op("DeleteDependencyRepoRefsByID"),
-		deletePackageRepoRefVersionsByID: op("DeletePackageRepoRefVersionsByID"),
+		listPackageRepoRefs:                 op("ListDependencyRepos"),
+		scanPackageRepoRefs:                 op("ScanPackageRepoRefs"),
+		insertPackageRepoRefs:               op("InsertDependencyRepos"),
+		deletePackageRepoRefsByID:           op("DeleteDependencyRepoRefsByID"),
+		deletePackageRepoRefVersionsByID:    op("DeletePackageRepoRefVersionsByID"),
+		deletePackageRepoRefs:               op("DeletePackageRepoRefs"),
+		resolvePackageRepoRefVersion:        op("ResolvePackageRepoRefVersion"),
+		archivePackageRepoRefsByID:          op("ArchivePackageRepoRefsByID"),
+		unarchivePackageRepoRefsByID:        op("UnarchivePackageRepoRefsByID"),
+		updatePackageRepoRefsVisibilityByID: op("UpdatePackageRepoRefsVisibilityByID"),
 
 		listPackageRepoFilters:  op("ListPackageRepoFilters"),
 		createPackageRepoFilter: op("CreatePackageRepoFilter"),
@@ -55,5 +73,11 @@ func newOperations(observationCtx *observation.Context) *operations {
 
 		shouldRefilterPackageRepoRefs: op("ShouldRefilterPackageRepoRefs"),
 		updateAllBlockedStatuses:      op("UpdateAllBlockedStatuses"),
+
+		countPackageRepoRefsByScheme: op("CountPackageRepoRefsByScheme"),
+		stats:                        op("Stats"),
+
+		listPackageLicenseDependents: op("ListPackageLicenseDependents"),
+		packageDependents:            op("PackageDependents"),
 	}
 }
@@ -20,6 +20,8 @@ func scanDependencyRepoWithVersions(s dbutil.Scanner) (shared.PackageRepoReferen
 		ids            []int64
 		blocked        []bool
 		lastCheckedAt  []sql.NullString
+		licenses       []string
+		originals      []string
 	)
 	err := s.Scan(
 		&ref.ID,
@@ -27,10 +29,17 @@ func scanDependencyRepoWithVersions(s dbutil.Scanner) (shared.PackageRepoReferen
 		&ref.Name,
 		&ref.Blocked,
 		&ref.LastCheckedAt,
+		&ref.ArchivedAt,
+		&ref.PublishedAt,
+		&ref.Description,
+		&ref.License,
+		&ref.Private,
 		pq.Array(&ids),
 		pq.Array(&versionStrings),
 		pq.Array(&blocked),
 		pq.Array(&lastCheckedAt),
+		pq.Array(&licenses),
+		pq.Array(&originals),
 	)
 	if err != nil {
 		return shared.PackageRepoReference{}, err
@@ -48,11 +57,13 @@ func scanDependencyRepoWithVersions(s dbutil.Scanner) (shared.PackageRepoReferen
 			t = &parsedT
 		}
 		ref.Versions = append(ref.Versions, shared.PackageRepoRefVersion{
-			ID:            int(ids[i]),
-			PackageRefID:  ref.ID,
-			Version:       version,
-			Blocked:       blocked[i],
-			LastCheckedAt: t,
+			ID:              int(ids[i]),
+			PackageRefID:    ref.ID,
+			Version:         version,
+			Blocked:         blocked[i],
+			LastCheckedAt:   t,
+			License:         licenses[i],
+			OriginalVersion: originals[i],
 		})
 	}
 	return ref, err
@@ -0,0 +1,253 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/regexp"
+	"github.com/keegancsmith/sqlf"
+	"github.com/sourcegraph/log"
+	"golang.org/x/time/rate"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// slowQueryExplainTimeout bounds the time spent explaining a slow query.
+const slowQueryExplainTimeout = 30 * time.Second
+
+// slowQueryHandle wraps a database handle to log the queries that take longer
+// than the threshold set by the experimental feature
+// dependencies.slowQueryLogThresholdMs. Each logged query has its parameters
+// redacted and comes with its query plan, unless the query uses temporary
+// tables.
+//
+// Queries returning rows are streamed, so the handle can't tell when they
+// finish. They are timed by store.query instead, until their rows are closed.
+type slowQueryHandle struct {
+	basestore.TransactableHandle
+	logger *slowQueryLogger
+}
+
+func newSlowQueryHandle(logger log.Logger, handle basestore.TransactableHandle) *slowQueryHandle {
+	return &slowQueryHandle{
+		TransactableHandle: handle,
+		logger: &slowQueryLogger{
+			logger:    logger.Scoped("slowQueries"),
+			handle:    handle,
+			limiter:   rate.NewLimiter(rate.Every(10*time.Second), 1),
+			threshold: slowQueryThreshold,
+		},
+	}
+}
+
+func (h *slowQueryHandle) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	result, err := h.TransactableHandle.ExecContext(ctx, query, args...)
+	h.logger.observe(query, args, time.Since(start))
+	return result, err
+}
+
+func (h *slowQueryHandle) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	start := time.Now()
+	row := h.TransactableHandle.QueryRowContext(ctx, query, args...)
+	h.logger.observe(query, args, time.Since(start))
+	return row
+}
+
+func (h *slowQueryHandle) Transact(ctx context.Context) (basestore.TransactableHandle, error) {
+	tx, err := h.TransactableHandle.Transact(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &slowQueryHandle{TransactableHandle: tx, logger: h.logger}, nil
+}
+
+// query runs query in db, which must be s.db or a transaction of it, and
+// returns its rows. The query is observed as slow once its rows are closed.
+func (s *store) query(ctx context.Context, db *basestore.Store, query *sqlf.Query) (basestore.Rows, error) {
+	start := time.Now()
+	rows, err := db.Query(ctx, query)
+	if err != nil {
+		s.slowQueries.observe(query.Query(sqlf.PostgresBindVar), query.Args(), time.Since(start))
+		return nil, err
+	}
+	return &observedRows{Rows: rows, onClose: func() {
+		s.slowQueries.observe(query.Query(sqlf.PostgresBindVar), query.Args(), time.Since(start))
+	}}, nil
+}
+
+// observedRows calls onClose the first time the rows are closed.
+type observedRows struct {
+	*sql.Rows
+	onClose func()
+}
+
+func (r *observedRows) Close() error {
+	err := r.Rows.Close()
+	if r.onClose != nil {
+		r.onClose()
+		r.onClose = nil
+	}
+	return err
+}
+
+type slowQueryLogger struct {
+	logger log.Logger
+	// handle is the handle outside of any transaction, used to explain slow
+	// queries without touching the transaction they ran in.
+	handle    basestore.TransactableHandle
+	limiter   *rate.Limiter
+	threshold func() time.Duration
+}
+
+// slowQueryThreshold returns the configured slow query threshold, or 0 if slow
+// queries should not be logged.
+func slowQueryThreshold() time.Duration {
+	features := conf.Get().ExperimentalFeatures
+	if features == nil {
+		return 0
+	}
+	return time.Duration(features.DependenciesSlowQueryLogThresholdMs) * time.Millisecond
+}
+
+func (l *slowQueryLogger) observe(query string, args []any, elapsed time.Duration) {
+	threshold := l.threshold()
+	if threshold <= 0 || elapsed < threshold || !l.limiter.Allow() {
+		return
+	}
+
+	// Explaining can take as long as the query itself, so don't hold up the
+	// caller.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), slowQueryExplainTimeout)
+		defer cancel()
+
+		fields := []log.Field{
+			log.Duration("elapsed", elapsed),
+			log.String("query", redactQuery(query, args)),
+		}
+		if usesTemporaryTables(query) {
+			fields = append(fields, log.String("plan", "<not explained: query uses temporary tables of its transaction>"))
+		} else if plan, err := l.explain(ctx, query, args); err != nil {
+			fields = append(fields, log.NamedError("explainError", err))
+		} else {
+			fields = append(fields, log.String("plan", plan))
+		}
+		l.logger.Warn("slow query", fields...)
+	}()
+}
+
+// errRollbackExplain is used to roll back the transaction queries are
+// explained in.
+var errRollbackExplain = errors.New("rollback explain")
+
+// explain returns the query plan of query. Read-only queries are run again to
+// report their actual timings. All queries are explained in a transaction
+// that is rolled back.
+func (l *slowQueryLogger) explain(ctx context.Context, query string, args []any) (_ string, err error) {
+	tx, err := l.handle.Transact(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tx.Done(errRollbackExplain) }()
+
+	explain := "EXPLAIN "
+	if isReadOnlyQuery(query) {
+		explain = "EXPLAIN (ANALYZE, BUFFERS) "
+	}
+	rows, err := tx.QueryContext(ctx, explain+query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = basestore.CloseRows(rows, err) }()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+var temporaryTablePattern = regexp.MustCompile(`\bt_package_repo_(refs|versions)\b`)
+
+// usesTemporaryTables returns whether query references the temporary tables
+// created by InsertPackageRepoRefs. These only exist in the transaction that
+// created them, so the query can't be explained outside of it.
+func usesTemporaryTables(query string) bool {
+	return temporaryTablePattern.MatchString(query)
+}
+
+var (
+	leadingCommentsPattern = regexp.MustCompile(`^(\s*--[^\n]*\n)*\s*`)
+	writeKeywordPattern    = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE|MERGE|TRUNCATE)\b`)
+	lockingClausePattern   = regexp.MustCompile(`(?i)\bFOR\s+(NO\s+KEY\s+UPDATE|UPDATE|KEY\s+SHARE|SHARE)\b`)
+)
+
+// isReadOnlyQuery returns whether query is a SELECT statement, possibly with
+// common table expressions, that doesn't modify or lock any rows. It errs on
+// the side of reporting queries as writes.
+func isReadOnlyQuery(query string) bool {
+	query = strings.ToUpper(leadingCommentsPattern.ReplaceAllString(query, ""))
+	if !strings.HasPrefix(query, "SELECT") && !strings.HasPrefix(query, "WITH") {
+		return false
+	}
+	return !writeKeywordPattern.MatchString(query) && !lockingClausePattern.MatchString(query)
+}
+
+var placeholderPattern = regexp.MustCompile(`\$(\d+)`)
+
+// redactQuery replaces the placeholders of query with descriptions of the
+// bound args. Package names and versions can be private, so only the type and
+// size of each value is logged, together with a short hash of strings and
+// bytes so that equal values can be told apart from different ones.
+func redactQuery(query string, args []any) string {
+	return placeholderPattern.ReplaceAllStringFunc(query, func(placeholder string) string {
+		i, err := strconv.Atoi(placeholder[1:])
+		if err != nil || i < 1 || i > len(args) {
+			return placeholder
+		}
+		return redactQueryArg(args[i-1])
+	})
+}
+
+func redactQueryArg(arg any) string {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return "<invalid>"
+		}
+		arg = value
+	}
+
+	switch v := arg.(type) {
+	case nil:
+		return "NULL"
+	case time.Time:
+		return "<timestamp>"
+	case []byte:
+		return fmt.Sprintf("<%d bytes, %s>", len(v), shortHash(v))
+	case string:
+		return fmt.Sprintf("<string, %d bytes, %s>", len(v), shortHash([]byte(v)))
+	default:
+		return fmt.Sprintf("<%T>", v)
+	}
+}
+
+// shortHash returns a prefix of the SHA-256 hash of value, which is enough to
+// compare redacted values within the logs.
+func shortHash(value []byte) string {
+	sum := sha256.Sum256(value)
+	return fmt.Sprintf("sha256:%x", sum[:4])
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/lib/pq"
+)
+
+func TestRedactQuery(t *testing.T) {
+	query := `SELECT * FROM lsif_dependency_repos WHERE id = ANY($1) AND scheme = $2 AND name = $3 AND blocked = $4 AND last_checked_at < $5 AND $10 IS NULL`
+	args := []any{
+		pq.Array([]int{1, 2}),
+		"npm",
+		"@private/pkg",
+		false,
+		time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	want := `SELECT * FROM lsif_dependency_repos WHERE id = ANY(<string, 5 bytes, %s>) AND scheme = <string, 3 bytes, %s> AND name = <string, 12 bytes, %s> AND blocked = <bool> AND last_checked_at < <timestamp> AND $10 IS NULL`
+	want = fmt.Sprintf(want, shortHash([]byte("{1,2}")), shortHash([]byte("npm")), shortHash([]byte("@private/pkg")))
+	if diff := cmp.Diff(want, redactQuery(query, args)); diff != "" {
+		t.Errorf("unexpected query (-want +got):\n%s", diff)
+	}
+
+	// Equal values are redacted the same way, different values of the same
+	// size are not.
+	if redactQueryArg("npm") != redactQueryArg("npm") {
+		t.Errorf("equal values redacted differently")
+	}
+	if redactQueryArg("npm") == redactQueryArg("pip") {
+		t.Errorf("different values redacted the same way")
+	}
+}
+
+func TestUsesTemporaryTables(t *testing.T) {
+	for query, want := range map[string]bool{
+		"SELECT * FROM lsif_dependency_repos":                                                   false,
+		"INSERT INTO lsif_dependency_repos SELECT * FROM t_package_repo_refs t":                 true,
+		"UPDATE package_repo_versions v SET blocked = t.blocked FROM t_package_repo_versions t": true,
+	} {
+		if got := usesTemporaryTables(query); got != want {
+			t.Errorf("unexpected result for %q. want=%v have=%v", query, want, got)
+		}
+	}
+}
+
+func TestIsReadOnlyQuery(t *testing.T) {
+	for query, want := range map[string]bool{
+		"SELECT 1": true,
+		"-- source: store.go\n  select updated_at FROM t":        true,
+		"WITH c AS (SELECT id FROM t) SELECT * FROM c":           true,
+		"WITH c AS (DELETE FROM t RETURNING id) SELECT * FROM c": false,
+		"SELECT * FROM t FOR UPDATE":                             false,
+		"SELECT * FROM t FOR NO KEY UPDATE SKIP LOCKED":          false,
+		"SELECT * FROM t FOR SHARE":                              false,
+		"SELECT * FROM t FOR KEY SHARE":                          false,
+		"UPDATE t SET x = 1":                                     false,
+		"INSERT INTO t VALUES (1)":                               false,
+	} {
+		if got := isReadOnlyQuery(query); got != want {
+			t.Errorf("unexpected result for %q. want=%v have=%v", query, want, got)
+		}
+	}
+}
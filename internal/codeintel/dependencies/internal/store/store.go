@@ -5,21 +5,26 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgconn"
 	"github.com/keegancsmith/sqlf"
 	"github.com/lib/pq"
+	"github.com/sourcegraph/log"
 	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/exp/slices"
 
+	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/dependencies/shared"
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/shared/versions"
 	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
 	"github.com/sourcegraph/sourcegraph/internal/database/batch"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/observation"
+	"github.com/sourcegraph/sourcegraph/internal/packagefilters"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
@@ -28,9 +33,15 @@ type Store interface {
 	WithTransact(context.Context, func(Store) error) error
 
 	ListPackageRepoRefs(ctx context.Context, opts ListDependencyReposOpts) (dependencyRepos []shared.PackageRepoReference, total int, hasMore bool, err error)
+	ScanPackageRepoRefs(ctx context.Context, opts ListDependencyReposOpts, f func(shared.PackageRepoReference) error) (err error)
 	InsertPackageRepoRefs(ctx context.Context, deps []shared.MinimalPackageRepoRef) (newDeps []shared.PackageRepoReference, newVersions []shared.PackageRepoRefVersion, err error)
 	DeletePackageRepoRefsByID(ctx context.Context, ids ...int) (err error)
 	DeletePackageRepoRefVersionsByID(ctx context.Context, ids ...int) (err error)
+	DeletePackageRepoRefs(ctx context.Context, opts DeletePackageRepoRefsOpts) (deletedPackages, deletedVersions int, err error)
+	ResolvePackageRepoRefVersion(ctx context.Context, opts ResolvePackageRepoRefVersionOpts) (_ shared.ResolvedPackageRepoRefVersion, found bool, err error)
+	ArchivePackageRepoRefsByID(ctx context.Context, ids ...int) (err error)
+	UnarchivePackageRepoRefsByID(ctx context.Context, ids ...int) (err error)
+	UpdatePackageRepoRefsVisibilityByID(ctx context.Context, private bool, ids ...int) (err error)
 
 	ListPackageRepoRefFilters(ctx context.Context, opts ListPackageRepoRefFiltersOpts) ([]shared.PackageRepoFilter, bool, error)
 	CreatePackageRepoFilter(ctx context.Context, input shared.MinimalPackageFilter) (filter *shared.PackageRepoFilter, err error)
@@ -39,27 +50,40 @@ type Store interface {
 
 	ShouldRefilterPackageRepoRefs(ctx context.Context) (exists bool, err error)
 	UpdateAllBlockedStatuses(ctx context.Context, pkgs []shared.PackageRepoReference, startTime time.Time) (pkgsUpdated, versionsUpdated int, err error)
+
+	CountPackageRepoRefsByScheme(ctx context.Context) (_ []shared.PackageRepoSchemeCount, err error)
+	Stats(ctx context.Context) (_ shared.PackageRepoStats, err error)
+
+	ListPackageLicenseDependents(ctx context.Context, license string) (_ []shared.PackageLicenseDependent, err error)
+	PackageDependents(ctx context.Context, scheme string, name reposource.PackageName, versionRange string) (_ []shared.PackageDependent, err error)
 }
 
 // store manages the database tables for package dependencies.
 type store struct {
-	db         *basestore.Store
-	operations *operations
+	logger      log.Logger
+	db          *basestore.Store
+	slowQueries *slowQueryLogger
+	operations  *operations
 }
 
 // New returns a new store.
 func New(op *observation.Context, db database.DB) *store {
+	handle := newSlowQueryHandle(op.Logger, db.Handle())
 	return &store{
-		db:         basestore.NewWithHandle(db.Handle()),
-		operations: newOperations(op),
+		logger:      log.Scoped("dependencies.store"),
+		db:          basestore.NewWithHandle(handle),
+		slowQueries: handle.logger,
+		operations:  newOperations(op),
 	}
 }
 
 func (s *store) WithTransact(ctx context.Context, f func(tx Store) error) error {
 	return s.db.WithTransact(ctx, func(tx *basestore.Store) error {
 		return f(&store{
-			db:         tx,
-			operations: s.operations,
+			logger:      s.logger,
+			db:          tx,
+			slowQueries: s.slowQueries,
+			operations:  s.operations,
 		})
 	})
 }
@@ -70,16 +94,25 @@ const (
 	FuzzinessExactMatch fuzziness = iota
 	FuzzinessWildcard
 	FuzzinessRegex
+	// FuzzinessSimilarity matches names containing a word similar to the given
+	// name, ignoring case, e.g. "recat-dom" matches "react-dom". Matches are
+	// ordered by descending similarity instead of by ID.
+	FuzzinessSimilarity
 )
 
 // ListDependencyReposOpts are options for listing dependency repositories.
 type ListDependencyReposOpts struct {
-	Scheme         string
-	Name           reposource.PackageName
-	Fuzziness      fuzziness
-	After          int
-	Limit          int
-	IncludeBlocked bool
+	Scheme          string
+	Name            reposource.PackageName
+	Fuzziness       fuzziness
+	After           int
+	Limit           int
+	IncludeBlocked  bool
+	IncludeArchived bool
+	// License, if set, only matches versions declaring the given license
+	// (ignoring case). Versions without a declared license fall back to the
+	// license of their package.
+	License string
 }
 
 // ListDependencyRepos returns dependency repositories to be synced by gitserver.
@@ -93,15 +126,7 @@ func (s *store) ListPackageRepoRefs(ctx context.Context, opts ListDependencyRepo
 		}})
 	}()
 
-	query := sqlf.Sprintf(
-		listDependencyReposQuery,
-		sqlf.Sprintf(groupedVersionedPackageReposColumns),
-		sqlf.Join([]*sqlf.Query{makeListDependencyReposConds(opts), makeOffset(opts.After)}, "AND"),
-		sqlf.Sprintf("GROUP BY lr.id"),
-		sqlf.Sprintf("ORDER BY lr.id ASC"),
-		makeLimit(opts.Limit),
-	)
-	dependencyRepos, err = basestore.NewSliceScanner(scanDependencyRepoWithVersions)(s.db.Query(ctx, query))
+	dependencyRepos, err = basestore.NewSliceScanner(scanDependencyRepoWithVersions)(s.query(ctx, s.db, makeListDependencyReposQuery(ctx, opts)))
 	if err != nil {
 		return nil, 0, false, errors.Wrap(err, "error listing dependency repos")
 	}
@@ -111,15 +136,15 @@ func (s *store) ListPackageRepoRefs(ctx context.Context, opts ListDependencyRepo
 		hasMore = true
 	}
 
-	query = sqlf.Sprintf(
+	query := sqlf.Sprintf(
 		listDependencyReposQuery,
 		sqlf.Sprintf("COUNT(DISTINCT(lr.id))"),
-		makeListDependencyReposConds(opts),
+		makeListDependencyReposConds(ctx, opts),
 		sqlf.Sprintf(""),
 		sqlf.Sprintf(""),
 		sqlf.Sprintf("LIMIT ALL"),
 	)
-	totalCount, _, err := basestore.ScanFirstInt(s.db.Query(ctx, query))
+	totalCount, _, err := basestore.ScanFirstInt(s.query(ctx, s.db, query))
 	if err != nil {
 		return nil, 0, false, errors.Wrap(err, "error counting dependency repos")
 	}
@@ -127,23 +152,78 @@ func (s *store) ListPackageRepoRefs(ctx context.Context, opts ListDependencyRepo
 	return dependencyRepos, totalCount, hasMore, err
 }
 
+// scanPackageRepoRefsBatchSize is the number of rows fetched by each query of
+// ScanPackageRepoRefs. It's a variable so that tests can lower it.
+var scanPackageRepoRefsBatchSize = 500
+
+// ScanPackageRepoRefs calls f with every dependency repository matching the given
+// options, in the order of ListPackageRepoRefs. Pages are fetched by separate
+// queries following the ID of the last row of the previous page, so no
+// transaction is held open while f runs. The Limit option is ignored. If f
+// returns an error, iteration stops and the error is returned.
+func (s *store) ScanPackageRepoRefs(ctx context.Context, opts ListDependencyReposOpts, f func(shared.PackageRepoReference) error) (err error) {
+	opts.Limit = scanPackageRepoRefsBatchSize
+	ctx, _, endObservation := s.operations.scanPackageRepoRefs.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.String("scheme", opts.Scheme),
+	}})
+	var numScanned int
+	defer func() {
+		endObservation(1, observation.Args{Attrs: []attribute.KeyValue{
+			attribute.Int("numDependencyRepos", numScanned),
+		}})
+	}()
+
+	for {
+		dependencyRepos, err := basestore.NewSliceScanner(scanDependencyRepoWithVersions)(s.query(ctx, s.db, makeListDependencyReposQuery(ctx, opts)))
+		if err != nil {
+			return errors.Wrap(err, "error listing dependency repos")
+		}
+
+		// The query fetches one row more than the limit to tell if there are
+		// more pages.
+		hasMore := len(dependencyRepos) > opts.Limit
+		if hasMore {
+			dependencyRepos = dependencyRepos[:opts.Limit]
+		}
+
+		for _, dependencyRepo := range dependencyRepos {
+			numScanned++
+			if err := f(dependencyRepo); err != nil {
+				return err
+			}
+		}
+
+		if !hasMore {
+			return nil
+		}
+		opts.After = dependencyRepos[len(dependencyRepos)-1].ID
+	}
+}
+
 const groupedVersionedPackageReposColumns = `
 	lr.id,
 	lr.scheme,
 	lr.name,
 	lr.blocked,
 	lr.last_checked_at,
+	lr.archived_at,
+	lr.published_at,
+	lr.description,
+	lr.license,
+	lr.private,
 	array_agg(prv.id ORDER BY prv.id) as vid,
 	array_agg(prv.version ORDER BY prv.id) as version,
 	array_agg(prv.blocked ORDER BY prv.id) as vers_blocked,
-	array_agg(prv.last_checked_at ORDER BY prv.id) as vers_last_checked_at
+	array_agg(prv.last_checked_at ORDER BY prv.id) as vers_last_checked_at,
+	array_agg(prv.license ORDER BY prv.id) as vers_license,
+	array_agg(prv.original_version ORDER BY prv.id) as vers_original_version
 `
 
 const listDependencyReposQuery = `
 SELECT %s
 FROM lsif_dependency_repos lr
 JOIN LATERAL (
-    SELECT id, package_id, version, blocked, last_checked_at
+    SELECT id, package_id, version, blocked, last_checked_at, license, original_version
     FROM package_repo_versions
     WHERE package_id = lr.id
     ORDER BY id
@@ -155,8 +235,21 @@ WHERE %s
 %s -- limit
 `
 
-func makeListDependencyReposConds(opts ListDependencyReposOpts) *sqlf.Query {
-	conds := make([]*sqlf.Query, 0, 4)
+// makeListDependencyReposQuery returns the query listing the page of dependency
+// repos selected by the given options.
+func makeListDependencyReposQuery(ctx context.Context, opts ListDependencyReposOpts) *sqlf.Query {
+	return sqlf.Sprintf(
+		listDependencyReposQuery,
+		sqlf.Sprintf(groupedVersionedPackageReposColumns),
+		sqlf.Join([]*sqlf.Query{makeListDependencyReposConds(ctx, opts), makeOffset(opts)}, "AND"),
+		sqlf.Sprintf("GROUP BY lr.id"),
+		makeOrder(opts),
+		makeLimit(opts.Limit),
+	)
+}
+
+func makeListDependencyReposConds(ctx context.Context, opts ListDependencyReposOpts) *sqlf.Query {
+	conds := make([]*sqlf.Query, 0, 7)
 
 	if opts.Scheme != "" {
 		conds = append(conds, sqlf.Sprintf("scheme = %s", opts.Scheme))
@@ -170,6 +263,11 @@ func makeListDependencyReposConds(opts ListDependencyReposOpts) *sqlf.Query {
 			conds = append(conds, sqlf.Sprintf("name LIKE ('%%%%' || %s || '%%%%')", opts.Name))
 		case FuzzinessRegex:
 			conds = append(conds, sqlf.Sprintf("name ~ %s", opts.Name))
+		case FuzzinessSimilarity:
+			// Matches if the word similarity of the name exceeds
+			// pg_trgm.word_similarity_threshold. The operator is served by
+			// lsif_dependency_repos_name_gin.
+			conds = append(conds, sqlf.Sprintf("lr.name %%> %s", opts.Name))
 		}
 	}
 
@@ -177,6 +275,18 @@ func makeListDependencyReposConds(opts ListDependencyReposOpts) *sqlf.Query {
 		conds = append(conds, sqlf.Sprintf("lr.blocked <> true AND prv.blocked <> true"))
 	}
 
+	if !opts.IncludeArchived {
+		conds = append(conds, sqlf.Sprintf("lr.archived_at IS NULL"))
+	}
+
+	if opts.License != "" {
+		conds = append(conds, sqlf.Sprintf("lower(COALESCE(NULLIF(prv.license, ''), lr.license)) = lower(%s)", opts.License))
+	}
+
+	// Private package repos are hidden from the actor of the context, see
+	// packageRepoVisibilityCond.
+	conds = append(conds, packageRepoVisibilityCond(ctx))
+
 	if len(conds) > 0 {
 		return sqlf.Sprintf("%s", sqlf.Join(conds, "AND"))
 	}
@@ -184,6 +294,28 @@ func makeListDependencyReposConds(opts ListDependencyReposOpts) *sqlf.Query {
 	return sqlf.Sprintf("TRUE")
 }
 
+// packageRepoVisibilityCond returns the condition on lsif_dependency_repos lr
+// that hides private package repos from the actor of the given context. Only
+// internal actors and site admins may see private package repos, as their names
+// disclose what is published to internal registries. It applies to every query
+// returning package repos, so background jobs must use an internal actor to see
+// private package repos.
+func packageRepoVisibilityCond(ctx context.Context) *sqlf.Query {
+	a := actor.FromContext(ctx)
+	if a.IsInternal() {
+		return sqlf.Sprintf("TRUE")
+	}
+
+	return sqlf.Sprintf(packageRepoVisibilityCondQuery, a.UID)
+}
+
+const packageRepoVisibilityCondQuery = `
+(
+	NOT lr.private OR
+	EXISTS (SELECT 1 FROM users u WHERE u.id = %s AND u.site_admin AND u.deleted_at IS NULL)
+)
+`
+
 func makeLimit(limit int) *sqlf.Query {
 	if limit == 0 {
 		return sqlf.Sprintf("LIMIT ALL")
@@ -192,14 +324,39 @@ func makeLimit(limit int) *sqlf.Query {
 	return sqlf.Sprintf("LIMIT %s", limit+1)
 }
 
-func makeOffset(id int) *sqlf.Query {
-	if id > 0 {
-		return sqlf.Sprintf("lr.id > %s", id)
+// makeOrder returns the order of listed dependency repos. Similarity matches are
+// ordered by descending similarity, and ties by descending ID so that both
+// columns can be compared as one row in makeOffset.
+func makeOrder(opts ListDependencyReposOpts) *sqlf.Query {
+	if opts.Name != "" && opts.Fuzziness == FuzzinessSimilarity {
+		return sqlf.Sprintf("ORDER BY word_similarity(%s, lr.name) DESC, lr.id DESC", opts.Name)
 	}
 
-	return sqlf.Sprintf("TRUE")
+	return sqlf.Sprintf("ORDER BY lr.id ASC")
 }
 
+// makeOffset returns the condition selecting the dependency repos following the
+// one with the ID opts.After in the order of makeOrder.
+func makeOffset(opts ListDependencyReposOpts) *sqlf.Query {
+	if opts.After <= 0 {
+		return sqlf.Sprintf("TRUE")
+	}
+
+	if opts.Name != "" && opts.Fuzziness == FuzzinessSimilarity {
+		return sqlf.Sprintf(similarityOffsetQuery, opts.Name, opts.Name, opts.After)
+	}
+
+	return sqlf.Sprintf("lr.id > %s", opts.After)
+}
+
+const similarityOffsetQuery = `
+(word_similarity(%s, lr.name), lr.id) < (
+	SELECT word_similarity(%s, after.name), after.id
+	FROM lsif_dependency_repos after
+	WHERE after.id = %s
+)
+`
+
 // InsertDependencyRepos creates the given dependency repos if they don't yet exist. The values that did not exist previously are returned.
 // [{npm, @types/nodejs, [v0.0.1]}, {npm, @types/nodejs, [v0.0.2]}] will be collapsed into [{npm, @types/nodejs, [v0.0.1, v0.0.2]}]
 func (s *store) InsertPackageRepoRefs(ctx context.Context, deps []shared.MinimalPackageRepoRef) (newDeps []shared.PackageRepoReference, newVersions []shared.PackageRepoRefVersion, err error) {
@@ -218,6 +375,41 @@ func (s *store) InsertPackageRepoRefs(ctx context.Context, deps []shared.Minimal
 		return
 	}
 
+	// Spell names and versions of supported ecosystems the same way regardless of
+	// where the reference came from, so that they're deduplicated below and by the
+	// unique indexes. Names that aren't valid in their ecosystem could never be
+	// synced, so they're skipped. Names and versions of unknown schemes are opaque
+	// and stored as-is.
+	validDeps := deps[:0]
+	for _, dep := range deps {
+		scheme, ok := shared.LookupPackageScheme(dep.Scheme)
+		if ok {
+			if err := scheme.ValidateName(dep.Name); err != nil {
+				s.logger.Warn("skipping package repo ref with invalid name",
+					log.String("scheme", dep.Scheme),
+					log.String("name", string(dep.Name)),
+					log.Error(err))
+				continue
+			}
+			dep.Name = scheme.NormalizeName(dep.Name)
+			dep.Versions = normalizePackageRepoRefVersions(scheme, dep.Versions)
+		}
+		validDeps = append(validDeps, dep)
+	}
+	deps = validDeps
+
+	if len(deps) == 0 {
+		return
+	}
+
+	// Callers usually check the package repo filters themselves, but the filters
+	// may have changed since, so block anything the current filters block. This
+	// guarantees that blocked packages are never synced. Packages and versions are
+	// never unblocked here; that's left to the packages filter job.
+	if err := s.blockFilteredPackageRepoRefs(ctx, deps); err != nil {
+		return nil, nil, err
+	}
+
 	slices.SortStableFunc(deps, func(a, b shared.MinimalPackageRepoRef) bool {
 		if a.Scheme != b.Scheme {
 			return a.Scheme < b.Scheme
@@ -231,6 +423,7 @@ func (s *store) InsertPackageRepoRefs(ctx context.Context, deps []shared.Minimal
 	for i, dep := range deps[1:] {
 		if dep.Name == deps[lastCommon].Name && dep.Scheme == deps[lastCommon].Scheme {
 			deps[lastCommon].Versions = append(deps[lastCommon].Versions, dep.Versions...)
+			mergePackageRepoRefMetadata(&deps[lastCommon], dep)
 			deps[i+1] = shared.MinimalPackageRepoRef{}
 		} else {
 			lastCommon = i + 1
@@ -266,10 +459,10 @@ func (s *store) InsertPackageRepoRefs(ctx context.Context, deps []shared.Minimal
 		tx.Handle(),
 		"t_package_repo_refs",
 		batch.MaxNumPostgresParameters,
-		[]string{"scheme", "name", "blocked", "last_checked_at"},
+		[]string{"scheme", "name", "blocked", "last_checked_at", "published_at", "description", "license"},
 		func(inserter *batch.Inserter) error {
 			for _, pkg := range deps {
-				if err := inserter.Insert(ctx, pkg.Scheme, pkg.Name, pkg.Blocked, pkg.LastCheckedAt); err != nil {
+				if err := inserter.Insert(ctx, pkg.Scheme, pkg.Name, pkg.Blocked, pkg.LastCheckedAt, pkg.PublishedAt, pkg.Description, pkg.License); err != nil {
 					return err
 				}
 			}
@@ -280,14 +473,24 @@ func (s *store) InsertPackageRepoRefs(ctx context.Context, deps []shared.Minimal
 		return nil, nil, errors.Wrap(err, "failed to insert package repos in temporary table")
 	}
 
+	// Refresh the metadata of existing package repos before inserting new ones, so
+	// that re-syncs don't leave stale metadata behind.
+	if err := tx.Exec(ctx, sqlf.Sprintf(updatePackageRepoRefsMetadataQuery)); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to update package repo metadata")
+	}
+
 	newDeps, err = basestore.NewSliceScanner(func(rows dbutil.Scanner) (dep shared.PackageRepoReference, err error) {
-		err = rows.Scan(&dep.ID, &dep.Scheme, &dep.Name, &dep.Blocked, &dep.LastCheckedAt)
+		err = rows.Scan(&dep.ID, &dep.Scheme, &dep.Name, &dep.Blocked, &dep.LastCheckedAt, &dep.PublishedAt, &dep.Description, &dep.License)
 		return
-	})(tx.Query(ctx, sqlf.Sprintf(transferPackageRepoRefsQuery)))
+	})(s.query(ctx, tx, sqlf.Sprintf(transferPackageRepoRefsQuery)))
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to transfer package repos from temporary table")
 	}
 
+	if err := enqueueExternalServiceSyncs(ctx, tx, newDeps); err != nil {
+		return nil, nil, err
+	}
+
 	// we need the IDs of all newly inserted and already existing package repo references
 	// for all of the references in `deps`, so that we have the package repo reference ID that
 	// we need for the package repo reference versions table.
@@ -325,7 +528,7 @@ func (s *store) InsertPackageRepoRefs(ctx context.Context, deps []shared.Minimal
 			sqlf.Join(params, ", "),
 		)
 
-		allIDsWindow, err := basestore.ScanInts(tx.Query(ctx, query))
+		allIDsWindow, err := basestore.ScanInts(s.query(ctx, tx, query))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -337,11 +540,11 @@ func (s *store) InsertPackageRepoRefs(ctx context.Context, deps []shared.Minimal
 		tx.Handle(),
 		"t_package_repo_versions",
 		batch.MaxNumPostgresParameters,
-		[]string{"package_id", "version", "blocked", "last_checked_at"},
+		[]string{"package_id", "version", "blocked", "last_checked_at", "license", "original_version"},
 		func(inserter *batch.Inserter) error {
 			for i, dep := range deps {
 				for _, version := range dep.Versions {
-					if err := inserter.Insert(ctx, allIDs[i], version.Version, version.Blocked, version.LastCheckedAt); err != nil {
+					if err := inserter.Insert(ctx, allIDs[i], version.Version, version.Blocked, version.LastCheckedAt, version.License, version.OriginalVersion); err != nil {
 						return err
 					}
 				}
@@ -352,10 +555,14 @@ func (s *store) InsertPackageRepoRefs(ctx context.Context, deps []shared.Minimal
 		return nil, nil, errors.Wrapf(err, "failed to insert package repo versions in temporary table")
 	}
 
+	if err := tx.Exec(ctx, sqlf.Sprintf(updatePackageRepoRefVersionsLicenseQuery)); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to update package repo version licenses")
+	}
+
 	newVersions, err = basestore.NewSliceScanner(func(rows dbutil.Scanner) (version shared.PackageRepoRefVersion, err error) {
-		err = rows.Scan(&version.ID, &version.PackageRefID, &version.Version, &version.Blocked, &version.LastCheckedAt)
+		err = rows.Scan(&version.ID, &version.PackageRefID, &version.Version, &version.Blocked, &version.LastCheckedAt, &version.License, &version.OriginalVersion)
 		return
-	})(tx.Query(ctx, sqlf.Sprintf(transferPackageRepoRefVersionsQuery)))
+	})(s.query(ctx, tx, sqlf.Sprintf(transferPackageRepoRefVersionsQuery)))
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to transfer package repos from temporary table")
 	}
@@ -363,12 +570,75 @@ func (s *store) InsertPackageRepoRefs(ctx context.Context, deps []shared.Minimal
 	return newDeps, newVersions, err
 }
 
+// blockFilteredPackageRepoRefs marks the given package repo references and
+// versions as blocked if the current package repo filters don't allow them.
+func (s *store) blockFilteredPackageRepoRefs(ctx context.Context, deps []shared.MinimalPackageRepoRef) error {
+	filters, _, err := s.ListPackageRepoRefFilters(ctx, ListPackageRepoRefFiltersOpts{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list package repo filters")
+	}
+	if len(filters) == 0 {
+		return nil
+	}
+
+	packageFilters, err := packagefilters.NewFilterLists(filters)
+	if err != nil {
+		return err
+	}
+
+	for i, dep := range deps {
+		if !packagefilters.IsPackageAllowed(dep.Scheme, dep.Name, packageFilters) {
+			deps[i].Blocked = true
+		}
+		// Don't modify the caller's versions.
+		deps[i].Versions = append([]shared.MinimalPackageRepoRefVersion(nil), dep.Versions...)
+		for j, version := range dep.Versions {
+			if !packagefilters.IsVersionedPackageAllowed(dep.Scheme, dep.Name, version.Version, packageFilters) {
+				deps[i].Versions[j].Blocked = true
+			}
+		}
+	}
+	return nil
+}
+
+// normalizePackageRepoRefVersions returns a copy of the given versions spelled
+// canonically for the scheme, remembering the original spelling of versions
+// that changed.
+func normalizePackageRepoRefVersions(scheme shared.PackageScheme, versions []shared.MinimalPackageRepoRefVersion) []shared.MinimalPackageRepoRefVersion {
+	normalized := make([]shared.MinimalPackageRepoRefVersion, 0, len(versions))
+	for _, version := range versions {
+		if canonical := scheme.NormalizeVersion(version.Version); canonical != version.Version {
+			version.OriginalVersion = version.Version
+			version.Version = canonical
+		}
+		normalized = append(normalized, version)
+	}
+	return normalized
+}
+
+// mergePackageRepoRefMetadata copies the metadata set on src, a duplicate
+// reference to the same package, onto dst.
+func mergePackageRepoRefMetadata(dst *shared.MinimalPackageRepoRef, src shared.MinimalPackageRepoRef) {
+	if src.PublishedAt != nil {
+		dst.PublishedAt = src.PublishedAt
+	}
+	if src.Description != "" {
+		dst.Description = src.Description
+	}
+	if src.License != "" {
+		dst.License = src.License
+	}
+}
+
 const temporaryPackageRepoRefsTableQuery = `
 CREATE TEMPORARY TABLE t_package_repo_refs (
 	scheme TEXT NOT NULL,
 	name TEXT NOT NULL,
 	blocked BOOLEAN NOT NULL,
-	last_checked_at TIMESTAMPTZ
+	last_checked_at TIMESTAMPTZ,
+	published_at TIMESTAMPTZ,
+	description TEXT NOT NULL,
+	license TEXT NOT NULL
 ) ON COMMIT DROP
 `
 
@@ -377,13 +647,33 @@ CREATE TEMPORARY TABLE t_package_repo_versions (
 	package_id BIGINT NOT NULL,
 	version TEXT NOT NULL,
 	blocked BOOLEAN NOT NULL,
-	last_checked_at TIMESTAMPTZ
+	last_checked_at TIMESTAMPTZ,
+	license TEXT NOT NULL,
+	original_version TEXT NOT NULL
 ) ON COMMIT DROP
 `
 
+// Metadata that is missing from the inserted package repos (e.g. because the
+// package host was not queried) does not overwrite known metadata.
+const updatePackageRepoRefsMetadataQuery = `
+UPDATE lsif_dependency_repos lr
+SET
+	published_at = COALESCE(t.published_at, lr.published_at),
+	description = COALESCE(NULLIF(t.description, ''), lr.description),
+	license = COALESCE(NULLIF(t.license, ''), lr.license)
+FROM t_package_repo_refs t
+WHERE
+	lr.scheme = t.scheme AND
+	lr.name = t.name AND (
+		(t.published_at IS NOT NULL AND t.published_at IS DISTINCT FROM lr.published_at) OR
+		(t.description <> '' AND t.description <> lr.description) OR
+		(t.license <> '' AND t.license <> lr.license)
+	)
+`
+
 const transferPackageRepoRefsQuery = `
-INSERT INTO lsif_dependency_repos (scheme, name, blocked, last_checked_at)
-SELECT scheme, name, blocked, last_checked_at
+INSERT INTO lsif_dependency_repos (scheme, name, blocked, last_checked_at, published_at, description, license)
+SELECT scheme, name, blocked, last_checked_at, published_at, description, license
 FROM t_package_repo_refs t
 WHERE NOT EXISTS (
 	SELECT scheme, name
@@ -392,14 +682,30 @@ WHERE NOT EXISTS (
 	name = t.name
 )
 ORDER BY name
-RETURNING id, scheme, name, blocked, last_checked_at
+RETURNING id, scheme, name, blocked, last_checked_at, published_at, description, license
+`
+
+// As with package metadata, an unknown license does not overwrite a known one.
+const updatePackageRepoRefVersionsLicenseQuery = `
+UPDATE package_repo_versions prv
+SET license = t.license
+FROM (
+	SELECT DISTINCT ON (package_id, version) package_id, version, license
+	FROM t_package_repo_versions
+	WHERE license <> ''
+	ORDER BY package_id, version, license
+) t
+WHERE
+	prv.package_id = t.package_id AND
+	prv.version = t.version AND
+	prv.license <> t.license
 `
 
 const transferPackageRepoRefVersionsQuery = `
-INSERT INTO package_repo_versions (package_id, version, blocked, last_checked_at)
+INSERT INTO package_repo_versions (package_id, version, blocked, last_checked_at, license, original_version)
 -- we dont reduce package repo versions,
 -- so DISTINCT here to avoid conflict
-SELECT DISTINCT ON (package_id, version) package_id, version, blocked, last_checked_at
+SELECT DISTINCT ON (package_id, version) package_id, version, blocked, last_checked_at, license, original_version
 FROM t_package_repo_versions t
 WHERE NOT EXISTS (
 	SELECT package_id, version
@@ -407,9 +713,10 @@ WHERE NOT EXISTS (
 	WHERE package_id = t.package_id AND
 	version = t.version
 )
--- unit tests rely on a certain order
-ORDER BY package_id, version
-RETURNING id, package_id, version, blocked, last_checked_at
+-- unit tests rely on a certain order; prefer duplicates that declare a license,
+-- then those with an original spelling
+ORDER BY package_id, version, license DESC, original_version DESC
+RETURNING id, package_id, version, blocked, last_checked_at, license, original_version
 `
 
 const getAttemptedInsertDependencyReposQuery = `
@@ -455,6 +762,361 @@ DELETE FROM package_repo_versions
 WHERE id = ANY(%s)
 `
 
+// DeletePackageRepoRefsOpts are options for bulk deleting package repo references.
+type DeletePackageRepoRefsOpts struct {
+	// Scheme is required, so that a single call can't wipe out every package.
+	Scheme string
+	// NameGlob matches package names, where * matches any sequence of characters
+	// and ? matches a single character. Empty matches every name.
+	NameGlob string
+	// VersionConstraints, if set, only deletes versions satisfying all of the given
+	// constraints (see versions.MatchesConstraints). Versions that can't be parsed
+	// for the scheme are never deleted.
+	VersionConstraints []string
+}
+
+// DeletePackageRepoRefs deletes the package repo references, or only their versions if
+// version constraints are given, matching the given options. Package repo references
+// left without versions are deleted as well. The deletions are made in a single statement.
+func (s *store) DeletePackageRepoRefs(ctx context.Context, opts DeletePackageRepoRefsOpts) (deletedPackages, deletedVersions int, err error) {
+	ctx, _, endObservation := s.operations.deletePackageRepoRefs.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.String("scheme", opts.Scheme),
+		attribute.String("nameGlob", opts.NameGlob),
+		attribute.StringSlice("versionConstraints", opts.VersionConstraints),
+	}})
+	defer func() {
+		endObservation(1, observation.Args{Attrs: []attribute.KeyValue{
+			attribute.Int("deletedPackages", deletedPackages),
+			attribute.Int("deletedVersions", deletedVersions),
+		}})
+	}()
+
+	if opts.Scheme == "" {
+		return 0, 0, errors.New("a scheme is required to delete package repo references")
+	}
+
+	conds := []*sqlf.Query{sqlf.Sprintf("lr.scheme = %s", opts.Scheme)}
+	if opts.NameGlob != "" {
+		conds = append(conds, sqlf.Sprintf("lr.name LIKE %s", globPattern(opts.NameGlob)))
+	}
+
+	if len(opts.VersionConstraints) == 0 {
+		row := s.db.QueryRow(ctx, sqlf.Sprintf(deletePackageRepoRefsQuery, sqlf.Join(conds, "AND")))
+		err = row.Scan(&deletedPackages, &deletedVersions)
+		return deletedPackages, deletedVersions, err
+	}
+
+	// Version ranges are ecosystem-specific, so the candidate versions are matched
+	// here rather than in the database.
+	err = s.db.WithTransact(ctx, func(tx *basestore.Store) error {
+		candidates, err := basestore.NewSliceScanner(func(rows dbutil.Scanner) (version shared.PackageRepoRefVersion, err error) {
+			err = rows.Scan(&version.ID, &version.Version)
+			return
+		})(s.query(ctx, tx, sqlf.Sprintf(deletePackageRepoRefVersionCandidatesQuery, sqlf.Join(conds, "AND"))))
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int, 0, len(candidates))
+		for _, candidate := range candidates {
+			if _, err := versions.Compare(opts.Scheme, candidate.Version, candidate.Version); err != nil {
+				continue
+			}
+			ok, err := versions.MatchesConstraints(opts.Scheme, candidate.Version, opts.VersionConstraints)
+			if err != nil {
+				return errors.Wrap(err, "invalid version constraints")
+			}
+			if ok {
+				ids = append(ids, candidate.ID)
+			}
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		row := tx.QueryRow(ctx, sqlf.Sprintf(deletePackageRepoRefVersionsQuery, pq.Array(ids), pq.Array(ids)))
+		return row.Scan(&deletedPackages, &deletedVersions)
+	})
+	return deletedPackages, deletedVersions, err
+}
+
+// globPattern returns a LIKE pattern equivalent to the given glob.
+func globPattern(glob string) string {
+	var b strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteByte('%')
+		case '?':
+			b.WriteByte('_')
+		case '%', '_', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Versions are deleted by the cascading foreign key. They are still visible to the
+// final SELECT, which uses the snapshot taken before the statement.
+const deletePackageRepoRefsQuery = `
+WITH deleted_packages AS (
+	DELETE FROM lsif_dependency_repos lr
+	WHERE %s
+	RETURNING lr.id
+)
+SELECT
+	(SELECT COUNT(*) FROM deleted_packages),
+	(SELECT COUNT(*) FROM package_repo_versions WHERE package_id IN (SELECT id FROM deleted_packages))
+`
+
+const deletePackageRepoRefVersionCandidatesQuery = `
+SELECT prv.id, prv.version
+FROM lsif_dependency_repos lr
+JOIN package_repo_versions prv ON prv.package_id = lr.id
+WHERE %s
+ORDER BY prv.id
+FOR UPDATE OF prv
+`
+
+// The package repo references are deleted in the same statement as the versions,
+// which all CTEs see as not yet deleted, hence the id <> ALL(...) check.
+const deletePackageRepoRefVersionsQuery = `
+WITH deleted_versions AS (
+	DELETE FROM package_repo_versions
+	WHERE id = ANY(%s)
+	RETURNING package_id
+),
+deleted_packages AS (
+	DELETE FROM lsif_dependency_repos lr
+	WHERE
+		lr.id IN (SELECT package_id FROM deleted_versions) AND
+		NOT EXISTS (
+			SELECT 1
+			FROM package_repo_versions prv
+			WHERE prv.package_id = lr.id AND prv.id <> ALL(%s)
+		)
+	RETURNING lr.id
+)
+SELECT
+	(SELECT COUNT(*) FROM deleted_packages),
+	(SELECT COUNT(*) FROM deleted_versions)
+`
+
+// enqueueExternalServiceSyncs enqueues sync jobs for the external services that sync
+// the given newly inserted package repos, so that repo-updater creates their repos
+// right away instead of on its next periodic sync. The jobs are rows in
+// repo-updater's own sync job queue, so they're only visible once the package
+// repos are committed, and the external services themselves aren't modified.
+func enqueueExternalServiceSyncs(ctx context.Context, tx *basestore.Store, newDeps []shared.PackageRepoReference) error {
+	kinds := map[string]struct{}{}
+	for _, dep := range newDeps {
+		if scheme, ok := shared.LookupPackageScheme(dep.Scheme); ok && scheme.SyncReferenced && !dep.Blocked {
+			kinds[scheme.ExternalServiceKind] = struct{}{}
+		}
+	}
+	if len(kinds) == 0 {
+		return nil
+	}
+
+	kindsArray := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		kindsArray = append(kindsArray, kind)
+	}
+	if err := tx.Exec(ctx, sqlf.Sprintf(enqueueExternalServiceSyncsQuery, pq.Array(kindsArray))); err != nil {
+		return errors.Wrap(err, "failed to enqueue external service syncs")
+	}
+	return nil
+}
+
+// A sync that is already processing may have listed the package repos before
+// they were inserted, so only a queued sync makes another one unnecessary.
+const enqueueExternalServiceSyncsQuery = `
+INSERT INTO external_service_sync_jobs (external_service_id)
+SELECT es.id
+FROM external_services es
+WHERE
+	es.kind = ANY(%s) AND
+	es.deleted_at IS NULL AND
+	NOT es.cloud_default AND
+	NOT EXISTS (
+		SELECT 1
+		FROM external_service_sync_jobs j
+		WHERE j.external_service_id = es.id AND j.state = 'queued'
+	)
+`
+
+// ResolvePackageRepoRefVersionOpts are options for resolving a version of a package repo reference.
+type ResolvePackageRepoRefVersionOpts struct {
+	Scheme  string
+	Name    reposource.PackageName
+	Version string
+	// AllowNearest falls back to the nearest available version of the package if the
+	// requested version is not available.
+	AllowNearest bool
+}
+
+// ResolvePackageRepoRefVersion returns the non-blocked version of the given package matching the
+// requested version. With AllowNearest, the nearest version is the highest available version below
+// the requested one or, if there is none, the lowest available version above it. Versions are
+// ordered as in versions.Compare, so no fallback happens for versions that can't be parsed.
+// Resolving never writes, versions are only added once they were synced.
+func (s *store) ResolvePackageRepoRefVersion(ctx context.Context, opts ResolvePackageRepoRefVersionOpts) (resolved shared.ResolvedPackageRepoRefVersion, found bool, err error) {
+	ctx, _, endObservation := s.operations.resolvePackageRepoRefVersion.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.String("scheme", opts.Scheme),
+		attribute.String("name", string(opts.Name)),
+		attribute.String("version", opts.Version),
+		attribute.Bool("allowNearest", opts.AllowNearest),
+	}})
+	defer func() {
+		endObservation(1, observation.Args{Attrs: []attribute.KeyValue{
+			attribute.Bool("found", found),
+			attribute.Bool("approximate", resolved.Approximate),
+		}})
+	}()
+
+	// Versions are stored normalized, see InsertPackageRepoRefs.
+	if scheme, ok := shared.LookupPackageScheme(opts.Scheme); ok {
+		opts.Name = scheme.NormalizeName(opts.Name)
+		opts.Version = scheme.NormalizeVersion(opts.Version)
+	}
+
+	candidates, err := basestore.NewSliceScanner(func(rows dbutil.Scanner) (version shared.PackageRepoRefVersion, err error) {
+		err = rows.Scan(&version.ID, &version.PackageRefID, &version.Version, &version.Blocked, &version.LastCheckedAt, &version.License, &version.OriginalVersion)
+		return
+	})(s.query(ctx, s.db, sqlf.Sprintf(resolvePackageRepoRefVersionCandidatesQuery, opts.Scheme, opts.Name, packageRepoVisibilityCond(ctx))))
+	if err != nil {
+		return shared.ResolvedPackageRepoRefVersion{}, false, err
+	}
+
+	for _, candidate := range candidates {
+		if candidate.Version == opts.Version {
+			return shared.ResolvedPackageRepoRefVersion{PackageRepoRefVersion: candidate}, true, nil
+		}
+	}
+
+	if !opts.AllowNearest {
+		return shared.ResolvedPackageRepoRefVersion{}, false, nil
+	}
+
+	var below, above *shared.PackageRepoRefVersion
+	for i, candidate := range candidates {
+		cmp, err := versions.Compare(opts.Scheme, candidate.Version, opts.Version)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case cmp == 0:
+			// An equivalent spelling of the requested version, e.g. 1.0 for 1.0.0.
+			return shared.ResolvedPackageRepoRefVersion{PackageRepoRefVersion: candidate}, true, nil
+		case cmp < 0:
+			if below == nil || mustCompare(opts.Scheme, candidate.Version, below.Version) > 0 {
+				below = &candidates[i]
+			}
+		default:
+			if above == nil || mustCompare(opts.Scheme, candidate.Version, above.Version) < 0 {
+				above = &candidates[i]
+			}
+		}
+	}
+
+	nearest := below
+	if nearest == nil {
+		nearest = above
+	}
+	if nearest == nil {
+		return shared.ResolvedPackageRepoRefVersion{}, false, nil
+	}
+	return shared.ResolvedPackageRepoRefVersion{PackageRepoRefVersion: *nearest, Approximate: true}, true, nil
+}
+
+// mustCompare compares two versions that are known to parse for the scheme.
+func mustCompare(scheme, a, b string) int {
+	cmp, _ := versions.Compare(scheme, a, b)
+	return cmp
+}
+
+const resolvePackageRepoRefVersionCandidatesQuery = `
+SELECT prv.id, prv.package_id, prv.version, prv.blocked, prv.last_checked_at, prv.license, prv.original_version
+FROM lsif_dependency_repos lr
+JOIN package_repo_versions prv ON prv.package_id = lr.id
+WHERE
+	lr.scheme = %s AND
+	lr.name = %s AND
+	NOT lr.blocked AND
+	NOT prv.blocked AND
+	%s -- visibility
+ORDER BY prv.id
+`
+
+// ArchivePackageRepoRefsByID marks the given package repo references as archived. Archived
+// package repo references keep their versions but are excluded from ListPackageRepoRefs unless
+// IncludeArchived is set, which stops them from being synced.
+func (s *store) ArchivePackageRepoRefsByID(ctx context.Context, ids ...int) (err error) {
+	ctx, _, endObservation := s.operations.archivePackageRepoRefsByID.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.Int("numIDs", len(ids)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return s.db.Exec(ctx, sqlf.Sprintf(archivePackageRepoRefsByIDQuery, pq.Array(ids)))
+}
+
+const archivePackageRepoRefsByIDQuery = `
+UPDATE lsif_dependency_repos
+SET archived_at = NOW()
+WHERE id = ANY(%s) AND archived_at IS NULL
+`
+
+// UnarchivePackageRepoRefsByID restores package repo references archived by ArchivePackageRepoRefsByID.
+func (s *store) UnarchivePackageRepoRefsByID(ctx context.Context, ids ...int) (err error) {
+	ctx, _, endObservation := s.operations.unarchivePackageRepoRefsByID.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.Int("numIDs", len(ids)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return s.db.Exec(ctx, sqlf.Sprintf(unarchivePackageRepoRefsByIDQuery, pq.Array(ids)))
+}
+
+const unarchivePackageRepoRefsByIDQuery = `
+UPDATE lsif_dependency_repos
+SET archived_at = NULL
+WHERE id = ANY(%s)
+`
+
+// UpdatePackageRepoRefsVisibilityByID marks the given package repo references as
+// private or public. Private package repos are hidden from actors who aren't
+// internal actors or site admins, see packageRepoVisibilityCond.
+func (s *store) UpdatePackageRepoRefsVisibilityByID(ctx context.Context, private bool, ids ...int) (err error) {
+	ctx, _, endObservation := s.operations.updatePackageRepoRefsVisibilityByID.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.Bool("private", private),
+		attribute.Int("numIDs", len(ids)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return s.db.Exec(ctx, sqlf.Sprintf(updatePackageRepoRefsVisibilityByIDQuery, private, pq.Array(ids)))
+}
+
+const updatePackageRepoRefsVisibilityByIDQuery = `
+UPDATE lsif_dependency_repos
+SET private = %s
+WHERE id = ANY(%s)
+`
+
 type ListPackageRepoRefFiltersOpts struct {
 	IDs            []int
 	PackageScheme  string
@@ -507,7 +1169,7 @@ func (s *store) ListPackageRepoRefFilters(ctx context.Context, opts ListPackageR
 	}
 
 	filters, err := basestore.NewSliceScanner(scanPackageFilter)(
-		s.db.Query(ctx, sqlf.Sprintf(
+		s.query(ctx, s.db, sqlf.Sprintf(
 			listPackageRepoRefFiltersQuery,
 			sqlf.Join(conds, "AND"),
 			limit,
@@ -563,7 +1225,7 @@ func (s *store) CreatePackageRepoFilter(ctx context.Context, input shared.Minima
 
 	err = basestore.NewCallbackScanner(func(s dbutil.Scanner) (bool, error) {
 		return false, s.Scan(&hydrated.ID, &hydrated.UpdatedAt)
-	})(s.db.Query(ctx, sqlf.Sprintf(createPackageRepoFilter, input.Behaviour, input.PackageScheme, matcherJSON)))
+	})(s.query(ctx, s.db, sqlf.Sprintf(createPackageRepoFilter, input.Behaviour, input.PackageScheme, matcherJSON)))
 	if err != nil {
 		return nil, errors.Wrap(err, "error inserting package repo filter")
 	}
@@ -682,7 +1344,7 @@ func (s *store) ShouldRefilterPackageRepoRefs(ctx context.Context) (exists bool,
 	ctx, _, endObservation := s.operations.shouldRefilterPackageRepoRefs.With(ctx, &err, observation.Args{})
 	defer endObservation(1, observation.Args{})
 
-	_, exists, err = basestore.ScanFirstInt(s.db.Query(ctx, sqlf.Sprintf(doPackageRepoRefsRequireRefilteringQuery)))
+	_, exists, err = basestore.ScanFirstInt(s.query(ctx, s.db, sqlf.Sprintf(doPackageRepoRefsRequireRefilteringQuery)))
 	return
 }
 
@@ -784,7 +1446,7 @@ func (s *store) UpdateAllBlockedStatuses(ctx context.Context, pkgs []shared.Pack
 
 		err = basestore.NewCallbackScanner(func(s dbutil.Scanner) (bool, error) {
 			return false, s.Scan(&pkgsUpdated, &versionsUpdated)
-		})(tx.Query(ctx, sqlf.Sprintf(updateAllBlockedStatusesQuery, startTime, startTime)))
+		})(s.query(ctx, tx, sqlf.Sprintf(updateAllBlockedStatusesQuery, startTime, startTime)))
 		return errors.Wrap(err, "error scanning update results")
 	})
 
@@ -836,3 +1498,240 @@ SELECT (
 	FROM updated_package_repo_versions
 ) AS versions_changed
 `
+
+// CountPackageRepoRefsByScheme returns the number of package repo references and
+// package repo versions stored for each scheme.
+func (s *store) CountPackageRepoRefsByScheme(ctx context.Context) (_ []shared.PackageRepoSchemeCount, err error) {
+	ctx, _, endObservation := s.operations.countPackageRepoRefsByScheme.With(ctx, &err, observation.Args{})
+	defer endObservation(1, observation.Args{})
+
+	return basestore.NewSliceScanner(func(rows dbutil.Scanner) (count shared.PackageRepoSchemeCount, err error) {
+		err = rows.Scan(&count.Scheme, &count.Packages, &count.Versions)
+		return
+	})(s.query(ctx, s.db, sqlf.Sprintf(countPackageRepoRefsBySchemeQuery)))
+}
+
+const countPackageRepoRefsBySchemeQuery = `
+SELECT
+	lr.scheme,
+	COUNT(DISTINCT lr.id),
+	COUNT(prv.id)
+FROM lsif_dependency_repos lr
+LEFT JOIN package_repo_versions prv ON prv.package_id = lr.id
+GROUP BY lr.scheme
+ORDER BY lr.scheme
+`
+
+// Stats returns the number of package repo references and versions per scheme, along with
+// how many of them are blocked or archived, and the totals across all schemes.
+func (s *store) Stats(ctx context.Context) (stats shared.PackageRepoStats, err error) {
+	ctx, _, endObservation := s.operations.stats.With(ctx, &err, observation.Args{})
+	defer endObservation(1, observation.Args{})
+
+	schemes, err := basestore.NewSliceScanner(func(rows dbutil.Scanner) (schemeStats shared.PackageRepoSchemeStats, err error) {
+		err = rows.Scan(
+			&schemeStats.Scheme,
+			&schemeStats.Packages,
+			&schemeStats.BlockedPackages,
+			&schemeStats.ArchivedPackages,
+			&schemeStats.Versions,
+			&schemeStats.BlockedVersions,
+		)
+		return
+	})(s.query(ctx, s.db, sqlf.Sprintf(statsQuery)))
+	if err != nil {
+		return shared.PackageRepoStats{}, err
+	}
+
+	stats.Schemes = schemes
+	for _, schemeStats := range schemes {
+		stats.Packages += schemeStats.Packages
+		stats.Versions += schemeStats.Versions
+		stats.BlockedPackages += schemeStats.BlockedPackages
+		stats.BlockedVersions += schemeStats.BlockedVersions
+		stats.ArchivedPackages += schemeStats.ArchivedPackages
+	}
+
+	return stats, nil
+}
+
+const statsQuery = `
+WITH version_counts AS (
+	SELECT
+		package_id,
+		COUNT(*) AS versions,
+		COUNT(*) FILTER (WHERE blocked) AS blocked_versions
+	FROM package_repo_versions
+	GROUP BY package_id
+)
+SELECT
+	lr.scheme,
+	COUNT(*),
+	COUNT(*) FILTER (WHERE lr.blocked),
+	COUNT(*) FILTER (WHERE lr.archived_at IS NOT NULL),
+	COALESCE(SUM(vc.versions), 0),
+	COALESCE(SUM(vc.blocked_versions), 0)
+FROM lsif_dependency_repos lr
+LEFT JOIN version_counts vc ON vc.package_id = lr.id
+GROUP BY lr.scheme
+ORDER BY lr.scheme
+`
+
+// ListPackageLicenseDependents returns the repositories with a completed precise index
+// referencing a package version that declares the given license (ignoring case), along
+// with the referenced package versions. Versions without a declared license fall back
+// to the license of their package. Only repositories visible to the current actor are
+// returned.
+func (s *store) ListPackageLicenseDependents(ctx context.Context, license string) (dependents []shared.PackageLicenseDependent, err error) {
+	ctx, _, endObservation := s.operations.listPackageLicenseDependents.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.String("license", license),
+	}})
+	defer func() {
+		endObservation(1, observation.Args{Attrs: []attribute.KeyValue{
+			attribute.Int("numDependents", len(dependents)),
+		}})
+	}()
+
+	authzConds, err := database.AuthzQueryConds(ctx, database.NewDBWith(s.logger, s.db))
+	if err != nil {
+		return nil, err
+	}
+
+	return basestore.NewSliceScanner(func(rows dbutil.Scanner) (dependent shared.PackageLicenseDependent, err error) {
+		err = rows.Scan(
+			&dependent.RepositoryID,
+			&dependent.RepositoryName,
+			&dependent.Scheme,
+			&dependent.Name,
+			&dependent.Version,
+			&dependent.License,
+		)
+		return
+	})(s.query(ctx, s.db, sqlf.Sprintf(listPackageLicenseDependentsQuery, license, packageRepoVisibilityCond(ctx), authzConds)))
+}
+
+// Package repos are stored under the normalized scheme and name of the references
+// that created them (see newPackage in the autoindexing dependency sync scheduler),
+// so references from SCIP indexers and Maven coordinates are mapped back here.
+const listPackageLicenseDependentsQuery = `
+WITH licensed_versions AS (
+	SELECT
+		lr.scheme,
+		lr.name,
+		prv.version,
+		COALESCE(NULLIF(prv.license, ''), lr.license) AS license
+	FROM lsif_dependency_repos lr
+	JOIN package_repo_versions prv ON prv.package_id = lr.id
+	WHERE
+		lower(COALESCE(NULLIF(prv.license, ''), lr.license)) = lower(%s) AND
+		%s -- visibility
+)
+SELECT DISTINCT
+	repo.id,
+	repo.name,
+	lv.scheme,
+	lv.name,
+	lv.version,
+	lv.license
+FROM licensed_versions lv
+JOIN lsif_references ref ON
+	ref.version = lv.version AND (
+		(ref.scheme = lv.scheme AND ref.name = lv.name) OR
+		(lv.scheme = 'npm' AND ref.scheme = 'scip-typescript' AND ref.name = lv.name) OR
+		(lv.scheme = 'python' AND ref.scheme = 'scip-python' AND ref.name = lv.name) OR
+		(lv.scheme = 'semanticdb' AND ref.scheme = 'semanticdb' AND ref.name = 'maven/' || replace(lv.name, ':', '/'))
+	)
+JOIN lsif_uploads u ON u.id = ref.dump_id
+JOIN repo ON repo.id = u.repository_id
+WHERE
+	u.state = 'completed' AND
+	repo.deleted_at IS NULL AND
+	repo.blocked IS NULL AND
+	%s -- authz conds
+ORDER BY repo.name, lv.scheme, lv.name, lv.version
+`
+
+// PackageDependents returns the repository commits with a completed precise index
+// referencing the package with the given scheme and name at a version in the given
+// range (see versions.MatchesConstraints). An empty range matches every version.
+// Referenced versions that can't be parsed for the scheme only match an empty range.
+// Only repositories visible to the current actor are returned.
+func (s *store) PackageDependents(ctx context.Context, scheme string, name reposource.PackageName, versionRange string) (dependents []shared.PackageDependent, err error) {
+	ctx, _, endObservation := s.operations.packageDependents.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.String("scheme", scheme),
+		attribute.String("name", string(name)),
+		attribute.String("versionRange", versionRange),
+	}})
+	defer func() {
+		endObservation(1, observation.Args{Attrs: []attribute.KeyValue{
+			attribute.Int("numDependents", len(dependents)),
+		}})
+	}()
+
+	authzConds, err := database.AuthzQueryConds(ctx, database.NewDBWith(s.logger, s.db))
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := basestore.NewSliceScanner(func(rows dbutil.Scanner) (dependent shared.PackageDependent, err error) {
+		err = rows.Scan(
+			&dependent.RepositoryID,
+			&dependent.RepositoryName,
+			&dependent.Commit,
+			&dependent.Version,
+		)
+		return
+	})(s.query(ctx, s.db, sqlf.Sprintf(packageDependentsQuery, scheme, name, scheme, name, packageRepoVisibilityCond(ctx), authzConds)))
+	if err != nil || versionRange == "" {
+		return candidates, err
+	}
+
+	// Version ranges are ecosystem-specific, so the referenced versions are matched
+	// here rather than in the database.
+	for _, candidate := range candidates {
+		if _, err := versions.Compare(scheme, candidate.Version, candidate.Version); err != nil {
+			continue
+		}
+		ok, err := versions.MatchesConstraints(scheme, candidate.Version, []string{versionRange})
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid version range")
+		}
+		if ok {
+			dependents = append(dependents, candidate)
+		}
+	}
+	return dependents, nil
+}
+
+// References are mapped to package repos the same way as in
+// listPackageLicenseDependentsQuery.
+const packageDependentsQuery = `
+WITH pkg AS (
+	SELECT %s::text AS scheme, %s::text AS name
+	-- Dependents of private package repos disclose the package repo.
+	WHERE NOT EXISTS (
+		SELECT 1
+		FROM lsif_dependency_repos lr
+		WHERE lr.scheme = %s::text AND lr.name = %s::text AND NOT %s
+	)
+)
+SELECT DISTINCT
+	repo.id,
+	repo.name,
+	u.commit,
+	ref.version
+FROM pkg
+JOIN lsif_references ref ON
+	(ref.scheme = pkg.scheme AND ref.name = pkg.name) OR
+	(pkg.scheme = 'npm' AND ref.scheme = 'scip-typescript' AND ref.name = pkg.name) OR
+	(pkg.scheme = 'python' AND ref.scheme = 'scip-python' AND ref.name = pkg.name) OR
+	(pkg.scheme = 'semanticdb' AND ref.scheme = 'semanticdb' AND ref.name = 'maven/' || replace(pkg.name, ':', '/'))
+JOIN lsif_uploads u ON u.id = ref.dump_id
+JOIN repo ON repo.id = u.repository_id
+WHERE
+	u.state = 'completed' AND
+	repo.deleted_at IS NULL AND
+	repo.blocked IS NULL AND
+	%s -- authz conds
+ORDER BY repo.name, u.commit, ref.version
+`
@@ -2,16 +2,24 @@ package store
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/keegancsmith/sqlf"
 	"github.com/sourcegraph/log/logtest"
 
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/authz"
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/dependencies/shared"
 	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
 	"github.com/sourcegraph/sourcegraph/internal/observation"
 	"github.com/sourcegraph/sourcegraph/internal/timeutil"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
 func TestInsertDependencyRepo(t *testing.T) {
@@ -185,6 +193,81 @@ func TestListPackageRepoRefs(t *testing.T) {
 	}
 }
 
+func TestScanPackageRepoRefs(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	pkgs := []shared.MinimalPackageRepoRef{
+		{Scheme: "npm", Name: "bar", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.0.0"}, {Version: "2.0.1"}}},
+		{Scheme: "npm", Name: "foo", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.0"}}},
+		{Scheme: "npm", Name: "banana", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.0.0"}}},
+		{Scheme: "somethingelse", Name: "banana", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "0.1.2"}}},
+		// should not be scanned due to no versions
+		{Scheme: "npm", Name: "burger", Versions: []shared.MinimalPackageRepoRefVersion{}},
+	}
+
+	if _, _, err := store.InsertPackageRepoRefs(ctx, pkgs); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := ListDependencyReposOpts{Scheme: "npm"}
+
+	want, _, _, err := store.ListPackageRepoRefs(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var scanned []shared.PackageRepoReference
+	if err := store.ScanPackageRepoRefs(ctx, opts, func(ref shared.PackageRepoReference) error {
+		scanned = append(scanned, ref)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(scanned) != 3 {
+		t.Errorf("unexpected number of scanned package repos: want=%d got=%d", 3, len(scanned))
+	}
+	if diff := cmp.Diff(want, scanned); diff != "" {
+		t.Errorf("mismatch (-want, +got): %s", diff)
+	}
+
+	// Scanning page by page yields the same results
+	batchSize := scanPackageRepoRefsBatchSize
+	scanPackageRepoRefsBatchSize = 2
+	t.Cleanup(func() { scanPackageRepoRefsBatchSize = batchSize })
+
+	scanned = nil
+	if err := store.ScanPackageRepoRefs(ctx, opts, func(ref shared.PackageRepoReference) error {
+		scanned = append(scanned, ref)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, scanned); diff != "" {
+		t.Errorf("mismatch with multiple pages (-want, +got): %s", diff)
+	}
+
+	// Errors returned by the callback stop the scan
+	errStop := errors.New("stop")
+	var numCalls int
+	if err := store.ScanPackageRepoRefs(ctx, opts, func(ref shared.PackageRepoReference) error {
+		numCalls++
+		return errStop
+	}); !errors.Is(err, errStop) {
+		t.Fatalf("unexpected error: want=%v got=%v", errStop, err)
+	}
+	if numCalls != 1 {
+		t.Errorf("unexpected number of callback invocations: want=%d got=%d", 1, numCalls)
+	}
+}
+
 func TestListPackageRepoRefsFuzzy(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
@@ -278,6 +361,24 @@ func TestListPackageRepoRefsFuzzy(t *testing.T) {
 				},
 			},
 		},
+		{
+			opts: ListDependencyReposOpts{
+				Name:      "APLESAUCE",
+				Fuzziness: FuzzinessSimilarity,
+			},
+			results: []shared.PackageRepoReference{
+				{
+					ID:     1,
+					Scheme: "npm",
+					Name:   "applesauce",
+					Versions: []shared.PackageRepoRefVersion{{
+						ID:           1,
+						PackageRefID: 1,
+						Version:      "1.2.3",
+					}},
+				},
+			},
+		},
 		{
 			opts: ListDependencyReposOpts{
 				Name: "turtle",
@@ -309,7 +410,7 @@ func TestListPackageRepoRefsFuzzy(t *testing.T) {
 	}
 }
 
-func TestDeletePackageRepoRefsByID(t *testing.T) {
+func TestListPackageRepoRefsSimilarityUsesIndex(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
 	}
@@ -319,52 +420,955 @@ func TestDeletePackageRepoRefsByID(t *testing.T) {
 	db := database.NewDB(logger, dbtest.NewDB(t))
 	store := New(&observation.TestContext, db)
 
-	repos := []shared.MinimalPackageRepoRef{
-		// Test same-set flushes
-		{Scheme: "npm", Name: "bar", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.0.0"}}},
-		{Scheme: "npm", Name: "bar", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "3.0.0"}}}, // version deleted
-		{Scheme: "npm", Name: "foo", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.0"}}}, // version deleted
-		{Scheme: "npm", Name: "foo", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.0.0"}}},
-		{Scheme: "npm", Name: "banan", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "4.2.0"}}}, // package deleted
+	query := makeListDependencyReposQuery(ctx, ListDependencyReposOpts{
+		Name:      "recat-dom",
+		Fuzziness: FuzzinessSimilarity,
+		Limit:     10,
+	})
+
+	var plan []string
+	if err := store.db.WithTransact(ctx, func(tx *basestore.Store) error {
+		// The test table is too small for the planner to prefer any index over
+		// a sequential scan on its own.
+		if err := tx.Exec(ctx, sqlf.Sprintf("SET LOCAL enable_seqscan = off")); err != nil {
+			return err
+		}
+
+		var err error
+		plan, err = basestore.ScanStrings(tx.Query(ctx, sqlf.Sprintf("EXPLAIN %s", query)))
+		return err
+	}); err != nil {
+		t.Fatal(err)
 	}
 
-	newDeps, newVersions, err := store.InsertPackageRepoRefs(ctx, repos)
+	if !strings.Contains(strings.Join(plan, "\n"), "lsif_dependency_repos_name_gin") {
+		t.Errorf("expected plan to use lsif_dependency_repos_name_gin:\n%s", strings.Join(plan, "\n"))
+	}
+}
+
+func TestInsertPackageRepoRefsEnqueuesSync(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO external_services (id, kind, display_name, config) VALUES
+			(1, 'NPMPACKAGES', 'npm', '{}'),
+			(2, 'PYTHONPACKAGES', 'python', '{}');
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	queuedServices := func() []int {
+		t.Helper()
+		ids, err := basestore.ScanInts(db.QueryContext(ctx, `SELECT external_service_id FROM external_service_sync_jobs WHERE state = 'queued' ORDER BY external_service_id`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ids
+	}
+
+	// Blocked package repos are never synced.
+	if _, _, err := store.InsertPackageRepoRefs(ctx, []shared.MinimalPackageRepoRef{
+		{Scheme: "python", Name: "requests", Blocked: true, Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.31.0"}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]int(nil), queuedServices()); diff != "" {
+		t.Errorf("unexpected queued external service syncs (-want +got):\n%s", diff)
+	}
+
+	if _, _, err := store.InsertPackageRepoRefs(ctx, []shared.MinimalPackageRepoRef{
+		{Scheme: "npm", Name: "left-pad", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.0"}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]int{1}, queuedServices()); diff != "" {
+		t.Errorf("unexpected queued external service syncs (-want +got):\n%s", diff)
+	}
+
+	// An already queued sync isn't enqueued twice.
+	if _, _, err := store.InsertPackageRepoRefs(ctx, []shared.MinimalPackageRepoRef{
+		{Scheme: "npm", Name: "is-odd", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "3.0.1"}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]int{1}, queuedServices()); diff != "" {
+		t.Errorf("unexpected queued external service syncs (-want +got):\n%s", diff)
+	}
+}
+
+func TestInsertPackageRepoRefsNormalizesVersions(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	_, newVersions, err := store.InsertPackageRepoRefs(ctx, []shared.MinimalPackageRepoRef{
+		{Scheme: "npm", Name: "left-pad", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "v1.0.0"}, {Version: "1.0.0"}, {Version: "2.0.0"}}},
+		{Scheme: "python", Name: "requests", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.31.0-RC1"}}},
+		{Scheme: "somethingelse", Name: "banana", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "v0.1.2"}}},
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if len(newDeps) != 3 {
-		t.Fatalf("unexpected number of inserted package repos: (want=%d,got=%d)", 3, len(newDeps))
+	want := []shared.PackageRepoRefVersion{
+		{ID: 1, PackageRefID: 1, Version: "v0.1.2"},
+		{ID: 2, PackageRefID: 2, Version: "1.0.0", OriginalVersion: "v1.0.0"},
+		{ID: 3, PackageRefID: 2, Version: "2.0.0"},
+		{ID: 4, PackageRefID: 3, Version: "2.31.0rc1", OriginalVersion: "2.31.0-RC1"},
+	}
+	if diff := cmp.Diff(want, newVersions); diff != "" {
+		t.Errorf("unexpected versions (-want +got):\n%s", diff)
 	}
 
-	if len(newVersions) != 5 {
-		t.Fatalf("unexpected number of inserted package repo versions: (want=%d,got=%d)", 5, len(newVersions))
+	// A different spelling of an existing version is not inserted again.
+	_, newVersions, err = store.InsertPackageRepoRefs(ctx, []shared.MinimalPackageRepoRef{
+		{Scheme: "npm", Name: "left-pad", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "=2.0.0"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newVersions) != 0 {
+		t.Errorf("unexpected new versions: %v", newVersions)
 	}
 
-	if err := store.DeletePackageRepoRefsByID(ctx, 1); err != nil {
+	resolved, found, err := store.ResolvePackageRepoRefVersion(ctx, ResolvePackageRepoRefVersionOpts{Scheme: "npm", Name: "left-pad", Version: "v2.0.0"})
+	if err != nil {
 		t.Fatal(err)
 	}
+	if !found || resolved.Version != "2.0.0" || resolved.Approximate {
+		t.Errorf("unexpected resolved version: found=%v version=%+v", found, resolved)
+	}
+}
 
-	if err := store.DeletePackageRepoRefVersionsByID(ctx, 3, 4); err != nil {
+func TestInsertPackageRepoRefsSkipsInvalidNames(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	newDeps, _, err := store.InsertPackageRepoRefs(ctx, []shared.MinimalPackageRepoRef{
+		{Scheme: shared.JVMPackagesScheme, Name: "not a coordinate", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.0"}}},
+		{Scheme: shared.NpmPackagesScheme, Name: "left-pad", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.0"}}},
+	})
+	if err != nil {
 		t.Fatal(err)
 	}
+	if len(newDeps) != 1 || newDeps[0].Name != "left-pad" {
+		t.Errorf("unexpected new dependencies: %+v", newDeps)
+	}
 
-	have, _, hasMore, err := store.ListPackageRepoRefs(ctx, ListDependencyReposOpts{
-		Scheme: shared.NpmPackagesScheme,
+	// Nothing is inserted if no name is valid.
+	newDeps, newVersions, err := store.InsertPackageRepoRefs(ctx, []shared.MinimalPackageRepoRef{
+		{Scheme: shared.JVMPackagesScheme, Name: "not a coordinate", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.0"}}},
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
+	if len(newDeps) != 0 || len(newVersions) != 0 {
+		t.Errorf("unexpected inserts: deps=%+v versions=%+v", newDeps, newVersions)
+	}
 
-	if hasMore {
-		t.Error("unexpected more-pages flag set, expected no more pages to follow")
+	_, total, _, err := store.ListPackageRepoRefs(ctx, ListDependencyReposOpts{Scheme: shared.JVMPackagesScheme})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 {
+		t.Errorf("unexpected number of %s package repo refs: %d", shared.JVMPackagesScheme, total)
+	}
+}
+
+func TestInsertPackageRepoRefsHonorsFilters(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	block := "BLOCK"
+	for _, filter := range []shared.MinimalPackageFilter{
+		{PackageScheme: "npm", Behaviour: &block, NameFilter: &struct{ PackageGlob string }{"@internal/*"}},
+		{PackageScheme: "npm", Behaviour: &block, VersionFilter: &struct {
+			PackageName string
+			VersionGlob string
+		}{"left-pad", ">=1.0.0, <2.0.0"}},
+	} {
+		if _, err := store.CreatePackageRepoFilter(ctx, filter); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, err := store.InsertPackageRepoRefs(ctx, []shared.MinimalPackageRepoRef{
+		{Scheme: "npm", Name: "@internal/secret", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.0"}}},
+		{Scheme: "npm", Name: "left-pad", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.5.0"}, {Version: "2.0.0"}}},
+	}); err != nil {
+		t.Fatal(err)
 	}
 
+	have, _, _, err := store.ListPackageRepoRefs(ctx, ListDependencyReposOpts{Scheme: "npm"})
+	if err != nil {
+		t.Fatal(err)
+	}
 	want := []shared.PackageRepoReference{
-		{ID: 2, Scheme: "npm", Name: "bar", Versions: []shared.PackageRepoRefVersion{{ID: 2, PackageRefID: 2, Version: "2.0.0"}}},
-		{ID: 3, Scheme: "npm", Name: "foo", Versions: []shared.PackageRepoRefVersion{{ID: 5, PackageRefID: 3, Version: "2.0.0"}}},
+		{ID: 2, Scheme: "npm", Name: "left-pad", Versions: []shared.PackageRepoRefVersion{{ID: 3, PackageRefID: 2, Version: "2.0.0"}}},
 	}
 	if diff := cmp.Diff(want, have); diff != "" {
+		t.Errorf("unexpected package repos (-want +got):\n%s", diff)
+	}
+}
+
+func TestInsertPackageRepoRefsMetadata(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	published := timeutil.Now()
+	republished := published.Add(time.Hour)
+
+	list := func() shared.PackageRepoReference {
+		t.Helper()
+		pkgs, _, _, err := store.ListPackageRepoRefs(ctx, ListDependencyReposOpts{Scheme: "npm", Name: "bar", Fuzziness: FuzzinessExactMatch})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pkgs) != 1 {
+			t.Fatalf("unexpected number of package repos: want=%d got=%d", 1, len(pkgs))
+		}
+		return pkgs[0]
+	}
+
+	for _, test := range []struct {
+		name        string
+		pkg         shared.MinimalPackageRepoRef
+		wantNew     bool
+		publishedAt *time.Time
+		description string
+		license     string
+	}{
+		{
+			name: "insert",
+			pkg: shared.MinimalPackageRepoRef{
+				Scheme: "npm", Name: "bar", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.0"}},
+				PublishedAt: &published, Description: "bars", License: "MIT",
+			},
+			wantNew:     true,
+			publishedAt: &published,
+			description: "bars",
+			license:     "MIT",
+		},
+		{
+			name: "refresh",
+			pkg: shared.MinimalPackageRepoRef{
+				Scheme: "npm", Name: "bar", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.1"}},
+				PublishedAt: &republished, Description: "more bars", License: "Apache-2.0",
+			},
+			publishedAt: &republished,
+			description: "more bars",
+			license:     "Apache-2.0",
+		},
+		{
+			name: "missing metadata is kept",
+			pkg: shared.MinimalPackageRepoRef{
+				Scheme: "npm", Name: "bar", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.2"}},
+			},
+			publishedAt: &republished,
+			description: "more bars",
+			license:     "Apache-2.0",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			newPkgs, _, err := store.InsertPackageRepoRefs(ctx, []shared.MinimalPackageRepoRef{test.pkg})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.wantNew != (len(newPkgs) == 1) {
+				t.Errorf("unexpected new package repos: %v", newPkgs)
+			}
+
+			pkg := list()
+			if diff := cmp.Diff(test.publishedAt, pkg.PublishedAt); diff != "" {
+				t.Errorf("unexpected published at (-want +got):\n%s", diff)
+			}
+			if pkg.Description != test.description {
+				t.Errorf("unexpected description: want=%q got=%q", test.description, pkg.Description)
+			}
+			if pkg.License != test.license {
+				t.Errorf("unexpected license: want=%q got=%q", test.license, pkg.License)
+			}
+		})
+	}
+}
+
+func TestPackageRepoRefLicenses(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	if _, _, err := store.InsertPackageRepoRefs(ctx, []shared.MinimalPackageRepoRef{
+		{Scheme: "npm", Name: "left-pad", License: "MIT", Versions: []shared.MinimalPackageRepoRefVersion{
+			{Version: "1.0.0"},
+			{Version: "2.0.0", License: "GPL-3.0"},
+		}},
+		{Scheme: "semanticdb", Name: "com.example:lib", Versions: []shared.MinimalPackageRepoRefVersion{
+			{Version: "1.0", License: "gpl-3.0"},
+		}},
+		{Scheme: "python", Name: "requests", License: "Apache-2.0", Versions: []shared.MinimalPackageRepoRefVersion{
+			{Version: "2.31.0"},
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A license learned later is recorded for existing versions, but an unknown one
+	// does not overwrite it.
+	if _, _, err := store.InsertPackageRepoRefs(ctx, []shared.MinimalPackageRepoRef{
+		{Scheme: "python", Name: "requests", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.31.0", License: "GPL-3.0"}}},
+		{Scheme: "npm", Name: "left-pad", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.0.0"}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, _, _, err := store.ListPackageRepoRefs(ctx, ListDependencyReposOpts{License: "gpl-3.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotVersions []string
+	for _, pkg := range pkgs {
+		for _, version := range pkg.Versions {
+			gotVersions = append(gotVersions, string(pkg.Name)+"@"+version.Version+" "+version.License)
+		}
+	}
+	wantVersions := []string{"com.example:lib@1.0 gpl-3.0", "left-pad@2.0.0 GPL-3.0", "requests@2.31.0 GPL-3.0"}
+	if diff := cmp.Diff(wantVersions, gotVersions); diff != "" {
+		t.Errorf("unexpected versions (-want +got):\n%s", diff)
+	}
+
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO repo (id, name) VALUES (50, 'github.com/foo/js'), (51, 'github.com/foo/java'), (52, 'github.com/foo/py');
+		INSERT INTO lsif_uploads (id, repository_id, commit, indexer, num_parts, uploaded_parts, state) VALUES
+			(100, 50, '0000000000000000000000000000000000000001', 'scip-typescript', 1, '{}', 'completed'),
+			(101, 51, '0000000000000000000000000000000000000002', 'scip-java', 1, '{}', 'completed'),
+			(102, 52, '0000000000000000000000000000000000000003', 'scip-python', 1, '{}', 'errored');
+		INSERT INTO lsif_references (dump_id, scheme, manager, name, version) VALUES
+			(100, 'scip-typescript', 'npm', 'left-pad', '1.0.0'),
+			(100, 'scip-typescript', 'npm', 'left-pad', '2.0.0'),
+			(101, 'semanticdb', 'maven', 'maven/com.example/lib', '1.0'),
+			(102, 'scip-python', 'python', 'requests', '2.31.0');
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	dependents, err := store.ListPackageLicenseDependents(ctx, "GPL-3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []shared.PackageLicenseDependent{
+		{RepositoryID: 51, RepositoryName: "github.com/foo/java", Scheme: "semanticdb", Name: "com.example:lib", Version: "1.0", License: "gpl-3.0"},
+		{RepositoryID: 50, RepositoryName: "github.com/foo/js", Scheme: "npm", Name: "left-pad", Version: "2.0.0", License: "GPL-3.0"},
+	}
+	if diff := cmp.Diff(expected, dependents); diff != "" {
+		t.Errorf("unexpected dependents (-want +got):\n%s", diff)
+	}
+	// Dependents in private repos are only returned to actors who can see them.
+	if _, err := db.ExecContext(ctx, `UPDATE repo SET private = true WHERE id = 51`); err != nil {
+		t.Fatal(err)
+	}
+	authz.SetProviders(false, nil)
+	t.Cleanup(func() { authz.SetProviders(true, nil) })
+
+	dependents, err = store.ListPackageLicenseDependents(ctx, "GPL-3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expected[1:], dependents); diff != "" {
+		t.Errorf("unexpected dependents (-want +got):\n%s", diff)
+	}
+	dependents, err = store.ListPackageLicenseDependents(actor.WithInternalActor(ctx), "GPL-3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expected, dependents); diff != "" {
+		t.Errorf("unexpected dependents (-want +got):\n%s", diff)
+	}
+}
+
+func TestPackageDependents(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO repo (id, name) VALUES (50, 'github.com/foo/a'), (51, 'github.com/foo/b'), (52, 'github.com/foo/c');
+		INSERT INTO lsif_uploads (id, repository_id, commit, indexer, num_parts, uploaded_parts, state) VALUES
+			(100, 50, '0000000000000000000000000000000000000001', 'scip-typescript', 1, '{}', 'completed'),
+			(101, 50, '0000000000000000000000000000000000000002', 'scip-typescript', 1, '{}', 'completed'),
+			(102, 51, '0000000000000000000000000000000000000003', 'lsif-node', 1, '{}', 'completed'),
+			(103, 52, '0000000000000000000000000000000000000004', 'scip-typescript', 1, '{}', 'errored');
+		INSERT INTO lsif_references (dump_id, scheme, manager, name, version) VALUES
+			(100, 'scip-typescript', 'npm', 'left-pad', '1.0.0'),
+			(101, 'scip-typescript', 'npm', 'left-pad', '1.3.0'),
+			(101, 'scip-typescript', 'npm', 'right-pad', '1.3.0'),
+			(102, 'npm', 'npm', 'left-pad', '2.0.0'),
+			(102, 'npm', 'npm', 'left-pad', 'latest'),
+			(103, 'scip-typescript', 'npm', 'left-pad', '1.0.0');
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	dependents, err := store.PackageDependents(ctx, "npm", "left-pad", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []shared.PackageDependent{
+		{RepositoryID: 50, RepositoryName: "github.com/foo/a", Commit: "0000000000000000000000000000000000000001", Version: "1.0.0"},
+		{RepositoryID: 50, RepositoryName: "github.com/foo/a", Commit: "0000000000000000000000000000000000000002", Version: "1.3.0"},
+		{RepositoryID: 51, RepositoryName: "github.com/foo/b", Commit: "0000000000000000000000000000000000000003", Version: "2.0.0"},
+		{RepositoryID: 51, RepositoryName: "github.com/foo/b", Commit: "0000000000000000000000000000000000000003", Version: "latest"},
+	}
+	if diff := cmp.Diff(expected, dependents); diff != "" {
+		t.Errorf("unexpected dependents (-want +got):\n%s", diff)
+	}
+
+	dependents, err = store.PackageDependents(ctx, "npm", "left-pad", ">= 1.2.0, < 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []shared.PackageDependent{
+		{RepositoryID: 50, RepositoryName: "github.com/foo/a", Commit: "0000000000000000000000000000000000000002", Version: "1.3.0"},
+		{RepositoryID: 51, RepositoryName: "github.com/foo/b", Commit: "0000000000000000000000000000000000000003", Version: "2.0.0"},
+	}
+	if diff := cmp.Diff(expected, dependents); diff != "" {
+		t.Errorf("unexpected dependents (-want +got):\n%s", diff)
+	}
+
+	if _, err := store.PackageDependents(ctx, "npm", "left-pad", ">= nope"); err == nil {
+		t.Error("expected an error for an invalid version range")
+	}
+
+	// Dependents in private repos are only returned to actors who can see them.
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO repo (id, name, private) VALUES (53, 'github.com/foo/private', true);
+		INSERT INTO lsif_uploads (id, repository_id, commit, indexer, num_parts, uploaded_parts, state) VALUES
+			(104, 53, '0000000000000000000000000000000000000005', 'scip-typescript', 1, '{}', 'completed');
+		INSERT INTO lsif_references (dump_id, scheme, manager, name, version) VALUES
+			(104, 'scip-typescript', 'npm', 'left-pad', '2.5.0');
+	`); err != nil {
+		t.Fatal(err)
+	}
+	authz.SetProviders(false, nil)
+	t.Cleanup(func() { authz.SetProviders(true, nil) })
+
+	dependentRepos := func(ctx context.Context) []string {
+		t.Helper()
+		dependents, err := store.PackageDependents(ctx, "npm", "left-pad", ">= 2.5.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, dependent := range dependents {
+			names = append(names, dependent.RepositoryName)
+		}
+		return names
+	}
+	if diff := cmp.Diff([]string(nil), dependentRepos(ctx)); diff != "" {
+		t.Errorf("unexpected dependent repos (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"github.com/foo/private"}, dependentRepos(actor.WithInternalActor(ctx))); diff != "" {
+		t.Errorf("unexpected dependent repos (-want +got):\n%s", diff)
+	}
+}
+
+func TestDeletePackageRepoRefsByID(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	repos := []shared.MinimalPackageRepoRef{
+		// Test same-set flushes
+		{Scheme: "npm", Name: "bar", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.0.0"}}},
+		{Scheme: "npm", Name: "bar", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "3.0.0"}}}, // version deleted
+		{Scheme: "npm", Name: "foo", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.0"}}}, // version deleted
+		{Scheme: "npm", Name: "foo", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.0.0"}}},
+		{Scheme: "npm", Name: "banan", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "4.2.0"}}}, // package deleted
+	}
+
+	newDeps, newVersions, err := store.InsertPackageRepoRefs(ctx, repos)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(newDeps) != 3 {
+		t.Fatalf("unexpected number of inserted package repos: (want=%d,got=%d)", 3, len(newDeps))
+	}
+
+	if len(newVersions) != 5 {
+		t.Fatalf("unexpected number of inserted package repo versions: (want=%d,got=%d)", 5, len(newVersions))
+	}
+
+	if err := store.DeletePackageRepoRefsByID(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeletePackageRepoRefVersionsByID(ctx, 3, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	have, _, hasMore, err := store.ListPackageRepoRefs(ctx, ListDependencyReposOpts{
+		Scheme: shared.NpmPackagesScheme,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hasMore {
+		t.Error("unexpected more-pages flag set, expected no more pages to follow")
+	}
+
+	want := []shared.PackageRepoReference{
+		{ID: 2, Scheme: "npm", Name: "bar", Versions: []shared.PackageRepoRefVersion{{ID: 2, PackageRefID: 2, Version: "2.0.0"}}},
+		{ID: 3, Scheme: "npm", Name: "foo", Versions: []shared.PackageRepoRefVersion{{ID: 5, PackageRefID: 3, Version: "2.0.0"}}},
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("mismatch (-want, +got): %s", diff)
+	}
+}
+
+func TestDeletePackageRepoRefs(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	refVersions := func(vs ...string) []shared.MinimalPackageRepoRefVersion {
+		refs := make([]shared.MinimalPackageRepoRefVersion, 0, len(vs))
+		for _, v := range vs {
+			refs = append(refs, shared.MinimalPackageRepoRefVersion{Version: v})
+		}
+		return refs
+	}
+	if _, _, err := store.InsertPackageRepoRefs(ctx, []shared.MinimalPackageRepoRef{
+		{Scheme: "npm", Name: "@acme/a", Versions: refVersions("1.0.0", "1.5.0", "2.0.0")},
+		{Scheme: "npm", Name: "@acme/b", Versions: refVersions("1.2.0")},
+		{Scheme: "npm", Name: "@acme_c", Versions: refVersions("1.0.0")},
+		{Scheme: "npm", Name: "acme", Versions: refVersions("1.0.0", "not-a-version")},
+		{Scheme: "python", Name: "@acme/a", Versions: refVersions("1.0.0")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	list := func() []string {
+		t.Helper()
+		pkgs, _, _, err := store.ListPackageRepoRefs(ctx, ListDependencyReposOpts{IncludeBlocked: true, IncludeArchived: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, pkg := range pkgs {
+			for _, version := range pkg.Versions {
+				names = append(names, pkg.Scheme+":"+string(pkg.Name)+"@"+version.Version)
+			}
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	if _, _, err := store.DeletePackageRepoRefs(ctx, DeletePackageRepoRefsOpts{NameGlob: "*"}); err == nil {
+		t.Fatal("expected error deleting without a scheme")
+	}
+	if _, _, err := store.DeletePackageRepoRefs(ctx, DeletePackageRepoRefsOpts{Scheme: "npm", VersionConstraints: []string{">= not-a-version"}}); err == nil {
+		t.Fatal("expected error deleting with invalid version constraints")
+	}
+
+	for _, test := range []struct {
+		name            string
+		opts            DeletePackageRepoRefsOpts
+		deletedPackages int
+		deletedVersions int
+		remaining       []string
+	}{
+		{
+			name:            "version range",
+			opts:            DeletePackageRepoRefsOpts{Scheme: "npm", NameGlob: "@acme/*", VersionConstraints: []string{"< 2.0.0"}},
+			deletedPackages: 1,
+			deletedVersions: 3,
+			remaining:       []string{"npm:@acme/a@2.0.0", "npm:@acme_c@1.0.0", "npm:acme@1.0.0", "npm:acme@not-a-version", "python:@acme/a@1.0.0"},
+		},
+		{
+			name:            "unparseable versions are kept",
+			opts:            DeletePackageRepoRefsOpts{Scheme: "npm", NameGlob: "acme", VersionConstraints: []string{"*"}},
+			deletedPackages: 0,
+			deletedVersions: 1,
+			remaining:       []string{"npm:@acme/a@2.0.0", "npm:@acme_c@1.0.0", "npm:acme@not-a-version", "python:@acme/a@1.0.0"},
+		},
+		{
+			name:            "name glob",
+			opts:            DeletePackageRepoRefsOpts{Scheme: "npm", NameGlob: "@acme?*"},
+			deletedPackages: 2,
+			deletedVersions: 2,
+			remaining:       []string{"npm:acme@not-a-version", "python:@acme/a@1.0.0"},
+		},
+		{
+			name:            "scheme",
+			opts:            DeletePackageRepoRefsOpts{Scheme: "python"},
+			deletedPackages: 1,
+			deletedVersions: 1,
+			remaining:       []string{"npm:acme@not-a-version"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			deletedPackages, deletedVersions, err := store.DeletePackageRepoRefs(ctx, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if deletedPackages != test.deletedPackages || deletedVersions != test.deletedVersions {
+				t.Errorf("unexpected number of deleted packages and versions: want=(%d, %d) got=(%d, %d)", test.deletedPackages, test.deletedVersions, deletedPackages, deletedVersions)
+			}
+			if diff := cmp.Diff(test.remaining, list()); diff != "" {
+				t.Errorf("unexpected remaining versions (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResolvePackageRepoRefVersion(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	if _, _, err := store.InsertPackageRepoRefs(ctx, []shared.MinimalPackageRepoRef{
+		{Scheme: "npm", Name: "left-pad", Versions: []shared.MinimalPackageRepoRefVersion{
+			{Version: "1.0.0"},
+			{Version: "1.2.0"},
+			{Version: "1.3.0", Blocked: true},
+			{Version: "2.0.0"},
+			{Version: "not-a-version"},
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name        string
+		version     string
+		nearest     bool
+		want        string
+		found       bool
+		approximate bool
+	}{
+		{name: "exact", version: "1.2.0", want: "1.2.0", found: true},
+		{name: "exact unparseable", version: "not-a-version", want: "not-a-version", found: true},
+		{name: "missing without fallback", version: "1.4.0"},
+		{name: "blocked without fallback", version: "1.3.0"},
+		{name: "nearest below", version: "1.4.0", nearest: true, want: "1.2.0", found: true, approximate: true},
+		{name: "nearest below skips blocked", version: "1.3.5", nearest: true, want: "1.2.0", found: true, approximate: true},
+		{name: "nearest above", version: "0.9.0", nearest: true, want: "1.0.0", found: true, approximate: true},
+		{name: "nearest unparseable", version: "latest", nearest: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			resolved, found, err := store.ResolvePackageRepoRefVersion(ctx, ResolvePackageRepoRefVersionOpts{
+				Scheme:       "npm",
+				Name:         "left-pad",
+				Version:      test.version,
+				AllowNearest: test.nearest,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found != test.found || resolved.Version != test.want || resolved.Approximate != test.approximate {
+				t.Errorf("unexpected resolution: want=(%q, %v, %v) got=(%q, %v, %v)", test.want, test.found, test.approximate, resolved.Version, found, resolved.Approximate)
+			}
+		})
+	}
+
+	// Resolving doesn't add the requested versions.
+	pkgs, _, _, err := store.ListPackageRepoRefs(ctx, ListDependencyReposOpts{Scheme: "npm", Name: "left-pad", IncludeBlocked: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, pkg := range pkgs {
+		for _, version := range pkg.Versions {
+			got = append(got, version.Version)
+		}
+	}
+	sort.Strings(got)
+	want := []string{"1.0.0", "1.2.0", "1.3.0", "2.0.0", "not-a-version"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected versions (-want +got):\n%s", diff)
+	}
+}
+
+func TestCountPackageRepoRefsByScheme(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	repos := []shared.MinimalPackageRepoRef{
+		{Scheme: "npm", Name: "bar", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.0.0"}, {Version: "3.0.0"}}},
+		{Scheme: "npm", Name: "foo", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.0"}}},
+		{Scheme: "python", Name: "requests", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.31.0"}}},
+		{Scheme: "python", Name: "numpy"},
+	}
+
+	if _, _, err := store.InsertPackageRepoRefs(ctx, repos); err != nil {
+		t.Fatal(err)
+	}
+
+	have, err := store.CountPackageRepoRefsByScheme(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []shared.PackageRepoSchemeCount{
+		{Scheme: "npm", Packages: 2, Versions: 3},
+		{Scheme: "python", Packages: 2, Versions: 1},
+	}
+	if diff := cmp.Diff(want, have); diff != "" {
+		t.Fatalf("mismatch (-want, +got): %s", diff)
+	}
+}
+
+func TestArchivePackageRepoRefsByID(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	repos := []shared.MinimalPackageRepoRef{
+		{Scheme: "npm", Name: "bar", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.0.0"}}},
+		{Scheme: "npm", Name: "foo", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.0"}}},
+	}
+
+	if _, _, err := store.InsertPackageRepoRefs(ctx, repos); err != nil {
+		t.Fatal(err)
+	}
+
+	listNames := func(includeArchived bool) (names []string) {
+		t.Helper()
+
+		have, _, _, err := store.ListPackageRepoRefs(ctx, ListDependencyReposOpts{
+			Scheme:          shared.NpmPackagesScheme,
+			IncludeArchived: includeArchived,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ref := range have {
+			if includeArchived && ref.Name == "bar" && ref.ArchivedAt == nil {
+				t.Error("expected archived package repo to have archived_at set")
+			}
+			names = append(names, string(ref.Name))
+		}
+		return names
+	}
+
+	if err := store.ArchivePackageRepoRefsByID(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"foo"}, listNames(false)); diff != "" {
+		t.Errorf("unexpected package repos excluding archived (-want, +got): %s", diff)
+	}
+	if diff := cmp.Diff([]string{"bar", "foo"}, listNames(true)); diff != "" {
+		t.Errorf("unexpected package repos including archived (-want, +got): %s", diff)
+	}
+
+	if err := store.UnarchivePackageRepoRefsByID(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"bar", "foo"}, listNames(false)); diff != "" {
+		t.Errorf("unexpected package repos after unarchiving (-want, +got): %s", diff)
+	}
+}
+
+func TestUpdatePackageRepoRefsVisibilityByID(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	repos := []shared.MinimalPackageRepoRef{
+		{Scheme: "npm", Name: "bar", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.0.0"}}},
+		{Scheme: "npm", Name: "foo", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.0"}}},
+	}
+
+	if _, _, err := store.InsertPackageRepoRefs(ctx, repos); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO users (id, username, site_admin) VALUES (1, 'admin', true), (2, 'user', false)
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.UpdatePackageRepoRefsVisibilityByID(ctx, true, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	listNames := func(ctx context.Context) (names []string) {
+		t.Helper()
+
+		have, total, _, err := store.ListPackageRepoRefs(ctx, ListDependencyReposOpts{
+			Scheme: shared.NpmPackagesScheme,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != len(have) {
+			t.Errorf("unexpected total count: want=%d have=%d", len(have), total)
+		}
+		for _, ref := range have {
+			if ref.Name == "bar" && !ref.Private {
+				t.Error("expected private package repo to be marked private")
+			}
+			names = append(names, string(ref.Name))
+		}
+		return names
+	}
+
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		want []string
+	}{
+		{"anonymous", ctx, []string{"foo"}},
+		{"user", actor.WithActor(ctx, actor.FromUser(2)), []string{"foo"}},
+		{"site admin", actor.WithActor(ctx, actor.FromUser(1)), []string{"bar", "foo"}},
+		{"internal", actor.WithInternalActor(ctx), []string{"bar", "foo"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, listNames(tc.ctx)); diff != "" {
+				t.Errorf("unexpected package repos (-want, +got): %s", diff)
+			}
+		})
+	}
+
+	t.Run("resolving versions", func(t *testing.T) {
+		opts := ResolvePackageRepoRefVersionOpts{Scheme: "npm", Name: "bar", Version: "2.0.0"}
+		if _, found, err := store.ResolvePackageRepoRefVersion(actor.WithActor(ctx, actor.FromUser(2)), opts); err != nil || found {
+			t.Errorf("expected private version to be hidden from user: found=%v err=%v", found, err)
+		}
+		if _, found, err := store.ResolvePackageRepoRefVersion(actor.WithActor(ctx, actor.FromUser(1)), opts); err != nil || !found {
+			t.Errorf("expected private version to be visible to site admin: found=%v err=%v", found, err)
+		}
+	})
+
+	if err := store.UpdatePackageRepoRefsVisibilityByID(ctx, false, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"bar", "foo"}, listNames(ctx)); diff != "" {
+		t.Errorf("unexpected package repos after making public (-want, +got): %s", diff)
+	}
+}
+
+func TestStats(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	store := New(&observation.TestContext, db)
+
+	repos := []shared.MinimalPackageRepoRef{
+		{Scheme: "npm", Name: "bar", Blocked: true, Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.0.0", Blocked: true}, {Version: "3.0.0"}}},
+		{Scheme: "npm", Name: "foo", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "1.0.0"}}},
+		{Scheme: "python", Name: "requests", Versions: []shared.MinimalPackageRepoRefVersion{{Version: "2.31.0"}}},
+	}
+
+	if _, _, err := store.InsertPackageRepoRefs(ctx, repos); err != nil {
+		t.Fatal(err)
+	}
+
+	have, _, _, err := store.ListPackageRepoRefs(ctx, ListDependencyReposOpts{Scheme: "python", Name: "requests"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ArchivePackageRepoRefsByID(ctx, have[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := store.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := shared.PackageRepoStats{
+		Schemes: []shared.PackageRepoSchemeStats{
+			{Scheme: "npm", Packages: 2, Versions: 3, BlockedPackages: 1, BlockedVersions: 1},
+			{Scheme: "python", Packages: 1, Versions: 1, ArchivedPackages: 1},
+		},
+		Packages:         3,
+		Versions:         4,
+		BlockedPackages:  1,
+		BlockedVersions:  1,
+		ArchivedPackages: 1,
+	}
+	if diff := cmp.Diff(want, stats); diff != "" {
 		t.Fatalf("mismatch (-want, +got): %s", diff)
 	}
 }
@@ -8,10 +8,16 @@ import (
 )
 
 type operations struct {
-	listPackageRepos                 *observation.Operation
-	insertPackageRepoRefs            *observation.Operation
-	deletePackageRepoRefVersionsByID *observation.Operation
-	deletePackageRepoRefsByID        *observation.Operation
+	listPackageRepos                    *observation.Operation
+	scanPackageRepoRefs                 *observation.Operation
+	insertPackageRepoRefs               *observation.Operation
+	deletePackageRepoRefVersionsByID    *observation.Operation
+	deletePackageRepoRefsByID           *observation.Operation
+	deletePackageRepoRefs               *observation.Operation
+	resolvePackageRepoRefVersion        *observation.Operation
+	archivePackageRepoRefsByID          *observation.Operation
+	unarchivePackageRepoRefsByID        *observation.Operation
+	updatePackageRepoRefsVisibilityByID *observation.Operation
 
 	listPackageRepoFilters  *observation.Operation
 	createPackageRepoFilter *observation.Operation
@@ -21,6 +27,11 @@ type operations struct {
 	isPackageRepoVersionAllowed  *observation.Operation
 	isPackageRepoAllowed         *observation.Operation
 	pkgsOrVersionsMatchingFilter *observation.Operation
+
+	stats *observation.Operation
+
+	listPackageLicenseDependents *observation.Operation
+	packageDependents            *observation.Operation
 }
 
 var m = new(metrics.SingletonREDMetrics)
@@ -44,10 +55,16 @@ func newOperations(observationCtx *observation.Context) *operations {
 	}
 
 	return &operations{
-		listPackageRepos:                 op("ListPackageRepoRefs"),
-		insertPackageRepoRefs:            op("InsertPackageRepoRefs"),
-		deletePackageRepoRefVersionsByID: op("DeletePackageRepoRefVersionsByID"),
-		deletePackageRepoRefsByID:        op("DeletePackageRepoRefsByID"),
+		listPackageRepos:                    op("ListPackageRepoRefs"),
+		scanPackageRepoRefs:                 op("ScanPackageRepoRefs"),
+		insertPackageRepoRefs:               op("InsertPackageRepoRefs"),
+		deletePackageRepoRefVersionsByID:    op("DeletePackageRepoRefVersionsByID"),
+		deletePackageRepoRefsByID:           op("DeletePackageRepoRefsByID"),
+		deletePackageRepoRefs:               op("DeletePackageRepoRefs"),
+		resolvePackageRepoRefVersion:        op("ResolvePackageRepoRefVersion"),
+		archivePackageRepoRefsByID:          op("ArchivePackageRepoRefsByID"),
+		unarchivePackageRepoRefsByID:        op("UnarchivePackageRepoRefsByID"),
+		updatePackageRepoRefsVisibilityByID: op("UpdatePackageRepoRefsVisibilityByID"),
 
 		listPackageRepoFilters:  op("ListPackageRepoFilters"),
 		createPackageRepoFilter: op("CreatePackageRepoFilter"),
@@ -57,5 +74,10 @@ func newOperations(observationCtx *observation.Context) *operations {
 		isPackageRepoVersionAllowed:  op("IsPackageRepoVersionAllowed"),
 		isPackageRepoAllowed:         op("IsPackageRepoAllowed"),
 		pkgsOrVersionsMatchingFilter: op("PkgsOrVersionsMatchingFilter"),
+
+		stats: op("Stats"),
+
+		listPackageLicenseDependents: op("ListPackageLicenseDependents"),
+		packageDependents:            op("PackageDependents"),
 	}
 }
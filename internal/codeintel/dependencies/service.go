@@ -3,12 +3,16 @@ package dependencies
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/sourcegraph/log"
 	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/dependencies/internal/store"
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/dependencies/shared"
 	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/observation"
 	"github.com/sourcegraph/sourcegraph/internal/packagefilters"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
@@ -16,24 +20,29 @@ import (
 
 // Service encapsulates the resolution and persistence of dependencies at the repository and package levels.
 type Service struct {
+	logger     log.Logger
 	store      store.Store
 	operations *operations
 }
 
 func newService(observationCtx *observation.Context, store store.Store) *Service {
 	return &Service{
+		logger:     log.Scoped("dependencies.service"),
 		store:      store,
 		operations: newOperations(observationCtx),
 	}
 }
 
 type (
-	PackageRepoReference         = shared.PackageRepoReference
-	PackageRepoRefVersion        = shared.PackageRepoRefVersion
-	MinimalPackageRepoRef        = shared.MinimalPackageRepoRef
-	MinimialVersionedPackageRepo = shared.MinimialVersionedPackageRepo
-	MinimalPackageRepoRefVersion = shared.MinimalPackageRepoRefVersion
-	PackageRepoFilter            = shared.PackageRepoFilter
+	PackageRepoReference          = shared.PackageRepoReference
+	PackageRepoRefVersion         = shared.PackageRepoRefVersion
+	MinimalPackageRepoRef         = shared.MinimalPackageRepoRef
+	MinimialVersionedPackageRepo  = shared.MinimialVersionedPackageRepo
+	MinimalPackageRepoRefVersion  = shared.MinimalPackageRepoRefVersion
+	PackageRepoFilter             = shared.PackageRepoFilter
+	PackageLicenseDependent       = shared.PackageLicenseDependent
+	PackageDependent              = shared.PackageDependent
+	ResolvedPackageRepoRefVersion = shared.ResolvedPackageRepoRefVersion
 )
 
 type ListDependencyReposOpts struct {
@@ -44,6 +53,10 @@ type ListDependencyReposOpts struct {
 
 	// ExactNameOnly enables exact name matching instead of substring.
 	ExactNameOnly bool
+	// Fuzzy matches names containing a word similar to Name, ignoring case,
+	// instead of substring, ordered by descending similarity. Ignored if
+	// ExactNameOnly is set.
+	Fuzzy bool
 	// After is the value predominantly used for pagination. When sorting by
 	// newest first, this should be the ID of the last element in the previous
 	// page, when excluding versions it should be the last package name in the
@@ -53,6 +66,12 @@ type ListDependencyReposOpts struct {
 	Limit int
 	// IncludeBlocked also includes those that would not be synced due to filter rules
 	IncludeBlocked bool
+	// IncludeArchived also includes those that were archived with ArchivePackageRepoRefsByID
+	IncludeArchived bool
+	// License only includes versions declaring the given license e.g. 'MIT',
+	// ignoring case. Versions without a declared license use the license of
+	// their package.
+	License string
 }
 
 func (s *Service) ListPackageRepoRefs(ctx context.Context, opts ListDependencyReposOpts) (_ []PackageRepoReference, total int, hasMore bool, err error) {
@@ -60,26 +79,49 @@ func (s *Service) ListPackageRepoRefs(ctx context.Context, opts ListDependencyRe
 		attribute.String("scheme", opts.Scheme),
 		attribute.String("name", string(opts.Name)),
 		attribute.Bool("exactOnly", opts.ExactNameOnly),
+		attribute.Bool("fuzzy", opts.Fuzzy),
 		attribute.Int("after", opts.After),
 		attribute.Int("limit", opts.Limit),
 	}})
 	defer endObservation(1, observation.Args{})
 
+	return s.store.ListPackageRepoRefs(ctx, s.storeListOpts(opts))
+}
+
+func (s *Service) storeListOpts(opts ListDependencyReposOpts) store.ListDependencyReposOpts {
 	storeopts := store.ListDependencyReposOpts{
-		Scheme:         opts.Scheme,
-		Name:           opts.Name,
-		After:          opts.After,
-		Limit:          opts.Limit,
-		IncludeBlocked: opts.IncludeBlocked,
+		Scheme:          opts.Scheme,
+		Name:            opts.Name,
+		After:           opts.After,
+		Limit:           opts.Limit,
+		IncludeBlocked:  opts.IncludeBlocked,
+		IncludeArchived: opts.IncludeArchived,
+		License:         opts.License,
 	}
 
 	if opts.ExactNameOnly {
 		storeopts.Fuzziness = store.FuzzinessExactMatch
+	} else if opts.Fuzzy {
+		storeopts.Fuzziness = store.FuzzinessSimilarity
 	} else {
 		storeopts.Fuzziness = store.FuzzinessWildcard
 	}
 
-	return s.store.ListPackageRepoRefs(ctx, storeopts)
+	return storeopts
+}
+
+// ScanPackageRepoRefs calls f with every package repo reference matching the given
+// options, in the order of ListPackageRepoRefs. Unlike ListPackageRepoRefs, all
+// pages are fetched, so the Limit option is ignored.
+func (s *Service) ScanPackageRepoRefs(ctx context.Context, opts ListDependencyReposOpts, f func(PackageRepoReference) error) (err error) {
+	ctx, _, endObservation := s.operations.scanPackageRepoRefs.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.String("scheme", opts.Scheme),
+		attribute.String("name", string(opts.Name)),
+		attribute.Int("after", opts.After),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	return s.store.ScanPackageRepoRefs(ctx, s.storeListOpts(opts), f)
 }
 
 func (s *Service) InsertPackageRepoRefs(ctx context.Context, deps []MinimalPackageRepoRef) (_ []shared.PackageRepoReference, _ []shared.PackageRepoRefVersion, err error) {
@@ -109,6 +151,160 @@ func (s *Service) DeletePackageRepoRefVersionsByID(ctx context.Context, ids ...i
 	return s.store.DeletePackageRepoRefVersionsByID(ctx, ids...)
 }
 
+type DeletePackageRepoRefsOpts struct {
+	// Scheme is the moniker scheme of the package repo references to delete. Required.
+	Scheme string
+	// NameGlob matches the names of the package repo references to delete e.g.
+	// '@acme/*'. '*' matches any sequence of characters and '?' a single one.
+	// Empty matches all names.
+	NameGlob string
+	// VersionConstraints restricts the deletion to versions satisfying all of the
+	// given constraints e.g. '>= 1.0.0, < 2.0.0'. Package repo references left
+	// without versions are deleted as well.
+	VersionConstraints []string
+}
+
+// DeletePackageRepoRefs deletes the package repo references matching the given options
+// in bulk, without having to resolve their IDs first. It returns the number of deleted
+// package repo references and versions.
+func (s *Service) DeletePackageRepoRefs(ctx context.Context, opts DeletePackageRepoRefsOpts) (deletedPackages, deletedVersions int, err error) {
+	ctx, _, endObservation := s.operations.deletePackageRepoRefs.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.String("scheme", opts.Scheme),
+		attribute.String("nameGlob", opts.NameGlob),
+		attribute.StringSlice("versionConstraints", opts.VersionConstraints),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	return s.store.DeletePackageRepoRefs(ctx, store.DeletePackageRepoRefsOpts{
+		Scheme:             opts.Scheme,
+		NameGlob:           opts.NameGlob,
+		VersionConstraints: opts.VersionConstraints,
+	})
+}
+
+type ResolvePackageRepoRefVersionOpts struct {
+	Scheme  string
+	Name    reposource.PackageName
+	Version string
+	// AllowNearest resolves to the nearest available version of the package, marked
+	// as approximate, if the requested version has not been synced.
+	AllowNearest bool
+	// Gitserver, if set, is asked to sync the requested version when resolving to
+	// the nearest version.
+	Gitserver GitserverClient
+}
+
+// GitserverClient is the subset of gitserver.Client used to sync versions of
+// package repos.
+type GitserverClient interface {
+	ResolveRevision(ctx context.Context, repo api.RepoName, spec string, opt gitserver.ResolveRevisionOptions) (api.CommitID, error)
+}
+
+const (
+	// maxConcurrentVersionSyncs is the number of requested versions synced at
+	// the same time. Versions requested while as many are syncing are requested
+	// again the next time they're resolved.
+	maxConcurrentVersionSyncs = 8
+
+	// versionSyncTimeout bounds how long syncing a requested version may take.
+	versionSyncTimeout = time.Minute
+)
+
+var versionSyncs = make(chan struct{}, maxConcurrentVersionSyncs)
+
+// ResolvePackageRepoRefVersion returns the available version of a package repo reference for the
+// requested version. found is false if no suitable version is available. If it resolves to the
+// nearest version, the requested version is synced in the background.
+func (s *Service) ResolvePackageRepoRefVersion(ctx context.Context, opts ResolvePackageRepoRefVersionOpts) (_ ResolvedPackageRepoRefVersion, found bool, err error) {
+	ctx, _, endObservation := s.operations.resolvePackageRepoRefVersion.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.String("scheme", opts.Scheme),
+		attribute.String("name", string(opts.Name)),
+		attribute.String("version", opts.Version),
+		attribute.Bool("allowNearest", opts.AllowNearest),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	resolved, found, err := s.store.ResolvePackageRepoRefVersion(ctx, store.ResolvePackageRepoRefVersionOpts{
+		Scheme:       opts.Scheme,
+		Name:         opts.Name,
+		Version:      opts.Version,
+		AllowNearest: opts.AllowNearest,
+	})
+	if err != nil || !found {
+		return resolved, found, err
+	}
+
+	if resolved.Approximate && opts.Gitserver != nil {
+		s.requestVersionSync(ctx, opts.Gitserver, opts.Scheme, opts.Name, opts.Version)
+	}
+	return resolved, true, nil
+}
+
+// requestVersionSync requests the revision v${VERSION}^0 of the package repo from
+// gitserver in the background. gitserver fetches the version from the package host
+// and, if it exists there, adds it to the package repo references, cf.
+// vcsPackagesSyncer.fetchRevspec. Versions are not added before they're fetched, so
+// versions that don't exist upstream are never added.
+func (s *Service) requestVersionSync(ctx context.Context, client GitserverClient, scheme string, name reposource.PackageName, version string) {
+	packageScheme, ok := shared.LookupPackageScheme(scheme)
+	if !ok {
+		return
+	}
+	repoName, err := packageScheme.RepoName(name)
+	if err != nil {
+		return
+	}
+
+	select {
+	case versionSyncs <- struct{}{}:
+	default:
+		return
+	}
+
+	go func() {
+		defer func() { <-versionSyncs }()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), versionSyncTimeout)
+		defer cancel()
+
+		// ResolveRevision appends ^0.
+		if _, err := client.ResolveRevision(ctx, repoName, "v"+version, gitserver.ResolveRevisionOptions{}); err != nil {
+			s.logger.Debug("failed to sync requested package repo version",
+				log.String("repo", string(repoName)),
+				log.String("version", version),
+				log.Error(err))
+		}
+	}()
+}
+
+func (s *Service) ArchivePackageRepoRefsByID(ctx context.Context, ids ...int) (err error) {
+	ctx, _, endObservation := s.operations.archivePackageRepoRefsByID.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.Int("packageRepoRefs", len(ids)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	return s.store.ArchivePackageRepoRefsByID(ctx, ids...)
+}
+
+func (s *Service) UnarchivePackageRepoRefsByID(ctx context.Context, ids ...int) (err error) {
+	ctx, _, endObservation := s.operations.unarchivePackageRepoRefsByID.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.Int("packageRepoRefs", len(ids)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	return s.store.UnarchivePackageRepoRefsByID(ctx, ids...)
+}
+
+func (s *Service) UpdatePackageRepoRefsVisibilityByID(ctx context.Context, private bool, ids ...int) (err error) {
+	ctx, _, endObservation := s.operations.updatePackageRepoRefsVisibilityByID.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.Bool("private", private),
+		attribute.Int("packageRepoRefs", len(ids)),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	return s.store.UpdatePackageRepoRefsVisibilityByID(ctx, private, ids...)
+}
+
 type ListPackageRepoRefFiltersOpts struct {
 	IDs            []int
 	PackageScheme  string
@@ -278,9 +474,9 @@ func (s *Service) PackagesOrVersionsMatchingFilter(ctx context.Context, filter s
 			}
 		}
 	} else {
-		matcher, err := packagefilters.NewVersionGlob(filter.VersionFilter.PackageName, filter.VersionFilter.VersionGlob)
+		matcher, err := packagefilters.NewVersionMatcher(filter.PackageScheme, filter.VersionFilter.PackageName, filter.VersionFilter.VersionGlob)
 		if err != nil {
-			return nil, 0, false, errors.Wrap(err, "failed to compile glob")
+			return nil, 0, false, errors.Wrap(err, "failed to compile version filter")
 		}
 		nameToMatch := filter.VersionFilter.PackageName
 
@@ -322,3 +518,36 @@ func (s *Service) PackagesOrVersionsMatchingFilter(ctx context.Context, filter s
 
 	return matchingPkgs, totalCount, hasMore, nil
 }
+
+// Stats summarizes the package repo references and versions stored per scheme.
+func (s *Service) Stats(ctx context.Context) (_ shared.PackageRepoStats, err error) {
+	ctx, _, endObservation := s.operations.stats.With(ctx, &err, observation.Args{})
+	defer endObservation(1, observation.Args{})
+
+	return s.store.Stats(ctx)
+}
+
+// ListPackageLicenseDependents returns the repositories whose precise indexes reference
+// a package version declaring the given license.
+func (s *Service) ListPackageLicenseDependents(ctx context.Context, license string) (_ []PackageLicenseDependent, err error) {
+	ctx, _, endObservation := s.operations.listPackageLicenseDependents.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.String("license", license),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	return s.store.ListPackageLicenseDependents(ctx, license)
+}
+
+// PackageDependents returns the repository commits whose precise indexes reference the
+// given package at a version in the given range, e.g. to find what is affected by
+// upgrading the package. An empty range matches every version.
+func (s *Service) PackageDependents(ctx context.Context, scheme string, name reposource.PackageName, versionRange string) (_ []PackageDependent, err error) {
+	ctx, _, endObservation := s.operations.packageDependents.With(ctx, &err, observation.Args{Attrs: []attribute.KeyValue{
+		attribute.String("scheme", scheme),
+		attribute.String("name", string(name)),
+		attribute.String("versionRange", versionRange),
+	}})
+	defer endObservation(1, observation.Args{})
+
+	return s.store.PackageDependents(ctx, scheme, name, versionRange)
+}
@@ -0,0 +1,89 @@
+package dependencies
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/log/logtest"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/database/dbtest"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+)
+
+type resolvedRevision struct {
+	repo api.RepoName
+	spec string
+}
+
+type fakeGitserverClient struct {
+	resolved chan resolvedRevision
+}
+
+func (c *fakeGitserverClient) ResolveRevision(_ context.Context, repo api.RepoName, spec string, _ gitserver.ResolveRevisionOptions) (api.CommitID, error) {
+	c.resolved <- resolvedRevision{repo: repo, spec: spec}
+	return "deadbeef", nil
+}
+
+func TestResolvePackageRepoRefVersionRequestsSync(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	ctx := context.Background()
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	svc := TestService(db)
+
+	if _, _, err := svc.InsertPackageRepoRefs(ctx, []MinimalPackageRepoRef{
+		{Scheme: "npm", Name: "left-pad", Versions: []MinimalPackageRepoRefVersion{{Version: "1.0.0"}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &fakeGitserverClient{resolved: make(chan resolvedRevision, 1)}
+	resolve := func(version string) ResolvedPackageRepoRefVersion {
+		t.Helper()
+		resolved, found, err := svc.ResolvePackageRepoRefVersion(ctx, ResolvePackageRepoRefVersionOpts{
+			Scheme:       "npm",
+			Name:         "left-pad",
+			Version:      version,
+			AllowNearest: true,
+			Gitserver:    client,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !found {
+			t.Fatalf("expected %s to resolve", version)
+		}
+		return resolved
+	}
+
+	// Resolving to the nearest version requests the exact version from gitserver,
+	// which syncs it.
+	if resolved := resolve("1.1.0"); !resolved.Approximate || resolved.Version != "1.0.0" {
+		t.Fatalf("unexpected resolution: %+v", resolved)
+	}
+	select {
+	case got := <-client.resolved:
+		want := resolvedRevision{repo: "npm/left-pad", spec: "v1.1.0"}
+		if got != want {
+			t.Errorf("unexpected revision requested: want=%+v got=%+v", want, got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the requested version to be synced")
+	}
+
+	// Exact versions are already synced.
+	if resolved := resolve("1.0.0"); resolved.Approximate {
+		t.Fatalf("unexpected resolution: %+v", resolved)
+	}
+	select {
+	case got := <-client.resolved:
+		t.Errorf("unexpected revision requested: %+v", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,157 @@
+package shared
+
+import (
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/shared/versions"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+)
+
+// PackageScheme describes a package ecosystem whose packages are synced as package
+// repos.
+type PackageScheme struct {
+	// Name is the scheme package repo references of the ecosystem are stored under.
+	Name string
+	// ExternalServiceKind is the kind of the external service that syncs the package
+	// repos of the ecosystem.
+	ExternalServiceKind string
+	// SyncReferenced is whether packages referenced by code intel uploads are
+	// added as package repos, and schedule a sync of the external service. Go
+	// modules aren't: dependencies of lsif-go uploads are indexed without a
+	// package repo, and Go module package repos are only synced from the
+	// external service's own configuration.
+	SyncReferenced bool
+
+	parse func(reposource.PackageName) (schemePackage, error)
+}
+
+// schemePackage is the part of reposource.Package that every ecosystem's parsed
+// package name implements.
+type schemePackage interface {
+	PackageSyntax() reposource.PackageName
+	RepoName() api.RepoName
+}
+
+// PackageSchemes are all package ecosystems supported by package repos.
+var PackageSchemes = []PackageScheme{
+	{
+		Name:                GoPackagesScheme,
+		ExternalServiceKind: extsvc.KindGoPackages,
+		parse: func(name reposource.PackageName) (schemePackage, error) {
+			return reposource.ParseGoDependencyFromName(name)
+		},
+	},
+	{
+		Name:                JVMPackagesScheme,
+		ExternalServiceKind: extsvc.KindJVMPackages,
+		SyncReferenced:      true,
+		parse: func(name reposource.PackageName) (schemePackage, error) {
+			// Names of references from SCIP indexers use the maven/<group>/<artifact>
+			// form of repo names.
+			if strings.HasPrefix(string(name), "maven/") {
+				return reposource.ParseMavenPackageFromRepoName(api.RepoName(name))
+			}
+			return reposource.ParseMavenPackageFromName(name)
+		},
+	},
+	{
+		Name:                NpmPackagesScheme,
+		ExternalServiceKind: extsvc.KindNpmPackages,
+		SyncReferenced:      true,
+		parse: func(name reposource.PackageName) (schemePackage, error) {
+			return reposource.ParseNpmPackageFromPackageSyntax(name)
+		},
+	},
+	{
+		Name:                PythonPackagesScheme,
+		ExternalServiceKind: extsvc.KindPythonPackages,
+		SyncReferenced:      true,
+		parse: func(name reposource.PackageName) (schemePackage, error) {
+			return reposource.ParsePythonPackageFromName(name), nil
+		},
+	},
+	{
+		Name:                RubyPackagesScheme,
+		ExternalServiceKind: extsvc.KindRubyPackages,
+		SyncReferenced:      true,
+		parse: func(name reposource.PackageName) (schemePackage, error) {
+			return reposource.ParseRubyPackageFromName(name), nil
+		},
+	},
+	{
+		Name:                RustPackagesScheme,
+		ExternalServiceKind: extsvc.KindRustPackages,
+		SyncReferenced:      true,
+		parse: func(name reposource.PackageName) (schemePackage, error) {
+			return reposource.ParseRustPackageFromName(name), nil
+		},
+	},
+}
+
+// LookupPackageScheme returns the package scheme with the given name.
+func LookupPackageScheme(name string) (PackageScheme, bool) {
+	for _, scheme := range PackageSchemes {
+		if scheme.Name == name {
+			return scheme, true
+		}
+	}
+	return PackageScheme{}, false
+}
+
+// LookupPackageSchemeByExternalServiceKind returns the package scheme synced by
+// external services of the given kind.
+func LookupPackageSchemeByExternalServiceKind(kind string) (PackageScheme, bool) {
+	for _, scheme := range PackageSchemes {
+		if scheme.ExternalServiceKind == kind {
+			return scheme, true
+		}
+	}
+	return PackageScheme{}, false
+}
+
+// ValidateName returns an error if name is not a valid package name in the
+// ecosystem. Surrounding whitespace is ignored, as in NormalizeName.
+func (s PackageScheme) ValidateName(name reposource.PackageName) error {
+	_, err := s.parse(reposource.PackageName(strings.TrimSpace(string(name))))
+	return err
+}
+
+// NormalizeName returns the canonical spelling of the given package name, such
+// as group:artifact for a Maven package referenced as maven/group/artifact.
+// Names that aren't valid in the ecosystem are returned unchanged.
+func (s PackageScheme) NormalizeName(name reposource.PackageName) reposource.PackageName {
+	name = reposource.PackageName(strings.TrimSpace(string(name)))
+	pkg, err := s.parse(name)
+	if err != nil {
+		return name
+	}
+	if maven, ok := pkg.(*reposource.MavenVersionedPackage); ok && maven.IsJDK() {
+		// The JDK is referenced by its repo name rather than a Maven coordinate.
+		return reposource.PackageName(maven.RepoName())
+	}
+	return pkg.PackageSyntax()
+}
+
+// RepoName returns the name of the repo the given package is synced to. Package
+// repos are cloned from their repo name by the external service.
+func (s PackageScheme) RepoName(name reposource.PackageName) (api.RepoName, error) {
+	pkg, err := s.parse(name)
+	if err != nil {
+		return "", err
+	}
+	return pkg.RepoName(), nil
+}
+
+// NormalizeVersion returns the canonical spelling of the given version in the
+// ecosystem (see versions.Normalize).
+func (s PackageScheme) NormalizeVersion(version string) string {
+	return versions.Normalize(s.Name, version)
+}
+
+// CompareVersions returns -1, 0 or 1 if version a is respectively lower than,
+// equal to or greater than version b in the ecosystem (see versions.Compare).
+func (s PackageScheme) CompareVersions(a, b string) (int, error) {
+	return versions.Compare(s.Name, a, b)
+}
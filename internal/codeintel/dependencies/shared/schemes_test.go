@@ -0,0 +1,84 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+)
+
+func TestPackageSchemeNormalizeName(t *testing.T) {
+	for _, tc := range []struct {
+		scheme string
+		name   reposource.PackageName
+		want   reposource.PackageName
+	}{
+		{JVMPackagesScheme, "maven/com.google.guava/guava", "com.google.guava:guava"},
+		{JVMPackagesScheme, "com.google.guava:guava", "com.google.guava:guava"},
+		{JVMPackagesScheme, "jdk", "jdk"},
+		{JVMPackagesScheme, "not a coordinate", "not a coordinate"},
+		{NpmPackagesScheme, " @types/node ", "@types/node"},
+		{PythonPackagesScheme, "requests", "requests"},
+	} {
+		scheme, ok := LookupPackageScheme(tc.scheme)
+		if !ok {
+			t.Fatalf("unknown scheme %q", tc.scheme)
+		}
+		if have := scheme.NormalizeName(tc.name); have != tc.want {
+			t.Errorf("unexpected name for %s %q. want=%q have=%q", tc.scheme, tc.name, tc.want, have)
+		}
+	}
+}
+
+func TestPackageSchemeRepoName(t *testing.T) {
+	for _, tc := range []struct {
+		scheme string
+		name   reposource.PackageName
+		want   api.RepoName
+	}{
+		{JVMPackagesScheme, "com.google.guava:guava", "maven/com.google.guava/guava"},
+		{NpmPackagesScheme, "@types/node", "npm/types/node"},
+		{PythonPackagesScheme, "requests", "python/requests"},
+		{RustPackagesScheme, "serde", "crates/serde"},
+	} {
+		scheme, _ := LookupPackageScheme(tc.scheme)
+		have, err := scheme.RepoName(tc.name)
+		if err != nil {
+			t.Fatalf("unexpected error for %s %q: %s", tc.scheme, tc.name, err)
+		}
+		if have != tc.want {
+			t.Errorf("unexpected repo name for %s %q. want=%q have=%q", tc.scheme, tc.name, tc.want, have)
+		}
+	}
+
+	scheme, _ := LookupPackageScheme(JVMPackagesScheme)
+	if err := scheme.ValidateName("not a coordinate"); err == nil {
+		t.Error("expected an error for an invalid maven coordinate")
+	}
+}
+
+func TestLookupPackageSchemeByExternalServiceKind(t *testing.T) {
+	for _, scheme := range PackageSchemes {
+		have, ok := LookupPackageSchemeByExternalServiceKind(scheme.ExternalServiceKind)
+		if !ok || have.Name != scheme.Name {
+			t.Errorf("unexpected scheme for kind %q. want=%q have=%q", scheme.ExternalServiceKind, scheme.Name, have.Name)
+		}
+	}
+
+	if _, ok := LookupPackageSchemeByExternalServiceKind(extsvc.KindGitHub); ok {
+		t.Error("expected no package scheme for GitHub")
+	}
+	if _, ok := LookupPackageScheme("gomod"); ok {
+		t.Error("expected no package scheme for gomod")
+	}
+}
+
+func TestSyncReferenced(t *testing.T) {
+	for _, scheme := range PackageSchemes {
+		// Go module package repos are only synced from their configuration.
+		if want := scheme.Name != GoPackagesScheme; scheme.SyncReferenced != want {
+			t.Errorf("unexpected SyncReferenced for %q. want=%v have=%v", scheme.Name, want, scheme.SyncReferenced)
+		}
+	}
+}
@@ -13,6 +13,17 @@ type PackageRepoReference struct {
 	Versions      []PackageRepoRefVersion
 	Blocked       bool
 	LastCheckedAt *time.Time
+	ArchivedAt    *time.Time
+
+	// PublishedAt, Description and License are metadata reported by the package
+	// host. They are refreshed whenever the reference is inserted again.
+	PublishedAt *time.Time
+	Description string
+	License     string
+
+	// Private package repos mirror internal registries and are hidden from
+	// users who aren't site admins.
+	Private bool
 }
 
 type PackageRepoRefVersion struct {
@@ -21,6 +32,50 @@ type PackageRepoRefVersion struct {
 	Version       string
 	Blocked       bool
 	LastCheckedAt *time.Time
+	License       string
+	// OriginalVersion is the spelling the version was first referenced by, if it
+	// differs from the normalized Version.
+	OriginalVersion string
+}
+
+// ResolvedPackageRepoRefVersion is the version of a package repo reference a
+// requested version resolved to.
+type ResolvedPackageRepoRefVersion struct {
+	PackageRepoRefVersion
+	// Approximate is true if the requested version is not available, and this is
+	// the nearest available version of the same package instead.
+	Approximate bool
+}
+
+// PackageRepoSchemeCount holds the number of package repo references and
+// versions stored for a single scheme.
+type PackageRepoSchemeCount struct {
+	Scheme   string
+	Packages int
+	Versions int
+}
+
+// PackageRepoStats summarizes the package repo references and versions stored
+// across all schemes.
+type PackageRepoStats struct {
+	Schemes []PackageRepoSchemeStats
+
+	Packages         int
+	Versions         int
+	BlockedPackages  int
+	BlockedVersions  int
+	ArchivedPackages int
+}
+
+// PackageRepoSchemeStats summarizes the package repo references and versions
+// stored for a single scheme.
+type PackageRepoSchemeStats struct {
+	Scheme           string
+	Packages         int
+	Versions         int
+	BlockedPackages  int
+	BlockedVersions  int
+	ArchivedPackages int
 }
 
 type MinimalPackageRepoRef struct {
@@ -29,12 +84,39 @@ type MinimalPackageRepoRef struct {
 	Versions      []MinimalPackageRepoRefVersion
 	Blocked       bool
 	LastCheckedAt *time.Time
+
+	PublishedAt *time.Time
+	Description string
+	License     string
 }
 
 type MinimalPackageRepoRefVersion struct {
 	Version       string
 	Blocked       bool
 	LastCheckedAt *time.Time
+	License       string
+	// OriginalVersion is set when inserting the version if Version is normalized.
+	OriginalVersion string
+}
+
+// PackageLicenseDependent is a repository with a precise index referencing a
+// package version that declares License.
+type PackageLicenseDependent struct {
+	RepositoryID   int
+	RepositoryName string
+	Scheme         string
+	Name           reposource.PackageName
+	Version        string
+	License        string
+}
+
+// PackageDependent is a commit of a repository with a precise index referencing
+// Version of a package.
+type PackageDependent struct {
+	RepositoryID   int
+	RepositoryName string
+	Commit         string
+	Version        string
 }
 
 type MinimialVersionedPackageRepo struct {
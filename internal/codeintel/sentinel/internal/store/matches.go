@@ -3,14 +3,13 @@ package store
 import (
 	"context"
 	"sort"
-	"strings"
 
-	"github.com/hashicorp/go-version"
 	"github.com/keegancsmith/sqlf"
 	"github.com/lib/pq"
 	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/sentinel/shared"
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/shared/versions"
 	"github.com/sourcegraph/sourcegraph/internal/database/basestore"
 	"github.com/sourcegraph/sourcegraph/internal/database/batch"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbutil"
@@ -225,17 +224,18 @@ func (s *store) ScanMatches(ctx context.Context, batchSize int) (numReferencesSc
 		numScanned := 0
 		scanFilteredVulnerabilityMatches := basestore.NewFilteredSliceScanner(func(s dbutil.Scanner) (m vulnerabilityMatch, _ bool, _ error) {
 			var (
+				scheme             string
 				version            string
 				versionConstraints []string
 			)
 
-			if err := s.Scan(&m.UploadID, &m.VulnerabilityAffectedPackageID, &version, pq.Array(&versionConstraints)); err != nil {
+			if err := s.Scan(&m.UploadID, &m.VulnerabilityAffectedPackageID, &scheme, &version, pq.Array(&versionConstraints)); err != nil {
 				return vulnerabilityMatch{}, false, err
 			}
 
 			numScanned++
-			matches, valid := versionMatchesConstraints(version, versionConstraints)
-			_ = valid // TODO - log un-parseable versions
+			matches, err := versions.MatchesConstraints(scheme, version, versionConstraints)
+			_ = err // TODO - log un-parseable versions
 
 			return m, matches, nil
 		})
@@ -322,6 +322,7 @@ locked_candidates AS (
 SELECT
 	r.dump_id,
 	vap.id,
+	r.scheme,
 	r.version,
 	vap.version_constraint
 FROM locked_candidates lc
@@ -422,20 +423,6 @@ var flattenMatches = func(ms []shared.VulnerabilityMatch) []shared.Vulnerability
 	return flattened
 }
 
-func versionMatchesConstraints(versionString string, constraints []string) (matches, valid bool) {
-	v, err := version.NewVersion(versionString)
-	if err != nil {
-		return false, false
-	}
-
-	constraint, err := version.NewConstraint(strings.Join(constraints, ","))
-	if err != nil {
-		return false, false
-	}
-
-	return constraint.Check(v), true
-}
-
 var scipSchemeToVulnerabilityLanguage = map[string]string{
 	"gomod": "go",
 	"npm":   "Javascript",
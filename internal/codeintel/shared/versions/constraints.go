@@ -0,0 +1,229 @@
+package versions
+
+import (
+	"strings"
+
+	"github.com/grafana/regexp"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// versionRange is a set of versions described by an ecosystem-specific range
+// syntax.
+type versionRange interface {
+	contains(v version) bool
+}
+
+// MatchesConstraints returns whether the given version satisfies all of the given
+// constraints according to the versioning rules of the given scheme.
+//
+// Each constraint is a list of clauses separated by commas or spaces, all of which
+// must hold, such as `>= 1.2.0, < 2.0.0`. Alternatives can be separated by `||`.
+// Clauses consist of an optional operator (=, ==, !=, <, <=, >, >=, ~>, ~=, ^ or ~)
+// followed by a version, which may end with a wildcard (`1.2.*` or `1.2.x`). For
+// Maven packages, constraints may also use Maven's range syntax (`[1.0,2.0)`).
+//
+// An error is returned if the version or any of the constraints cannot be parsed.
+func MatchesConstraints(scheme, version string, constraints []string) (bool, error) {
+	eco := ecosystemForScheme(scheme)
+
+	v, err := eco.parse(version)
+	if err != nil {
+		return false, err
+	}
+	release := releaseSegments(version)
+
+	for _, constraint := range constraints {
+		ok, err := matchesConstraint(eco, v, release, constraint)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchesConstraint(eco ecosystem, v version, release []int, constraint string) (bool, error) {
+	if eco.parseRange != nil {
+		r, ok, err := eco.parseRange(constraint)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return r.contains(v), nil
+		}
+	}
+
+	alternatives := strings.Split(constraint, "||")
+	for _, alternative := range alternatives {
+		clauses, err := parseClauses(alternative)
+		if err != nil {
+			return false, err
+		}
+
+		matches := true
+		for _, c := range clauses {
+			ok, err := c.matches(eco, v, release)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type clause struct {
+	op      string
+	version string
+}
+
+var clausePattern = regexp.MustCompile(`(===|==|!=|>=|<=|~>|~=|>|<|=|\^|~)?\s*([^\s,|<>=!~^]+)`)
+
+func parseClauses(s string) ([]clause, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "*" {
+		return nil, nil
+	}
+
+	matches := clausePattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return nil, errors.Newf("invalid version constraint %q", s)
+	}
+
+	clauses := make([]clause, 0, len(matches))
+	last := 0
+	for _, m := range matches {
+		// Everything between two clauses must be a separator.
+		if strings.Trim(s[last:m[0]], " \t,") != "" {
+			return nil, errors.Newf("invalid version constraint %q", s)
+		}
+		last = m[1]
+
+		var op string
+		if m[2] >= 0 {
+			op = s[m[2]:m[3]]
+		}
+		clauses = append(clauses, clause{op: op, version: s[m[4]:m[5]]})
+	}
+	if strings.Trim(s[last:], " \t,") != "" {
+		return nil, errors.Newf("invalid version constraint %q", s)
+	}
+
+	return clauses, nil
+}
+
+func (c clause) matches(eco ecosystem, v version, release []int) (bool, error) {
+	if prefix, ok := wildcardPrefix(c.version); ok {
+		matches := hasPrefix(release, prefix)
+		switch c.op {
+		case "", "=", "==", "===":
+			return matches, nil
+		case "!=":
+			return !matches, nil
+		default:
+			return false, errors.Newf("operator %q does not support wildcard version %q", c.op, c.version)
+		}
+	}
+
+	target, err := eco.parse(c.version)
+	if err != nil {
+		return false, err
+	}
+	cmp := v.compare(target)
+
+	switch c.op {
+	case "", "=", "==", "===":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	}
+
+	// The remaining operators have a lower bound of the given version and an
+	// upper bound derived from its release segments.
+	segments := releaseSegments(c.version)
+	if len(segments) == 0 {
+		return false, errors.Newf("operator %q requires a numeric version, got %q", c.op, c.version)
+	}
+
+	var upper []int
+	switch c.op {
+	case "~>", "~=":
+		// Pessimistic (RubyGems) and compatible release (PEP 440) operators allow
+		// the last given segment to increase: ~> 1.2.3 means >= 1.2.3, < 1.3.
+		if c.op == "~=" && len(segments) < 2 {
+			return false, errors.Newf("operator ~= requires at least two release segments, got %q", c.version)
+		}
+		upper = bump(segments, max(len(segments)-2, 0))
+	case "^":
+		// Caret ranges allow changes that do not modify the left-most non-zero
+		// segment: ^1.2.3 means >= 1.2.3, < 2.0.0 and ^0.2.3 means < 0.3.0.
+		i := 0
+		for i < len(segments)-1 && segments[i] == 0 {
+			i++
+		}
+		upper = bump(segments, i)
+	case "~":
+		// Tilde ranges allow patch-level changes if a minor version is given and
+		// minor-level changes if not: ~1.2.3 means >= 1.2.3, < 1.3.0.
+		if len(segments) >= 2 {
+			upper = bump(segments, 1)
+		} else {
+			upper = bump(segments, 0)
+		}
+	default:
+		return false, errors.Newf("unknown version constraint operator %q", c.op)
+	}
+
+	return cmp >= 0 && compareSegments(release, upper) < 0, nil
+}
+
+// wildcardPrefix returns the release segments preceding a wildcard in the given
+// version, if it contains one. For example, `1.2.*` yields [1 2].
+func wildcardPrefix(s string) ([]int, bool) {
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	last := parts[len(parts)-1]
+	if last != "*" && last != "x" && last != "X" {
+		return nil, false
+	}
+
+	prefix := releaseSegments(strings.Join(parts[:len(parts)-1], "."))
+	if len(prefix) != len(parts)-1 {
+		return nil, false
+	}
+	return prefix, true
+}
+
+func hasPrefix(segments, prefix []int) bool {
+	for i, p := range prefix {
+		var s int
+		if i < len(segments) {
+			s = segments[i]
+		}
+		if s != p {
+			return false
+		}
+	}
+	return true
+}
+
+// bump returns the segments up to and including index i, with the segment at
+// index i incremented.
+func bump(segments []int, i int) []int {
+	bumped := append([]int(nil), segments[:i+1]...)
+	bumped[i]++
+	return bumped
+}
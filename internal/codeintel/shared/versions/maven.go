@@ -0,0 +1,332 @@
+package versions
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// mavenVersion is a Maven artifact version, ordered like Maven's
+// ComparableVersion: numeric items compare numerically, well-known qualifiers
+// compare by their release stage, and any other qualifier compares
+// lexically after them.
+type mavenVersion struct {
+	items []mavenItem
+}
+
+type mavenItem struct {
+	// number is set for numeric items. Otherwise, qualifier holds the
+	// (canonicalized) string item.
+	number    *big.Int
+	qualifier string
+	// sublist is set if the item was preceded by a hyphen, in which case it
+	// starts a nested list of items (1-1 is not the same as 1.1).
+	sublist []mavenItem
+	isList  bool
+}
+
+// mavenQualifiers orders the well-known qualifiers. The empty qualifier stands
+// for the release itself.
+var mavenQualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+var mavenQualifierAliases = map[string]string{
+	"a":       "alpha",
+	"b":       "beta",
+	"m":       "milestone",
+	"cr":      "rc",
+	"ga":      "",
+	"final":   "",
+	"release": "",
+}
+
+func parseMaven(s string) (version, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return nil, errors.New("invalid Maven version: empty")
+	}
+	return mavenVersion{items: parseMavenItems(s)}, nil
+}
+
+func parseMavenItems(s string) []mavenItem {
+	var (
+		root  []mavenItem
+		stack = []*[]mavenItem{&root}
+		start = 0
+	)
+
+	cur := func() *[]mavenItem { return stack[len(stack)-1] }
+
+	flush := func(end int, followedByDigit bool) {
+		token := s[start:end]
+		if token == "" {
+			token = "0"
+		}
+		*cur() = append(*cur(), newMavenItem(token, followedByDigit))
+	}
+
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '.':
+			flush(i, i+1 < len(s) && isDigit(s[i+1]))
+			start = i + 1
+		case c == '-':
+			flush(i, i+1 < len(s) && isDigit(s[i+1]))
+			start = i + 1
+			// A hyphen starts a new nested list, after dropping trailing null
+			// items from the current one (1.0-alpha is 1-alpha).
+			*cur() = normalizeMavenItems(*cur())
+			*cur() = append(*cur(), mavenItem{isList: true})
+			list := &(*cur())[len(*cur())-1].sublist
+			stack = append(stack, list)
+		case i > start && isDigit(c) != isDigit(s[i-1]):
+			// Transitions between digits and letters separate items as well
+			// (1alpha2 is 1-alpha-2).
+			flush(i, isDigit(c))
+			start = i
+			*cur() = append(*cur(), mavenItem{isList: true})
+			list := &(*cur())[len(*cur())-1].sublist
+			stack = append(stack, list)
+		}
+	}
+	flush(len(s), false)
+
+	return normalizeMavenItems(root)
+}
+
+func newMavenItem(token string, followedByDigit bool) mavenItem {
+	if n, ok := new(big.Int).SetString(token, 10); ok {
+		return mavenItem{number: n}
+	}
+	// Single letter qualifiers are only aliases when followed by a number
+	// (a1 is alpha-1 but a is just "a").
+	if len(token) == 1 && !followedByDigit {
+		return mavenItem{qualifier: token}
+	}
+	if alias, ok := mavenQualifierAliases[token]; ok {
+		token = alias
+	}
+	return mavenItem{qualifier: token}
+}
+
+// normalizeMavenItems removes trailing null items (0, "", ga, final, release)
+// from each list, so that 1.0 == 1 and 1-ga == 1.
+func normalizeMavenItems(items []mavenItem) []mavenItem {
+	for i := range items {
+		if items[i].isList {
+			items[i].sublist = normalizeMavenItems(items[i].sublist)
+		}
+	}
+	for len(items) > 0 && items[len(items)-1].isNull() {
+		items = items[:len(items)-1]
+	}
+	return items
+}
+
+func (i mavenItem) isNull() bool {
+	switch {
+	case i.isList:
+		return len(i.sublist) == 0
+	case i.number != nil:
+		return i.number.Sign() == 0
+	default:
+		return i.qualifier == ""
+	}
+}
+
+func (v mavenVersion) compare(other version) int {
+	return compareMavenLists(v.items, other.(mavenVersion).items)
+}
+
+func compareMavenLists(a, b []mavenItem) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var c int
+		switch {
+		case i >= len(a):
+			c = -compareMavenItem(b[i], nil)
+		case i >= len(b):
+			c = compareMavenItem(a[i], nil)
+		default:
+			c = compareMavenItem(a[i], &b[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareMavenItem compares an item with another item, or with a missing item
+// if other is nil.
+func compareMavenItem(item mavenItem, other *mavenItem) int {
+	switch {
+	case item.number != nil:
+		if other == nil {
+			return item.number.Sign()
+		}
+		switch {
+		case other.number != nil:
+			return item.number.Cmp(other.number)
+		default:
+			// Numbers are newer than qualifiers and lists (1.1 > 1-1 > 1-sp).
+			return 1
+		}
+
+	case item.isList:
+		if other == nil {
+			if len(item.sublist) == 0 {
+				return 0
+			}
+			return compareMavenItem(item.sublist[0], nil)
+		}
+		switch {
+		case other.number != nil:
+			return -1
+		case other.isList:
+			return compareMavenLists(item.sublist, other.sublist)
+		default:
+			return 1
+		}
+
+	default:
+		if other == nil {
+			return compareMavenQualifiers(item.qualifier, "")
+		}
+		switch {
+		case other.number != nil, other.isList:
+			return -1
+		default:
+			return compareMavenQualifiers(item.qualifier, other.qualifier)
+		}
+	}
+}
+
+func compareMavenQualifiers(a, b string) int {
+	ra, rb := mavenQualifierRank(a), mavenQualifierRank(b)
+	if ra != rb {
+		return compareInts(ra, rb)
+	}
+	if ra == len(mavenQualifiers) {
+		// Unknown qualifiers sort after the known ones, lexically.
+		return strings.Compare(a, b)
+	}
+	return 0
+}
+
+func mavenQualifierRank(q string) int {
+	for i, known := range mavenQualifiers {
+		if q == known {
+			return i
+		}
+	}
+	return len(mavenQualifiers)
+}
+
+// mavenRange is a union of version intervals, such as `[1.0,2.0),[3.0,)`.
+type mavenRange []mavenInterval
+
+type mavenInterval struct {
+	lower, upper                   version
+	lowerInclusive, upperInclusive bool
+}
+
+func (r mavenRange) contains(v version) bool {
+	for _, interval := range r {
+		if interval.contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (i mavenInterval) contains(v version) bool {
+	if i.lower != nil {
+		c := v.compare(i.lower)
+		if c < 0 || (c == 0 && !i.lowerInclusive) {
+			return false
+		}
+	}
+	if i.upper != nil {
+		c := v.compare(i.upper)
+		if c > 0 || (c == 0 && !i.upperInclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseMavenRange parses a Maven version range specification. It reports false
+// if the given constraint does not use the range syntax.
+func parseMavenRange(s string) (versionRange, bool, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || (s[0] != '[' && s[0] != '(') {
+		return nil, false, nil
+	}
+
+	var r mavenRange
+	for s != "" {
+		end := strings.IndexAny(s, "])")
+		if end < 0 {
+			return nil, false, errors.Newf("invalid Maven version range %q: unclosed range", s)
+		}
+
+		interval, err := parseMavenInterval(s[0], s[1:end], s[end])
+		if err != nil {
+			return nil, false, err
+		}
+		r = append(r, interval)
+
+		s = strings.TrimSpace(s[end+1:])
+		s = strings.TrimSpace(strings.TrimPrefix(s, ","))
+		if s != "" && s[0] != '[' && s[0] != '(' {
+			return nil, false, errors.Newf("invalid Maven version range %q", s)
+		}
+	}
+
+	return r, true, nil
+}
+
+func parseMavenInterval(opening byte, body string, closing byte) (mavenInterval, error) {
+	interval := mavenInterval{lowerInclusive: opening == '[', upperInclusive: closing == ']'}
+
+	bounds := strings.Split(body, ",")
+	switch len(bounds) {
+	case 1:
+		// [1.0] pins an exact version.
+		if !interval.lowerInclusive || !interval.upperInclusive {
+			return mavenInterval{}, errors.Newf("invalid Maven version range %q: single versions must be enclosed in []", body)
+		}
+		v, err := parseMaven(bounds[0])
+		if err != nil {
+			return mavenInterval{}, err
+		}
+		interval.lower, interval.upper = v, v
+
+	case 2:
+		if lower := strings.TrimSpace(bounds[0]); lower != "" {
+			v, err := parseMaven(lower)
+			if err != nil {
+				return mavenInterval{}, err
+			}
+			interval.lower = v
+		}
+		if upper := strings.TrimSpace(bounds[1]); upper != "" {
+			v, err := parseMaven(upper)
+			if err != nil {
+				return mavenInterval{}, err
+			}
+			interval.upper = v
+		}
+		if interval.lower != nil && interval.upper != nil && interval.lower.compare(interval.upper) > 0 {
+			return mavenInterval{}, errors.Newf("invalid Maven version range %q: lower bound is greater than upper bound", body)
+		}
+
+	default:
+		return mavenInterval{}, errors.Newf("invalid Maven version range %q", body)
+	}
+
+	return interval, nil
+}
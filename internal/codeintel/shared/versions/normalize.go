@@ -0,0 +1,93 @@
+package versions
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/grafana/regexp"
+)
+
+var semverPattern = regexp.MustCompile(`^[vV=]?(\d+\.\d+\.\d+)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// Normalize returns the canonical spelling of the given version in the ecosystem
+// of the given package scheme, so that the same version isn't stored under
+// multiple spellings:
+//
+//   - Go module versions always have a `v` prefix.
+//   - Other semantic versions don't have a `v` or `=` prefix. npm ignores build
+//     metadata, so it is dropped for npm versions.
+//   - PEP 440 versions are normalized as specified by PEP 440, e.g. `1.0-Alpha.1`
+//     becomes `1.0a1`.
+//
+// Maven and RubyGems versions are used verbatim to fetch sources, so only
+// surrounding whitespace is removed. Versions that can't be parsed are returned
+// without surrounding whitespace, but are otherwise unchanged.
+func Normalize(scheme, version string) string {
+	version = strings.TrimSpace(version)
+
+	switch ecosystemForScheme(scheme).name {
+	case pep440Ecosystem.name:
+		return normalizePEP440(version)
+	case semverEcosystem.name:
+		m := semverPattern.FindStringSubmatch(version)
+		if m == nil {
+			return version
+		}
+		switch scheme {
+		case "go", "gomod":
+			return "v" + m[1] + m[2] + m[3]
+		case "npm":
+			return m[1] + m[2]
+		default:
+			return m[1] + m[2] + m[3]
+		}
+	default:
+		return version
+	}
+}
+
+// normalizePEP440 returns the normalized form of a PEP 440 version.
+// See https://peps.python.org/pep-0440/#normalization.
+func normalizePEP440(version string) string {
+	s := strings.ToLower(version)
+	m := pep440Pattern.FindStringSubmatch(s)
+	if m == nil {
+		return version
+	}
+
+	var b strings.Builder
+	if m[1] != "" {
+		b.WriteString(strconv.Itoa(atoiOrZero(m[1])))
+		b.WriteByte('!')
+	}
+	for i, part := range strings.Split(m[2], ".") {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(strconv.Itoa(atoiOrZero(part)))
+	}
+	if m[3] != "" {
+		switch m[3] {
+		case "a", "alpha":
+			b.WriteString("a")
+		case "b", "beta":
+			b.WriteString("b")
+		default:
+			b.WriteString("rc")
+		}
+		b.WriteString(strconv.Itoa(atoiOrZero(m[4])))
+	}
+	if m[5] != "" {
+		b.WriteString(".post" + strconv.Itoa(atoiOrZero(m[5])))
+	} else if m[6] != "" {
+		b.WriteString(".post" + strconv.Itoa(atoiOrZero(m[7])))
+	}
+	if m[8] != "" {
+		b.WriteString(".dev" + strconv.Itoa(atoiOrZero(m[9])))
+	}
+	if i := strings.Index(s, "+"); i >= 0 {
+		local := strings.NewReplacer("-", ".", "_", ".").Replace(s[i+1:])
+		b.WriteString("+" + local)
+	}
+	return b.String()
+}
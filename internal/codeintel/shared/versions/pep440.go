@@ -0,0 +1,146 @@
+package versions
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/grafana/regexp"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// pep440Version is a Python package version as specified by PEP 440.
+type pep440Version struct {
+	epoch   int
+	release []int
+	// pre is the pre-release phase (0 = a, 1 = b, 2 = rc) and number, if any.
+	pre *[2]int
+	// post and dev are the post- and development release numbers, if any.
+	post *int
+	dev  *int
+}
+
+var pep440Pattern = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|alpha|b|beta|c|rc|pre|preview)[-_.]?(\d*))?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+	`(?:[-_.]?(dev)[-_.]?(\d*))?` +
+	`(?:\+[a-z0-9]+(?:[-_.][a-z0-9]+)*)?$`)
+
+func parsePEP440(s string) (version, error) {
+	m := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return nil, errors.Newf("invalid PEP 440 version %q", s)
+	}
+
+	v := pep440Version{}
+	if m[1] != "" {
+		v.epoch, _ = strconv.Atoi(m[1])
+	}
+	for _, part := range strings.Split(m[2], ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, errors.Newf("invalid PEP 440 version %q", s)
+		}
+		v.release = append(v.release, n)
+	}
+	// Trailing zeros are insignificant: 1.0 == 1.0.0.
+	for len(v.release) > 1 && v.release[len(v.release)-1] == 0 {
+		v.release = v.release[:len(v.release)-1]
+	}
+
+	if m[3] != "" {
+		phase := 2
+		switch m[3] {
+		case "a", "alpha":
+			phase = 0
+		case "b", "beta":
+			phase = 1
+		}
+		v.pre = &[2]int{phase, atoiOrZero(m[4])}
+	}
+	if m[5] != "" {
+		n := atoiOrZero(m[5])
+		v.post = &n
+	} else if m[6] != "" {
+		n := atoiOrZero(m[7])
+		v.post = &n
+	}
+	if m[8] != "" {
+		n := atoiOrZero(m[9])
+		v.dev = &n
+	}
+
+	return v, nil
+}
+
+func (v pep440Version) compare(other version) int {
+	o := other.(pep440Version)
+
+	if c := compareInts(v.epoch, o.epoch); c != 0 {
+		return c
+	}
+	if c := compareSegments(v.release, o.release); c != 0 {
+		return c
+	}
+	if c := compareInts(v.preKey(), o.preKey()); c != 0 {
+		return c
+	}
+	if v.pre != nil && o.pre != nil {
+		if c := compareInts(v.pre[0], o.pre[0]); c != 0 {
+			return c
+		}
+		if c := compareInts(v.pre[1], o.pre[1]); c != 0 {
+			return c
+		}
+	}
+	if c := compareOptional(v.post, o.post, -1); c != 0 {
+		return c
+	}
+	// Development releases sort before the release they precede.
+	return compareOptional(v.dev, o.dev, 1)
+}
+
+// preKey orders versions that differ only in whether they are pre-releases: a
+// development release without a pre-release or post-release segment (1.0.dev1)
+// sorts before any pre-release (1.0a1), which sorts before the final release.
+func (v pep440Version) preKey() int {
+	switch {
+	case v.pre == nil && v.post == nil && v.dev != nil:
+		return -1
+	case v.pre != nil:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// compareOptional compares two optional numbers, where a missing number compares
+// as the given sign relative to any present number.
+func compareOptional(a, b *int, missing int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return missing
+	case b == nil:
+		return -missing
+	default:
+		return compareInts(*a, *b)
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func atoiOrZero(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,106 @@
+package versions
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/grafana/regexp"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// rubygemsVersion is a RubyGems version as ordered by Gem::Version: segments are
+// compared pairwise, numbers compare numerically and sort after strings, and a
+// version containing a letter is a pre-release (1.0.a < 1.0).
+type rubygemsVersion struct {
+	segments []rubygemsSegment
+}
+
+type rubygemsSegment struct {
+	number *big.Int
+	str    string
+}
+
+var (
+	rubygemsPattern        = regexp.MustCompile(`^[0-9]+(?:\.[0-9a-zA-Z]+)*(?:-[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+	rubygemsSegmentPattern = regexp.MustCompile(`[0-9]+|[a-z]+`)
+)
+
+func parseRubyGems(s string) (version, error) {
+	s = strings.TrimSpace(s)
+	if !rubygemsPattern.MatchString(s) {
+		return nil, errors.Newf("invalid RubyGems version %q", s)
+	}
+	// Like Gem::Version, treat hyphens as pre-release markers (1.0-1 is 1.0.pre.1).
+	s = strings.ReplaceAll(s, "-", ".pre.")
+
+	var segments []rubygemsSegment
+	for _, part := range rubygemsSegmentPattern.FindAllString(strings.ToLower(s), -1) {
+		if n, ok := new(big.Int).SetString(part, 10); ok {
+			segments = append(segments, rubygemsSegment{number: n})
+		} else {
+			segments = append(segments, rubygemsSegment{str: part})
+		}
+	}
+
+	return rubygemsVersion{segments: canonicalRubyGemsSegments(segments)}, nil
+}
+
+// canonicalRubyGemsSegments drops trailing zero segments, as well as zero
+// segments directly preceding the first string segment, so that 1.0 == 1 and
+// 1.0.a == 1.a.
+func canonicalRubyGemsSegments(segments []rubygemsSegment) []rubygemsSegment {
+	firstString := len(segments)
+	for i, s := range segments {
+		if s.number == nil {
+			firstString = i
+			break
+		}
+	}
+
+	release := trimZeroRubyGemsSegments(segments[:firstString])
+	prerelease := trimZeroRubyGemsSegments(segments[firstString:])
+	return append(append([]rubygemsSegment(nil), release...), prerelease...)
+}
+
+func trimZeroRubyGemsSegments(segments []rubygemsSegment) []rubygemsSegment {
+	for len(segments) > 0 {
+		last := segments[len(segments)-1]
+		if last.number == nil || last.number.Sign() != 0 {
+			break
+		}
+		segments = segments[:len(segments)-1]
+	}
+	return segments
+}
+
+func (v rubygemsVersion) compare(other version) int {
+	a, b := v.segments, other.(rubygemsVersion).segments
+
+	for i := 0; i < len(a) || i < len(b); i++ {
+		// Missing segments compare as zero.
+		x, y := rubygemsSegment{number: new(big.Int)}, rubygemsSegment{number: new(big.Int)}
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+
+		switch {
+		case x.number != nil && y.number != nil:
+			if c := x.number.Cmp(y.number); c != 0 {
+				return c
+			}
+		case x.number != nil:
+			return 1
+		case y.number != nil:
+			return -1
+		default:
+			if c := strings.Compare(x.str, y.str); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+// Package versions compares package versions and matches them against version
+// constraints using the rules of the ecosystem a package scheme belongs to.
+// Semantic versions, PEP 440 (Python), Maven and RubyGems versions order
+// differently, so versions must never be compared as plain strings.
+package versions
+
+import (
+	"strconv"
+	"strings"
+
+	goversion "github.com/hashicorp/go-version"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// version is a parsed version of a single ecosystem. Versions are only ever
+// compared with versions parsed by the same ecosystem.
+type version interface {
+	compare(other version) int
+}
+
+type ecosystem struct {
+	name  string
+	parse func(string) (version, error)
+	// parseRange is set for ecosystems with their own range syntax, such as
+	// Maven's `[1.0,2.0)`. It reports false if the constraint is not a range.
+	parseRange func(string) (versionRange, bool, error)
+}
+
+var (
+	semverEcosystem   = ecosystem{name: "semver", parse: parseSemver}
+	pep440Ecosystem   = ecosystem{name: "pep440", parse: parsePEP440}
+	mavenEcosystem    = ecosystem{name: "maven", parse: parseMaven, parseRange: parseMavenRange}
+	rubygemsEcosystem = ecosystem{name: "rubygems", parse: parseRubyGems}
+)
+
+// ecosystemForScheme returns the versioning rules for the given package scheme.
+// Both the schemes of package repos and those of precise code intel indexers are
+// recognized. Unknown schemes fall back to (lenient) semantic versioning.
+func ecosystemForScheme(scheme string) ecosystem {
+	switch scheme {
+	case "python", "pip", "pypi":
+		return pep440Ecosystem
+	case "semanticdb", "maven", "jvm-dependencies":
+		return mavenEcosystem
+	case "scip-ruby", "rubygems", "gem":
+		return rubygemsEcosystem
+	default:
+		// go, gomod, npm, rust-analyzer, cargo, ...
+		return semverEcosystem
+	}
+}
+
+// Compare returns -1, 0 or 1 if version a is respectively lower than, equal to or
+// greater than version b, according to the versioning rules of the given scheme.
+func Compare(scheme, a, b string) (int, error) {
+	eco := ecosystemForScheme(scheme)
+
+	va, err := eco.parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := eco.parse(b)
+	if err != nil {
+		return 0, err
+	}
+
+	return va.compare(vb), nil
+}
+
+type semverVersion struct {
+	v *goversion.Version
+}
+
+func parseSemver(s string) (version, error) {
+	v, err := goversion.NewVersion(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid semantic version %q", s)
+	}
+	return semverVersion{v: v}, nil
+}
+
+func (v semverVersion) compare(other version) int {
+	return v.v.Compare(other.(semverVersion).v)
+}
+
+// releaseSegments returns the leading dot-separated numeric segments of the given
+// version, ignoring a `v` prefix and a PEP 440 epoch. For example, `v1.2.3-rc1`
+// yields [1 2 3]. These are used to compute the upper bounds of `^`, `~`, `~>`
+// and `~=` constraints and to match wildcards, which are defined in terms of the
+// release segments in every ecosystem.
+func releaseSegments(s string) []int {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.Index(s, "!"); i >= 0 {
+		s = s[i+1:]
+	}
+
+	var segments []int
+	for _, part := range strings.Split(s, ".") {
+		end := 0
+		for end < len(part) && part[end] >= '0' && part[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			break
+		}
+		n, err := strconv.Atoi(part[:end])
+		if err != nil {
+			break
+		}
+		segments = append(segments, n)
+		if end != len(part) {
+			break
+		}
+	}
+	return segments
+}
+
+// compareSegments compares two lists of numeric segments, treating missing
+// trailing segments as zero.
+func compareSegments(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
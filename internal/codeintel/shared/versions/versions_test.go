@@ -0,0 +1,208 @@
+package versions
+
+import (
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	testCases := []struct {
+		scheme string
+		a, b   string
+		want   int
+	}{
+		// Semantic versions
+		{"npm", "1.2.3", "1.2.3", 0},
+		{"npm", "1.2.3", "1.10.0", -1},
+		{"gomod", "v1.10.0", "v1.9.0", 1},
+		{"npm", "1.0.0-rc.1", "1.0.0", -1},
+		{"npm", "1.0.0-alpha", "1.0.0-beta", -1},
+		{"rust-analyzer", "1.0", "1.0.0", 0},
+
+		// PEP 440
+		{"python", "1.0", "1.0.0", 0},
+		{"python", "1.0.dev1", "1.0a1", -1},
+		{"python", "1.0a1", "1.0b1", -1},
+		{"python", "1.0b2", "1.0rc1", -1},
+		{"python", "1.0rc1", "1.0", -1},
+		{"python", "1.0", "1.0.post1", -1},
+		{"python", "1.0.post1.dev1", "1.0.post1", -1},
+		{"python", "1.0a1.dev1", "1.0a1", -1},
+		{"python", "1!0.1", "2.0", 1},
+		{"python", "1.0-1", "1.0.post1", 0},
+		{"python", "1.10", "1.9", 1},
+
+		// Maven
+		{"semanticdb", "1.0", "1", 0},
+		{"semanticdb", "1.0-ga", "1.0", 0},
+		{"semanticdb", "1.0-alpha-1", "1.0-beta-1", -1},
+		{"semanticdb", "1.0-rc1", "1.0-SNAPSHOT", -1},
+		{"semanticdb", "1.0-SNAPSHOT", "1.0", -1},
+		{"semanticdb", "1.0", "1.0-sp1", -1},
+		{"semanticdb", "1.0-sp1", "1.0.1", -1},
+		{"semanticdb", "1.0-a1", "1.0-alpha-1", 0},
+		{"semanticdb", "2.0.10", "2.0.9", 1},
+		{"semanticdb", "1.0-foo", "1.0", 1},
+
+		// RubyGems
+		{"scip-ruby", "1.0", "1", 0},
+		{"scip-ruby", "1.0.a", "1.0", -1},
+		{"scip-ruby", "1.0.a", "1.0.b", -1},
+		{"scip-ruby", "1.0.rc1", "1.0.0", -1},
+		{"scip-ruby", "1.10.0", "1.9.9", 1},
+		{"scip-ruby", "1.0.0-1", "1.0.0", -1},
+	}
+
+	for _, testCase := range testCases {
+		got, err := Compare(testCase.scheme, testCase.a, testCase.b)
+		if err != nil {
+			t.Fatalf("unexpected error comparing %q and %q (%s): %s", testCase.a, testCase.b, testCase.scheme, err)
+		}
+		if got != testCase.want {
+			t.Errorf("unexpected result comparing %q and %q (%s). want=%d have=%d", testCase.a, testCase.b, testCase.scheme, testCase.want, got)
+		}
+
+		reversed, err := Compare(testCase.scheme, testCase.b, testCase.a)
+		if err != nil {
+			t.Fatalf("unexpected error comparing %q and %q (%s): %s", testCase.b, testCase.a, testCase.scheme, err)
+		}
+		if reversed != -testCase.want {
+			t.Errorf("unexpected result comparing %q and %q (%s). want=%d have=%d", testCase.b, testCase.a, testCase.scheme, -testCase.want, reversed)
+		}
+	}
+}
+
+func TestMatchesConstraints(t *testing.T) {
+	testCases := []struct {
+		scheme      string
+		version     string
+		constraints []string
+		want        bool
+	}{
+		// Comparison operators
+		{"npm", "1.2.3", []string{">= 1.2.0, < 2.0.0"}, true},
+		{"npm", "2.0.0", []string{">= 1.2.0, < 2.0.0"}, false},
+		{"npm", "1.2.3", []string{">=1.2.0 <2.0.0"}, true},
+		{"npm", "1.2.3", []string{">= 1.2.0", "< 1.2.3"}, false},
+		{"npm", "1.2.3", []string{"!= 1.2.3"}, false},
+		{"gomod", "v1.2.3", []string{"= v1.2.3"}, true},
+
+		// Alternatives
+		{"npm", "1.5.0", []string{"< 1.0.0 || >= 1.4.0"}, true},
+		{"npm", "1.2.0", []string{"< 1.0.0 || >= 1.4.0"}, false},
+
+		// Wildcards
+		{"npm", "1.2.9", []string{"1.2.x"}, true},
+		{"npm", "1.3.0", []string{"1.2.*"}, false},
+		{"python", "1.2.9", []string{"== 1.2.*"}, true},
+		{"python", "1.3", []string{"!= 1.2.*"}, true},
+		{"npm", "1.2.3", []string{"*"}, true},
+
+		// Caret and tilde ranges
+		{"npm", "1.9.0", []string{"^1.2.3"}, true},
+		{"npm", "2.0.0", []string{"^1.2.3"}, false},
+		{"npm", "1.2.2", []string{"^1.2.3"}, false},
+		{"npm", "0.2.9", []string{"^0.2.3"}, true},
+		{"npm", "0.3.0", []string{"^0.2.3"}, false},
+		{"npm", "1.2.9", []string{"~1.2.3"}, true},
+		{"npm", "1.3.0", []string{"~1.2.3"}, false},
+		{"npm", "1.9.0", []string{"~1"}, true},
+
+		// Pessimistic and compatible release operators
+		{"scip-ruby", "1.2.9", []string{"~> 1.2.3"}, true},
+		{"scip-ruby", "1.3.0", []string{"~> 1.2.3"}, false},
+		{"scip-ruby", "1.9", []string{"~> 1.2"}, true},
+		{"scip-ruby", "2.0", []string{"~> 1.2"}, false},
+		{"python", "2.2.1", []string{"~= 2.2"}, true},
+		{"python", "3.0", []string{"~= 2.2"}, false},
+		{"python", "1.4.5", []string{"~= 1.4.5"}, true},
+		{"python", "1.5.0", []string{"~= 1.4.5"}, false},
+
+		// Ecosystem-specific ordering
+		{"python", "1.0rc1", []string{"< 1.0"}, true},
+		{"python", "1.0.post1", []string{"> 1.0"}, true},
+		{"scip-ruby", "2.0.0.rc1", []string{">= 2.0.0"}, false},
+		{"semanticdb", "1.0-SNAPSHOT", []string{"< 1.0"}, true},
+
+		// Maven ranges
+		{"semanticdb", "1.5", []string{"[1.0,2.0)"}, true},
+		{"semanticdb", "2.0", []string{"[1.0,2.0)"}, false},
+		{"semanticdb", "1.0", []string{"(1.0,2.0]"}, false},
+		{"semanticdb", "0.9", []string{"(,1.0]"}, true},
+		{"semanticdb", "3.1", []string{"[1.0,)"}, true},
+		{"semanticdb", "1.5", []string{"[1.5]"}, true},
+		{"semanticdb", "1.6", []string{"[1.5]"}, false},
+		{"semanticdb", "3.5", []string{"(,1.0],[3.0,)"}, true},
+		{"semanticdb", "2.0", []string{"(,1.0],[3.0,)"}, false},
+		{"semanticdb", "1.5", []string{">= 1.0, < 2.0"}, true},
+	}
+
+	for _, testCase := range testCases {
+		got, err := MatchesConstraints(testCase.scheme, testCase.version, testCase.constraints)
+		if err != nil {
+			t.Fatalf("unexpected error matching %q against %q (%s): %s", testCase.version, testCase.constraints, testCase.scheme, err)
+		}
+		if got != testCase.want {
+			t.Errorf("unexpected result matching %q against %q (%s). want=%v have=%v", testCase.version, testCase.constraints, testCase.scheme, testCase.want, got)
+		}
+	}
+}
+
+func TestMatchesConstraintsInvalid(t *testing.T) {
+	testCases := []struct {
+		scheme      string
+		version     string
+		constraints []string
+	}{
+		{"npm", "not-a-version", []string{">= 1.0.0"}},
+		{"npm", "1.0.0", []string{">= not-a-version"}},
+		{"npm", "1.0.0", []string{"> 1.*"}},
+		{"python", "1.0", []string{"~= 1"}},
+		{"semanticdb", "1.0", []string{"[1.0,2.0"}},
+		{"semanticdb", "1.0", []string{"[2.0,1.0]"}},
+		{"semanticdb", "1.0", []string{"(1.0)"}},
+	}
+
+	for _, testCase := range testCases {
+		if _, err := MatchesConstraints(testCase.scheme, testCase.version, testCase.constraints); err == nil {
+			t.Errorf("expected error matching %q against %q (%s)", testCase.version, testCase.constraints, testCase.scheme)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		scheme  string
+		version string
+		want    string
+	}{
+		// Semantic versions
+		{"npm", "v1.2.3", "1.2.3"},
+		{"npm", "=1.2.3-rc.1", "1.2.3-rc.1"},
+		{"npm", "1.2.3+build.5", "1.2.3"},
+		{"rust-analyzer", " v1.2.3+build.5 ", "1.2.3+build.5"},
+		{"go", "1.2.3", "v1.2.3"},
+		{"go", "v2.0.0+incompatible", "v2.0.0+incompatible"},
+		{"npm", "latest", "latest"},
+		{"npm", "v1.2", "v1.2"},
+
+		// PEP 440
+		{"python", "1.0", "1.0"},
+		{"python", "v1.0-Alpha.1", "1.0a1"},
+		{"python", "1.0c1", "1.0rc1"},
+		{"python", "1.0-1", "1.0.post1"},
+		{"python", "1.0.rev2-dev", "1.0.post2.dev0"},
+		{"python", "01.02", "1.2"},
+		{"python", "1.0+Ubuntu-1", "1.0+ubuntu.1"},
+		{"python", "not-a-version", "not-a-version"},
+
+		// Maven and RubyGems
+		{"semanticdb", " 1.0-SNAPSHOT ", "1.0-SNAPSHOT"},
+		{"scip-ruby", "1.0.rc1", "1.0.rc1"},
+	}
+
+	for _, testCase := range testCases {
+		if got := Normalize(testCase.scheme, testCase.version); got != testCase.want {
+			t.Errorf("unexpected normalized version of %q (%s). want=%q have=%q", testCase.version, testCase.scheme, testCase.want, got)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package codemonitors
 
 import (
 	"context"
-	"sort"
 	"sync"
 
 	"github.com/sourcegraph/log"
@@ -173,68 +172,33 @@ func hookWithID(
 ) error {
 	cm := db.CodeMonitors()
 
-	// Resolve the requested revisions into a static set of commit hashes
-	commitHashes, err := gs.ResolveRevisions(ctx, args.Repo, args.Revisions)
-	if err != nil {
-		return err
-	}
-
 	// Look up the previously searched set of commit hashes
 	lastSearched, err := cm.GetLastSearched(ctx, monitorID, repoID)
 	if err != nil {
 		return err
 	}
-	if stringsEqual(commitHashes, lastSearched) {
-		// Early return if the repo hasn't changed since last search
-		return nil
-	}
 
-	// Merge requested hashes and excluded hashes
-	newRevs := make([]gitprotocol.RevisionSpecifier, 0, len(commitHashes)+len(lastSearched))
-	for _, hash := range commitHashes {
-		newRevs = append(newRevs, gitprotocol.RevisionSpecifier{RevSpec: hash})
-	}
-	for _, exclude := range lastSearched {
-		newRevs = append(newRevs, gitprotocol.RevisionSpecifier{RevSpec: "^" + exclude})
+	commitHashes, searchErr := commit.SearchAfterWatermark(ctx, gs, args, lastSearched, doSearch)
+	if searchErr != nil && commitHashes == nil {
+		// The revisions could not be resolved, so nothing was searched.
+		return searchErr
 	}
 
-	// Update args with the new set of revisions
-	argsCopy := *args
-	argsCopy.Revisions = newRevs
-
-	// Execute the search
-	searchErr := doSearch(&argsCopy)
-
 	// NOTE(camdencheek): we want to always save the "last searched" commits
 	// because if we stream results, the user will get a notification for them
 	// whether or not there was an error and forcing a re-search will cause the
 	// user to get repeated notifications for the same commits. This makes code
 	// monitors look very broken, and should be avoided.
-	upsertErr := cm.UpsertLastSearched(ctx, monitorID, repoID, commitHashes)
-	if upsertErr != nil {
-		return upsertErr
+	//
+	// Most runs find no new commits though, so skip the write if the
+	// watermark didn't advance.
+	if !commit.SameWatermark(commitHashes, lastSearched) {
+		upsertErr := cm.UpsertLastSearched(ctx, monitorID, repoID, commitHashes)
+		if upsertErr != nil {
+			return upsertErr
+		}
 	}
 
 	// Still return the error so it can be displayed to the user
-	if searchErr != nil {
-		return errors.Wrap(searchErr, "search failed, some commits may be skipped")
-	}
-
-	return nil
-}
-
-func stringsEqual(left, right []string) bool {
-	if len(left) != len(right) {
-		return false
-	}
-
-	sort.Strings(left)
-	sort.Strings(right)
-
-	for i := range left {
-		if right[i] != left[i] {
-			return false
-		}
-	}
-	return true
+	return searchErr
 }
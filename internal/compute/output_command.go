@@ -103,6 +103,11 @@ func resultChunks(r result.Match, kind string, onlyPath bool) []string {
 		return []string{content}
 	case *result.OwnerMatch:
 		return []string{m.ResolvedOwner.Identifier()}
+	case *result.CommitAuthorMatch:
+		if !m.Date.IsZero() {
+			return []string{m.Date.Format("2006-01-02")}
+		}
+		return []string{m.Name + " <" + m.Email + ">"}
 	default:
 		panic("unsupported result kind in compute output command")
 	}
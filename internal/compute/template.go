@@ -197,6 +197,7 @@ type MetaEnvironment struct {
 	Email   string
 	Lang    string
 	Owner   string
+	Symbols string
 }
 
 var empty = struct{}{}
@@ -214,6 +215,7 @@ var builtinVariables = map[string]struct{}{
 	"date.year":       empty,
 	"email":           empty,
 	"lang":            empty,
+	"symbols":         empty,
 }
 
 func templatize(pattern string, env *MetaEnvironment) string {
@@ -302,6 +304,7 @@ func NewMetaEnvironment(r searchresult.Match, content string) *MetaEnvironment {
 			Path:    path,
 			Lang:    lang,
 			Content: content,
+			Symbols: hunkSymbols(m.Hunks),
 		}
 	case *searchresult.OwnerMatch:
 		return &MetaEnvironment{
@@ -310,6 +313,47 @@ func NewMetaEnvironment(r searchresult.Match, content string) *MetaEnvironment {
 			Owner:   m.ResolvedOwner.Identifier(),
 			Content: content,
 		}
+	case *searchresult.CommitAuthorMatch:
+		return &MetaEnvironment{
+			Repo:    string(m.Repo.Name),
+			Author:  m.Name,
+			Date:    m.Date,
+			Email:   m.Email,
+			Content: content,
+		}
 	}
 	return &MetaEnvironment{}
 }
+
+// hunkSymbols returns the distinct symbols enclosing hunks as a comma-separated
+// list of "kind name" entries, in the order they first appear.
+func hunkSymbols(hunks []searchresult.Hunk) string {
+	var symbols []string
+	seen := map[searchresult.HunkSymbol]struct{}{}
+	for _, hunk := range hunks {
+		if hunk.EnclosingSymbol == nil {
+			continue
+		}
+		if _, ok := seen[*hunk.EnclosingSymbol]; ok {
+			continue
+		}
+		seen[*hunk.EnclosingSymbol] = empty
+		symbols = append(symbols, hunk.EnclosingSymbol.Kind+" "+hunk.EnclosingSymbol.Name)
+	}
+	return strings.Join(symbols, ", ")
+}
+
+// NeedsSymbols returns true if cmd references the $symbols variable, in which
+// case the symbols enclosing diff hunks must be resolved before running it.
+func NeedsSymbols(cmd Command) bool {
+	c, ok := cmd.(*Output)
+	if !ok {
+		return false
+	}
+	for _, atom := range *scanTemplate([]byte(c.OutputPattern)) {
+		if v, ok := atom.(Variable); ok && v.Name == "$symbols" {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,9 @@ import (
 	"testing"
 
 	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+
+	searchresult "github.com/sourcegraph/sourcegraph/internal/search/result"
 )
 
 func Test_scanTemplate(t *testing.T) {
@@ -63,4 +66,26 @@ func Test_substituteMetaVariables(t *testing.T) {
 			"artifcats: $1 $foo $author",
 			&MetaEnvironment{Author: "hi"},
 		))
+
+	autogold.Expect("changed: function main, method Run").
+		Equal(t, test(
+			"changed: $symbols",
+			NewMetaEnvironment(&searchresult.CommitDiffMatch{DiffFile: &searchresult.DiffFile{
+				OrigName: "main.go",
+				NewName:  "main.go",
+				Hunks: []searchresult.Hunk{
+					{EnclosingSymbol: &searchresult.HunkSymbol{Name: "main", Kind: "function"}},
+					{},
+					{EnclosingSymbol: &searchresult.HunkSymbol{Name: "Run", Kind: "method"}},
+					{EnclosingSymbol: &searchresult.HunkSymbol{Name: "main", Kind: "function"}},
+				},
+			}}, ""),
+		))
+}
+
+func TestNeedsSymbols(t *testing.T) {
+	require.True(t, NeedsSymbols(&Output{OutputPattern: "$repo: $symbols"}))
+	require.False(t, NeedsSymbols(&Output{OutputPattern: "$repo: $symbolsx"}))
+	require.False(t, NeedsSymbols(&Output{OutputPattern: "$repo"}))
+	require.False(t, NeedsSymbols(&MatchOnly{}))
 }
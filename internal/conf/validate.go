@@ -221,6 +221,7 @@ var siteConfigSecrets = []struct {
 	{readPath: `embeddings.accessToken`, editPaths: []string{"embeddings", "accessToken"}},
 	{readPath: `completions.accessToken`, editPaths: []string{"completions", "accessToken"}},
 	{readPath: `app.dotcomAuthToken`, editPaths: []string{"app", "dotcomAuthToken"}},
+	{readPath: `experimentalFeatures.search\.export\.opensearch.password`, editPaths: []string{"experimentalFeatures", "search.export.opensearch", "password"}},
 }
 
 // UnredactSecrets unredacts unchanged secrets back to their original value for
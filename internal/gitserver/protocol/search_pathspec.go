@@ -0,0 +1,198 @@
+package protocol
+
+import (
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// Pathspecs returns git pathspecs that limit the commits git log lists to the
+// ones that can match the query, or nil if the query can't be limited this
+// way.
+//
+// Limiting the commits also limits the modified files git log reports to the
+// pathspecs, so the query is only limited when the modified files aren't
+// requested and every file filter of the query can be expressed as pathspecs.
+func (r *SearchRequest) Pathspecs() []string {
+	if r.IncludeModifiedFiles {
+		return nil
+	}
+	return QueryPathspecs(r.Query)
+}
+
+// QueryPathspecs returns git pathspecs matching a superset of the files the
+// DiffModifiesFile predicates of the query match. It returns nil unless the
+// query only matches commits that modify one of these files and all the
+// predicates can be converted, outside of any negation.
+func QueryPathspecs(n Node) []string {
+	if !requiresModifiedFile(n) {
+		return nil
+	}
+
+	var pathspecs []string
+	seen := make(map[string]struct{})
+	ok := true
+	var visit func(Node)
+	visit = func(n Node) {
+		switch v := n.(type) {
+		case *DiffModifiesFile:
+			specs, converted := regexpToPathspecs(v.Expr, v.IgnoreCase)
+			if !converted {
+				ok = false
+				return
+			}
+			for _, spec := range specs {
+				if _, dup := seen[spec]; !dup {
+					seen[spec] = struct{}{}
+					pathspecs = append(pathspecs, spec)
+				}
+			}
+		case *Operator:
+			if v.Kind == Not {
+				// A negated file filter must see every modified file.
+				if containsDiffModifiesFile(v) {
+					ok = false
+				}
+				return
+			}
+			for _, operand := range v.Operands {
+				visit(operand)
+			}
+		}
+	}
+	visit(n)
+
+	if !ok {
+		return nil
+	}
+	return pathspecs
+}
+
+// requiresModifiedFile returns whether n only matches commits for which one of
+// its DiffModifiesFile predicates matches.
+func requiresModifiedFile(n Node) bool {
+	switch v := n.(type) {
+	case *DiffModifiesFile:
+		return true
+	case *Operator:
+		switch v.Kind {
+		case And:
+			for _, operand := range v.Operands {
+				if requiresModifiedFile(operand) {
+					return true
+				}
+			}
+		case Or:
+			for _, operand := range v.Operands {
+				if !requiresModifiedFile(operand) {
+					return false
+				}
+			}
+			return len(v.Operands) > 0
+		}
+	}
+	return false
+}
+
+func containsDiffModifiesFile(n Node) bool {
+	switch v := n.(type) {
+	case *DiffModifiesFile:
+		return true
+	case *Operator:
+		for _, operand := range v.Operands {
+			if containsDiffModifiesFile(operand) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// regexpToPathspecs converts a file regexp to pathspecs. Only literals,
+// optionally anchored or surrounded by `.*`, and alternations of these are
+// converted.
+func regexpToPathspecs(expr string, ignoreCase bool) ([]string, bool) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, false
+	}
+	re = re.Simplify()
+
+	alternatives := []*syntax.Regexp{re}
+	if re.Op == syntax.OpAlternate {
+		alternatives = re.Sub
+	}
+
+	pathspecs := make([]string, 0, len(alternatives))
+	for _, alternative := range alternatives {
+		pathspec, ok := literalToPathspec(alternative, ignoreCase)
+		if !ok {
+			return nil, false
+		}
+		pathspecs = append(pathspecs, pathspec)
+	}
+	return pathspecs, true
+}
+
+func literalToPathspec(re *syntax.Regexp, ignoreCase bool) (string, bool) {
+	parts := []*syntax.Regexp{re}
+	if re.Op == syntax.OpConcat {
+		parts = re.Sub
+	}
+
+	anchoredStart, anchoredEnd := false, false
+	if len(parts) > 0 && parts[0].Op == syntax.OpBeginText {
+		anchoredStart = true
+		parts = parts[1:]
+	} else if len(parts) > 0 && isAnyString(parts[0]) {
+		parts = parts[1:]
+	}
+	if len(parts) > 0 && parts[len(parts)-1].Op == syntax.OpEndText {
+		anchoredEnd = true
+		parts = parts[:len(parts)-1]
+	} else if len(parts) > 0 && isAnyString(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+
+	if len(parts) != 1 || parts[0].Op != syntax.OpLiteral {
+		return "", false
+	}
+	literal := parts[0]
+	if literal.Flags&syntax.FoldCase != 0 {
+		ignoreCase = true
+	}
+
+	var b strings.Builder
+	if ignoreCase {
+		b.WriteString(":(icase)")
+	} else if anchoredStart && strings.HasPrefix(string(literal.Rune), ":") {
+		// A leading colon would be read as pathspec magic.
+		return "", false
+	}
+	// Without the glob magic, wildcards match across directories.
+	if !anchoredStart {
+		b.WriteByte('*')
+	}
+	for _, r := range literal.Rune {
+		if ignoreCase {
+			// Case folded literals hold the smallest rune of each fold
+			// orbit, e.g. `README` for (?i)readme. icase matches any case,
+			// so spell them the way they were most likely written.
+			r = unicode.ToLower(r)
+		}
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	if !anchoredEnd {
+		b.WriteByte('*')
+	}
+	return b.String(), true
+}
+
+// isAnyString returns whether re is `.*`.
+func isAnyString(re *syntax.Regexp) bool {
+	return re.Op == syntax.OpStar && len(re.Sub) == 1 &&
+		(re.Sub[0].Op == syntax.OpAnyCharNotNL || re.Sub[0].Op == syntax.OpAnyChar)
+}
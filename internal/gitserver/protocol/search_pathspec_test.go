@@ -0,0 +1,53 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryPathspecs(t *testing.T) {
+	file := func(expr string) Node { return &DiffModifiesFile{Expr: expr} }
+	and := func(operands ...Node) Node { return &Operator{Kind: And, Operands: operands} }
+	or := func(operands ...Node) Node { return &Operator{Kind: Or, Operands: operands} }
+	not := func(operand Node) Node { return &Operator{Kind: Not, Operands: []Node{operand}} }
+	diff := &DiffMatches{Expr: "foo"}
+
+	cases := []struct {
+		name  string
+		query Node
+		want  []string
+	}{
+		{"unanchored literal", file(`internal/`), []string{"*internal/*"}},
+		{"anchored literal", file(`^internal/search/`), []string{"internal/search/*"}},
+		{"extension", file(`\.go$`), []string{"*.go"}},
+		{"surrounding wildcards", file(`^cmd/.*`), []string{"cmd/*"}},
+		{"glob characters are escaped", file(`^a\*b\?`), []string{`a\*b\?*`}},
+		{"ignore case", &DiffModifiesFile{Expr: "readme", IgnoreCase: true}, []string{":(icase)*readme*"}},
+		{"case folding flag", file(`(?i)readme`), []string{":(icase)*readme*"}},
+		{"case folding flag with capitals", file(`(?i)^Makefile$`), []string{":(icase)makefile"}},
+		{"alternation", file(`(?:\.go$)|(?:^client/)`), []string{"*.go", "client/*"}},
+		{"conjunction with other predicates", and(diff, file(`^a/`)), []string{"a/*"}},
+		{"every file filter is included", and(file(`^a/`), file(`^b/`)), []string{"a/*", "b/*"}},
+		{"disjunction of file filters", or(file(`^a/`), and(diff, file(`^b/`))), []string{"a/*", "b/*"}},
+		{"disjunction without file filter", or(file(`^a/`), diff), nil},
+		{"no file filter", diff, nil},
+		{"negated file filter", and(file(`^a/`), not(file(`^b/`))), nil},
+		{"only negated file filter", not(file(`^a/`)), nil},
+		{"character class", file(`^[ab]/`), nil},
+		{"unconvertible filter", and(file(`^a/`), file(`^b.c`)), nil},
+		{"leading colon", file(`^:a`), nil},
+		{"invalid regexp", file(`(`), nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, QueryPathspecs(tc.query))
+		})
+	}
+
+	t.Run("modified files requested", func(t *testing.T) {
+		r := &SearchRequest{Query: file(`^a/`), IncludeModifiedFiles: true}
+		require.Nil(t, r.Pathspecs())
+	})
+}
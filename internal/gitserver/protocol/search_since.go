@@ -0,0 +1,43 @@
+package protocol
+
+import "time"
+
+// Since returns the time git log can be limited to with --since, or the zero
+// time if the query matches commits of any age. See QuerySince.
+func (r *SearchRequest) Since() time.Time {
+	return QuerySince(r.Query)
+}
+
+// QuerySince returns the latest time that every commit the query matches is
+// committed after, or the zero time if there is no such bound. Only CommitAfter
+// predicates outside of any negation bound the query.
+func QuerySince(n Node) time.Time {
+	switch v := n.(type) {
+	case *CommitAfter:
+		return v.Time
+	case *Operator:
+		switch v.Kind {
+		case And:
+			var since time.Time
+			for _, operand := range v.Operands {
+				if t := QuerySince(operand); t.After(since) {
+					since = t
+				}
+			}
+			return since
+		case Or:
+			var since time.Time
+			for i, operand := range v.Operands {
+				t := QuerySince(operand)
+				if t.IsZero() {
+					return time.Time{}
+				}
+				if i == 0 || t.Before(since) {
+					since = t
+				}
+			}
+			return since
+		}
+	}
+	return time.Time{}
+}
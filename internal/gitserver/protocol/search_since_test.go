@@ -0,0 +1,38 @@
+package protocol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuerySince(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2021, 9, d, 0, 0, 0, 0, time.UTC) }
+	after := func(d int) Node { return &CommitAfter{Time: day(d)} }
+	and := func(operands ...Node) Node { return &Operator{Kind: And, Operands: operands} }
+	or := func(operands ...Node) Node { return &Operator{Kind: Or, Operands: operands} }
+	not := func(operand Node) Node { return &Operator{Kind: Not, Operands: []Node{operand}} }
+	diff := &DiffMatches{Expr: "foo"}
+
+	cases := []struct {
+		name  string
+		query Node
+		want  time.Time
+	}{
+		{"after", after(8), day(8)},
+		{"conjunction with other predicates", and(diff, after(8)), day(8)},
+		{"latest of a conjunction", and(after(8), after(10)), day(10)},
+		{"earliest of a disjunction", or(after(10), and(diff, after(8))), day(8)},
+		{"disjunction without bound", or(after(8), diff), time.Time{}},
+		{"negated bound", and(diff, not(after(8))), time.Time{}},
+		{"before", &CommitBefore{Time: day(8)}, time.Time{}},
+		{"no bound", diff, time.Time{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, QuerySince(tc.query))
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"os/exec"
 	"strings"
+	"time"
 
 	godiff "github.com/sourcegraph/go-diff/diff"
 	"github.com/sourcegraph/log"
@@ -89,6 +90,14 @@ type CommitSearcher struct {
 	IncludeDiff          bool
 	IncludeModifiedFiles bool
 	RepoName             api.RepoName
+
+	// Pathspecs, if set, limits the commits to the ones that modify files
+	// matching these git pathspecs. See protocol.SearchRequest.Pathspecs.
+	Pathspecs []string
+
+	// Since, if set, limits the commits to the ones committed after it. See
+	// protocol.SearchRequest.Since.
+	Since time.Time
 }
 
 // Search runs a search for commits matching the given predicate across the revisions passed in as revisionArgs.
@@ -140,6 +149,15 @@ func (cs *CommitSearcher) gitArgs() []string {
 	if cs.IncludeModifiedFiles {
 		args = append(args, "--name-status")
 	}
+	if !cs.Since.IsZero() {
+		args = append(args, "--since="+cs.Since.Format(time.RFC3339))
+	}
+	if len(cs.Pathspecs) > 0 {
+		// Don't let history simplification hide commits on branches that
+		// were merged without changes to the pathspecs.
+		args = append(args, "--full-history", "--")
+		args = append(args, cs.Pathspecs...)
+	}
 	return args
 }
 
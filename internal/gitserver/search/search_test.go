@@ -12,6 +12,7 @@ import (
 	"strings"
 	"testing"
 	"testing/quick"
+	"time"
 	"unicode/utf8"
 
 	"github.com/sourcegraph/go-diff/diff"
@@ -115,6 +116,30 @@ func TestSearch(t *testing.T) {
 		require.Equal(t, matches[2].Author.Name, "camden1")
 	})
 
+	t.Run("since limits the commits git lists", func(t *testing.T) {
+		tree, err := ToMatchTree(protocol.NewAnd())
+		require.NoError(t, err)
+		for _, tc := range []struct {
+			since time.Time
+			want  int
+		}{
+			{time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC), 3},
+			{time.Date(2007, 1, 1, 0, 0, 0, 0, time.UTC), 0},
+		} {
+			searcher := &CommitSearcher{
+				RepoDir: dir,
+				Query:   tree,
+				Since:   tc.since,
+			}
+			var matches []*protocol.CommitMatch
+			err = searcher.Search(context.Background(), func(match *protocol.CommitMatch) {
+				matches = append(matches, match)
+			})
+			require.NoError(t, err)
+			require.Len(t, matches, tc.want)
+		}
+	})
+
 	t.Run("and with no operands matches all", func(t *testing.T) {
 		query := protocol.NewAnd()
 		tree, err := ToMatchTree(query)
@@ -1,9 +1,12 @@
 package packagefilters
 
 import (
+	"strings"
+
 	"github.com/gobwas/glob"
 
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/dependencies/shared"
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/shared/versions"
 	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
@@ -25,7 +28,7 @@ func NewFilterLists(filters []shared.PackageRepoFilter) (p PackageFilters, err e
 				return PackageFilters{}, errors.Wrapf(err, "error building glob matcher for %q", filter.NameFilter.PackageGlob)
 			}
 		} else {
-			matcher, err = NewVersionGlob(filter.VersionFilter.PackageName, filter.VersionFilter.VersionGlob)
+			matcher, err = NewVersionMatcher(filter.PackageScheme, filter.VersionFilter.PackageName, filter.VersionFilter.VersionGlob)
 			if err != nil {
 				return PackageFilters{}, errors.Wrapf(err, "error building glob matcher for %q %q", filter.VersionFilter.PackageName, filter.VersionFilter.VersionGlob)
 			}
@@ -50,6 +53,9 @@ func IsPackageAllowed(scheme string, pkgName reposource.PackageName, filters Pac
 		if vglob, ok := block.(versionGlob); ok && vglob.globStr != "*" {
 			continue
 		}
+		if _, ok := block.(versionConstraint); ok {
+			continue
+		}
 
 		if block.Matches(pkgName, "") {
 			return false
@@ -64,13 +70,16 @@ func IsPackageAllowed(scheme string, pkgName reposource.PackageName, filters Pac
 
 	var (
 		namesAllowlist    []PackageMatcher
-		versionsAllowlist []versionGlob
+		versionsAllowlist []string
 	)
 	for _, allow := range filters.allowlists[scheme] {
-		if _, ok := allow.(packageNameGlob); ok {
+		switch allow := allow.(type) {
+		case packageNameGlob:
 			namesAllowlist = append(namesAllowlist, allow)
-		} else {
-			versionsAllowlist = append(versionsAllowlist, allow.(versionGlob))
+		case versionGlob:
+			versionsAllowlist = append(versionsAllowlist, allow.packageName)
+		case versionConstraint:
+			versionsAllowlist = append(versionsAllowlist, allow.packageName)
 		}
 	}
 
@@ -79,8 +88,8 @@ func IsPackageAllowed(scheme string, pkgName reposource.PackageName, filters Pac
 		isAllowed = isAllowed || allow.Matches(pkgName, "")
 	}
 
-	for _, allow := range versionsAllowlist {
-		isAllowed = isAllowed || allow.packageName == string(pkgName)
+	for _, packageName := range versionsAllowlist {
+		isAllowed = isAllowed || packageName == string(pkgName)
 	}
 
 	return isAllowed
@@ -144,3 +153,42 @@ func (v versionGlob) Matches(pkg reposource.PackageName, version string) bool {
 	// has to match exactly
 	return string(pkg) == v.packageName && v.g.Match(version)
 }
+
+// NewVersionMatcher returns a matcher for the versions of the given package. The
+// pattern is either a glob such as `1.2.*`, or a version range in the syntax of
+// versions.MatchesConstraints such as `>=1.0.0, <2.0.0` if it starts with a
+// comparison operator.
+func NewVersionMatcher(scheme, packageName, pattern string) (PackageMatcher, error) {
+	if !isVersionConstraint(pattern) {
+		return NewVersionGlob(packageName, pattern)
+	}
+
+	// Every ecosystem can parse the version 0, so an error can only stem from the
+	// constraint.
+	if _, err := versions.MatchesConstraints(scheme, "0", []string{pattern}); err != nil {
+		return nil, err
+	}
+	return versionConstraint{scheme, packageName, pattern}, nil
+}
+
+// isVersionConstraint returns true if the given version pattern is a version
+// range rather than a glob.
+func isVersionConstraint(pattern string) bool {
+	pattern = strings.TrimSpace(pattern)
+	return pattern != "" && strings.ContainsRune("<>=!^~", rune(pattern[0]))
+}
+
+type versionConstraint struct {
+	scheme      string
+	packageName string
+	constraint  string
+}
+
+func (v versionConstraint) Matches(pkg reposource.PackageName, version string) bool {
+	if string(pkg) != v.packageName {
+		return false
+	}
+	// Versions that can't be compared are never in range.
+	ok, err := versions.MatchesConstraints(v.scheme, version, []string{v.constraint})
+	return err == nil && ok
+}
@@ -0,0 +1,59 @@
+package packagefilters
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/codeintel/dependencies/shared"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+)
+
+func TestVersionRangeFilters(t *testing.T) {
+	versionFilter := func(behaviour, name, pattern string) shared.PackageRepoFilter {
+		return shared.PackageRepoFilter{
+			Behaviour:     behaviour,
+			PackageScheme: "npm",
+			VersionFilter: &struct {
+				PackageName string
+				VersionGlob string
+			}{name, pattern},
+		}
+	}
+
+	filters, err := NewFilterLists([]shared.PackageRepoFilter{
+		versionFilter("BLOCK", "left-pad", ">=1.0.0, <1.3.0"),
+		versionFilter("ALLOW", "react", "^18.0.0"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name    string
+		version string
+		allowed bool
+	}{
+		{"left-pad", "0.9.0", true},
+		{"left-pad", "1.2.0", false},
+		{"left-pad", "1.3.0", true},
+		{"left-pad", "not-a-version", true},
+		{"react", "18.2.0", true},
+		{"react", "17.0.2", false},
+	} {
+		if allowed := IsVersionedPackageAllowed("npm", reposource.PackageName(test.name), test.version, filters); allowed != test.allowed {
+			t.Errorf("unexpected result for %s@%s: want=%v have=%v", test.name, test.version, test.allowed, allowed)
+		}
+	}
+
+	// Version ranges don't block packages, only their versions.
+	if !IsPackageAllowed("npm", "left-pad", filters) {
+		t.Error("expected left-pad to be allowed")
+	}
+	// Packages with allowed versions are allowed.
+	if !IsPackageAllowed("npm", "react", filters) {
+		t.Error("expected react to be allowed")
+	}
+
+	if _, err := NewFilterLists([]shared.PackageRepoFilter{versionFilter("BLOCK", "left-pad", ">= banana")}); err == nil {
+		t.Error("expected an error for an invalid version range")
+	}
+}
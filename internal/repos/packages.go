@@ -2,10 +2,13 @@ package repos
 
 import (
 	"context"
+	"sort"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
 
+	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/dependencies"
 	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
@@ -51,27 +54,48 @@ func (s *PackagesSource) CheckConnection(ctx context.Context) error {
 }
 
 func (s *PackagesSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	s.listRepos(ctx, results, 0)
+}
+
+// errPackageLimitReached stops the dependencies store scan of listRepos once
+// the requested number of packages has been looked up.
+var errPackageLimitReached = errors.New("package limit reached")
+
+// listRepos is ListRepos, but stops after looking up limit packages when limit
+// is positive. It reports whether packages were left out because of the limit.
+func (s *PackagesSource) listRepos(ctx context.Context, results chan SourceResult, limit int) (truncated bool) {
 	staticConfigDeps, err := s.configDependencies()
 	if err != nil {
 		results <- SourceResult{Source: s, Err: err}
-		return
+		return false
 	}
 
 	handledPackages := make(map[reposource.PackageName]struct{})
+	lookedUp := 0
 
 	for _, dep := range staticConfigDeps {
 		if err := ctx.Err(); err != nil {
 			results <- SourceResult{Source: s, Err: err}
-			return
+			return false
 		}
 
 		if _, ok := handledPackages[dep.PackageSyntax()]; !ok {
+			if limit > 0 && lookedUp >= limit {
+				return true
+			}
+			lookedUp++
+
 			_, err := getPackageFromName(s.src, dep.PackageSyntax())
 			if err != nil {
 				results <- SourceResult{Source: s, Err: err}
 				continue
 			}
-			repo := s.packageToRepoType(dep)
+			private, err := s.isPrivate(ctx, dep.PackageSyntax())
+			if err != nil {
+				results <- SourceResult{Source: s, Err: err}
+				continue
+			}
+			repo := s.packageToRepoType(dep, private)
 			results <- SourceResult{Source: s, Repo: repo}
 			handledPackages[dep.PackageSyntax()] = struct{}{}
 		}
@@ -89,51 +113,46 @@ func (s *PackagesSource) ListRepos(ctx context.Context, results chan SourceResul
 		}
 	}()
 
-	const batchLimit = 100
-	var lastID int
-	for {
-		depRepos, _, _, err := s.depsSvc.ListPackageRepoRefs(ctx, dependencies.ListDependencyReposOpts{
-			Scheme: s.scheme,
-			After:  lastID,
-			Limit:  batchLimit,
-			// deliberate for clarity
-			IncludeBlocked: false,
-		})
-		if err != nil {
-			results <- SourceResult{Source: s, Err: err}
-			return
+	// Private package repos are only listed for internal actors.
+	err = s.depsSvc.ScanPackageRepoRefs(actor.WithInternalActor(ctx), dependencies.ListDependencyReposOpts{
+		Scheme: s.scheme,
+		// deliberate for clarity
+		IncludeBlocked: false,
+	}, func(depRepo dependencies.PackageRepoReference) error {
+		if _, ok := handledPackages[depRepo.Name]; ok {
+			return nil
 		}
-		if len(depRepos) == 0 {
-			break
+		if limit > 0 && lookedUp >= limit {
+			return errPackageLimitReached
 		}
-
-		lastID = depRepos[len(depRepos)-1].ID
-
-		for _, depRepo := range depRepos {
-			if _, ok := handledPackages[depRepo.Name]; ok {
-				continue
-			}
-			if err := sem.Acquire(ctx, 1); err != nil {
-				return
-			}
-			depRepo := depRepo
-			g.Go(func() error {
-				defer sem.Release(1)
-				pkg, err := getPackageFromName(s.src, depRepo.Name)
-				if err != nil {
-					if !errcode.IsNotFound(err) {
-						results <- SourceResult{Source: s, Err: err}
-					}
-					return nil
+		lookedUp++
+		if err := sem.Acquire(ctx, 1); err != nil {
+			return err
+		}
+		g.Go(func() error {
+			defer sem.Release(1)
+			pkg, err := getPackageFromName(s.src, depRepo.Name)
+			if err != nil {
+				if !errcode.IsNotFound(err) {
+					results <- SourceResult{Source: s, Err: err}
 				}
+				return nil
+			}
 
-				repo := s.packageToRepoType(pkg)
-				results <- SourceResult{Source: s, Repo: repo}
+			repo := s.packageToRepoType(pkg, depRepo.Private)
+			results <- SourceResult{Source: s, Repo: repo}
 
-				return nil
-			})
-		}
+			return nil
+		})
+		return nil
+	})
+	if errors.Is(err, errPackageLimitReached) {
+		return true
+	}
+	if err != nil && ctx.Err() == nil {
+		results <- SourceResult{Source: s, Err: err}
 	}
+	return false
 }
 
 func (s *PackagesSource) GetRepo(ctx context.Context, repoName string) (*types.Repo, error) {
@@ -155,10 +174,35 @@ func (s *PackagesSource) GetRepo(ctx context.Context, repoName string) (*types.R
 	if err != nil {
 		return nil, err
 	}
-	return s.packageToRepoType(pkg), nil
+
+	private, err := s.isPrivate(ctx, parsedPkg.PackageSyntax())
+	if err != nil {
+		return nil, err
+	}
+	return s.packageToRepoType(pkg, private), nil
 }
 
-func (s *PackagesSource) packageToRepoType(dep reposource.Package) *types.Repo {
+// isPrivate returns whether the package repo reference of the given package is
+// private. Packages without a reference are public.
+func (s *PackagesSource) isPrivate(ctx context.Context, name reposource.PackageName) (bool, error) {
+	refs, _, _, err := s.depsSvc.ListPackageRepoRefs(actor.WithInternalActor(ctx), dependencies.ListDependencyReposOpts{
+		Scheme:          s.scheme,
+		Name:            name,
+		ExactNameOnly:   true,
+		IncludeBlocked:  true,
+		IncludeArchived: true,
+		Limit:           1,
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "error looking up package repo (%s, %s)", s.scheme, name)
+	}
+	return len(refs) > 0 && refs[0].Private, nil
+}
+
+// packageToRepoType returns the repo of the given package. Private package
+// repos are synced as private repos, so that they are only visible to users
+// with explicit permissions and site admins.
+func (s *PackagesSource) packageToRepoType(dep reposource.Package, private bool) *types.Repo {
 	urn := s.svc.URN()
 	repoName := dep.RepoName()
 	return &types.Repo{
@@ -170,7 +214,7 @@ func (s *PackagesSource) packageToRepoType(dep reposource.Package) *types.Repo {
 			ServiceID:   extsvc.KindToType(s.svc.Kind),
 			ServiceType: extsvc.KindToType(s.svc.Kind),
 		},
-		Private: false,
+		Private: private,
 		Sources: map[string]*types.SourceInfo{
 			urn: {
 				ID:       urn,
@@ -226,3 +270,123 @@ func (s *PackagesSource) configDependencies() (dependencies []reposource.Version
 	}
 	return dependencies, nil
 }
+
+const (
+	// defaultSyncPreviewSampleSize is the number of repository names returned
+	// per category by PreviewSync when no sample size is given.
+	defaultSyncPreviewSampleSize = 10
+	// maxSyncPreviewSampleSize caps the sample size, and with it the number
+	// of packages PreviewSync looks up.
+	maxSyncPreviewSampleSize = 100
+	// syncPreviewTimeout bounds the time PreviewSync spends looking up
+	// packages. It runs inside frontend requests.
+	syncPreviewTimeout = 30 * time.Second
+)
+
+// PackagesSyncPreview describes the changes a sync of a package host
+// connection would make to the set of repositories it owns.
+type PackagesSyncPreview struct {
+	Scheme string
+
+	New       int
+	Changed   int
+	Removed   int
+	Unchanged int
+
+	// The samples are sorted by name and hold at most the requested sample
+	// size of names each.
+	NewSample     []api.RepoName
+	ChangedSample []api.RepoName
+	RemovedSample []api.RepoName
+
+	// Truncated is true when not every package was looked up, because the
+	// sample size or the timeout was reached. The counts are then lower
+	// bounds, and no repositories are reported as removed.
+	Truncated bool
+}
+
+// PreviewSync runs the same discovery as ListRepos against the dependencies
+// store and compares the result with existing, the repositories currently
+// owned by the connection. Nothing is written: this lets site admins see what
+// enabling or changing a package host connection would do before saving it.
+//
+// At most sampleSize packages are looked up, within syncPreviewTimeout.
+func (s *PackagesSource) PreviewSync(ctx context.Context, existing types.Repos, sampleSize int) (*PackagesSyncPreview, error) {
+	if sampleSize <= 0 {
+		sampleSize = defaultSyncPreviewSampleSize
+	}
+	if sampleSize > maxSyncPreviewSampleSize {
+		sampleSize = maxSyncPreviewSampleSize
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, syncPreviewTimeout)
+	defer cancel()
+
+	var truncated bool
+	results := make(chan SourceResult)
+	go func() {
+		truncated = s.listRepos(ctx, results, sampleSize)
+		close(results)
+	}()
+
+	var errs error
+	sourced := make(map[api.RepoName]*types.Repo)
+	for res := range results {
+		if res.Err != nil {
+			if errors.Is(res.Err, context.DeadlineExceeded) {
+				continue
+			}
+			errs = errors.Append(errs, &SourceError{Err: res.Err, ExtSvc: s.svc})
+			continue
+		}
+		sourced[res.Repo.Name] = res.Repo
+	}
+	if errs != nil {
+		return nil, errs
+	}
+
+	preview := &PackagesSyncPreview{
+		Scheme:    s.scheme,
+		Truncated: truncated || ctx.Err() == context.DeadlineExceeded,
+	}
+	var newNames, changedNames, removedNames []api.RepoName
+
+	existingNames := make(map[api.RepoName]struct{}, len(existing))
+	for _, repo := range existing {
+		existingNames[repo.Name] = struct{}{}
+
+		sourcedRepo, ok := sourced[repo.Name]
+		if !ok {
+			// When truncated, the repo may simply not have been looked up.
+			if !preview.Truncated {
+				removedNames = append(removedNames, repo.Name)
+			}
+			continue
+		}
+		// Update modifies the receiver, so we compare against a clone.
+		if repo.Clone().Update(sourcedRepo) != types.RepoUnmodified {
+			changedNames = append(changedNames, repo.Name)
+		} else {
+			preview.Unchanged++
+		}
+	}
+	for name := range sourced {
+		if _, ok := existingNames[name]; !ok {
+			newNames = append(newNames, name)
+		}
+	}
+
+	preview.New, preview.NewSample = len(newNames), samplePreviewNames(newNames, sampleSize)
+	preview.Changed, preview.ChangedSample = len(changedNames), samplePreviewNames(changedNames, sampleSize)
+	preview.Removed, preview.RemovedSample = len(removedNames), samplePreviewNames(removedNames, sampleSize)
+
+	return preview, nil
+}
+
+func samplePreviewNames(names []api.RepoName, n int) []api.RepoName {
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	if len(names) > n {
+		names = names[:n]
+	}
+	return names
+}
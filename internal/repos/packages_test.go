@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/codeintel/dependencies"
 	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
@@ -40,6 +42,151 @@ func TestPackagesSource_GetRepo(t *testing.T) {
 	}
 }
 
+func TestPackagesSource_PrivatePackages(t *testing.T) {
+	ctx := context.Background()
+	svc := testDependenciesService(ctx, t, []dependencies.MinimalPackageRepoRef{
+		{
+			Scheme:   "go",
+			Name:     "github.com/sourcegraph-testing/go-repo-a",
+			Versions: []dependencies.MinimalPackageRepoRefVersion{{Version: "1.0.0"}},
+		},
+	})
+
+	refs, _, _, err := svc.ListPackageRepoRefs(ctx, dependencies.ListDependencyReposOpts{Scheme: "go"})
+	if err != nil || len(refs) != 1 {
+		t.Fatalf("unexpected package repos: %v, %v", refs, err)
+	}
+	if err := svc.UpdatePackageRepoRefsVisibilityByID(ctx, true, refs[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	src := &PackagesSource{
+		src: &dummyPackagesSource{},
+		svc: &types.ExternalService{
+			ID:     1,
+			Kind:   extsvc.KindGoPackages,
+			Config: extsvc.NewEmptyConfig(),
+		},
+		scheme:  "go",
+		depsSvc: svc,
+	}
+
+	// Private package repos are synced as private repos, even though the
+	// source doesn't run as an internal actor.
+	repo, err := src.GetRepo(ctx, "go/github.com/sourcegraph-testing/go-repo-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !repo.Private {
+		t.Fatal("expected repo of private package repo to be private")
+	}
+
+	repos, err := ListAll(ctx, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || !repos[0].Private {
+		t.Fatalf("expected a single private repo, got %v", repos)
+	}
+}
+
+func TestPackagesSource_PreviewSync(t *testing.T) {
+	ctx := context.Background()
+	svc := testDependenciesService(ctx, t, []dependencies.MinimalPackageRepoRef{
+		{
+			Scheme:   "go",
+			Name:     "github.com/sourcegraph-testing/go-repo-a",
+			Versions: []dependencies.MinimalPackageRepoRefVersion{{Version: "1.0.0"}},
+		},
+		{
+			Scheme:   "go",
+			Name:     "github.com/sourcegraph-testing/go-repo-b",
+			Versions: []dependencies.MinimalPackageRepoRefVersion{{Version: "1.0.0"}},
+		},
+		{
+			Scheme:   "go",
+			Name:     "github.com/sourcegraph-testing/go-repo-c",
+			Versions: []dependencies.MinimalPackageRepoRefVersion{{Version: "1.0.0"}},
+		},
+	})
+
+	src := &PackagesSource{
+		src: &dummyPackagesSource{},
+		svc: &types.ExternalService{
+			ID:     1,
+			Kind:   extsvc.KindGoPackages,
+			Config: extsvc.NewEmptyConfig(),
+		},
+		configDeps: []string{"github.com/sourcegraph-testing/go-repo-d@v1.0.0"},
+		scheme:     "go",
+		depsSvc:    svc,
+	}
+
+	existingRepo := func(name string) *types.Repo {
+		pkg, err := reposource.ParseGoDependencyFromName(reposource.PackageName(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return src.packageToRepoType(pkg, false)
+	}
+	changed := existingRepo("github.com/sourcegraph-testing/go-repo-b")
+	changed.Description = "outdated"
+	existing := types.Repos{
+		existingRepo("github.com/sourcegraph-testing/go-repo-a"),
+		changed,
+		existingRepo("github.com/sourcegraph-testing/go-repo-e"),
+		existingRepo("github.com/sourcegraph-testing/go-repo-f"),
+	}
+
+	preview, err := src.PreviewSync(ctx, existing, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &PackagesSyncPreview{
+		Scheme:    "go",
+		New:       2,
+		Changed:   1,
+		Removed:   2,
+		Unchanged: 1,
+		NewSample: []api.RepoName{
+			"go/github.com/sourcegraph-testing/go-repo-c",
+			"go/github.com/sourcegraph-testing/go-repo-d",
+		},
+		ChangedSample: []api.RepoName{"go/github.com/sourcegraph-testing/go-repo-b"},
+		RemovedSample: []api.RepoName{
+			"go/github.com/sourcegraph-testing/go-repo-e",
+			"go/github.com/sourcegraph-testing/go-repo-f",
+		},
+	}
+	if diff := cmp.Diff(want, preview); diff != "" {
+		t.Fatalf("unexpected preview (-want +got):\n%s", diff)
+	}
+
+	// With a smaller sample size only the configured package and the first
+	// package repo reference are looked up, so nothing is reported as removed.
+	preview, err = src.PreviewSync(ctx, existing, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = &PackagesSyncPreview{
+		Scheme:    "go",
+		New:       1,
+		Unchanged: 1,
+		NewSample: []api.RepoName{"go/github.com/sourcegraph-testing/go-repo-d"},
+		Truncated: true,
+	}
+	if diff := cmp.Diff(want, preview); diff != "" {
+		t.Fatalf("unexpected truncated preview (-want +got):\n%s", diff)
+	}
+
+	// The existing repositories are left untouched.
+	if changed.Description != "outdated" {
+		t.Fatalf("expected existing repo to be unmodified, got description %q", changed.Description)
+	}
+}
+
 var _ packagesSource = &dummyPackagesSource{}
 
 // dummyPackagesSource is a tiny shim around Go-specific methods to track when they're called.
@@ -23,6 +23,13 @@ type Client struct {
 	// Note: This header can be spoofed and relies on trusted clients/proxies.
 	// For sourcegraph.com we use cloudflare headers to avoid spoofing.
 	ForwardedFor string
+	// WAFIP is the IP of the originating client as provided by a WAF
+	// (typically Cloudflare) behind which Sourcegraph is hosted. Unlike
+	// ForwardedFor, it can't be spoofed by the client, and unlike IP, it isn't
+	// the address of a proxy in front of Sourcegraph. It is only set on
+	// external requests when the WAF's headers are trusted, see
+	// SRC_USE_CLOUDFLARE_HEADERS, and isn't propagated to other services.
+	WAFIP string
 	// UserAgent is value of the User-Agent header:
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/User-Agent
 	UserAgent string
@@ -96,8 +96,12 @@ func httpMiddleware(next http.Handler, external bool) http.Handler {
 			}
 		}
 
-		var wafIPCountryCode string
+		var wafIP, wafIPCountryCode string
 		if external && useCloudflareHeaders {
+			// Cloudflare sets the connecting client IP itself, so unlike
+			// X-Forwarded-For it can be trusted.
+			wafIP = req.Header.Get("Cf-Connecting-Ip")
+
 			// Try to find trusted Cloudflare-provided country code of the request.
 			// https://developers.cloudflare.com/fundamentals/reference/http-request-headers/#cf-ipcountry
 			//
@@ -111,6 +115,7 @@ func httpMiddleware(next http.Handler, external bool) http.Handler {
 		ctxWithClient := WithClient(req.Context(), &Client{
 			IP:           strings.Split(req.RemoteAddr, ":")[0],
 			ForwardedFor: req.Header.Get(headerKeyForwardedFor),
+			WAFIP:        wafIP,
 			UserAgent:    req.Header.Get(headerKeyUserAgent),
 
 			wafIPCountryCode: wafIPCountryCode,
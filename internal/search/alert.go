@@ -7,6 +7,7 @@ import (
 	"time"
 	"unicode"
 
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
@@ -19,17 +20,34 @@ type Alert struct {
 	Kind            string // An identifier indicating the kind of alert
 	// The higher the priority the more important is the alert.
 	Priority int
+	// GeneratedQueries are the queries Smart Search ran instead of, or in
+	// addition to, the original query. Unlike the rest of the alert, they are
+	// kept when a higher priority alert takes precedence, so that clients can
+	// always tell which queries produced the results.
+	GeneratedQueries []*QueryDescription
 }
 
+// MaxPriorityAlert returns the alert with the highest priority, or the first
+// of them if several have the same priority. If it has no generated queries,
+// it gets the generated queries of the first alert that has some.
 func MaxPriorityAlert(alerts ...*Alert) (max *Alert) {
+	var generated []*QueryDescription
 	for _, alert := range alerts {
 		if alert == nil {
 			continue
 		}
+		if len(generated) == 0 {
+			generated = alert.GeneratedQueries
+		}
 		if max == nil || alert.Priority > max.Priority {
 			max = alert
 		}
 	}
+	if max != nil && len(max.GeneratedQueries) == 0 && len(generated) > 0 {
+		cp := *max
+		cp.GeneratedQueries = generated
+		max = &cp
+	}
 	return max
 }
 
@@ -108,10 +126,15 @@ func AlertForTimeout(usedTime time.Duration, suggestTime time.Duration, queryStr
 		}
 	}
 
+	description := fmt.Sprintf("We weren't able to find any results in %s.", usedTime.Round(time.Second))
+	if searchJobsEnabled() {
+		description += " You can also run this query as a search job, which searches in the background and notifies you when the results are ready."
+	}
+
 	return &Alert{
 		PrometheusType: "timed_out",
 		Title:          "Timed out while searching",
-		Description:    fmt.Sprintf("We weren't able to find any results in %s.", usedTime.Round(time.Second)),
+		Description:    description,
 		ProposedQueries: []*QueryDescription{
 			{
 				Description: "query with longer timeout",
@@ -122,6 +145,15 @@ func AlertForTimeout(usedTime time.Duration, suggestTime time.Duration, queryStr
 	}
 }
 
+// searchJobsEnabled returns true if queries can be run as search jobs. It
+// matches the check done by the search jobs service.
+func searchJobsEnabled() bool {
+	if experimentalFeatures := conf.SiteConfig().ExperimentalFeatures; experimentalFeatures != nil {
+		return experimentalFeatures.SearchJobs != nil && *experimentalFeatures.SearchJobs
+	}
+	return true
+}
+
 // capFirst capitalizes the first rune in the given string. It can be safely
 // used with UTF-8 strings.
 func capFirst(s string) string {
@@ -143,6 +175,21 @@ func AlertForStalePermissions() *Alert {
 	}
 }
 
+// AlertForSubRepoPermissionsUnavailable returns an alert for a search that was
+// aborted because sub-repo permissions could not be checked and sub-repo
+// permissions are configured to fail closed.
+func AlertForSubRepoPermissionsUnavailable() *Alert {
+	return &Alert{
+		PrometheusType: "sub_repo_permissions_unavailable",
+		Kind:           "sub-repo-permissions-unavailable",
+		Title:          "Search aborted",
+		Description:    "Permissions for some of the results could not be verified, so the search was stopped. Please try again later, or contact your site admin if the problem persists.",
+		// Takes precedence over other alerts, since the results shown are
+		// incomplete.
+		Priority: 2,
+	}
+}
+
 func AlertForStructuralSearchNotSet(queryString string) *Alert {
 	return &Alert{
 		PrometheusType: "structural_search_not_set",
@@ -167,7 +167,7 @@ func (o *Observer) alertForNoResolvedRepos(ctx context.Context, q query.Q) *sear
 func (o *Observer) multierrorToAlert(ctx context.Context, me errors.MultiError) (resAlert *search.Alert, resErr error) {
 	for _, err := range me.Errors() {
 		alert, err := o.errorToAlert(ctx, err)
-		resAlert = maxAlertByPriority(resAlert, alert)
+		resAlert = search.MaxPriorityAlert(resAlert, alert)
 		resErr = errors.Append(resErr, err)
 	}
 
@@ -198,9 +198,7 @@ func (o *Observer) Error(ctx context.Context, err error) {
 
 // update to alert if it is more important than our current alert.
 func (o *Observer) update(alert *search.Alert) {
-	if o.alert == nil || alert.Priority > o.alert.Priority {
-		o.alert = alert
-	}
+	o.alert = search.MaxPriorityAlert(o.alert, alert)
 }
 
 // Done returns the highest priority alert and an error.MultiError containing
@@ -239,6 +237,7 @@ func (o *Observer) errorToAlert(ctx context.Context, err error) (*search.Alert,
 		mErr *searchrepos.MissingRepoRevsError
 		oErr *errOverRepoLimit
 		lErr *ErrLuckyQueries
+		fErr *ErrLiteralFallback
 	)
 
 	if errors.HasType(err, authz.ErrStalePermissions{}) {
@@ -281,11 +280,25 @@ func (o *Observer) errorToAlert(ctx context.Context, err error) (*search.Alert,
 			kind = string(smartSearchPureResults)
 		}
 		return &search.Alert{
-			PrometheusType:  "smart_search_notice",
-			Title:           title,
-			Kind:            kind,
-			Description:     description,
-			ProposedQueries: lErr.ProposedQueries,
+			PrometheusType:   "smart_search_notice",
+			Title:            title,
+			Kind:             kind,
+			Description:      description,
+			ProposedQueries:  lErr.ProposedQueries,
+			GeneratedQueries: lErr.ProposedQueries,
+		}, nil
+	}
+
+	if errors.As(err, &fErr) {
+		return &search.Alert{
+			PrometheusType: "smart_search_literal_fallback",
+			Title:          "Searched for the pattern literally",
+			Description:    fmt.Sprintf("The pattern is not a valid regular expression (%s), so Smart Search searched for it literally instead.", fErr.Err),
+			ProposedQueries: []*search.QueryDescription{{
+				Description: "Search for the pattern literally",
+				Query:       fErr.Query,
+				PatternType: query.SearchTypeLiteral,
+			}},
 		}, nil
 	}
 
@@ -310,21 +323,6 @@ func (o *Observer) errorToAlert(ctx context.Context, err error) (*search.Alert,
 	return nil, err
 }
 
-func maxAlertByPriority(a, b *search.Alert) *search.Alert {
-	if a == nil {
-		return b
-	}
-	if b == nil {
-		return a
-	}
-
-	if a.Priority < b.Priority {
-		return b
-	}
-
-	return a
-}
-
 func needsRepositoryConfiguration(ctx context.Context, db database.DB) (bool, error) {
 	kinds := make([]string, 0, len(database.ExternalServiceKinds))
 	for kind, config := range database.ExternalServiceKinds {
@@ -367,6 +365,19 @@ func (e *ErrLuckyQueries) Error() string {
 	return "Showing results for lucky search"
 }
 
+// ErrLiteralFallback notifies that Smart Search searched the patterns of a
+// query literally because they are not valid regular expressions.
+type ErrLiteralFallback struct {
+	// Query is the query without its patterntype filter.
+	Query string
+	// Err is the error parsing the patterns as regular expressions.
+	Err error
+}
+
+func (e *ErrLiteralFallback) Error() string {
+	return "Searched patterns literally: " + e.Err.Error()
+}
+
 // isContextError returns true if ctx.Err() is not nil or if err
 // is an error caused by context cancelation or timeout.
 func isContextError(ctx context.Context, err error) bool {
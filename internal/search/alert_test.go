@@ -46,6 +46,21 @@ func TestMaxPriorityAlert(t *testing.T) {
 		a1 := Alert{Title: "test1"}
 		require.Equal(t, &a1, MaxPriorityAlert(&a1, nil))
 	})
+
+	t.Run("keeps generated queries of lower priority alerts", func(t *testing.T) {
+		generated := []*QueryDescription{{Query: "generated", PatternType: query.SearchTypeLucky}}
+		a1 := Alert{Title: "test1", GeneratedQueries: generated}
+		a2 := Alert{Title: "test2", Priority: 2}
+		require.Equal(t, &Alert{Title: "test2", Priority: 2, GeneratedQueries: generated}, MaxPriorityAlert(&a1, &a2))
+		// The alerts themselves are not modified.
+		require.Nil(t, a2.GeneratedQueries)
+	})
+
+	t.Run("prefers own generated queries", func(t *testing.T) {
+		a1 := Alert{Title: "test1", GeneratedQueries: []*QueryDescription{{Query: "a"}}}
+		a2 := Alert{Title: "test2", Priority: 2, GeneratedQueries: []*QueryDescription{{Query: "b"}}}
+		require.Equal(t, &a2, MaxPriorityAlert(&a1, &a2))
+	})
 }
 
 func TestSearchPatternForSuggestion(t *testing.T) {
@@ -119,6 +119,19 @@ func (s *searchClient) Plan(
 		query.Init(searchQuery, searchType),
 		query.With(searchContextsQueryEnabled, substituteContextsStep),
 	)
+	var regexpParseErr error
+	if err != nil && searchType == query.SearchTypeRegex && searchMode == search.SmartSearch {
+		// Smart Search searches patterns that aren't valid regular
+		// expressions literally instead of failing.
+		literalPlan, literalErr := query.Pipeline(
+			query.Init(searchQuery, query.SearchTypeLiteral),
+			query.With(searchContextsQueryEnabled, substituteContextsStep),
+		)
+		if literalErr == nil {
+			tr.AddEvent("searching patterns literally", attribute.String("error", err.Error()))
+			plan, searchType, regexpParseErr, err = literalPlan, query.SearchTypeLiteral, err, nil
+		}
+	}
 	if err != nil {
 		return nil, &QueryError{Query: searchQuery, Err: err}
 	}
@@ -145,6 +158,7 @@ func (s *searchClient) Plan(
 		Protocol:               protocol,
 		ContextLines:           finalContextLines,
 		SanitizeSearchPatterns: sanitizeSearchPatterns(ctx, s.runtimeClients.DB, s.runtimeClients.Logger), // Experimental: check site config to see if search sanitization is enabled
+		RegexpParseError:       regexpParseErr,
 	}
 
 	tr.AddEvent("parsed query", attribute.Stringer("query", inputs.Query))
@@ -165,6 +179,14 @@ func (s *searchClient) Execute(
 		return nil, err
 	}
 
+	if inputs.Ordering != nil {
+		// Results are sent even if the search fails or times out, so that
+		// the results found so far aren't lost.
+		parent, ordered := stream, streaming.NewOrderingStream(inputs.Ordering.Limit)
+		defer func() { parent.Send(ordered.Event()) }()
+		stream = ordered
+	}
+
 	return planJob.Run(ctx, s.JobClients(), stream)
 }
 
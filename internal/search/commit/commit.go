@@ -33,6 +33,28 @@ type SearchJob struct {
 	IncludeModifiedFiles bool
 	Concurrency          int
 
+	// RefFilters restricts results to commits whose refs or source refs match
+	// the `ref:` filters of the query.
+	RefFilters []RefFilter
+
+	// PerRef reports a separate result for each source ref a commit was found
+	// from instead of a single result per commit.
+	PerRef bool
+
+	// AfterWatermarks, if set, restricts the search in each repo to commits
+	// that are not reachable from the repo's watermark: the commits its
+	// revisions resolved to on a previous search. See SearchAfterWatermark.
+	AfterWatermarks map[api.RepoID][]string `json:"-"`
+
+	// OnWatermark, if set, is called with the new watermark of each searched
+	// repo when AfterWatermarks is set.
+	OnWatermark func(api.RepoID, []string) `json:"-"`
+
+	// CommitAfter, if set, is the repo:has.commit.after() filter of the query. It
+	// is checked for each repo right before searching it instead of during repo
+	// resolution, so that searching doesn't wait on every repo being checked.
+	CommitAfter *query.RepoHasCommitAfterArgs
+
 	// CodeMonitorSearchWrapper, if set, will wrap the commit search with extra logic specific to code monitors.
 	CodeMonitorSearchWrapper CodeMonitorHook `json:"-"`
 }
@@ -54,6 +76,12 @@ func (j *SearchJob) Run(ctx context.Context, clients job.RuntimeClients, stream
 	}
 
 	searchRepoRev := func(ctx context.Context, repoRev *search.RepositoryRevisions) error {
+		if j.CommitAfter != nil {
+			if err := searchrepos.FilterHasCommitAfter(ctx, clients.Gitserver, repoRev, j.CommitAfter); err != nil {
+				return err
+			}
+		}
+
 		// Skip the repo if no revisions were resolved for it
 		if len(repoRev.Revs) == 0 {
 			return nil
@@ -68,10 +96,35 @@ func (j *SearchJob) Run(ctx context.Context, clients job.RuntimeClients, stream
 			IncludeModifiedFiles: j.IncludeModifiedFiles,
 		}
 
+		// Commits found from the default revision have the source ref HEAD.
+		// Resolve it to the default branch, so that ref filters and per-ref
+		// results see the branch.
+		var defaultBranch string
+		if len(j.RefFilters) > 0 || j.PerRef {
+			var err error
+			defaultBranch, _, err = clients.Gitserver.GetDefaultBranch(ctx, repoRev.Repo.Name, false)
+			if err != nil {
+				return err
+			}
+		}
+
 		onMatches := func(in []protocol.CommitMatch) {
 			res := make([]result.Match, 0, len(in))
 			for _, protocolMatch := range in {
-				res = append(res, protocolMatchToCommitMatch(repoRev.Repo, j.Diff, protocolMatch))
+				match := protocolMatchToCommitMatch(repoRev.Repo, j.Diff, protocolMatch)
+				if defaultBranch != "" {
+					match.SourceRefs = resolveHEAD(match.SourceRefs, defaultBranch)
+				}
+				if !matchesRefFilters(match, j.RefFilters) {
+					continue
+				}
+				if j.PerRef {
+					for _, perRef := range match.SplitBySourceRef() {
+						res = append(res, perRef)
+					}
+					continue
+				}
+				res = append(res, match)
 			}
 			stream.Send(streaming.SearchEvent{
 				Results: res,
@@ -93,6 +146,13 @@ func (j *SearchJob) Run(ctx context.Context, clients job.RuntimeClients, stream
 		if j.CodeMonitorSearchWrapper != nil {
 			return j.CodeMonitorSearchWrapper(ctx, clients.DB, clients.Gitserver, args, repoRev.Repo.ID, doSearch)
 		}
+		if j.AfterWatermarks != nil {
+			watermark, err := SearchAfterWatermark(ctx, clients.Gitserver, args, j.AfterWatermarks[repoRev.Repo.ID], doSearch)
+			if watermark != nil && j.OnWatermark != nil {
+				j.OnWatermark(repoRev.Repo.ID, watermark)
+			}
+			return err
+		}
 		return doSearch(args)
 	}
 
@@ -132,6 +192,26 @@ func (j *SearchJob) Attributes(v job.Verbosity) (res []attribute.KeyValue) {
 		res = append(res,
 			attribute.Bool("includeModifiedFiles", j.IncludeModifiedFiles),
 		)
+		if len(j.RefFilters) > 0 {
+			globs := make([]string, 0, len(j.RefFilters))
+			for _, filter := range j.RefFilters {
+				if filter.Negated {
+					globs = append(globs, "-"+filter.Glob)
+				} else {
+					globs = append(globs, filter.Glob)
+				}
+			}
+			res = append(res, attribute.StringSlice("refFilters", globs))
+		}
+		if j.PerRef {
+			res = append(res, attribute.Bool("perRef", j.PerRef))
+		}
+		if j.CommitAfter != nil {
+			res = append(res,
+				attribute.String("commitAfter.time", j.CommitAfter.TimeRef),
+				attribute.Bool("commitAfter.negated", j.CommitAfter.Negated),
+			)
+		}
 		fallthrough
 	case job.VerbosityBasic:
 		res = append(res,
@@ -139,6 +219,11 @@ func (j *SearchJob) Attributes(v job.Verbosity) (res []attribute.KeyValue) {
 			attribute.Bool("diff", j.Diff),
 			attribute.Int("limit", j.Limit),
 		)
+		// File filters that gitserver pushes down into the git log pathspecs.
+		pathspecs := (&gitprotocol.SearchRequest{Query: j.Query, IncludeModifiedFiles: j.IncludeModifiedFiles}).Pathspecs()
+		if len(pathspecs) > 0 {
+			res = append(res, attribute.StringSlice("pathspecs", pathspecs))
+		}
 		res = append(res, trace.Scoped("repoOpts", j.RepoOpts.Attributes()...)...)
 	}
 	return res
@@ -248,6 +333,30 @@ func QueryToGitQuery(b query.Basic, diff bool) gitprotocol.Node {
 	return gitprotocol.Reduce(gitprotocol.NewAnd(res...))
 }
 
+// PlanCommitAfter moves the repo:has.commit.after() filter of repoOpts out of
+// repo resolution for a commit search. It returns the updated repo options, the
+// git query to search with and the filter the search job should check for each
+// repo instead.
+//
+// The filter limits the search to commits after its cutoff, so that the git
+// invocation is bounded by date (see protocol.SearchRequest.Since). Every match
+// then proves that its repo has such a commit, so no per-repo check is needed.
+// Negated filters can't be expressed this way and are checked for each repo.
+func PlanCommitAfter(repoOpts search.RepoOptions, q gitprotocol.Node) (search.RepoOptions, gitprotocol.Node, *query.RepoHasCommitAfterArgs) {
+	commitAfter := repoOpts.CommitAfter
+	if commitAfter == nil {
+		return repoOpts, q, nil
+	}
+	repoOpts.CommitAfter = nil
+
+	if !commitAfter.Negated {
+		if cutoff, err := query.ParseGitDate(commitAfter.TimeRef, time.Now); err == nil {
+			return repoOpts, gitprotocol.Reduce(gitprotocol.NewAnd(q, &gitprotocol.CommitAfter{Time: cutoff})), nil
+		}
+	}
+	return repoOpts, q, commitAfter
+}
+
 func searchRevsToGitserverRevs(in []string) []gitprotocol.RevisionSpecifier {
 	out := make([]gitprotocol.RevisionSpecifier, 0, len(in))
 	for _, rev := range in {
@@ -350,7 +459,7 @@ func protocolMatchToCommitMatch(repo types.MinimalRepo, diff bool, in protocol.C
 	var structuredDiff []result.DiffFile
 	if diff {
 		diffPreview = &in.Diff
-		structuredDiff, _ = result.ParseDiffString(in.Diff.Content)
+		structuredDiff, _ = result.ParseDiffPreview(in.Diff)
 	} else {
 		messagePreview = &in.Message
 	}
@@ -372,9 +481,49 @@ func protocolMatchToCommitMatch(repo types.MinimalRepo, diff bool, in protocol.C
 			Parents: in.Parents,
 		},
 		Repo:           repo,
+		Refs:           in.Refs,
+		SourceRefs:     in.SourceRefs,
 		DiffPreview:    diffPreview,
 		Diff:           structuredDiff,
 		MessagePreview: messagePreview,
 		ModifiedFiles:  in.ModifiedFiles,
 	}
 }
+
+// RefFilter is a `ref:` filter of a commit or diff search.
+type RefFilter struct {
+	Glob    string
+	Negated bool
+}
+
+// QueryToRefFilters returns the `ref:` filters of the given query.
+func QueryToRefFilters(b query.Basic) []RefFilter {
+	var filters []RefFilter
+	for _, parameter := range b.Parameters {
+		if parameter.Field == query.FieldRef {
+			filters = append(filters, RefFilter{Glob: parameter.Value, Negated: parameter.Negated})
+		}
+	}
+	return filters
+}
+
+// resolveHEAD replaces the HEAD source ref with the given default branch.
+func resolveHEAD(sourceRefs []string, defaultBranch string) []string {
+	resolved := make([]string, 0, len(sourceRefs))
+	for _, ref := range sourceRefs {
+		if result.NormalizeRef(ref) == "HEAD" {
+			ref = defaultBranch
+		}
+		resolved = append(resolved, ref)
+	}
+	return resolved
+}
+
+func matchesRefFilters(match *result.CommitMatch, filters []RefFilter) bool {
+	for _, filter := range filters {
+		if match.MatchesRef(filter.Glob) == filter.Negated {
+			return false
+		}
+	}
+	return true
+}
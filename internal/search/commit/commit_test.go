@@ -11,6 +11,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/database"
 	"github.com/sourcegraph/sourcegraph/internal/database/dbmocks"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	"github.com/sourcegraph/sourcegraph/internal/types"
 )
@@ -74,6 +75,46 @@ func TestQueryToGitQuery(t *testing.T) {
 	}
 }
 
+func TestPlanCommitAfter(t *testing.T) {
+	q := &protocol.MessageMatches{Expr: "fix"}
+	cutoff, err := query.ParseGitDate("2021-09-08", time.Now)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name        string
+		commitAfter *query.RepoHasCommitAfterArgs
+		wantQuery   protocol.Node
+		want        *query.RepoHasCommitAfterArgs
+	}{{
+		name:        "no filter",
+		commitAfter: nil,
+		wantQuery:   q,
+		want:        nil,
+	}, {
+		name:        "filter bounds the git query",
+		commitAfter: &query.RepoHasCommitAfterArgs{TimeRef: "2021-09-08"},
+		wantQuery:   protocol.Reduce(protocol.NewAnd(q, &protocol.CommitAfter{Time: cutoff})),
+		want:        nil,
+	}, {
+		name:        "negated filter is checked per repo",
+		commitAfter: &query.RepoHasCommitAfterArgs{TimeRef: "2021-09-08", Negated: true},
+		wantQuery:   q,
+		want:        &query.RepoHasCommitAfterArgs{TimeRef: "2021-09-08", Negated: true},
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repoOpts, gitQuery, commitAfter := PlanCommitAfter(search.RepoOptions{CommitAfter: tc.commitAfter}, q)
+			require.Nil(t, repoOpts.CommitAfter)
+			require.Equal(t, tc.wantQuery, gitQuery)
+			require.Equal(t, tc.want, commitAfter)
+			if tc.commitAfter != nil && !tc.commitAfter.Negated {
+				require.Equal(t, cutoff, protocol.QuerySince(gitQuery))
+			}
+		})
+	}
+}
+
 func TestExpandUsernamesToEmails(t *testing.T) {
 	users := dbmocks.NewStrictMockUserStore()
 	users.GetByUsernameFunc.SetDefaultHook(func(_ context.Context, username string) (*types.User, error) {
@@ -0,0 +1,69 @@
+package commit
+
+import (
+	"context"
+	"sort"
+
+	gitprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// SearchAfterWatermark runs doSearch over only the commits that are reachable
+// from the revisions in args but not from any of the commits in watermark,
+// which is the set of commit hashes the revisions resolved to on a previous
+// search. It returns the commit hashes the revisions resolve to now, which is
+// the watermark for the next search.
+//
+// If the revisions resolve to the same commits as watermark, doSearch is not
+// called at all. The new watermark is returned even if doSearch fails, since
+// results may already have been streamed.
+func SearchAfterWatermark(ctx context.Context, gs GitserverClient, args *gitprotocol.SearchRequest, watermark []string, doSearch DoSearchFunc) (newWatermark []string, err error) {
+	// Resolve the requested revisions into a static set of commit hashes
+	commitHashes, err := gs.ResolveRevisions(ctx, args.Repo, args.Revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	if SameWatermark(commitHashes, watermark) {
+		// Early return if the repo hasn't changed since the last search
+		return commitHashes, nil
+	}
+
+	// Merge requested hashes and excluded hashes
+	newRevs := make([]gitprotocol.RevisionSpecifier, 0, len(commitHashes)+len(watermark))
+	for _, hash := range commitHashes {
+		newRevs = append(newRevs, gitprotocol.RevisionSpecifier{RevSpec: hash})
+	}
+	for _, exclude := range watermark {
+		newRevs = append(newRevs, gitprotocol.RevisionSpecifier{RevSpec: "^" + exclude})
+	}
+
+	// Update args with the new set of revisions
+	argsCopy := *args
+	argsCopy.Revisions = newRevs
+
+	if err := doSearch(&argsCopy); err != nil {
+		return commitHashes, errors.Wrap(err, "search failed, some commits may be skipped")
+	}
+	return commitHashes, nil
+}
+
+// SameWatermark returns whether the watermarks left and right contain the same
+// commit hashes, in any order.
+func SameWatermark(left, right []string) bool {
+	if len(left) != len(right) {
+		return false
+	}
+
+	left = append([]string(nil), left...)
+	right = append([]string(nil), right...)
+	sort.Strings(left)
+	sort.Strings(right)
+
+	for i := range left {
+		if right[i] != left[i] {
+			return false
+		}
+	}
+	return true
+}
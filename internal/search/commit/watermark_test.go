@@ -0,0 +1,70 @@
+package commit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+type resolvingGitserverClient struct {
+	GitserverClient
+	resolved []string
+}
+
+func (c resolvingGitserverClient) ResolveRevisions(context.Context, api.RepoName, []protocol.RevisionSpecifier) ([]string, error) {
+	return c.resolved, nil
+}
+
+func TestSearchAfterWatermark(t *testing.T) {
+	ctx := context.Background()
+	gs := resolvingGitserverClient{resolved: []string{"hash3", "hash4"}}
+
+	t.Run("no watermark", func(t *testing.T) {
+		var searched []protocol.RevisionSpecifier
+		watermark, err := SearchAfterWatermark(ctx, gs, &protocol.SearchRequest{}, nil, func(args *protocol.SearchRequest) error {
+			searched = args.Revisions
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"hash3", "hash4"}, watermark)
+		require.Equal(t, []protocol.RevisionSpecifier{{RevSpec: "hash3"}, {RevSpec: "hash4"}}, searched)
+	})
+
+	t.Run("excludes previous watermark", func(t *testing.T) {
+		var searched []protocol.RevisionSpecifier
+		watermark, err := SearchAfterWatermark(ctx, gs, &protocol.SearchRequest{}, []string{"hash1", "hash2"}, func(args *protocol.SearchRequest) error {
+			searched = args.Revisions
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"hash3", "hash4"}, watermark)
+		require.Equal(t, []protocol.RevisionSpecifier{
+			{RevSpec: "hash3"},
+			{RevSpec: "hash4"},
+			{RevSpec: "^hash1"},
+			{RevSpec: "^hash2"},
+		}, searched)
+	})
+
+	t.Run("unchanged watermark skips search", func(t *testing.T) {
+		watermark, err := SearchAfterWatermark(ctx, gs, &protocol.SearchRequest{}, []string{"hash4", "hash3"}, func(*protocol.SearchRequest) error {
+			t.Fatal("unexpected search")
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"hash3", "hash4"}, watermark)
+	})
+
+	t.Run("search error still returns watermark", func(t *testing.T) {
+		watermark, err := SearchAfterWatermark(ctx, gs, &protocol.SearchRequest{}, nil, func(*protocol.SearchRequest) error {
+			return errors.New("oops")
+		})
+		require.ErrorContains(t, err, "some commits may be skipped")
+		require.Equal(t, []string{"hash3", "hash4"}, watermark)
+	})
+}
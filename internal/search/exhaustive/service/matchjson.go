@@ -9,6 +9,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/exhaustive/uploadstore"
 	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
 // NewJSONWriter creates a MatchJSONWriter which appends matches to a JSON array
@@ -42,6 +43,35 @@ func (m MatchJSONWriter) Write(match result.Match) error {
 	return m.w.Append(eventMatch)
 }
 
+// ErrMatchLimitReached is returned by a MatchWriter created with
+// NewLimitMatchWriter once it has written limit results. Callers should treat
+// it as a signal to stop searching rather than as a failure.
+var ErrMatchLimitReached = errors.New("match limit reached")
+
+// NewLimitMatchWriter wraps w such that at most limit results are written.
+// The match which crosses the limit is truncated.
+func NewLimitMatchWriter(w MatchWriter, limit int) MatchWriter {
+	return &limitMatchWriter{w: w, remaining: limit}
+}
+
+type limitMatchWriter struct {
+	w         MatchWriter
+	remaining int
+}
+
+func (l *limitMatchWriter) Write(match result.Match) error {
+	if l.remaining <= 0 {
+		return ErrMatchLimitReached
+	}
+
+	if match.ResultCount() > l.remaining {
+		match.Limit(l.remaining)
+	}
+	l.remaining -= match.ResultCount()
+
+	return l.w.Write(match)
+}
+
 type blobUploader struct {
 	ctx    context.Context
 	store  uploadstore.Store
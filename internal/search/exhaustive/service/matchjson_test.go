@@ -74,6 +74,27 @@ func mkFileMatch(repo types.MinimalRepo, path string, lineNumbers ...int) *resul
 	}
 }
 
+func TestLimitMatchWriter(t *testing.T) {
+	var written []result.Match
+	w := NewLimitMatchWriter(matchWriterFunc(func(m result.Match) error {
+		written = append(written, m)
+		return nil
+	}), 3)
+
+	repo := types.MinimalRepo{ID: 1, Name: "repo"}
+
+	require.NoError(t, w.Write(mkFileMatch(repo, "a.go", 1)))
+	require.NoError(t, w.Write(mkFileMatch(repo, "b.go", 1, 2, 3)))
+	require.ErrorIs(t, w.Write(mkFileMatch(repo, "c.go", 1)), ErrMatchLimitReached)
+
+	require.Len(t, written, 2)
+	require.Equal(t, 3, result.Matches(written).ResultCount())
+}
+
+type matchWriterFunc func(result.Match) error
+
+func (f matchWriterFunc) Write(m result.Match) error { return f(m) }
+
 func TestBufferedWriter(t *testing.T) {
 	mockStore := setupMockStore(t)
 
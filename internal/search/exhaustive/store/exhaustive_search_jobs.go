@@ -391,6 +391,46 @@ func (s *Store) DeleteExhaustiveSearchJob(ctx context.Context, id int64) (err er
 	return s.Exec(ctx, sqlf.Sprintf(deleteExhaustiveSearchJobQueryFmtStr, id))
 }
 
+// ListSearchJobsToNotify returns up to limit search jobs that have finished,
+// either completed or failed, and whose initiator has not been notified yet.
+// It is meant to be called by the worker, so it does not check permissions.
+func (s *Store) ListSearchJobsToNotify(ctx context.Context, limit int) (jobs []*types.ExhaustiveSearchJob, err error) {
+	ctx, _, endObservation := s.operations.listSearchJobsToNotify.With(ctx, &err, opAttrs(
+		attribute.Int("limit", limit),
+	))
+	defer func() {
+		endObservation(1, opAttrs(attribute.Int("length", len(jobs))))
+	}()
+
+	q := listSearchJobQuery(sqlf.Sprintf(listSearchJobsToNotifyWhereFmtStr, limit))
+
+	return scanExhaustiveSearchJobsList(s.Store.Query(ctx, q))
+}
+
+const listSearchJobsToNotifyWhereFmtStr = `
+WHERE agg_state IN ('completed', 'failed')
+AND id IN (SELECT id FROM exhaustive_search_jobs WHERE notified_at IS NULL)
+ORDER BY id ASC
+LIMIT %s
+`
+
+// MarkSearchJobNotified records that the initiator of the search job has been
+// notified about its completion.
+func (s *Store) MarkSearchJobNotified(ctx context.Context, id int64) (err error) {
+	ctx, _, endObservation := s.operations.markSearchJobNotified.With(ctx, &err, opAttrs(
+		attribute.Int64("ID", id),
+	))
+	defer endObservation(1, observation.Args{})
+
+	return s.Exec(ctx, sqlf.Sprintf(markSearchJobNotifiedFmtStr, time.Now(), id))
+}
+
+const markSearchJobNotifiedFmtStr = `
+UPDATE exhaustive_search_jobs
+SET notified_at = %s
+WHERE id = %s
+`
+
 // | state      | count |
 // |------------|-------|
 // | processing | 2     |
@@ -396,6 +396,64 @@ func TestStore_AggregateStatus(t *testing.T) {
 	}
 }
 
+func TestStore_SearchJobsToNotify(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	logger := logtest.Scoped(t)
+	db := database.NewDB(logger, dbtest.NewDB(t))
+	bs := basestore.NewWithHandle(db.Handle())
+
+	_, err := createRepo(db, "repo1")
+	require.NoError(t, err)
+
+	userID, err := createUser(bs, "alice")
+	require.NoError(t, err)
+
+	s := store.New(db, &observation.TestContext)
+	ctx := actor.WithActor(context.Background(), actor.FromUser(userID))
+
+	completed := createJobCascade(t, ctx, s, stateCascade{
+		searchJob:   types.JobStateCompleted,
+		repoJobs:    []types.JobState{types.JobStateCompleted},
+		repoRevJobs: []types.JobState{types.JobStateCompleted},
+	})
+	failed := createJobCascade(t, ctx, s, stateCascade{
+		searchJob:   types.JobStateCompleted,
+		repoJobs:    []types.JobState{types.JobStateCompleted},
+		repoRevJobs: []types.JobState{types.JobStateCompleted, types.JobStateFailed},
+	})
+	_ = createJobCascade(t, ctx, s, stateCascade{
+		searchJob:   types.JobStateCompleted,
+		repoJobs:    []types.JobState{types.JobStateCompleted},
+		repoRevJobs: []types.JobState{types.JobStateProcessing},
+	})
+	_ = createJobCascade(t, ctx, s, stateCascade{
+		searchJob: types.JobStateCanceled,
+	})
+
+	internalCtx := actor.WithInternalActor(context.Background())
+
+	jobIDs := func() []int64 {
+		jobs, err := s.ListSearchJobsToNotify(internalCtx, 10)
+		require.NoError(t, err)
+		ids := make([]int64, 0, len(jobs))
+		for _, j := range jobs {
+			ids = append(ids, j.ID)
+		}
+		return ids
+	}
+
+	require.Equal(t, []int64{completed, failed}, jobIDs())
+
+	require.NoError(t, s.MarkSearchJobNotified(internalCtx, completed))
+	require.Equal(t, []int64{failed}, jobIDs())
+
+	require.NoError(t, s.MarkSearchJobNotified(internalCtx, failed))
+	require.Empty(t, jobIDs())
+}
+
 // createJobCascade creates a cascade of jobs (1 search job -> n repo jobs -> m
 // repo rev jobs) with states as defined in stateCascade.
 //
@@ -65,6 +65,8 @@ type operations struct {
 	userHasAccess             *observation.Operation
 	listExhaustiveSearchJobs  *observation.Operation
 	deleteExhaustiveSearchJob *observation.Operation
+	listSearchJobsToNotify    *observation.Operation
+	markSearchJobNotified     *observation.Operation
 
 	createExhaustiveSearchRepoJob         *observation.Operation
 	createExhaustiveSearchRepoRevisionJob *observation.Operation
@@ -98,6 +100,8 @@ func newOperations(observationCtx *observation.Context) *operations {
 		userHasAccess:             op("UserHasAccess"),
 		listExhaustiveSearchJobs:  op("ListExhaustiveSearchJobs"),
 		deleteExhaustiveSearchJob: op("DeleteExhaustiveSearchJob"),
+		listSearchJobsToNotify:    op("ListSearchJobsToNotify"),
+		markSearchJobNotified:     op("MarkSearchJobNotified"),
 
 		createExhaustiveSearchRepoJob:         op("CreateExhaustiveSearchRepoJob"),
 		createExhaustiveSearchRepoRevisionJob: op("CreateExhaustiveSearchRepoRevisionJob"),
@@ -34,10 +34,13 @@ type object map[string]object
 
 var validSelectors = object{
 	Commit: object{
+		"author": nil,
+		"date":   nil,
 		"diff": object{
 			"added":   nil,
 			"removed": nil,
 		},
+		"ref": nil,
 	},
 	Content: nil,
 	File: {
@@ -63,7 +63,11 @@ func (j *alertJob) Run(ctx context.Context, clients job.RuntimeClients, stream s
 		if !statsObserver.Status.Any(search.RepoStatusTimedOut) {
 			usedTime := time.Since(start)
 			suggestTime := longer(2, usedTime)
-			return search.AlertForTimeout(usedTime, suggestTime, j.inputs.OriginalQuery, j.inputs.PatternType), nil
+			timeoutAlert := search.AlertForTimeout(usedTime, suggestTime, j.inputs.OriginalQuery, j.inputs.PatternType)
+			if a := search.MaxPriorityAlert(jobAlert, observerAlert); a != nil {
+				timeoutAlert.GeneratedQueries = a.GeneratedQueries
+			}
+			return timeoutAlert, nil
 		} else {
 			err = nil
 		}
@@ -0,0 +1,101 @@
+package jobutil
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/job"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+)
+
+// NewDiffLanguageFilterJob creates a filter job to post-filter diff results
+// for lang: and -lang: filters.
+//
+// Commit search evaluates lang: filters by matching file extensions, which is
+// ambiguous for extensions that are shared by several languages. This job
+// checks each diff result against the languages detected for its modified
+// files (see result.CommitMatch.ModifiedLanguages), so that the results agree
+// with the language breakdown sent to the client. All filters are AND'ed
+// together. Results that are not diffs are passed through unchanged.
+//
+// include and exclude are expected to be canonical language names, as
+// returned by enry.GetLanguageByAlias.
+func NewDiffLanguageFilterJob(child job.Job, include, exclude []string) job.Job {
+	return &diffLanguageFilterJob{
+		child:   child,
+		include: include,
+		exclude: exclude,
+	}
+}
+
+type diffLanguageFilterJob struct {
+	child job.Job
+
+	include []string
+	exclude []string
+}
+
+func (j *diffLanguageFilterJob) Run(ctx context.Context, clients job.RuntimeClients, stream streaming.Sender) (alert *search.Alert, err error) {
+	_, ctx, stream, finish := job.StartSpan(ctx, stream, j)
+	defer finish(alert, err)
+
+	filteredStream := streaming.StreamFunc(func(event streaming.SearchEvent) {
+		filtered := event.Results[:0]
+		for _, res := range event.Results {
+			if cm, ok := res.(*result.CommitMatch); ok && cm.DiffPreview != nil && !j.Filtered(cm.ModifiedLanguages()) {
+				continue
+			}
+			filtered = append(filtered, res)
+		}
+		event.Results = filtered
+		stream.Send(event)
+	})
+
+	return j.child.Run(ctx, clients, filteredStream)
+}
+
+func (j *diffLanguageFilterJob) MapChildren(fn job.MapFunc) job.Job {
+	cp := *j
+	cp.child = job.Map(j.child, fn)
+	return &cp
+}
+
+func (j *diffLanguageFilterJob) Name() string {
+	return "DiffLanguageFilterJob"
+}
+
+func (j *diffLanguageFilterJob) Children() []job.Describer {
+	return []job.Describer{j.child}
+}
+
+func (j *diffLanguageFilterJob) Attributes(v job.Verbosity) (res []attribute.KeyValue) {
+	switch v {
+	case job.VerbosityMax:
+		fallthrough
+	case job.VerbosityBasic:
+		res = append(res,
+			attribute.StringSlice("includeLanguages", j.include),
+			attribute.StringSlice("excludeLanguages", j.exclude),
+		)
+	}
+	return res
+}
+
+// Filtered returns true if a diff modifying files in the given languages
+// passes all include and exclude filters.
+func (j *diffLanguageFilterJob) Filtered(languages map[string]int) bool {
+	for _, lang := range j.include {
+		if languages[lang] == 0 {
+			return false
+		}
+	}
+	for _, lang := range j.exclude {
+		if languages[lang] > 0 {
+			return false
+		}
+	}
+	return true
+}
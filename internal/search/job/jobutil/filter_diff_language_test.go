@@ -0,0 +1,77 @@
+package jobutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/job"
+	"github.com/sourcegraph/sourcegraph/internal/search/job/mockjob"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+)
+
+func TestDiffLanguageFilterJob(t *testing.T) {
+	diff := func(paths ...string) *result.CommitMatch {
+		cm := &result.CommitMatch{DiffPreview: &result.MatchedString{}}
+		for _, path := range paths {
+			cm.Diff = append(cm.Diff, result.DiffFile{OrigName: path, NewName: path})
+		}
+		return cm
+	}
+
+	goDiff := diff("main.go")
+	mixedDiff := diff("main.go", "README.md")
+	commit := &result.CommitMatch{}
+	file := &result.FileMatch{File: result.File{Path: "main.c"}}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		matches result.Matches
+		want    result.Matches
+	}{{
+		name:    "include keeps diffs modifying the language",
+		include: []string{"Go"},
+		matches: result.Matches{goDiff, diff("main.c")},
+		want:    result.Matches{goDiff},
+	}, {
+		name:    "include requires every language",
+		include: []string{"Go", "Markdown"},
+		matches: result.Matches{goDiff, mixedDiff},
+		want:    result.Matches{mixedDiff},
+	}, {
+		name:    "exclude drops diffs modifying the language",
+		exclude: []string{"Markdown"},
+		matches: result.Matches{goDiff, mixedDiff},
+		want:    result.Matches{goDiff},
+	}, {
+		name:    "non-diff results are passed through",
+		include: []string{"Go"},
+		matches: result.Matches{commit, file},
+		want:    result.Matches{commit, file},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			childJob := mockjob.NewMockJob()
+			childJob.RunFunc.SetDefaultHook(func(_ context.Context, _ job.RuntimeClients, s streaming.Sender) (*search.Alert, error) {
+				s.Send(streaming.SearchEvent{Results: append(result.Matches{}, tc.matches...)})
+				return nil, nil
+			})
+
+			var got result.Matches
+			streamCollector := streaming.StreamFunc(func(ev streaming.SearchEvent) {
+				got = append(got, ev.Results...)
+			})
+
+			j := NewDiffLanguageFilterJob(childJob, tc.include, tc.exclude)
+			alert, err := j.Run(context.Background(), job.RuntimeClients{}, streamCollector)
+			require.Nil(t, alert)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package jobutil
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/regexp"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/job"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+)
+
+// NewGroupByRepoJob creates a job that collapses the commit and diff matches
+// of its child into one result.CommitGroupMatch per repository. Other matches
+// are streamed unchanged. query is the query the child was built from, and is
+// scoped to each repository to let clients expand a group.
+func NewGroupByRepoJob(query string, child job.Job) job.Job {
+	return &groupByRepoJob{query: query, child: child}
+}
+
+type groupByRepoJob struct {
+	query string
+	child job.Job
+}
+
+func (j *groupByRepoJob) Run(ctx context.Context, clients job.RuntimeClients, stream streaming.Sender) (alert *search.Alert, err error) {
+	_, ctx, stream, finish := job.StartSpan(ctx, stream, j)
+	defer func() { finish(alert, err) }()
+
+	return j.child.Run(ctx, clients, newGroupingStream(stream, j.query))
+}
+
+func (j *groupByRepoJob) Name() string {
+	return "GroupByRepoJob"
+}
+
+func (j *groupByRepoJob) Attributes(v job.Verbosity) (res []attribute.KeyValue) {
+	switch v {
+	case job.VerbosityMax:
+		fallthrough
+	case job.VerbosityBasic:
+		res = append(res,
+			attribute.String("query", j.query),
+		)
+	}
+	return res
+}
+
+func (j *groupByRepoJob) Children() []job.Describer {
+	return []job.Describer{j.child}
+}
+
+func (j *groupByRepoJob) MapChildren(fn job.MapFunc) job.Job {
+	cp := *j
+	cp.child = job.Map(j.child, fn)
+	return &cp
+}
+
+// newGroupingStream returns a child Stream of parent that replaces commit and
+// diff matches with the updated summaries of their repositories.
+func newGroupingStream(parent streaming.Sender, query string) streaming.Sender {
+	var mux sync.Mutex
+	groups := make(map[api.RepoID]*result.CommitGroupMatch)
+
+	return streaming.StreamFunc(func(e streaming.SearchEvent) {
+		// We send while holding the lock so that the summaries of a
+		// repository reach the parent in the order of their totals.
+		mux.Lock()
+		defer mux.Unlock()
+
+		var updated []*result.CommitGroupMatch
+		newResults := make(map[api.RepoID]int)
+
+		results := e.Results[:0]
+		for _, match := range e.Results {
+			var isDiff bool
+			switch v := match.(type) {
+			case *result.CommitMatch:
+				isDiff = v.DiffPreview != nil
+			case *result.CommitDiffMatch:
+				isDiff = true
+			default:
+				results = append(results, match)
+				continue
+			}
+
+			repo := match.RepoName()
+			group, ok := groups[repo.ID]
+			if !ok {
+				group = &result.CommitGroupMatch{
+					Repo:  repo,
+					Query: fmt.Sprintf("repo:^%s$ %s", regexp.QuoteMeta(string(repo.Name)), query),
+				}
+				groups[repo.ID] = group
+			}
+			if _, ok := newResults[repo.ID]; !ok {
+				updated = append(updated, group)
+			}
+
+			if isDiff {
+				group.DiffCount++
+			} else {
+				group.CommitCount++
+			}
+			newResults[repo.ID] += match.ResultCount()
+		}
+
+		// Send copies, since the summaries of a repository keep changing
+		// and matches may be modified downstream.
+		for _, group := range updated {
+			summary := *group
+			summary.NewResults = newResults[group.Repo.ID]
+			results = append(results, &summary)
+		}
+		e.Results = results
+
+		parent.Send(e)
+	})
+}
@@ -0,0 +1,114 @@
+package jobutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/job"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestGroupingStream(t *testing.T) {
+	foo := types.MinimalRepo{ID: 1, Name: "github.com/sourcegraph/foo"}
+	bar := types.MinimalRepo{ID: 2, Name: "github.com/sourcegraph/bar"}
+
+	commit := func(repo types.MinimalRepo, id string) *result.CommitMatch {
+		return &result.CommitMatch{Repo: repo, Commit: gitdomain.Commit{ID: api.CommitID(id)}}
+	}
+	diff := func(repo types.MinimalRepo, id string) *result.CommitMatch {
+		cm := commit(repo, id)
+		cm.DiffPreview = &result.MatchedString{
+			Content:       "diff",
+			MatchedRanges: make(result.Ranges, 2),
+		}
+		return cm
+	}
+	repoMatch := &result.RepoMatch{Name: bar.Name, ID: bar.ID}
+
+	agg := streaming.NewAggregatingStream()
+	s := newGroupingStream(agg, "type:commit fix")
+
+	s.Send(streaming.SearchEvent{Results: result.Matches{
+		commit(foo, "1"),
+		repoMatch,
+		diff(bar, "2"),
+		commit(foo, "3"),
+	}})
+	s.Send(streaming.SearchEvent{Results: result.Matches{
+		diff(foo, "4"),
+	}})
+
+	require.Equal(t, result.Matches{
+		repoMatch,
+		&result.CommitGroupMatch{
+			Repo:        foo,
+			CommitCount: 2,
+			Query:       `repo:^github\.com/sourcegraph/foo$ type:commit fix`,
+			NewResults:  2,
+		},
+		&result.CommitGroupMatch{
+			Repo:       bar,
+			DiffCount:  1,
+			Query:      `repo:^github\.com/sourcegraph/bar$ type:commit fix`,
+			NewResults: 2,
+		},
+		// The second summary of foo holds the totals so far, but only
+		// counts the new diff match as results.
+		&result.CommitGroupMatch{
+			Repo:        foo,
+			CommitCount: 2,
+			DiffCount:   1,
+			Query:       `repo:^github\.com/sourcegraph/foo$ type:commit fix`,
+			NewResults:  2,
+		},
+	}, agg.Results)
+}
+
+func TestNewPlanJob_GroupCommitsByRepo(t *testing.T) {
+	test := func(q string) (groupJobs int, parent string) {
+		plan, err := query.Pipeline(query.Init(q, query.SearchTypeLiteral))
+		require.NoError(t, err)
+
+		inputs := &search.Inputs{
+			UserSettings: &schema.Settings{},
+			PatternType:  query.SearchTypeLiteral,
+			Protocol:     search.Streaming,
+			Features:     &search.Features{GroupCommitsByRepo: true},
+		}
+		j, err := NewPlanJob(inputs, plan)
+		require.NoError(t, err)
+
+		var visit func(d job.Describer, parentName string)
+		visit = func(d job.Describer, parentName string) {
+			if _, ok := d.(*groupByRepoJob); ok {
+				groupJobs++
+				parent = parentName
+			}
+			for _, child := range d.Children() {
+				visit(child, d.Name())
+			}
+		}
+		visit(j, "")
+		return groupJobs, parent
+	}
+
+	// Grouping is applied once, above the combinators, so that they merge
+	// the underlying matches instead of summaries.
+	groupJobs, parent := test("type:commit foo or type:diff bar")
+	require.Equal(t, 1, groupJobs)
+	require.Equal(t, "AlertJob", parent)
+
+	groupJobs, _ = test("type:commit foo and bar")
+	require.Equal(t, 1, groupJobs)
+
+	groupJobs, _ = test("type:file foo")
+	require.Equal(t, 0, groupJobs)
+}
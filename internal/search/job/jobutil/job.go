@@ -4,6 +4,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-enry/go-enry/v2"
 	"github.com/grafana/regexp"
 
 	zoektquery "github.com/sourcegraph/zoekt/query"
@@ -41,9 +42,13 @@ func NewPlanJob(inputs *search.Inputs, plan query.Plan) (job.Job, error) {
 		children = append(children, child)
 	}
 
-	jobTree := NewOrJob(children...)
+	jobTree := normalizeExpressionJobs(NewOrJob(children...))
 	newJob := func(b query.Basic) (job.Job, error) {
-		return NewBasicJob(inputs, b)
+		j, err := NewBasicJob(inputs, b)
+		if err != nil {
+			return nil, err
+		}
+		return normalizeExpressionJobs(j), nil
 	}
 
 	if inputs.PatternType == query.SearchTypeCodyContext {
@@ -61,6 +66,17 @@ func NewPlanJob(inputs *search.Inputs, plan query.Plan) (job.Job, error) {
 
 	if inputs.SearchMode == search.SmartSearch || inputs.PatternType == query.SearchTypeLucky {
 		jobTree = smartsearch.NewSmartSearchJob(jobTree, newJob, plan)
+		if inputs.RegexpParseError != nil {
+			jobTree = smartsearch.NewLiteralFallbackJob(jobTree, plan, inputs.RegexpParseError)
+		}
+	}
+
+	{ // Group commit and diff matches by repository if requested
+		// This wraps the whole tree so that combinators merge and dedupe the
+		// underlying matches rather than the per-repository summaries.
+		if inputs.Features.GroupCommitsByRepo && planHasCommitResults(inputs, plan) {
+			jobTree = NewGroupByRepoJob(query.StringHuman(plan.ToQ()), jobTree)
+		}
 	}
 
 	alertJob := NewAlertJob(inputs, jobTree)
@@ -68,6 +84,17 @@ func NewPlanJob(inputs *search.Inputs, plan query.Plan) (job.Job, error) {
 	return logJob, nil
 }
 
+// planHasCommitResults returns whether any query in plan searches for commit
+// or diff results.
+func planHasCommitResults(inputs *search.Inputs, plan query.Plan) bool {
+	for _, b := range plan {
+		if computeResultTypes(b, inputs.PatternType).Has(result.TypeCommit | result.TypeDiff) {
+			return true
+		}
+	}
+	return false
+}
+
 // NewBasicJob converts a query.Basic into its job tree representation.
 func NewBasicJob(inputs *search.Inputs, b query.Basic) (job.Job, error) {
 	var children []job.Job
@@ -166,15 +193,23 @@ func NewBasicJob(inputs *search.Inputs, b query.Basic) (job.Job, error) {
 		if resultTypes.Has(result.TypeCommit) || resultTypes.Has(result.TypeDiff) {
 			_, _, own := isOwnershipSearch(b)
 			diff := resultTypes.Has(result.TypeDiff)
-			repoOptionsCopy := repoOptions
+			repoOptionsCopy, gitQuery, commitAfter := commit.PlanCommitAfter(repoOptions, commit.QueryToGitQuery(originalQuery, diff))
 			repoOptionsCopy.OnlyCloned = true
-			addJob(&commit.SearchJob{
-				Query:                commit.QueryToGitQuery(originalQuery, diff),
+			commitJob := &commit.SearchJob{
+				Query:                gitQuery,
 				RepoOpts:             repoOptionsCopy,
+				CommitAfter:          commitAfter,
 				Diff:                 diff,
 				Limit:                int(fileMatchLimit),
 				IncludeModifiedFiles: authz.SubRepoEnabled(authz.DefaultSubRepoPermsChecker) || own,
-			})
+				RefFilters:           commit.QueryToRefFilters(originalQuery),
+				PerRef:               selector.Root() == filter.Commit && len(selector) > 1 && selector[1] == "ref",
+			}
+			if w := inputs.CommitWatermarks; w != nil {
+				commitJob.AfterWatermarks = w.After
+				commitJob.OnWatermark = w.Advance
+			}
+			addJob(commitJob)
 		}
 
 		addJob(&searchrepos.ComputeExcludedJob{
@@ -219,6 +254,12 @@ func NewBasicJob(inputs *search.Inputs, b query.Basic) (job.Job, error) {
 		}
 	}
 
+	{ // Apply lang: post-search filter to diff results
+		if includeLangs, excludeLangs, ok := isDiffLanguageSearch(b, inputs.PatternType); ok {
+			basicJob = NewDiffLanguageFilterJob(basicJob, includeLangs, excludeLangs)
+		}
+	}
+
 	{ // Apply subrepo permissions checks
 		checker := authz.DefaultSubRepoPermsChecker
 		if authz.SubRepoEnabled(checker) {
@@ -580,6 +621,24 @@ func isContributorSearch(b query.Basic) (include, exclude []string, ok bool) {
 	return nil, nil, false
 }
 
+// isDiffLanguageSearch returns the canonical names of the languages in the
+// lang: and -lang: filters of a query that searches diffs.
+func isDiffLanguageSearch(b query.Basic, searchType query.SearchType) (include, exclude []string, ok bool) {
+	if !computeResultTypes(b, searchType).Has(result.TypeDiff) {
+		return nil, nil, false
+	}
+	langInclude, langExclude := b.IncludeExcludeValues(query.FieldLang)
+	if len(langInclude) == 0 && len(langExclude) == 0 {
+		return nil, nil, false
+	}
+	return mapSlice(langInclude, languageName), mapSlice(langExclude, languageName), true
+}
+
+func languageName(lang string) string {
+	name, _ := enry.GetLanguageByAlias(lang) // Invariant: lang is valid.
+	return name
+}
+
 func contributorsAsRegexp(contributors []string, isCaseSensitive bool) (res []*regexp.Regexp) {
 	for _, pattern := range contributors {
 		if isCaseSensitive {
@@ -548,9 +548,7 @@ func TestNewPlanJob(t *testing.T) {
               (limit . 2000)
               (repoOpts.onlyCloned . true))
             REPOSCOMPUTEEXCLUDED
-            (OR
-              NOOP
-              NOOP))))
+            NOOP)))
       (TIMEOUT
         (timeout . 20s)
         (LIMIT
@@ -562,9 +560,7 @@ func TestNewPlanJob(t *testing.T) {
               (limit . 2000)
               (repoOpts.onlyCloned . true))
             REPOSCOMPUTEEXCLUDED
-            (OR
-              NOOP
-              NOOP)))))))`),
+            NOOP))))))`),
 	}, {
 		query:      `(type:repo a) or (type:file b)`,
 		protocol:   search.Streaming,
@@ -614,9 +610,7 @@ func TestNewPlanJob(t *testing.T) {
             (query . (or sym:substr:"a" sym:substr:"b"))
             (type . symbol))
           REPOSCOMPUTEEXCLUDED
-          (OR
-            NOOP
-            NOOP))))))`),
+          NOOP)))))`),
 	},
 		{
 			query:      `repo:contains.path(a) repo:contains.content(b)`,
@@ -0,0 +1,160 @@
+package jobutil
+
+import (
+	"reflect"
+
+	"github.com/sourcegraph/sourcegraph/internal/search/job"
+)
+
+// normalizeExpressionJobs simplifies the AndJobs and OrJobs of a job tree.
+// Generated plans, in particular after smart search expansion, contain
+// nested expressions and repeated operands, each of which would otherwise
+// fan out to the search backends separately.
+//
+//   - Nested AndJobs and OrJobs are flattened into their parent.
+//   - Duplicate operands of an expression are removed, as are NoopJob operands
+//     of OrJobs. Expressions that are left with a single operand are replaced
+//     by that operand.
+//   - Operands that occur in several expressions run once, and their events are
+//     teed to each of the expressions. See teeJob.
+//
+// Jobs are considered equal if they are deeply equal.
+func normalizeExpressionJobs(j job.Job) job.Job {
+	j = job.Map(j, flattenExpressionJob)
+	return teeDuplicateOperands(j)
+}
+
+// flattenExpressionJob flattens and deduplicates the operands of an AndJob or
+// OrJob. The operands must already be flattened.
+func flattenExpressionJob(j job.Job) job.Job {
+	switch v := j.(type) {
+	case *AndJob:
+		operands := make([]job.Job, 0, len(v.children))
+		for _, child := range v.children {
+			if and, ok := child.(*AndJob); ok {
+				operands = append(operands, and.children...)
+			} else {
+				operands = append(operands, child)
+			}
+		}
+		return NewAndJob(dedupJobs(operands)...)
+
+	case *OrJob:
+		operands := make([]job.Job, 0, len(v.children))
+		for _, child := range v.children {
+			switch c := child.(type) {
+			case *OrJob:
+				operands = append(operands, c.children...)
+			case *NoopJob:
+				// A NoopJob never contributes matches to a union.
+			default:
+				operands = append(operands, child)
+			}
+		}
+		return NewOrJob(dedupJobs(operands)...)
+
+	default:
+		return j
+	}
+}
+
+// dedupJobs returns jobs without the jobs that are equal to an earlier job.
+func dedupJobs(jobs []job.Job) []job.Job {
+	deduped := make([]job.Job, 0, len(jobs))
+OUTER:
+	for _, j := range jobs {
+		for _, seen := range deduped {
+			if reflect.DeepEqual(j, seen) {
+				continue OUTER
+			}
+		}
+		deduped = append(deduped, j)
+	}
+	return deduped
+}
+
+// teeDuplicateOperands replaces operands that occur in more than one
+// expression of the tree by teeJobs that share a single run of the operand.
+//
+// Only operands that don't contain expressions themselves are teed. These are
+// the subtrees that actually query the search backends, and it ensures that
+// teed operands are never nested in each other.
+func teeDuplicateOperands(j job.Job) job.Job {
+	type operand struct {
+		job    job.Job
+		count  int
+		shared *sharedRun
+	}
+	var operands []*operand
+	lookup := func(j job.Job) *operand {
+		for _, o := range operands {
+			if reflect.DeepEqual(o.job, j) {
+				return o
+			}
+		}
+		return nil
+	}
+
+	job.Visit(j, func(d job.Describer) {
+		for _, child := range expressionOperands(d) {
+			if !isTeeable(child) {
+				continue
+			}
+			if o := lookup(child); o != nil {
+				o.count++
+			} else {
+				operands = append(operands, &operand{job: child, count: 1})
+			}
+		}
+	})
+
+	teeOperands := func(children []job.Job) []job.Job {
+		res := make([]job.Job, 0, len(children))
+		for _, child := range children {
+			o := lookup(child)
+			if o == nil || o.count < 2 {
+				res = append(res, child)
+				continue
+			}
+			if o.shared == nil {
+				o.shared = &sharedRun{child: o.job}
+			}
+			res = append(res, &teeJob{shared: o.shared})
+		}
+		return res
+	}
+
+	return job.Map(j, func(j job.Job) job.Job {
+		switch v := j.(type) {
+		case *AndJob:
+			cp := *v
+			cp.children = teeOperands(v.children)
+			return &cp
+		case *OrJob:
+			cp := *v
+			cp.children = teeOperands(v.children)
+			return &cp
+		default:
+			return j
+		}
+	})
+}
+
+// expressionOperands returns the operands of d if it is an AndJob or OrJob.
+func expressionOperands(d job.Describer) []job.Job {
+	switch v := d.(type) {
+	case *AndJob:
+		return v.children
+	case *OrJob:
+		return v.children
+	default:
+		return nil
+	}
+}
+
+func isTeeable(j job.Job) bool {
+	if _, ok := j.(*NoopJob); ok {
+		return false
+	}
+	return !job.HasDescendent[*AndJob](j) && !job.HasDescendent[*OrJob](j)
+}
@@ -0,0 +1,127 @@
+package jobutil
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/job"
+	"github.com/sourcegraph/sourcegraph/internal/search/job/mockjob"
+	"github.com/sourcegraph/sourcegraph/internal/search/job/printer"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func newNamedMockJob(name string) *mockjob.MockJob {
+	j := mockjob.NewMockJob()
+	j.NameFunc.SetDefaultReturn(name)
+	j.MapChildrenFunc.SetDefaultHook(func(job.MapFunc) job.Job { return j })
+	return j
+}
+
+func TestNormalizeExpressionJobs(t *testing.T) {
+	a, b, c := newNamedMockJob("A"), newNamedMockJob("B"), newNamedMockJob("C")
+
+	test := func(j job.Job) string {
+		return "\n" + printer.SexpPretty(normalizeExpressionJobs(j))
+	}
+
+	autogold.Expect(`
+(OR
+  A
+  B
+  C)`).Equal(t, test(NewOrJob(NewOrJob(a, b), c)))
+
+	autogold.Expect(`
+(AND
+  A
+  B)`).Equal(t, test(NewAndJob(a, NewAndJob(b, a))))
+
+	autogold.Expect(`
+NOOP`).Equal(t, test(NewOrJob(NewNoopJob(), NewNoopJob())))
+
+	autogold.Expect(`
+A`).Equal(t, test(NewOrJob(a, NewNoopJob(), a)))
+
+	autogold.Expect(`
+(OR
+  (AND
+    (TEE
+      A)
+    B)
+  (AND
+    (TEE
+      A)
+    C))`).Equal(t, test(NewOrJob(NewAndJob(a, b), NewAndJob(a, c))))
+}
+
+func TestNormalizeExpressionJobs_RunsDuplicateOperandsOnce(t *testing.T) {
+	newFileMatch := func() *result.FileMatch {
+		return &result.FileMatch{
+			File:         result.File{Repo: types.MinimalRepo{Name: "repo"}, Path: "path"},
+			ChunkMatches: result.ChunkMatches{{Content: "content"}},
+		}
+	}
+
+	newMatchJob := func(name string, match *result.FileMatch) *mockjob.MockJob {
+		j := newNamedMockJob(name)
+		j.RunFunc.SetDefaultHook(func(_ context.Context, _ job.RuntimeClients, s streaming.Sender) (*search.Alert, error) {
+			s.Send(streaming.SearchEvent{Results: result.Matches{match}})
+			return nil, nil
+		})
+		return j
+	}
+
+	sharedMatch := newFileMatch()
+	a := newNamedMockJob("A")
+	b := newMatchJob("B", newFileMatch())
+	c := newMatchJob("C", newFileMatch())
+
+	j := normalizeExpressionJobs(NewOrJob(NewAndJob(a, b), NewAndJob(a, c)))
+
+	var shared *sharedRun
+	job.VisitType(j, func(t *teeJob) { shared = t.shared })
+	require.NotNil(t, shared)
+
+	// Hold back the results of A until both expressions subscribed to it, so
+	// that they share its run.
+	a.RunFunc.SetDefaultHook(func(_ context.Context, _ job.RuntimeClients, s streaming.Sender) (*search.Alert, error) {
+		for {
+			shared.mu.Lock()
+			subscribers := shared.current.subscribers
+			shared.mu.Unlock()
+			if subscribers == 2 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		s.Send(streaming.SearchEvent{Results: result.Matches{sharedMatch}})
+		return nil, nil
+	})
+
+	var (
+		mu      sync.Mutex
+		matches result.Matches
+	)
+	_, err := j.Run(context.Background(), job.RuntimeClients{}, streaming.StreamFunc(func(event streaming.SearchEvent) {
+		mu.Lock()
+		matches = append(matches, event.Results...)
+		mu.Unlock()
+	}))
+	require.NoError(t, err)
+
+	require.Len(t, matches, 1)
+	require.Len(t, a.RunFunc.History(), 1)
+	require.Len(t, b.RunFunc.History(), 1)
+	require.Len(t, c.RunFunc.History(), 1)
+
+	// Merging the matches of the expressions must not modify the match
+	// that was teed to both of them.
+	require.Len(t, sharedMatch.ChunkMatches, 1)
+}
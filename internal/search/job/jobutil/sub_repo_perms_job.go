@@ -4,12 +4,15 @@ import (
 	"context"
 	"sync"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/sourcegraph/log"
 	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/job"
 	"github.com/sourcegraph/sourcegraph/internal/search/result"
@@ -17,6 +20,18 @@ import (
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
+var (
+	metricSubRepoPermsFilteredResults = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "src_search_sub_repo_perms_filtered_results_total",
+		Help: "Total number of search results dropped by sub-repo permission filtering.",
+	}, []string{"reason"})
+
+	metricSubRepoPermsFailClosedAborts = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "src_search_sub_repo_perms_fail_closed_aborts_total",
+		Help: "Total number of searches aborted because sub-repo permissions could not be checked.",
+	})
+)
+
 // NewFilterJob creates a job that filters the streamed results
 // of its child job using the default authz.DefaultSubRepoPermsChecker.
 func NewFilterJob(child job.Job) job.Job {
@@ -32,24 +47,48 @@ func (s *subRepoPermsFilterJob) Run(ctx context.Context, clients job.RuntimeClie
 	defer func() { finish(alert, err) }()
 
 	checker := authz.DefaultSubRepoPermsChecker
+	failClosed := subRepoPermsFailClosed()
+
+	// In fail-closed mode we stop the search as soon as permissions can't be
+	// checked, rather than streaming results that may be incomplete.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	var (
-		mu   sync.Mutex
-		errs error
+		mu      sync.Mutex
+		errs    error
+		aborted bool
 	)
 
 	filteredStream := streaming.StreamFunc(func(event streaming.SearchEvent) {
 		var err error
-		event.Results, err = applySubRepoFiltering(ctx, checker, clients.Logger, event.Results)
+		event.Results, err = applySubRepoFiltering(ctx, checker, clients.Logger, event.Results, failClosed)
+
+		mu.Lock()
 		if err != nil {
-			mu.Lock()
+			if failClosed {
+				aborted = true
+				cancel()
+			}
 			errs = errors.Append(errs, err)
-			mu.Unlock()
+		}
+		skip := aborted
+		mu.Unlock()
+
+		if skip {
+			return
 		}
 		stream.Send(event)
 	})
 
 	alert, err = s.child.Run(ctx, clients, filteredStream)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if aborted {
+		metricSubRepoPermsFailClosedAborts.Inc()
+		return search.AlertForSubRepoPermissionsUnavailable(), nil
+	}
 	if err != nil {
 		errs = errors.Append(errs, err)
 	}
@@ -72,9 +111,20 @@ func (s *subRepoPermsFilterJob) MapChildren(fn job.MapFunc) job.Job {
 	return &cp
 }
 
+// subRepoPermsFailClosed returns true if searches must be aborted when
+// sub-repo permissions can't be checked.
+func subRepoPermsFailClosed() bool {
+	if p := conf.ExperimentalFeatures().SubRepoPermissions; p != nil {
+		return p.FailClosed
+	}
+	return false
+}
+
 // applySubRepoFiltering filters a set of matches using the provided
-// authz.SubRepoPermissionChecker
-func applySubRepoFiltering(ctx context.Context, checker authz.SubRepoPermissionChecker, logger log.Logger, matches []result.Match) ([]result.Match, error) {
+// authz.SubRepoPermissionChecker. If failClosed is true, failing to determine
+// whether sub-repo permissions are enabled for a repository is reported as an
+// error, instead of only omitting the matches of that repository.
+func applySubRepoFiltering(ctx context.Context, checker authz.SubRepoPermissionChecker, logger log.Logger, matches []result.Match, failClosed bool) ([]result.Match, error) {
 	if !authz.SubRepoEnabled(checker) {
 		return matches, nil
 	}
@@ -87,9 +137,16 @@ func applySubRepoFiltering(ctx context.Context, checker authz.SubRepoPermissionC
 
 	errCache := map[api.RepoName]struct{}{} // cache repos that errored
 
+	var denied, errored int
+	defer func() {
+		metricSubRepoPermsFilteredResults.WithLabelValues("denied").Add(float64(denied))
+		metricSubRepoPermsFilteredResults.WithLabelValues("error").Add(float64(errored))
+	}()
+
 	for _, m := range matches {
 		// If the check errored before, skip the repo
 		if _, ok := errCache[m.RepoName().Name]; ok {
+			errored++
 			continue
 		}
 		enabled, err := authz.SubRepoEnabledForRepoID(ctx, checker, m.RepoName().ID)
@@ -99,6 +156,10 @@ func applySubRepoFiltering(ctx context.Context, checker authz.SubRepoPermissionC
 				logger.Error("Could not determine if sub-repo permissions are enabled for repo, skipping", log.Error(err), log.String("repoName", string(m.RepoName().Name)))
 			}
 			errCache[m.RepoName().Name] = struct{}{}
+			if failClosed {
+				errs = errors.Append(errs, err)
+			}
+			errored++
 			continue
 		}
 		if !enabled {
@@ -117,22 +178,28 @@ func applySubRepoFiltering(ctx context.Context, checker authz.SubRepoPermissionC
 			perms, err := authz.ActorPermissions(ctx, checker, a, content)
 			if err != nil {
 				errs = errors.Append(errs, err)
+				errored++
 				continue
 			}
 
 			if perms.Include(authz.Read) {
 				filtered = append(filtered, m)
+			} else {
+				denied++
 			}
 		case *result.CommitMatch:
 			allowed, err := authz.CanReadAnyPath(ctx, checker, mm.Repo.Name, mm.ModifiedFiles)
 			if err != nil {
 				errs = errors.Append(errs, err)
+				errored++
 				continue
 			}
 			if allowed {
 				if !diffIsEmpty(mm.DiffPreview) {
 					filtered = append(filtered, m)
 				}
+			} else {
+				denied++
 			}
 		case *result.RepoMatch:
 			// Repo filtering is taken care of by our usual repo filtering logic
@@ -47,16 +47,21 @@ func TestApplySubRepoFiltering(t *testing.T) {
 	})
 
 	noSubRepoPermsID := api.RepoID(42)
+	unavailableID := api.RepoID(43)
 	checker.EnabledForRepoIDFunc.SetDefaultHook(func(ctx context.Context, id api.RepoID) (bool, error) {
 		if id == noSubRepoPermsID {
 			return false, nil
 		}
+		if id == unavailableID {
+			return false, errors.New("unavailable")
+		}
 		return true, nil
 	})
 
 	type args struct {
-		ctxActor *actor.Actor
-		matches  []result.Match
+		ctxActor   *actor.Actor
+		matches    []result.Match
+		failClosed bool
 	}
 	tests := []struct {
 		name        string
@@ -233,12 +238,44 @@ func TestApplySubRepoFiltering(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "omit matches from repos for which sub-repo perms can't be checked",
+			args: args{
+				ctxActor: actor.FromUser(userWithSubRepoPerms),
+				matches: []result.Match{
+					&result.FileMatch{
+						File: result.File{
+							Path: "main.go",
+							Repo: types.MinimalRepo{ID: unavailableID},
+						},
+					},
+				},
+			},
+			wantMatches: []result.Match{},
+		},
+		{
+			name: "fail closed errors for repos for which sub-repo perms can't be checked",
+			args: args{
+				ctxActor: actor.FromUser(userWithSubRepoPerms),
+				matches: []result.Match{
+					&result.FileMatch{
+						File: result.File{
+							Path: "main.go",
+							Repo: types.MinimalRepo{ID: unavailableID},
+						},
+					},
+				},
+				failClosed: true,
+			},
+			wantMatches: []result.Match{},
+			wantErr:     "subRepoFilterFunc",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := actor.WithActor(context.Background(), tt.args.ctxActor)
-			matches, err := applySubRepoFiltering(ctx, checker, logtest.Scoped(t), tt.args.matches)
+			matches, err := applySubRepoFiltering(ctx, checker, logtest.Scoped(t), tt.args.matches, tt.args.failClosed)
 			if diff := cmp.Diff(matches, tt.wantMatches, cmpopts.IgnoreUnexported(search.RepoStatusMap{})); diff != "" {
 				t.Fatal(diff)
 			}
@@ -0,0 +1,210 @@
+package jobutil
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/exp/slices"
+
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/job"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+)
+
+// teeJob runs a job that is shared with other teeJobs. While the shared job is
+// running, teeJobs that are run subscribe to that run instead of starting
+// another one: they receive all events of the run, including the ones sent
+// before they subscribed, and its alert and error.
+//
+// The shared run is canceled once all subscribers are done. A teeJob that is
+// run after that starts a new run.
+type teeJob struct {
+	shared *sharedRun
+}
+
+func (t *teeJob) Run(ctx context.Context, clients job.RuntimeClients, stream streaming.Sender) (alert *search.Alert, err error) {
+	_, ctx, stream, finish := job.StartSpan(ctx, stream, t)
+	defer func() { finish(alert, err) }()
+
+	run := t.shared.subscribe(ctx, clients)
+	defer t.shared.unsubscribe(run)
+
+	for sent := 0; ; {
+		run.mu.Lock()
+		events, done, updated := run.events[sent:], run.done, run.updated
+		alert, err = run.alert, run.err
+		run.mu.Unlock()
+
+		// Subscribers may modify the matches they receive, e.g. when merging
+		// them, so each of them gets its own copy.
+		for _, event := range events {
+			stream.Send(streaming.SearchEvent{
+				Results: copyMatches(event.Results),
+				Stats:   event.Stats,
+			})
+		}
+		sent += len(events)
+
+		if done {
+			return alert, err
+		}
+
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (t *teeJob) Name() string {
+	return "TeeJob"
+}
+
+func (t *teeJob) Attributes(job.Verbosity) []attribute.KeyValue { return nil }
+
+func (t *teeJob) Children() []job.Describer {
+	return []job.Describer{t.shared.child}
+}
+
+func (t *teeJob) MapChildren(fn job.MapFunc) job.Job {
+	return &teeJob{shared: t.shared.derive(job.Map(t.shared.child, fn))}
+}
+
+// sharedRun is the job shared by a set of teeJobs.
+type sharedRun struct {
+	child job.Job
+
+	mu      sync.Mutex
+	current *teeRun
+	// derived are the sharedRuns of the mapped copies of child. teeJobs whose
+	// children are mapped to equal jobs keep sharing a run.
+	derived []*sharedRun
+}
+
+func (s *sharedRun) derive(child job.Job) *sharedRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if reflect.DeepEqual(s.child, child) {
+		return s
+	}
+	for _, d := range s.derived {
+		if reflect.DeepEqual(d.child, child) {
+			return d
+		}
+	}
+	d := &sharedRun{child: child}
+	s.derived = append(s.derived, d)
+	return d
+}
+
+// subscribe returns the current run of the shared job, starting it if it is
+// not running.
+func (s *sharedRun) subscribe(ctx context.Context, clients job.RuntimeClients) *teeRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current == nil {
+		// The run outlives the context of the subscriber that started it if
+		// other subscribers are still interested in it.
+		runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		s.current = &teeRun{cancel: cancel, updated: make(chan struct{})}
+		go s.current.run(runCtx, clients, s.child)
+	}
+	s.current.subscribers++
+	return s.current
+}
+
+func (s *sharedRun) unsubscribe(run *teeRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run.subscribers--
+	if run.subscribers == 0 {
+		run.cancel()
+		if s.current == run {
+			s.current = nil
+		}
+	}
+}
+
+// teeRun is a single run of a shared job. It records all events of the run so
+// that they can be replayed to late subscribers.
+type teeRun struct {
+	// subscribers is protected by the mutex of the sharedRun.
+	subscribers int
+	cancel      context.CancelFunc
+
+	mu     sync.Mutex
+	events []streaming.SearchEvent
+	done   bool
+	alert  *search.Alert
+	err    error
+	// updated is closed and replaced whenever an event is added or the run is
+	// done.
+	updated chan struct{}
+}
+
+func (r *teeRun) run(ctx context.Context, clients job.RuntimeClients, child job.Job) {
+	alert, err := child.Run(ctx, clients, streaming.StreamFunc(func(event streaming.SearchEvent) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.events = append(r.events, event)
+		r.notifyLocked()
+	}))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done, r.alert, r.err = true, alert, err
+	r.notifyLocked()
+}
+
+func (r *teeRun) notifyLocked() {
+	close(r.updated)
+	r.updated = make(chan struct{})
+}
+
+// copyMatches returns a copy of matches that can be modified without
+// affecting matches. Match types that are never modified after being sent are
+// not copied.
+func copyMatches(matches result.Matches) result.Matches {
+	if matches == nil {
+		return nil
+	}
+	res := make(result.Matches, 0, len(matches))
+	for _, match := range matches {
+		switch v := match.(type) {
+		case *result.FileMatch:
+			cp := *v
+			cp.ChunkMatches = slices.Clone(v.ChunkMatches)
+			cp.Symbols = slices.Clone(v.Symbols)
+			match = &cp
+		case *result.CommitMatch:
+			cp := *v
+			cp.MessagePreview = copyMatchedString(v.MessagePreview)
+			cp.DiffPreview = copyMatchedString(v.DiffPreview)
+			cp.Diff = slices.Clone(v.Diff)
+			match = &cp
+		case *result.RepoMatch:
+			cp := *v
+			cp.DescriptionMatches = slices.Clone(v.DescriptionMatches)
+			cp.RepoNameMatches = slices.Clone(v.RepoNameMatches)
+			match = &cp
+		}
+		res = append(res, match)
+	}
+	return res
+}
+
+func copyMatchedString(s *result.MatchedString) *result.MatchedString {
+	if s == nil {
+		return nil
+	}
+	cp := *s
+	cp.MatchedRanges = slices.Clone(s.MatchedRanges)
+	return &cp
+}
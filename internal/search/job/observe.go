@@ -2,6 +2,7 @@ package job
 
 import (
 	"context"
+	"strings"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/atomic"
@@ -13,9 +14,17 @@ import (
 
 type finishSpanFunc func(*search.Alert, error)
 
+// StartSpan starts a span for a run of the given job. Since every job starts a
+// span and runs its children with the returned context, the spans of a search
+// form the same tree as its explain output. Besides the attributes of the job,
+// each span records the backend the job queries (if any), the number of results
+// the job streamed and whether any of them were truncated by a limit.
 func StartSpan(ctx context.Context, stream streaming.Sender, job Job) (trace.Trace, context.Context, streaming.Sender, finishSpanFunc) {
 	tr, ctx := trace.New(ctx, job.Name())
 	tr.SetAttributes(job.Attributes(VerbosityMax)...)
+	if backend := jobBackend(job.Name()); backend != "" {
+		tr.SetAttributes(attribute.String("backend", backend))
+	}
 
 	observingStream := newObservingStream(tr, stream)
 
@@ -24,11 +33,35 @@ func StartSpan(ctx context.Context, stream streaming.Sender, job Job) (trace.Tra
 		if alert != nil {
 			tr.SetAttributes(attribute.String("alert", alert.Title))
 		}
-		tr.SetAttributes(attribute.Int64("total_results", observingStream.totalEvents.Load()))
+		tr.SetAttributes(
+			attribute.Int64("total_results", observingStream.totalEvents.Load()),
+			attribute.Bool("limit_hit", observingStream.limitHit.Load()),
+		)
 		tr.End()
 	}
 }
 
+// jobBackends maps job name prefixes to the backend those jobs query.
+var jobBackends = []struct {
+	prefix  string
+	backend string
+}{
+	{prefix: "Zoekt", backend: "zoekt"},
+	{prefix: "Searcher", backend: "searcher"},
+	{prefix: "Structural", backend: "searcher"},
+	{prefix: "Commit", backend: "gitserver"},
+	{prefix: "Diff", backend: "gitserver"},
+}
+
+func jobBackend(name string) string {
+	for _, b := range jobBackends {
+		if strings.HasPrefix(name, b.prefix) {
+			return b.backend
+		}
+	}
+	return ""
+}
+
 func newObservingStream(tr trace.Trace, parent streaming.Sender) *observingStream {
 	return &observingStream{tr: tr, parent: parent}
 }
@@ -37,6 +70,7 @@ type observingStream struct {
 	tr          trace.Trace
 	parent      streaming.Sender
 	totalEvents atomic.Int64
+	limitHit    atomic.Bool
 }
 
 func (o *observingStream) Send(event streaming.SearchEvent) {
@@ -48,5 +82,8 @@ func (o *observingStream) Send(event streaming.SearchEvent) {
 			o.tr.AddEvent("first results")
 		}
 	}
+	if event.Stats.IsLimitHit {
+		o.limitHit.Store(true)
+	}
 	o.parent.Send(event)
 }
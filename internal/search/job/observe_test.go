@@ -0,0 +1,110 @@
+package job
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	oteltracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+)
+
+// spanTestJob streams numResults results after running its children in order.
+type spanTestJob struct {
+	name       string
+	children   []Job
+	numResults int
+	limitHit   bool
+}
+
+func (j *spanTestJob) Run(ctx context.Context, clients RuntimeClients, s streaming.Sender) (alert *search.Alert, err error) {
+	_, ctx, s, finish := StartSpan(ctx, s, j)
+	defer func() { finish(alert, err) }()
+
+	for _, child := range j.children {
+		if _, err := child.Run(ctx, clients, s); err != nil {
+			return nil, err
+		}
+	}
+	if j.numResults > 0 {
+		s.Send(streaming.SearchEvent{
+			Results: make(result.Matches, j.numResults),
+			Stats:   streaming.Stats{IsLimitHit: j.limitHit},
+		})
+	}
+	return nil, nil
+}
+
+func (j *spanTestJob) Name() string { return j.name }
+
+func (j *spanTestJob) Attributes(Verbosity) []attribute.KeyValue {
+	return []attribute.KeyValue{attribute.String("name", j.name)}
+}
+
+func (j *spanTestJob) Children() []Describer {
+	children := make([]Describer, 0, len(j.children))
+	for _, child := range j.children {
+		children = append(children, child)
+	}
+	return children
+}
+
+func (j *spanTestJob) MapChildren(MapFunc) Job { return j }
+
+func TestStartSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(oteltracesdk.NewTracerProvider(oteltracesdk.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	root := &spanTestJob{
+		name: "ParallelJob",
+		children: []Job{
+			&spanTestJob{name: "ZoektGlobalTextSearchJob", numResults: 3, limitHit: true},
+			&spanTestJob{name: "CommitSearchJob", numResults: 2},
+		},
+	}
+	_, err := root.Run(context.Background(), RuntimeClients{}, streaming.NewNullStream())
+	require.NoError(t, err)
+
+	type span struct {
+		Parent     string
+		Attributes map[attribute.Key]attribute.Value
+	}
+	spans := map[string]span{}
+	names := map[string]string{}
+	for _, s := range recorder.Ended() {
+		names[s.SpanContext().SpanID().String()] = s.Name()
+	}
+	for _, s := range recorder.Ended() {
+		attrs := map[attribute.Key]attribute.Value{}
+		for _, kv := range s.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		spans[s.Name()] = span{Parent: names[s.Parent().SpanID().String()], Attributes: attrs}
+	}
+
+	require.Len(t, spans, 3)
+
+	require.Equal(t, "", spans["ParallelJob"].Parent)
+	require.Equal(t, int64(5), spans["ParallelJob"].Attributes["total_results"].AsInt64())
+	require.True(t, spans["ParallelJob"].Attributes["limit_hit"].AsBool())
+	require.NotContains(t, spans["ParallelJob"].Attributes, attribute.Key("backend"))
+
+	require.Equal(t, "ParallelJob", spans["ZoektGlobalTextSearchJob"].Parent)
+	require.Equal(t, "ZoektGlobalTextSearchJob", spans["ZoektGlobalTextSearchJob"].Attributes["name"].AsString())
+	require.Equal(t, "zoekt", spans["ZoektGlobalTextSearchJob"].Attributes["backend"].AsString())
+	require.Equal(t, int64(3), spans["ZoektGlobalTextSearchJob"].Attributes["total_results"].AsInt64())
+	require.True(t, spans["ZoektGlobalTextSearchJob"].Attributes["limit_hit"].AsBool())
+
+	require.Equal(t, "ParallelJob", spans["CommitSearchJob"].Parent)
+	require.Equal(t, "gitserver", spans["CommitSearchJob"].Attributes["backend"].AsString())
+	require.Equal(t, int64(2), spans["CommitSearchJob"].Attributes["total_results"].AsInt64())
+	require.False(t, spans["CommitSearchJob"].Attributes["limit_hit"].AsBool())
+}
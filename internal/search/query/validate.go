@@ -1,6 +1,7 @@
 package query
 
 import (
+	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -222,6 +223,13 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 		return err
 	}
 
+	isValidRefGlob := func() error {
+		if _, err := path.Match(value, ""); err != nil {
+			return errors.Errorf("invalid value %q for field %q: %s", value, field, err)
+		}
+		return nil
+	}
+
 	isValidGitDate := func() error {
 		_, err := ParseGitDate(value, time.Now)
 		return err
@@ -278,6 +286,9 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 		FieldCommitter,
 		FieldMessage:
 		return satisfies(isValidRegexp)
+	case
+		FieldRef:
+		return satisfies(isValidRefGlob)
 	case
 		FieldIndex,
 		FieldFork,
@@ -345,9 +356,13 @@ func validateCommitParameters(nodes []Node) error {
 	var seenCommitParam string
 	var typeCommitExists bool
 	VisitParameter(nodes, func(field, value string, _ bool, _ Annotation) {
-		if field == FieldAuthor || field == FieldBefore || field == FieldAfter || field == FieldMessage {
+		if field == FieldAuthor || field == FieldBefore || field == FieldAfter || field == FieldMessage || field == FieldRef {
 			seenCommitParam = field
 		}
+		// Authors and dates can only be selected from commit results.
+		if field == FieldSelect && (value == "commit.author" || value == "commit.date") {
+			seenCommitParam = field + ":" + value
+		}
 		if field == FieldType && (value == "commit" || value == "diff") {
 			typeCommitExists = true
 		}
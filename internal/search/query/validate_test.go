@@ -95,6 +95,18 @@ func TestValidation(t *testing.T) {
 			input: "repo:foo author:rob@saucegraph.com",
 			want:  `your query contains the field 'author', which requires type:commit or type:diff in the query`,
 		},
+		{
+			input: "repo:foo ref:refs/heads/release/*",
+			want:  `your query contains the field 'ref', which requires type:commit or type:diff in the query`,
+		},
+		{
+			input: "repo:foo select:commit.author",
+			want:  `your query contains the field 'select:commit.author', which requires type:commit or type:diff in the query`,
+		},
+		{
+			input: "type:commit ref:refs/heads/[",
+			want:  `invalid value "refs/heads/[" for field "ref": syntax error in pattern`,
+		},
 		{
 			input: "repohasfile:README type:symbol yolo",
 			want:  "repohasfile is not compatible for type:symbol. Subscribe to https://github.com/sourcegraph/sourcegraph/issues/4610 for updates",
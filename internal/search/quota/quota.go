@@ -0,0 +1,294 @@
+// Package quota tracks per-user daily usage of search features that can
+// generate a lot of load from a single request, and enforces the quotas
+// configured in search.limits.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/redispool"
+	"github.com/sourcegraph/sourcegraph/internal/requestclient"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/limits"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// Kind is a kind of usage that is subject to a daily quota.
+type Kind string
+
+const (
+	// KindComputeResults counts the results returned by compute queries.
+	KindComputeResults Kind = "compute_results"
+	// KindSmartSearchQueries counts the queries generated and run by smart search.
+	KindSmartSearchQueries Kind = "smart_search_queries"
+)
+
+// Kinds are all kinds of usage that are subject to a quota.
+var Kinds = []Kind{KindComputeResults, KindSmartSearchQueries}
+
+// period is the length of a quota period. A period starts with the first usage
+// after the previous period ended.
+const period = 24 * time.Hour
+
+// Usage is the usage of a quota in the current period.
+type Usage struct {
+	Kind  Kind
+	Used  int
+	Limit int
+	// ResetsAt is when the current period ends, or nil if there was no usage yet.
+	ResetsAt *time.Time
+}
+
+// ExceededError is returned when a quota is exhausted.
+type ExceededError struct {
+	Kind     Kind
+	Limit    int
+	ResetsAt time.Time
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("daily quota of %d %s exceeded, resets at %s", e.Limit, e.Kind.noun(), e.ResetsAt.Truncate(time.Second))
+}
+
+// Alert returns an alert telling the user that the quota was exceeded.
+func (e *ExceededError) Alert() *search.Alert {
+	return &search.Alert{
+		PrometheusType: "quota_exceeded_" + string(e.Kind),
+		Title:          "Daily quota exceeded",
+		Description:    fmt.Sprintf("You have used your daily quota of %d %s. It resets at %s. Contact your site admin if you need a higher quota.", e.Limit, e.Kind.noun(), e.ResetsAt.UTC().Format(time.RFC1123)),
+	}
+}
+
+func (k Kind) noun() string {
+	switch k {
+	case KindComputeResults:
+		return "compute results"
+	case KindSmartSearchQueries:
+		return "smart search queries"
+	}
+	return string(k)
+}
+
+// Store stores the usage of quotas in redis.
+type Store struct {
+	rstore redispool.KeyValue
+}
+
+// NewStore returns a Store backed by the given key-value store.
+func NewStore(rstore redispool.KeyValue) *Store {
+	return &Store{rstore: rstore}
+}
+
+// DefaultStore is the Store backed by the default redis store.
+var DefaultStore = NewStore(redispool.Store)
+
+// Remaining returns how much of the quota of kind the current actor can use
+// before it is exceeded. If the quota is exceeded, an *ExceededError is
+// returned. limited is false if the actor isn't subject to a quota, in which
+// case remaining is meaningless.
+//
+// Concurrent requests of the actor may use the remaining quota in the meantime,
+// so usage must be recorded with Reserve.
+func (s *Store) Remaining(ctx context.Context, kind Kind) (remaining int, limited bool, err error) {
+	limit := configuredLimit(kind)
+	if limit <= 0 {
+		return 0, false, nil
+	}
+	key, ok := subjectKey(ctx, kind)
+	if !ok {
+		return 0, false, nil
+	}
+
+	rstore := s.rstore.WithContext(ctx)
+	used, err := rstore.Get(key).Int()
+	if err != nil && err != redis.ErrNil {
+		return 0, false, errors.Wrap(err, "failed to read quota usage")
+	}
+
+	if used >= limit {
+		return 0, true, s.exceeded(ctx, kind, key, limit)
+	}
+
+	return limit - used, true, nil
+}
+
+// Reserve records the usage of up to n units of the quota of kind for the
+// current actor, and returns how many were granted. Fewer than n units are
+// granted if less is left, and an *ExceededError is returned if none is left.
+// Checking and recording the usage is atomic, so concurrent requests of the
+// actor can't use more than the quota together. Units that end up unused
+// should be given back with Release. All n units are granted if the actor
+// isn't subject to a quota.
+func (s *Store) Reserve(ctx context.Context, kind Kind, n int) (granted int, err error) {
+	limit := configuredLimit(kind)
+	if n <= 0 || limit <= 0 {
+		return n, nil
+	}
+	key, ok := subjectKey(ctx, kind)
+	if !ok {
+		return n, nil
+	}
+
+	c, err := s.rstore.Pool().GetContext(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get redis connection")
+	}
+	defer c.Close()
+
+	granted, err = redis.Int(reserveScript.DoContext(ctx, c, key, n, limit, int(period/time.Second)))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to reserve quota usage")
+	}
+	if granted <= 0 {
+		return 0, s.exceeded(ctx, kind, key, limit)
+	}
+	return granted, nil
+}
+
+// Release gives back n units of the quota of kind that the current actor
+// reserved but didn't use.
+func (s *Store) Release(ctx context.Context, kind Kind, n int) error {
+	if n <= 0 || configuredLimit(kind) <= 0 {
+		return nil
+	}
+	key, ok := subjectKey(ctx, kind)
+	if !ok {
+		return nil
+	}
+
+	c, err := s.rstore.Pool().GetContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get redis connection")
+	}
+	defer c.Close()
+
+	if _, err := releaseScript.DoContext(ctx, c, key, n); err != nil {
+		return errors.Wrap(err, "failed to release quota usage")
+	}
+	return nil
+}
+
+// exceeded returns the *ExceededError for the quota of kind stored at key.
+func (s *Store) exceeded(ctx context.Context, kind Kind, key string, limit int) error {
+	ttl, err := s.rstore.WithContext(ctx).TTL(key)
+	if err != nil {
+		return errors.Wrap(err, "failed to get TTL for quota usage")
+	}
+	return &ExceededError{
+		Kind:     kind,
+		Limit:    limit,
+		ResetsAt: time.Now().Add(time.Duration(ttl) * time.Second),
+	}
+}
+
+// reserveScript increments the usage stored at KEYS[1] by up to ARGV[1]
+// without exceeding the limit ARGV[2], and returns the increment. If the key
+// has no expiry yet, a new period of ARGV[3] seconds is started. Running this
+// as a script makes the check, the increment and the expiry atomic, so
+// concurrent requests can't exceed the quota or lose the expiry and turn a
+// daily quota into a permanent one.
+var reserveScript = redis.NewScript(1, `
+local used = tonumber(redis.call('GET', KEYS[1]) or '0')
+local granted = math.min(tonumber(ARGV[1]), tonumber(ARGV[2]) - used)
+if granted <= 0 then
+	return 0
+end
+redis.call('INCRBY', KEYS[1], granted)
+if redis.call('TTL', KEYS[1]) < 0 then
+	redis.call('EXPIRE', KEYS[1], ARGV[3])
+end
+return granted
+`)
+
+// releaseScript decrements the usage stored at KEYS[1] by ARGV[1]. Without any
+// usage left, the key is deleted so that the next usage starts a new period.
+var releaseScript = redis.NewScript(1, `
+local used = redis.call('DECRBY', KEYS[1], ARGV[1])
+if used <= 0 then
+	redis.call('DEL', KEYS[1])
+end
+return used
+`)
+
+// Usage returns the current actor's usage of every configured quota.
+func (s *Store) Usage(ctx context.Context) ([]Usage, error) {
+	rstore := s.rstore.WithContext(ctx)
+
+	var usages []Usage
+	for _, kind := range Kinds {
+		limit := configuredLimit(kind)
+		if limit <= 0 {
+			continue
+		}
+		key, ok := subjectKey(ctx, kind)
+		if !ok {
+			continue
+		}
+
+		used, err := rstore.Get(key).Int()
+		if err != nil && err != redis.ErrNil {
+			return nil, errors.Wrap(err, "failed to read quota usage")
+		}
+
+		usage := Usage{Kind: kind, Used: min(used, limit), Limit: limit}
+		if used > 0 {
+			ttl, err := rstore.TTL(key)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to get TTL for quota usage")
+			}
+			if ttl >= 0 {
+				resetsAt := time.Now().Add(time.Duration(ttl) * time.Second)
+				usage.ResetsAt = &resetsAt
+			}
+		}
+		usages = append(usages, usage)
+	}
+
+	return usages, nil
+}
+
+func configuredLimit(kind Kind) int {
+	l := limits.SearchLimits(conf.Get())
+	switch kind {
+	case KindComputeResults:
+		return l.PerUserDailyComputeResults
+	case KindSmartSearchQueries:
+		return l.PerUserDailySmartSearchQueries
+	}
+	return 0
+}
+
+// subjectKey returns the key under which the usage of the current actor is
+// stored. Internal actors are not subject to quotas. Anonymous users are
+// tracked by their IP address as reported by a trusted WAF, and aren't subject
+// to quotas without one.
+func subjectKey(ctx context.Context, kind Kind) (string, bool) {
+	a := actor.FromContext(ctx)
+	if a.IsInternal() {
+		return "", false
+	}
+	if a.IsAuthenticated() {
+		return fmt.Sprintf("search_quota:user:%d:%s", a.UID, kind), true
+	}
+
+	req := requestclient.FromContext(ctx)
+	if req == nil {
+		return "", false
+	}
+	// Neither of the other addresses identifies a client: the IP of the
+	// connection is the address of the load balancer or reverse proxy in front
+	// of the frontend, which all anonymous users would share, and
+	// X-Forwarded-For is set by the client and could be changed on every
+	// request to evade the quota.
+	ip := req.WAFIP
+	if ip == "" {
+		return "", false
+	}
+	return fmt.Sprintf("search_quota:anon:%s:%s", ip, kind), true
+}
@@ -0,0 +1,176 @@
+package quota
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/redispool"
+	"github.com/sourcegraph/sourcegraph/internal/requestclient"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestStore(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		SearchLimits: &schema.SearchLimits{PerUserDailyComputeResults: 10},
+	}})
+	t.Cleanup(func() { conf.Mock(nil) })
+
+	s := NewStore(newTestKeyValue(t))
+
+	alice := actor.WithActor(context.Background(), actor.FromUser(1))
+	bob := actor.WithActor(context.Background(), actor.FromUser(2))
+
+	remaining, limited, err := s.Remaining(alice, KindComputeResults)
+	require.NoError(t, err)
+	require.True(t, limited)
+	require.Equal(t, 10, remaining)
+
+	granted, err := s.Reserve(alice, KindComputeResults, 7)
+	require.NoError(t, err)
+	require.Equal(t, 7, granted)
+	remaining, _, err = s.Remaining(alice, KindComputeResults)
+	require.NoError(t, err)
+	require.Equal(t, 3, remaining)
+
+	// Unused units are given back.
+	require.NoError(t, s.Release(alice, KindComputeResults, 2))
+	remaining, _, err = s.Remaining(alice, KindComputeResults)
+	require.NoError(t, err)
+	require.Equal(t, 5, remaining)
+
+	// Only what is left is granted.
+	granted, err = s.Reserve(alice, KindComputeResults, 7)
+	require.NoError(t, err)
+	require.Equal(t, 5, granted)
+
+	_, err = s.Reserve(alice, KindComputeResults, 1)
+	var exceeded *ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, 10, exceeded.Limit)
+	require.WithinDuration(t, time.Now().Add(period), exceeded.ResetsAt, time.Minute)
+
+	_, _, err = s.Remaining(alice, KindComputeResults)
+	require.ErrorAs(t, err, &exceeded)
+
+	// Usage is tracked per user.
+	remaining, _, err = s.Remaining(bob, KindComputeResults)
+	require.NoError(t, err)
+	require.Equal(t, 10, remaining)
+
+	// Unconfigured quotas are unlimited.
+	_, limited, err = s.Remaining(alice, KindSmartSearchQueries)
+	require.NoError(t, err)
+	require.False(t, limited)
+	granted, err = s.Reserve(alice, KindSmartSearchQueries, 100)
+	require.NoError(t, err)
+	require.Equal(t, 100, granted)
+
+	// Internal actors are never limited.
+	_, limited, err = s.Remaining(actor.WithInternalActor(context.Background()), KindComputeResults)
+	require.NoError(t, err)
+	require.False(t, limited)
+
+	// Anonymous users are tracked by the IP address reported by the WAF.
+	anonymous := requestclient.WithClient(context.Background(), &requestclient.Client{IP: "192.168.1.1", WAFIP: "203.0.113.1"})
+	_, err = s.Reserve(anonymous, KindComputeResults, 4)
+	require.NoError(t, err)
+	remaining, limited, err = s.Remaining(anonymous, KindComputeResults)
+	require.NoError(t, err)
+	require.True(t, limited)
+	require.Equal(t, 6, remaining)
+
+	// X-Forwarded-For is client controlled, so it doesn't give a fresh quota.
+	spoofed := requestclient.WithClient(context.Background(), &requestclient.Client{IP: "192.168.1.1", ForwardedFor: "10.0.0.1", WAFIP: "203.0.113.1"})
+	remaining, _, err = s.Remaining(spoofed, KindComputeResults)
+	require.NoError(t, err)
+	require.Equal(t, 6, remaining)
+
+	// Another client behind the same proxy has its own quota.
+	other := requestclient.WithClient(context.Background(), &requestclient.Client{IP: "192.168.1.1", WAFIP: "203.0.113.2"})
+	remaining, _, err = s.Remaining(other, KindComputeResults)
+	require.NoError(t, err)
+	require.Equal(t, 10, remaining)
+
+	// Without a trusted client IP, the connection's IP is most likely a proxy
+	// shared by all anonymous users, so they aren't limited.
+	proxied := requestclient.WithClient(context.Background(), &requestclient.Client{IP: "192.168.1.1"})
+	_, err = s.Reserve(proxied, KindComputeResults, 100)
+	require.NoError(t, err)
+	_, limited, err = s.Remaining(proxied, KindComputeResults)
+	require.NoError(t, err)
+	require.False(t, limited)
+	remaining, _, err = s.Remaining(other, KindComputeResults)
+	require.NoError(t, err)
+	require.Equal(t, 10, remaining)
+
+	usages, err := s.Usage(alice)
+	require.NoError(t, err)
+	require.Len(t, usages, 1)
+	require.Equal(t, KindComputeResults, usages[0].Kind)
+	require.Equal(t, 10, usages[0].Used)
+	require.Equal(t, 10, usages[0].Limit)
+	require.NotNil(t, usages[0].ResetsAt)
+}
+
+func TestStoreReserveConcurrently(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		SearchLimits: &schema.SearchLimits{PerUserDailyComputeResults: 10},
+	}})
+	t.Cleanup(func() { conf.Mock(nil) })
+
+	s := NewStore(newTestKeyValue(t))
+	alice := actor.WithActor(context.Background(), actor.FromUser(1))
+
+	// Concurrent requests can't use more than the quota together.
+	var g errgroup.Group
+	var total atomic.Int64
+	for i := 0; i < 20; i++ {
+		g.Go(func() error {
+			granted, err := s.Reserve(alice, KindComputeResults, 3)
+			if errors.HasType(err, &ExceededError{}) {
+				return nil
+			}
+			total.Add(int64(granted))
+			return err
+		})
+	}
+	require.NoError(t, g.Wait())
+	require.Equal(t, int64(10), total.Load())
+}
+
+// newTestKeyValue returns a KeyValue backed by a local redis with all quota
+// usage cleared. The test is skipped if redis isn't available outside of CI.
+func newTestKeyValue(t *testing.T) redispool.KeyValue {
+	t.Helper()
+
+	pool := &redis.Pool{
+		MaxIdle:     3,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", "127.0.0.1:6379")
+		},
+	}
+	t.Cleanup(func() { pool.Close() })
+
+	c := pool.Get()
+	defer c.Close()
+	if _, err := c.Do("PING"); err != nil {
+		if os.Getenv("CI") == "" {
+			t.Skip("could not connect to redis", err)
+		}
+		t.Fatal(err)
+	}
+	require.NoError(t, redispool.DeleteAllKeysWithPrefix(c, "search_quota:"))
+
+	return redispool.RedisKeyValue(pool)
+}
@@ -0,0 +1,87 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/authz"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+const (
+	hasCommitAfterCacheSize = 10000
+
+	// hasCommitAfterCacheTTL bounds how long an answer is reused. Answers can go
+	// stale when new commits are pushed or a relative cutoff like "1 week ago"
+	// moves, so this is kept short.
+	hasCommitAfterCacheTTL = 5 * time.Minute
+)
+
+type hasCommitAfterKey struct {
+	repo    api.RepoName
+	rev     string
+	timeRef string
+}
+
+type hasCommitAfterEntry struct {
+	hasCommitAfter bool
+	expiresAt      time.Time
+}
+
+// hasCommitAfterCache caches answers to repo:has.commit.after() across queries.
+var hasCommitAfterCache, _ = lru.New[hasCommitAfterKey, hasCommitAfterEntry](hasCommitAfterCacheSize)
+
+// hasCommitAfter returns whether the given revision of a repo has a commit after
+// the given time. Revisions or repos that don't exist have no commits after any
+// time.
+func hasCommitAfter(ctx context.Context, gs gitserver.Client, repo api.RepoName, timeRef, rev string) (bool, error) {
+	// With sub-repo permissions, the answer depends on the current user.
+	cacheable := !authz.SubRepoEnabled(authz.DefaultSubRepoPermsChecker)
+
+	key := hasCommitAfterKey{repo: repo, rev: rev, timeRef: timeRef}
+	if cacheable {
+		if entry, ok := hasCommitAfterCache.Get(key); ok && time.Now().Before(entry.expiresAt) {
+			return entry.hasCommitAfter, nil
+		}
+	}
+
+	ok, err := gs.HasCommitAfter(ctx, repo, timeRef, rev)
+	if err != nil {
+		if errors.HasType(err, &gitdomain.RevisionNotFoundError{}) || gitdomain.IsRepoNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if cacheable {
+		hasCommitAfterCache.Add(key, hasCommitAfterEntry{hasCommitAfter: ok, expiresAt: time.Now().Add(hasCommitAfterCacheTTL)})
+	}
+	return ok, nil
+}
+
+// FilterHasCommitAfter removes the revisions of repoRev that don't satisfy the
+// given repo:has.commit.after() filter. It lets jobs apply the filter to each repo
+// as they search it rather than during repo resolution.
+func FilterHasCommitAfter(ctx context.Context, gs gitserver.Client, repoRev *search.RepositoryRevisions, args *query.RepoHasCommitAfterArgs) error {
+	// repoRev.Revs may be shared with other jobs, so it is replaced rather
+	// than filtered in place.
+	revs := make([]string, 0, len(repoRev.Revs))
+	for _, rev := range repoRev.Revs {
+		ok, err := hasCommitAfter(ctx, gs, repoRev.Repo.Name, args.TimeRef, rev)
+		if err != nil {
+			return err
+		}
+		if ok != args.Negated {
+			revs = append(revs, rev)
+		}
+	}
+	repoRev.Revs = revs
+	return nil
+}
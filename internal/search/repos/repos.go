@@ -601,17 +601,9 @@ func (r *Resolver) filterHasCommitAfter(
 		for _, rev := range allRevs {
 			rev := rev
 			p.Go(func(ctx context.Context) error {
-				if hasCommitAfter, err := r.gitserver.HasCommitAfter(ctx, repoRev.Repo.Name, op.CommitAfter.TimeRef, rev); err != nil {
-					if errors.HasType(err, &gitdomain.RevisionNotFoundError{}) || gitdomain.IsRepoNotExist(err) {
-						// If the revision does not exist or the repo does not exist,
-						// it certainly does not have any commits after some time.
-						// Ignore the error, but filter this repo out.
-						return nil
-					}
+				if ok, err := hasCommitAfter(ctx, r.gitserver, repoRev.Repo.Name, op.CommitAfter.TimeRef, rev); err != nil {
 					return err
-				} else if !op.CommitAfter.Negated && !hasCommitAfter {
-					return nil
-				} else if op.CommitAfter.Negated && hasCommitAfter {
+				} else if ok == op.CommitAfter.Negated {
 					return nil
 				}
 
@@ -785,6 +785,9 @@ func TestRepoHasFileContent(t *testing.T) {
 }
 
 func TestRepoHasCommitAfter(t *testing.T) {
+	hasCommitAfterCache.Purge()
+	t.Cleanup(hasCommitAfterCache.Purge)
+
 	repoA := types.MinimalRepo{ID: 1, Name: "example.com/1"}
 	repoB := types.MinimalRepo{ID: 2, Name: "example.com/2"}
 	repoC := types.MinimalRepo{ID: 3, Name: "example.com/3"}
@@ -886,3 +889,40 @@ func TestRepoHasCommitAfter(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterHasCommitAfter(t *testing.T) {
+	// Answers are cached process-wide, so start from an empty cache.
+	hasCommitAfterCache.Purge()
+	t.Cleanup(hasCommitAfterCache.Purge)
+
+	repo := types.MinimalRepo{ID: 1, Name: "example.com/filter-has-commit-after"}
+
+	mockGitserver := gitserver.NewMockClient()
+	mockGitserver.HasCommitAfterFunc.SetDefaultHook(func(_ context.Context, _ api.RepoName, _ string, rev string) (bool, error) {
+		switch rev {
+		case "recent":
+			return true, nil
+		case "stale":
+			return false, nil
+		default:
+			return false, &gitdomain.RevisionNotFoundError{}
+		}
+	})
+
+	for _, negated := range []bool{false, true} {
+		revs := []string{"recent", "stale", "missing"}
+		repoRev := &search.RepositoryRevisions{Repo: repo, Revs: revs}
+		err := FilterHasCommitAfter(context.Background(), mockGitserver, repoRev, &query.RepoHasCommitAfterArgs{TimeRef: "yesterday", Negated: negated})
+		require.NoError(t, err)
+		require.Equal(t, []string{"recent", "stale", "missing"}, revs, "the input revisions must not be modified")
+
+		if negated {
+			require.Equal(t, []string{"stale", "missing"}, repoRev.Revs)
+		} else {
+			require.Equal(t, []string{"recent"}, repoRev.Revs)
+		}
+	}
+
+	// Answers are cached across calls, except for revisions that don't exist.
+	require.Len(t, mockGitserver.HasCommitAfterFunc.History(), 4)
+}
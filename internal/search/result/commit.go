@@ -3,13 +3,16 @@ package result
 import (
 	"fmt"
 	"net/url"
+	"path"
 	"strings"
 
 	"github.com/xeonx/timeago"
+	"golang.org/x/exp/slices"
 
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
 	"github.com/sourcegraph/sourcegraph/internal/search/filter"
 	"github.com/sourcegraph/sourcegraph/internal/types"
+	"github.com/sourcegraph/sourcegraph/lib/codeintel/languages"
 )
 
 type CommitMatch struct {
@@ -28,6 +31,12 @@ type CommitMatch struct {
 	// should be set to []string{"my-branch"}
 	SourceRefs []string
 
+	// Ref is set when per-ref results are requested with `select:commit.ref`.
+	// It is the single source ref this match is reported for and is part of
+	// the match's key, so that the same commit found from different refs is
+	// not deduplicated.
+	Ref string
+
 	// MessagePreview and DiffPreview are mutually exclusive. Only one should be set
 	MessagePreview *MatchedString
 	// DiffPreview is a string representation of the diff along with the matched
@@ -54,6 +63,12 @@ func (cm *CommitMatch) Body() MatchedString {
 		}
 	}
 
+	if cm.MessagePreview == nil {
+		// Matches found without searching the message, e.g. only by their
+		// author, have no highlights.
+		return MatchedString{Content: "```COMMIT_EDITMSG\n" + string(cm.Commit.Message) + "\n```"}
+	}
+
 	return MatchedString{
 		Content:       "```COMMIT_EDITMSG\n" + cm.MessagePreview.Content + "\n```",
 		MatchedRanges: cm.MessagePreview.MatchedRanges.Add(Location{Line: 1, Offset: len("```COMMIT_EDITMSG\n")}),
@@ -129,23 +144,221 @@ func (cm *CommitMatch) Select(path filter.SelectPath) Match {
 					return nil
 				}
 				cm.DiffPreview = filteredMatch
+				cm.Diff = selectDiffFilesKind(cm.Diff, diffKindPrefix(fields[1]))
 				return cm
 			}
 			return nil
 		}
+		if len(fields) > 0 && fields[0] == "ref" {
+			// Commits without a known source ref are reported once, with an
+			// empty Ref.
+			return cm
+		}
+		if len(fields) > 0 && (fields[0] == "author" || fields[0] == "date") {
+			return selectCommitAuthor(cm.Repo, cm.Commit.Author, fields[0])
+		}
 		return cm
 	}
 	return nil
 }
 
-// AppendMatches merges highlight information for commit messages. Diff contents
-// are not currently supported. TODO(@team/search): Diff highlight information
-// cannot reliably merge this way because of offset issues with markdown
-// rendering.
+// MatchesRef returns whether any of the refs pointing to this commit or the
+// source refs it was found from match the given glob, as understood by
+// path.Match. Refs are matched by their full name, e.g. "refs/heads/main", and
+// by their short name, e.g. "main".
+func (cm *CommitMatch) MatchesRef(glob string) bool {
+	matches := func(ref string) bool {
+		ref = NormalizeRef(ref)
+		if ref == "" {
+			return false
+		}
+		if ok, _ := path.Match(glob, ref); ok {
+			return true
+		}
+		var other string
+		switch {
+		case strings.HasPrefix(ref, "refs/heads/"):
+			other = strings.TrimPrefix(ref, "refs/heads/")
+		case strings.HasPrefix(ref, "refs/tags/"):
+			other = strings.TrimPrefix(ref, "refs/tags/")
+		case !strings.HasPrefix(ref, "refs/") && ref != "HEAD":
+			other = "refs/heads/" + ref
+		default:
+			return false
+		}
+		ok, _ := path.Match(glob, other)
+		return ok
+	}
+
+	for _, ref := range cm.SourceRefs {
+		if matches(ref) {
+			return true
+		}
+	}
+	for _, ref := range cm.Refs {
+		if matches(ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeRef returns the name of a ref as reported by git log's %D or %S
+// placeholders, which decorate refs such as "HEAD -> refs/heads/main" or
+// "tag: refs/tags/v1.0.0".
+func NormalizeRef(ref string) string {
+	ref = strings.TrimSpace(ref)
+	ref = strings.TrimPrefix(ref, "HEAD -> ")
+	ref = strings.TrimPrefix(ref, "tag: ")
+	return ref
+}
+
+// SplitBySourceRef returns a copy of this commit match for each of its source
+// refs, with Ref set to that source ref. If the match has no source refs, it
+// is returned as is, so that it is still reported once.
+//
+// The copies don't share their previews or diffs, so that limiting or merging
+// one of them leaves the others unchanged.
+func (cm *CommitMatch) SplitBySourceRef() []*CommitMatch {
+	res := make([]*CommitMatch, 0, len(cm.SourceRefs))
+	for _, ref := range cm.SourceRefs {
+		ref = NormalizeRef(ref)
+		if ref == "" {
+			continue
+		}
+		perRef := *cm
+		perRef.Ref = ref
+		perRef.MessagePreview = copyMatchedString(cm.MessagePreview)
+		perRef.DiffPreview = copyMatchedString(cm.DiffPreview)
+		perRef.Diff = copyDiffFiles(cm.Diff)
+		res = append(res, &perRef)
+	}
+	if len(res) == 0 {
+		return []*CommitMatch{cm}
+	}
+	return res
+}
+
+func copyMatchedString(s *MatchedString) *MatchedString {
+	if s == nil {
+		return nil
+	}
+	cp := *s
+	cp.MatchedRanges = slices.Clone(s.MatchedRanges)
+	return &cp
+}
+
+func copyDiffFiles(files []DiffFile) []DiffFile {
+	if files == nil {
+		return nil
+	}
+	res := make([]DiffFile, len(files))
+	for i, file := range files {
+		res[i] = file
+		res[i].NameRanges = slices.Clone(file.NameRanges)
+		res[i].Hunks = make([]Hunk, len(file.Hunks))
+		for j, hunk := range file.Hunks {
+			res[i].Hunks[j] = hunk
+			res[i].Hunks[j].Lines = slices.Clone(hunk.Lines)
+			if hunk.LineRanges != nil {
+				res[i].Hunks[j].LineRanges = make([]Ranges, len(hunk.LineRanges))
+				for k, ranges := range hunk.LineRanges {
+					res[i].Hunks[j].LineRanges[k] = slices.Clone(ranges)
+				}
+			}
+			if hunk.EnclosingSymbol != nil {
+				symbol := *hunk.EnclosingSymbol
+				res[i].Hunks[j].EnclosingSymbol = &symbol
+			}
+		}
+	}
+	return res
+}
+
+// AppendMatches merges highlight information for commit messages and diffs,
+// merging ranges that overlap.
+//
+// The diff preview of a match only contains the files and hunks that matched,
+// so the diff previews of the same commit can differ. Their structured forms
+// (cf. DiffFile.NameRanges and Hunk.LineRanges) are merged, and unless the
+// previews are the same, the preview is formatted again from the merged diff.
 func (cm *CommitMatch) AppendMatches(src *CommitMatch) {
 	if cm.MessagePreview != nil && src.MessagePreview != nil {
-		cm.MessagePreview.MatchedRanges = append(cm.MessagePreview.MatchedRanges, src.MessagePreview.MatchedRanges...)
+		cm.MessagePreview.MatchedRanges = append(cm.MessagePreview.MatchedRanges, src.MessagePreview.MatchedRanges...).Normalize(cm.MessagePreview.Content)
 	}
+	if cm.DiffPreview != nil && src.DiffPreview != nil {
+		if cm.DiffPreview.Content == src.DiffPreview.Content {
+			// Merging the preview ranges directly also keeps the ranges on
+			// hunk headers, which the structured diff doesn't attribute.
+			if cm.Diff != nil || src.Diff != nil {
+				if diff, err := cm.mergedDiff(src); err == nil {
+					cm.Diff = diff
+				}
+			}
+			cm.DiffPreview.MatchedRanges = append(cm.DiffPreview.MatchedRanges, src.DiffPreview.MatchedRanges...).Normalize(cm.DiffPreview.Content)
+			return
+		}
+		diff, err := cm.mergedDiff(src)
+		if err != nil {
+			return
+		}
+		cm.Diff = diff
+		cm.DiffPreview = formatDiffPreview(cm.Diff)
+	}
+}
+
+// mergedDiff returns the structured diffs of cm and src merged.
+func (cm *CommitMatch) mergedDiff(src *CommitMatch) ([]DiffFile, error) {
+	dst, err := cm.structuredDiff()
+	if err != nil {
+		return nil, err
+	}
+	other, err := src.structuredDiff()
+	if err != nil {
+		return nil, err
+	}
+	return mergeDiffFiles(dst, other), nil
+}
+
+// structuredDiff returns Diff, parsing it from DiffPreview if it isn't set.
+func (cm *CommitMatch) structuredDiff() ([]DiffFile, error) {
+	if cm.Diff != nil || cm.DiffPreview.Content == "" {
+		return cm.Diff, nil
+	}
+	return ParseDiffPreview(*cm.DiffPreview)
+}
+
+// ModifiedLanguages returns the number of files modified by the commit for
+// each detected language. It uses ModifiedFiles when those were requested, and
+// falls back to the file names in the structured Diff otherwise. Files for
+// which no language can be detected are not counted.
+func (cm *CommitMatch) ModifiedLanguages() map[string]int {
+	paths := cm.ModifiedFiles
+	if len(paths) == 0 {
+		paths = make([]string, 0, len(cm.Diff))
+		for _, diffFile := range cm.Diff {
+			path := diffFile.NewName
+			if path == "/dev/null" {
+				path = diffFile.OrigName
+			}
+			paths = append(paths, path)
+		}
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for _, path := range paths {
+		// cannot error because it's given a nil content fetcher
+		candidates, _ := languages.GetLanguages(path, nil)
+		if len(candidates) == 0 {
+			continue
+		}
+		counts[candidates[0]]++
+	}
+	return counts
 }
 
 // Key implements Match interface's Key() method
@@ -157,6 +370,7 @@ func (cm *CommitMatch) Key() Key {
 	return Key{
 		TypeRank:   typeRank,
 		Repo:       cm.Repo.Name,
+		Rev:        cm.Ref,
 		AuthorDate: cm.Commit.Author.Date,
 		Commit:     cm.Commit.ID,
 	}
@@ -184,6 +398,14 @@ func (cm *CommitMatch) URL() *url.URL {
 	return u
 }
 
+// PatchURL returns the URL serving the commit in the format of git
+// format-patch, so that it can be applied elsewhere with git am.
+func (cm *CommitMatch) PatchURL() *url.URL {
+	u := (&RepoMatch{Name: cm.Repo.Name, ID: cm.Repo.ID, Rev: string(cm.Commit.ID)}).URL()
+	u.Path = u.Path + "/-/patch"
+	return u
+}
+
 func displayRepoName(repoPath string) string {
 	parts := strings.Split(repoPath, "/")
 	if len(parts) >= 3 && strings.Contains(parts[0], ".") {
@@ -221,19 +443,25 @@ func modifiedLinesExist(lines []string, prefix string) bool {
 	return false
 }
 
+// diffKindPrefix returns the prefix of the lines selected by the field of
+// select:commit.diff.added (resp. removed).
+func diffKindPrefix(field string) string {
+	switch field {
+	case "added":
+		return "+"
+	case "removed":
+		return "-"
+	}
+	return ""
+}
+
 // selectCommitDiffKind returns a commit match `c` if it contains `added` (resp.
 // `removed`) lines set by `field. It ensures that highlight information only
 // applies to the modified lines selected by `field`. If there are no matches
 // (i.e., no highlight information) coresponding to modified lines, it is
 // removed from the result set (returns nil).
 func selectCommitDiffKind(diffPreview *MatchedString, field string) *MatchedString {
-	var prefix string
-	if field == "added" {
-		prefix = "+"
-	}
-	if field == "removed" {
-		prefix = "-"
-	}
+	prefix := diffKindPrefix(field)
 	if len(diffPreview.MatchedRanges) == 0 {
 		// No highlights, implying no pattern was specified. Filter by
 		// whether there exists lines corresponding to additions or
@@ -270,3 +498,50 @@ func (r *CommitMatch) CommitToDiffMatches() []*CommitDiffMatch {
 	}
 	return matches
 }
+
+// diffFileKeys returns the keys of the diff matches of the files of the diff,
+// cf. CommitToDiffMatches. They identify the changes of the commit that are
+// also reported by diff matches. Per-ref matches have no such keys, since diff
+// matches aren't reported per ref.
+func (r *CommitMatch) diffFileKeys() []Key {
+	if r.DiffPreview == nil || r.Ref != "" {
+		return nil
+	}
+	diff, err := r.structuredDiff()
+	if err != nil {
+		return nil
+	}
+	keys := make([]Key, 0, len(diff))
+	for i := range diff {
+		keys = append(keys, r.diffFileKey(&diff[i]))
+	}
+	return keys
+}
+
+func (r *CommitMatch) diffFileKey(diffFile *DiffFile) Key {
+	return (&CommitDiffMatch{Commit: r.Commit, Repo: r.Repo, DiffFile: diffFile}).Key()
+}
+
+// removeDiffFiles removes the files of the diff for which seen returns true,
+// and formats the diff preview again from the remaining files. It returns
+// whether any file remains.
+func (r *CommitMatch) removeDiffFiles(seen func(Key) bool) bool {
+	diff, err := r.structuredDiff()
+	if err != nil {
+		return true
+	}
+	remaining := make([]DiffFile, 0, len(diff))
+	for i := range diff {
+		if !seen(r.diffFileKey(&diff[i])) {
+			remaining = append(remaining, diff[i])
+		}
+	}
+	if len(remaining) == 0 {
+		return false
+	}
+	if len(remaining) < len(diff) {
+		r.Diff = remaining
+		r.DiffPreview = formatDiffPreview(remaining)
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package result
+
+import (
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
+	"github.com/sourcegraph/sourcegraph/internal/search/filter"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+// CommitAuthorMatch is the author of a commit, as selected from commit and diff
+// matches by select:commit.author or select:commit.date. Only the selected
+// fields are set, so that the commits of a repository with the same author
+// (resp. authored on the same day) are deduplicated to a single match.
+type CommitAuthorMatch struct {
+	Repo types.MinimalRepo
+
+	// Name and Email identify the author. They are only set for
+	// select:commit.author.
+	Name  string
+	Email string
+
+	// Date is the UTC day the commit was authored on. It is only set for
+	// select:commit.date.
+	Date time.Time
+}
+
+// selectCommitAuthor returns the match selecting field ("author" or "date")
+// from a commit authored by author in repo.
+func selectCommitAuthor(repo types.MinimalRepo, author gitdomain.Signature, field string) Match {
+	switch field {
+	case "author":
+		return &CommitAuthorMatch{Repo: repo, Name: author.Name, Email: author.Email}
+	case "date":
+		date := author.Date.UTC()
+		return &CommitAuthorMatch{
+			Repo: repo,
+			Date: time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+		}
+	}
+	return nil
+}
+
+func (m *CommitAuthorMatch) RepoName() types.MinimalRepo {
+	return m.Repo
+}
+
+func (m *CommitAuthorMatch) ResultCount() int {
+	return 1
+}
+
+func (m *CommitAuthorMatch) Limit(limit int) int {
+	return limit - 1
+}
+
+func (m *CommitAuthorMatch) Select(path filter.SelectPath) Match {
+	switch path.Root() {
+	case filter.Repository:
+		return &RepoMatch{
+			Name: m.Repo.Name,
+			ID:   m.Repo.ID,
+		}
+	case filter.Commit:
+		if len(path) != 2 {
+			return nil
+		}
+		if path[1] == "author" && m.Date.IsZero() || path[1] == "date" && !m.Date.IsZero() {
+			return m
+		}
+	}
+	return nil
+}
+
+func (m *CommitAuthorMatch) Key() Key {
+	k := Key{
+		TypeRank:   rankCommitAuthorMatch,
+		Repo:       m.Repo.Name,
+		AuthorDate: m.Date,
+	}
+	if m.Name != "" || m.Email != "" {
+		k.Author = m.Name + " <" + m.Email + ">"
+	}
+	return k
+}
+
+func (m *CommitAuthorMatch) searchResultMarker() {}
@@ -2,10 +2,14 @@ package result
 
 import (
 	"fmt"
+	"path"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/grafana/regexp"
+	"golang.org/x/exp/slices"
 
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
 	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
@@ -59,8 +63,16 @@ func (cm *CommitDiffMatch) Key() Key {
 	}
 }
 
+// ResultCount counts the highlights of the preview. Matches without a preview,
+// such as those converted from commit matches, count their changed lines
+// instead.
 func (cm *CommitDiffMatch) ResultCount() int {
-	matchCount := len(cm.Preview.MatchedRanges)
+	matchCount := 0
+	if cm.Preview != nil {
+		matchCount = len(cm.Preview.MatchedRanges)
+	} else {
+		matchCount = cm.changedLineCount()
+	}
 	if matchCount > 0 {
 		return matchCount
 	}
@@ -70,6 +82,10 @@ func (cm *CommitDiffMatch) ResultCount() int {
 }
 
 func (cm *CommitDiffMatch) Limit(limit int) int {
+	if cm.Preview == nil {
+		return cm.limitHunks(limit)
+	}
+
 	limitMatchedString := func(ms *MatchedString) int {
 		if len(ms.MatchedRanges) == 0 {
 			return limit - 1
@@ -83,37 +99,188 @@ func (cm *CommitDiffMatch) Limit(limit int) int {
 	return limitMatchedString(cm.Preview)
 }
 
-func (cm *CommitDiffMatch) Select(path filter.SelectPath) Match {
-	switch path.Root() {
+// changedLineCount returns the number of added and removed lines in the hunks.
+func (cm *CommitDiffMatch) changedLineCount() int {
+	if cm.DiffFile == nil {
+		return 0
+	}
+	count := 0
+	for _, hunk := range cm.Hunks {
+		for _, line := range hunk.Lines {
+			if isChangedLine(line) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// limitHunks truncates the hunks after the limit-th changed line and returns
+// the remaining limit.
+func (cm *CommitDiffMatch) limitHunks(limit int) int {
+	count := cm.changedLineCount()
+	if count == 0 {
+		return limit - 1
+	}
+	if count <= limit {
+		return limit - count
+	}
+
+	// Hunks share their backing array with the diff of the commit match they
+	// were created from, so build new ones rather than truncating in place.
+	var hunks []Hunk
+	remaining := limit
+	for _, hunk := range cm.Hunks {
+		if remaining == 0 {
+			break
+		}
+		for i, line := range hunk.Lines {
+			if !isChangedLine(line) {
+				continue
+			}
+			remaining--
+			if remaining == 0 {
+				hunk.Lines = hunk.Lines[:i+1]
+				if hunk.LineRanges != nil {
+					hunk.LineRanges = hunk.LineRanges[:i+1]
+				}
+				break
+			}
+		}
+		hunks = append(hunks, hunk)
+	}
+	diffFile := *cm.DiffFile
+	diffFile.Hunks = hunks
+	cm.DiffFile = &diffFile
+	return 0
+}
+
+func isChangedLine(line string) bool {
+	return len(line) > 0 && (line[0] == '+' || line[0] == '-')
+}
+
+func (cm *CommitDiffMatch) Select(selectPath filter.SelectPath) Match {
+	switch selectPath.Root() {
 	case filter.Repository:
 		return &RepoMatch{
 			Name: cm.Repo.Name,
 			ID:   cm.Repo.ID,
 		}
+	case filter.File:
+		filePath := cm.Path()
+		if len(selectPath) > 1 && selectPath[1] == "directory" {
+			filePath = path.Clean(path.Dir(filePath)) + "/" // Add trailing slash for clarity.
+		}
+		return &FileMatch{
+			File: File{
+				Repo:     cm.Repo,
+				CommitID: cm.Commit.ID,
+				Path:     filePath,
+			},
+		}
 	case filter.Commit:
-		fields := path[1:]
+		fields := selectPath[1:]
 		if len(fields) > 0 && fields[0] == "diff" {
 			if len(fields) == 1 {
 				return cm
 			}
 			if len(fields) == 2 {
-				filteredMatch := selectCommitDiffKind(cm.Preview, fields[1])
-				if filteredMatch == nil {
-					// no result after selecting, propagate no result.
-					return nil
-				}
-				cm.Preview = filteredMatch
-				return cm
+				return cm.selectDiffKind(fields[1])
 			}
 			return nil
 		}
+		if len(fields) > 0 && (fields[0] == "author" || fields[0] == "date") {
+			return selectCommitAuthor(cm.Repo, cm.Commit.Author, fields[0])
+		}
 		return cm
 	}
 	return nil
 }
 
+// selectDiffKind narrows cm to the hunks that contain `added` (resp.
+// `removed`) lines set by field. It returns nil if there are no such hunks in
+// the file, or if the highlights of the preview don't apply to such lines
+// (cf. selectCommitDiffKind).
+func (cm *CommitDiffMatch) selectDiffKind(field string) Match {
+	var prefix string
+	switch field {
+	case "added":
+		prefix = "+"
+	case "removed":
+		prefix = "-"
+	default:
+		return nil
+	}
+	if cm.DiffFile == nil {
+		return nil
+	}
+
+	var hunks []Hunk
+	for _, hunk := range cm.Hunks {
+		if modifiedLinesExist(hunk.Lines, prefix) {
+			hunks = append(hunks, hunk.selectLineRanges(prefix))
+		}
+	}
+	if len(hunks) == 0 {
+		return nil
+	}
+
+	if cm.Preview != nil {
+		// The preview is shared by the diff matches of a commit, so narrow
+		// a copy of it.
+		preview := *cm.Preview
+		if selectCommitDiffKind(&preview, field) == nil {
+			return nil
+		}
+		cm.Preview = &preview
+	}
+
+	diffFile := *cm.DiffFile
+	diffFile.Hunks = hunks
+	cm.DiffFile = &diffFile
+	return cm
+}
+
 func (cm *CommitDiffMatch) searchResultMarker() {}
 
+// selectLineRanges returns a copy of h that only keeps the ranges matched on
+// lines starting with prefix.
+func (h Hunk) selectLineRanges(prefix string) Hunk {
+	if h.LineRanges == nil {
+		return h
+	}
+	var lineRanges []Ranges
+	for i, ranges := range h.LineRanges {
+		if len(ranges) == 0 || !strings.HasPrefix(h.Lines[i], prefix) {
+			continue
+		}
+		if lineRanges == nil {
+			lineRanges = make([]Ranges, len(h.Lines))
+		}
+		lineRanges[i] = ranges
+	}
+	h.LineRanges = lineRanges
+	return h
+}
+
+// selectDiffFilesKind returns a copy of files that only keeps the ranges
+// matched on lines starting with prefix, cf. selectCommitDiffKind.
+func selectDiffFilesKind(files []DiffFile, prefix string) []DiffFile {
+	if files == nil {
+		return nil
+	}
+	res := make([]DiffFile, len(files))
+	for i, file := range files {
+		hunks := make([]Hunk, len(file.Hunks))
+		for j, hunk := range file.Hunks {
+			hunks[j] = hunk.selectLineRanges(prefix)
+		}
+		file.Hunks = hunks
+		res[i] = file
+	}
+	return res
+}
+
 // FormatDiffFiles inverts ParseDiffString
 func FormatDiffFiles(res []DiffFile) string {
 	var buf strings.Builder
@@ -197,6 +364,182 @@ func ParseDiffString(diff string) (res []DiffFile, err error) {
 	return res, nil
 }
 
+// ParseDiffPreview parses the content of preview like ParseDiffString, and
+// attributes the ranges matched in the preview to the lines of the hunks they
+// are on (cf. DiffFile.NameRanges and Hunk.LineRanges), so that consumers of
+// the structured diff don't have to map them from the preview again. Ranges
+// spanning several lines are split by line. Ranges on hunk headers are not
+// attributed.
+func ParseDiffPreview(preview MatchedString) ([]DiffFile, error) {
+	files, err := ParseDiffString(preview.Content)
+	if err != nil || len(preview.MatchedRanges) == 0 {
+		return files, err
+	}
+
+	// Split the ranges by line, relative to the line they are on.
+	contentLines := strings.Split(preview.Content, "\n")
+	lineStarts := make([]int, len(contentLines))
+	for i := 1; i < len(contentLines); i++ {
+		lineStarts[i] = lineStarts[i-1] + len(contentLines[i-1]) + 1
+	}
+	lineRanges := make(map[int]Ranges)
+	for _, r := range preview.MatchedRanges.Normalize(preview.Content) {
+		for i := max(r.Start.Line, 0); i <= r.End.Line && i < len(contentLines); i++ {
+			line := contentLines[i]
+			lr := Range{End: Location{Offset: len(line), Column: utf8.RuneCountInString(line)}}
+			if i == r.Start.Line {
+				lr.Start = Location{Offset: r.Start.Offset - lineStarts[i], Column: r.Start.Column}
+			}
+			if i == r.End.Line {
+				lr.End = Location{Offset: r.End.Offset - lineStarts[i], Column: r.End.Column}
+			}
+			if lr.End.Offset > lr.Start.Offset {
+				lineRanges[i] = append(lineRanges[i], lr)
+			}
+		}
+	}
+
+	// ParseDiffString skips empty lines, so the structure of the diff maps
+	// onto the nonempty lines of the preview.
+	var lines []int
+	for i, line := range contentLines {
+		if line != "" {
+			lines = append(lines, i)
+		}
+	}
+	next := 0
+	nextRanges := func() Ranges {
+		if next >= len(lines) {
+			return nil
+		}
+		next++
+		return lineRanges[lines[next-1]]
+	}
+	for i := range files {
+		if ranges := nextRanges(); len(ranges) > 0 {
+			files[i].NameRanges = ranges
+		}
+		for j := range files[i].Hunks {
+			hunk := &files[i].Hunks[j]
+			nextRanges() // hunk header
+			for k := range hunk.Lines {
+				ranges := nextRanges()
+				if len(ranges) == 0 {
+					continue
+				}
+				if hunk.LineRanges == nil {
+					hunk.LineRanges = make([]Ranges, len(hunk.Lines))
+				}
+				hunk.LineRanges[k] = ranges
+			}
+		}
+	}
+	return files, nil
+}
+
+// formatDiffPreview formats files like FormatDiffFiles, with the ranges
+// matched on their names and the lines of their hunks (cf. DiffFile.NameRanges
+// and Hunk.LineRanges) as the matched ranges of the preview. It inverts
+// ParseDiffPreview, except for the ranges on hunk headers.
+func formatDiffPreview(files []DiffFile) *MatchedString {
+	content := FormatDiffFiles(files)
+
+	contentLines := strings.Split(content, "\n")
+	lineStarts := make([]int, len(contentLines))
+	for i := 1; i < len(contentLines); i++ {
+		lineStarts[i] = lineStarts[i-1] + len(contentLines[i-1]) + 1
+	}
+
+	var ranges Ranges
+	addRanges := func(line int, lineRanges Ranges) {
+		for _, r := range lineRanges {
+			ranges = append(ranges, Range{
+				Start: Location{Offset: lineStarts[line] + r.Start.Offset, Line: line, Column: r.Start.Column},
+				End:   Location{Offset: lineStarts[line] + r.End.Offset, Line: line, Column: r.End.Column},
+			})
+		}
+	}
+	line := 0
+	for _, file := range files {
+		addRanges(line, file.NameRanges)
+		line++ // file names
+		for _, hunk := range file.Hunks {
+			line++ // hunk header
+			for i := range hunk.Lines {
+				if hunk.LineRanges != nil {
+					addRanges(line, hunk.LineRanges[i])
+				}
+				line++
+			}
+		}
+	}
+	return &MatchedString{Content: content, MatchedRanges: ranges}
+}
+
+// mergeDiffFiles merges the structured diffs of the same commit, which can
+// differ in the files and hunks they contain. Ranges matched on the names of
+// the same file or on the same line are merged. Neither a nor b are modified.
+func mergeDiffFiles(a, b []DiffFile) []DiffFile {
+	res := make([]DiffFile, 0, len(a)+len(b))
+	for _, file := range a {
+		file.Hunks = append([]Hunk(nil), file.Hunks...)
+		res = append(res, file)
+	}
+
+	for _, file := range b {
+		i := slices.IndexFunc(res, func(f DiffFile) bool {
+			return f.OrigName == file.OrigName && f.NewName == file.NewName
+		})
+		if i < 0 {
+			file.Hunks = append([]Hunk(nil), file.Hunks...)
+			res = append(res, file)
+			continue
+		}
+
+		merged := &res[i]
+		if len(file.NameRanges) > 0 {
+			nameLine := escaper.Replace(file.OrigName) + " " + escaper.Replace(file.NewName)
+			merged.NameRanges = append(append(Ranges(nil), merged.NameRanges...), file.NameRanges...).Normalize(nameLine)
+		}
+		for _, hunk := range file.Hunks {
+			j := slices.IndexFunc(merged.Hunks, func(h Hunk) bool {
+				return h.OldStart == hunk.OldStart && h.NewStart == hunk.NewStart &&
+					h.OldCount == hunk.OldCount && h.NewCount == hunk.NewCount
+			})
+			if j < 0 {
+				merged.Hunks = append(merged.Hunks, hunk)
+				continue
+			}
+			merged.Hunks[j] = mergeHunkRanges(merged.Hunks[j], hunk)
+		}
+		sort.SliceStable(merged.Hunks, func(i, j int) bool {
+			return merged.Hunks[i].OldStart < merged.Hunks[j].OldStart
+		})
+	}
+	return res
+}
+
+// mergeHunkRanges returns a copy of a with the ranges matched on the lines of
+// b, which is the same hunk, merged in.
+func mergeHunkRanges(a, b Hunk) Hunk {
+	if b.LineRanges == nil || len(a.Lines) != len(b.Lines) {
+		return a
+	}
+	lineRanges := make([]Ranges, len(a.Lines))
+	for i, line := range a.Lines {
+		var ranges Ranges
+		if a.LineRanges != nil {
+			ranges = append(ranges, a.LineRanges[i]...)
+		}
+		ranges = append(ranges, b.LineRanges[i]...)
+		if len(ranges) > 0 {
+			lineRanges[i] = ranges.Normalize(line)
+		}
+	}
+	a.LineRanges = lineRanges
+	return a
+}
+
 var errInvalidDiff = errors.New("invalid diff format")
 var splitRegex = lazyregexp.New(`(.*[^\\]) (.*)`)
 
@@ -237,6 +580,11 @@ func parseHunkHeader(headerLine string) (oldStart, oldCount, newStart, newCount
 type DiffFile struct {
 	OrigName, NewName string
 	Hunks             []Hunk
+
+	// NameRanges are the ranges of the diff preview matched on the line naming
+	// the files, relative to that line. It is nil if nothing matched there, or
+	// if it wasn't parsed with ParseDiffPreview.
+	NameRanges Ranges
 }
 
 type Hunk struct {
@@ -244,6 +592,94 @@ type Hunk struct {
 	OldCount, NewCount int
 	Header             string
 	Lines              []string
+
+	// LineRanges are the ranges of the diff preview matched on each line of
+	// Lines, relative to the line including its prefix. It is nil if nothing
+	// matched in the hunk, or if it wasn't parsed with ParseDiffPreview.
+	// Otherwise, it has the same length as Lines.
+	LineRanges []Ranges
+
+	// EnclosingSymbol is the innermost symbol in the new version of the file
+	// that encloses the first changed line of the hunk. It is nil unless
+	// symbols were resolved with SetEnclosingSymbols.
+	EnclosingSymbol *HunkSymbol
+}
+
+// HunkSymbol is the structured form of the symbol a hunk changes.
+type HunkSymbol struct {
+	Name string
+	// Kind is the selector kind of the symbol (e.g. "function", "class"), cf.
+	// ToSelectKind.
+	Kind string
+}
+
+// enclosingSymbolKinds are the selector kinds of symbols that can enclose a
+// hunk. Other symbols, such as variables and fields, are too fine-grained to
+// describe what a hunk changes.
+var enclosingSymbolKinds = map[string]struct{}{
+	"class":       {},
+	"constructor": {},
+	"function":    {},
+	"interface":   {},
+	"method":      {},
+	"module":      {},
+	"namespace":   {},
+	"struct":      {},
+}
+
+// SetEnclosingSymbols sets the EnclosingSymbol of each hunk from symbols,
+// which are the symbols of the new version of the file. Since ctags doesn't
+// report where a symbol ends, the enclosing symbol is approximated by the
+// closest preceding symbol of an enclosing kind.
+func (cm *CommitDiffMatch) SetEnclosingSymbols(symbols Symbols) {
+	if cm.PathStatus() == Deleted {
+		return
+	}
+
+	// Hunks share their backing array with the diff of the commit match they
+	// were created from, so copy them before modifying them.
+	hunks := make([]Hunk, len(cm.Hunks))
+	copy(hunks, cm.Hunks)
+	diffFile := *cm.DiffFile
+	diffFile.Hunks = hunks
+	cm.DiffFile = &diffFile
+
+	for i := range hunks {
+		line := hunks[i].firstChangedLine()
+		var enclosing *Symbol
+		for j, s := range symbols {
+			if s.Path != "" && s.Path != cm.NewName {
+				continue
+			}
+			if _, ok := enclosingSymbolKinds[ToSelectKind[strings.ToLower(s.Kind)]]; !ok {
+				continue
+			}
+			if s.Line > line || (enclosing != nil && s.Line <= enclosing.Line) {
+				continue
+			}
+			enclosing = &symbols[j]
+		}
+		if enclosing != nil {
+			hunks[i].EnclosingSymbol = &HunkSymbol{
+				Name: enclosing.Name,
+				Kind: ToSelectKind[strings.ToLower(enclosing.Kind)],
+			}
+		}
+	}
+}
+
+// firstChangedLine returns the 1-based line number in the new version of the
+// file of the first line changed by the hunk. For a hunk that only removes
+// lines, it is the line following the removal.
+func (h *Hunk) firstChangedLine() int {
+	line := h.NewStart
+	for _, l := range h.Lines {
+		if len(l) > 0 && (l[0] == '+' || l[0] == '-') {
+			return line
+		}
+		line++
+	}
+	return line
 }
 
 type PathStatus int
@@ -6,6 +6,10 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/hexops/autogold/v2"
+
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
+	"github.com/sourcegraph/sourcegraph/internal/search/filter"
+	"github.com/sourcegraph/sourcegraph/internal/types"
 )
 
 const input = `client/web/src/enterprise/codeintel/badge/components/IndexerSummary.module.scss client/web/src/enterprise/codeintel/badge/components/IndexerSummary.module.scss
@@ -54,9 +58,193 @@ func TestParseDiffString(t *testing.T) {
 
 }
 
+func TestParseDiffPreview(t *testing.T) {
+	content := "a.go a.go\n@@ -1,1 +1,2 @@\n-foo\n+bar\n+baz\n"
+	res, err := ParseDiffPreview(MatchedString{
+		Content: content,
+		MatchedRanges: Ranges{
+			// "a.go" in the file names
+			{Start: Location{Offset: 0, Line: 0, Column: 0}, End: Location{Offset: 4, Line: 0, Column: 4}},
+			// "foo"
+			{Start: Location{Offset: 27, Line: 2, Column: 1}, End: Location{Offset: 30, Line: 2, Column: 4}},
+			// "ar\n+ba" is split by line
+			{Start: Location{Offset: 33, Line: 3, Column: 2}, End: Location{Offset: 39, Line: 4, Column: 3}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []DiffFile{{
+		OrigName:   "a.go",
+		NewName:    "a.go",
+		NameRanges: Ranges{{Start: Location{Offset: 0, Column: 0}, End: Location{Offset: 4, Column: 4}}},
+		Hunks: []Hunk{{
+			OldStart: 1, OldCount: 1, NewStart: 1, NewCount: 2,
+			Lines: []string{"-foo", "+bar", "+baz"},
+			LineRanges: []Ranges{
+				{{Start: Location{Offset: 1, Column: 1}, End: Location{Offset: 4, Column: 4}}},
+				{{Start: Location{Offset: 2, Column: 2}, End: Location{Offset: 4, Column: 4}}},
+				{{Start: Location{Offset: 0, Column: 0}, End: Location{Offset: 3, Column: 3}}},
+			},
+		}},
+	}}, res)
+
+	t.Run("no ranges", func(t *testing.T) {
+		res, err := ParseDiffPreview(MatchedString{Content: content})
+		require.NoError(t, err)
+		require.Nil(t, res[0].Hunks[0].LineRanges)
+	})
+}
+
 func TestCommitDiffMatch(t *testing.T) {
 	res, _ := ParseDiffString(input)
 	commitDiff := &CommitDiffMatch{DiffFile: &res[0]}
 	autogold.Expect("client/web/src/enterprise/codeintel/badge/components/IndexerSummary.module.scss").
 		Equal(t, commitDiff.Path())
 }
+
+func TestCommitDiffMatch_SetEnclosingSymbols(t *testing.T) {
+	res, err := ParseDiffString(input)
+	require.NoError(t, err)
+	original := res[1].Hunks
+	commitDiff := &CommitDiffMatch{DiffFile: &res[1]}
+
+	path := "client/web/src/enterprise/codeintel/badge/components/IndexerSummary.tsx"
+	commitDiff.SetEnclosingSymbols(Symbols{
+		{Name: "IndexerSummaryProps", Kind: "interface", Path: path, Line: 10},
+		{Name: "IndexerSummary", Kind: "constant", Path: path, Line: 57},
+		{Name: "IndexerSummary", Kind: "function", Path: path, Line: 57},
+		{Name: "className", Kind: "variable", Path: path, Line: 59},
+		{Name: "Badge", Kind: "function", Path: "other.tsx", Line: 60},
+		{Name: "render", Kind: "method", Path: path, Line: 63},
+	})
+
+	var got []*HunkSymbol
+	for _, hunk := range commitDiff.Hunks {
+		got = append(got, hunk.EnclosingSymbol)
+	}
+	require.Equal(t, []*HunkSymbol{
+		{Name: "IndexerSummary", Kind: "function"},
+		{Name: "IndexerSummary", Kind: "function"},
+		{Name: "render", Kind: "method"},
+	}, got)
+
+	// The hunks of the parsed diff are left untouched.
+	for _, hunk := range original {
+		require.Nil(t, hunk.EnclosingSymbol)
+	}
+}
+
+func TestCommitDiffMatch_WithoutPreview(t *testing.T) {
+	newMatch := func(t *testing.T) *CommitDiffMatch {
+		res, err := ParseDiffString(input)
+		require.NoError(t, err)
+		return &CommitDiffMatch{
+			Repo:     types.MinimalRepo{ID: 1, Name: "github.com/sourcegraph/sourcegraph"},
+			Commit:   gitdomain.Commit{ID: "abc"},
+			DiffFile: &res[1],
+		}
+	}
+
+	t.Run("ResultCount", func(t *testing.T) {
+		// Three hunks with one removed and one added line each.
+		require.Equal(t, 6, newMatch(t).ResultCount())
+		require.Equal(t, 1, (&CommitDiffMatch{DiffFile: &DiffFile{}}).ResultCount())
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		cm := newMatch(t)
+		original := cm.Hunks
+		require.Equal(t, 0, cm.Limit(3))
+		require.Equal(t, 3, cm.ResultCount())
+		require.Len(t, cm.Hunks, 2)
+		require.Equal(t, []string{
+			`                     {summary.uploads.length + summary.indexes.length > 0 ? (`,
+			`-                        <Badge variant="success" className={className}>`,
+		}, cm.Hunks[1].Lines)
+		// The hunks of the parsed diff are left untouched.
+		require.Len(t, original[1].Lines, 4)
+
+		cm = newMatch(t)
+		require.Equal(t, 4, cm.Limit(10))
+		require.Len(t, cm.Hunks, 3)
+	})
+
+	t.Run("Select", func(t *testing.T) {
+		require.Equal(t, &RepoMatch{ID: 1, Name: "github.com/sourcegraph/sourcegraph"},
+			newMatch(t).Select(filter.SelectPath{filter.Repository}))
+
+		path := "client/web/src/enterprise/codeintel/badge/components/IndexerSummary.tsx"
+		require.Equal(t, &FileMatch{File: File{
+			Repo:     types.MinimalRepo{ID: 1, Name: "github.com/sourcegraph/sourcegraph"},
+			CommitID: "abc",
+			Path:     path,
+		}}, newMatch(t).Select(filter.SelectPath{filter.File}))
+
+		fm := newMatch(t).Select(filter.SelectPath{filter.File, "directory"}).(*FileMatch)
+		require.Equal(t, "client/web/src/enterprise/codeintel/badge/components/", fm.Path)
+
+		require.NotNil(t, newMatch(t).Select(filter.SelectPath{filter.Commit, "diff", "added"}))
+
+		cm := newMatch(t)
+		cm.Hunks = []Hunk{{Lines: []string{" context", "+added"}}}
+		require.NotNil(t, cm.Select(filter.SelectPath{filter.Commit, "diff", "added"}))
+		require.Nil(t, cm.Select(filter.SelectPath{filter.Commit, "diff", "removed"}))
+		require.Nil(t, cm.Select(filter.SelectPath{filter.Symbol}))
+	})
+}
+
+func TestCommitDiffMatch_SelectDiffKind(t *testing.T) {
+	added := filter.SelectPath{filter.Commit, "diff", "added"}
+	removed := filter.SelectPath{filter.Commit, "diff", "removed"}
+
+	t.Run("file granular", func(t *testing.T) {
+		res, err := ParseDiffString(input)
+		require.NoError(t, err)
+
+		// RequestLink.module.scss only adds lines.
+		require.Nil(t, (&CommitDiffMatch{DiffFile: &res[2]}).Select(removed))
+		require.NotNil(t, (&CommitDiffMatch{DiffFile: &res[2]}).Select(added))
+	})
+
+	t.Run("narrows hunks", func(t *testing.T) {
+		diffFile := &DiffFile{Hunks: []Hunk{
+			{Lines: []string{" a", "+b"}},
+			{Lines: []string{" c", "-d"}},
+		}}
+		cm := (&CommitDiffMatch{DiffFile: diffFile}).Select(removed).(*CommitDiffMatch)
+		require.Equal(t, []Hunk{{Lines: []string{" c", "-d"}}}, cm.Hunks)
+		// The hunks of the original diff are left untouched.
+		require.Len(t, diffFile.Hunks, 2)
+	})
+
+	t.Run("narrows line ranges", func(t *testing.T) {
+		foo := Ranges{{Start: Location{Offset: 1, Column: 1}, End: Location{Offset: 4, Column: 4}}}
+		diffFile := &DiffFile{Hunks: []Hunk{{
+			Lines:      []string{"+foo", "-foo"},
+			LineRanges: []Ranges{foo, foo},
+		}}}
+		cm := (&CommitDiffMatch{DiffFile: diffFile}).Select(added).(*CommitDiffMatch)
+		require.Equal(t, []Ranges{foo, nil}, cm.Hunks[0].LineRanges)
+		// The hunks of the original diff are left untouched.
+		require.Equal(t, []Ranges{foo, foo}, diffFile.Hunks[0].LineRanges)
+	})
+
+	t.Run("narrows a copy of the preview", func(t *testing.T) {
+		preview := &MatchedString{
+			Content: "+foo\n-foo",
+			MatchedRanges: Ranges{
+				{Start: Location{Offset: 1, Line: 0, Column: 1}, End: Location{Offset: 4, Line: 0, Column: 4}},
+				{Start: Location{Offset: 6, Line: 1, Column: 1}, End: Location{Offset: 9, Line: 1, Column: 4}},
+			},
+		}
+		diffFile := &DiffFile{Hunks: []Hunk{{Lines: []string{"+foo", "-foo"}}}}
+
+		cm := (&CommitDiffMatch{Preview: preview, DiffFile: diffFile}).Select(added).(*CommitDiffMatch)
+		require.Equal(t, preview.MatchedRanges[:1], cm.Preview.MatchedRanges)
+		// The preview is shared with the other diff matches of the commit.
+		require.Len(t, preview.MatchedRanges, 2)
+
+		// No highlights apply to removed lines.
+		preview.MatchedRanges = preview.MatchedRanges[:1]
+		require.Nil(t, (&CommitDiffMatch{Preview: preview, DiffFile: diffFile}).Select(removed))
+	})
+}
@@ -0,0 +1,75 @@
+package result
+
+import (
+	"github.com/sourcegraph/sourcegraph/internal/search/filter"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+// CommitGroupMatch summarizes the commit and diff matches found in a single
+// repository. It is sent instead of the individual matches when a search
+// groups commit and diff results by repository.
+//
+// A repository's summary is sent again whenever more matches are found in it.
+// Each summary holds the totals found so far, so it replaces any earlier
+// summary with the same Key.
+type CommitGroupMatch struct {
+	Repo types.MinimalRepo
+
+	// CommitCount and DiffCount are the number of commit and diff matches
+	// found in Repo so far.
+	CommitCount int
+	DiffCount   int
+
+	// Query is a search query that lists the grouped matches. It is the query
+	// that was grouped, scoped to Repo.
+	Query string
+
+	// NewResults is the result count of the matches that are new since the
+	// previous summary for Repo. Summaries count as these results, so that
+	// limits and progress keep counting the underlying matches.
+	NewResults int
+}
+
+func (m *CommitGroupMatch) RepoName() types.MinimalRepo {
+	return m.Repo
+}
+
+func (m *CommitGroupMatch) ResultCount() int {
+	return m.NewResults
+}
+
+func (m *CommitGroupMatch) Limit(limit int) int {
+	if m.NewResults > limit {
+		m.NewResults = limit
+	}
+	return limit - m.NewResults
+}
+
+// AppendMatches merges other, a summary of the same repository, into m. The
+// summaries may count the same underlying matches, so the larger totals are
+// kept rather than adding them up.
+func (m *CommitGroupMatch) AppendMatches(other *CommitGroupMatch) {
+	m.CommitCount = max(m.CommitCount, other.CommitCount)
+	m.DiffCount = max(m.DiffCount, other.DiffCount)
+	m.NewResults = max(m.NewResults, other.NewResults)
+}
+
+func (m *CommitGroupMatch) Select(path filter.SelectPath) Match {
+	switch path.Root() {
+	case filter.Repository:
+		return &RepoMatch{
+			Name: m.Repo.Name,
+			ID:   m.Repo.ID,
+		}
+	}
+	return nil
+}
+
+func (m *CommitGroupMatch) Key() Key {
+	return Key{
+		TypeRank: rankCommitGroupMatch,
+		Repo:     m.Repo.Name,
+	}
+}
+
+func (m *CommitGroupMatch) searchResultMarker() {}
@@ -28,6 +28,7 @@ type stableCommitMatchJSON struct {
 	Parents         []string                  `json:"parents,omitempty"`
 	Refs            []string                  `json:"refs,omitempty"`
 	SourceRefs      []string                  `json:"sourceRefs,omitempty"`
+	Ref             string                    `json:"ref,omitempty"`
 	MessagePreview  *MatchedString            `json:"messagePreview,omitempty"`
 	DiffPreview     *MatchedString            `json:"diffPreview,omitempty"`
 	ModifiedFiles   []string                  `json:"modifiedFiles,omitempty"`
@@ -69,6 +70,7 @@ func (cm CommitMatch) MarshalJSON() ([]byte, error) {
 		Parents:         parents,
 		Refs:            cm.Refs,
 		SourceRefs:      cm.SourceRefs,
+		Ref:             cm.Ref,
 		MessagePreview:  cm.MessagePreview,
 		DiffPreview:     cm.DiffPreview,
 		ModifiedFiles:   cm.ModifiedFiles,
@@ -99,7 +101,7 @@ func (cm *CommitMatch) UnmarshalJSON(input []byte) (err error) {
 
 	var structuredDiff []DiffFile
 	if unmarshaler.DiffPreview != nil {
-		structuredDiff, err = ParseDiffString(unmarshaler.DiffPreview.Content)
+		structuredDiff, err = ParseDiffPreview(*unmarshaler.DiffPreview)
 		if err != nil {
 			return err
 		}
@@ -124,6 +126,7 @@ func (cm *CommitMatch) UnmarshalJSON(input []byte) (err error) {
 		},
 		Refs:           unmarshaler.Refs,
 		SourceRefs:     unmarshaler.SourceRefs,
+		Ref:            unmarshaler.Ref,
 		MessagePreview: unmarshaler.MessagePreview,
 		DiffPreview:    unmarshaler.DiffPreview,
 		Diff:           structuredDiff,
@@ -3,6 +3,14 @@ package result
 import (
 	"testing"
 	"testing/quick"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
+	"github.com/sourcegraph/sourcegraph/internal/search/filter"
+	"github.com/sourcegraph/sourcegraph/internal/types"
 )
 
 func TestCommitSearchResult_Limit(t *testing.T) {
@@ -40,3 +48,208 @@ func TestCommitSearchResult_Limit(t *testing.T) {
 		}
 	}
 }
+
+func TestCommitMatch_ModifiedLanguages(t *testing.T) {
+	t.Run("modified files", func(t *testing.T) {
+		cm := &CommitMatch{
+			ModifiedFiles: []string{"main.go", "internal/util.go", "README.md", "Makefile.unknown-extension"},
+		}
+		require.Equal(t, map[string]int{"Go": 2, "Markdown": 1}, cm.ModifiedLanguages())
+	})
+
+	t.Run("structured diff", func(t *testing.T) {
+		cm := &CommitMatch{
+			Diff: []DiffFile{
+				{OrigName: "/dev/null", NewName: "web/index.ts"},
+				{OrigName: "lib/main.py", NewName: "/dev/null"},
+				{OrigName: "web/app.ts", NewName: "web/app.ts"},
+			},
+		}
+		require.Equal(t, map[string]int{"TypeScript": 2, "Python": 1}, cm.ModifiedLanguages())
+	})
+
+	t.Run("no files", func(t *testing.T) {
+		cm := &CommitMatch{}
+		require.Nil(t, cm.ModifiedLanguages())
+	})
+}
+
+func TestCommitMatch_MatchesRef(t *testing.T) {
+	// Refs and source refs as reported by git log --decorate=full with the %D
+	// and %S placeholders.
+	cm := &CommitMatch{
+		Refs:       []string{"HEAD -> refs/heads/main", "tag: refs/tags/v1.0.0", "refs/remotes/origin/main"},
+		SourceRefs: []string{"refs/heads/release/1.0"},
+	}
+
+	require.True(t, cm.MatchesRef("refs/heads/release/*"))
+	require.True(t, cm.MatchesRef("release/*"))
+	require.True(t, cm.MatchesRef("refs/tags/v1.*"))
+	require.True(t, cm.MatchesRef("v1.*"))
+	require.True(t, cm.MatchesRef("refs/heads/main"))
+	require.True(t, cm.MatchesRef("main"))
+	require.True(t, cm.MatchesRef("refs/remotes/origin/*"))
+	require.False(t, cm.MatchesRef("refs/heads/release"))
+	require.False(t, cm.MatchesRef("refs/heads/feature/*"))
+	require.False(t, cm.MatchesRef("HEAD -> *"))
+
+	// Commits not pointed to by any ref have a single empty ref.
+	cm = &CommitMatch{Refs: []string{""}, SourceRefs: []string{"HEAD"}}
+	require.True(t, cm.MatchesRef("HEAD"))
+	require.False(t, cm.MatchesRef("main"))
+	require.False(t, cm.MatchesRef("refs/heads/*"))
+}
+
+func TestCommitMatch_PatchURL(t *testing.T) {
+	cm := &CommitMatch{
+		Repo:   types.MinimalRepo{Name: "github.com/sourcegraph/sourcegraph"},
+		Commit: gitdomain.Commit{ID: "0123456789abcdef0123456789abcdef01234567"},
+	}
+	require.Equal(t, "/github.com/sourcegraph/sourcegraph@0123456789abcdef0123456789abcdef01234567/-/patch", cm.PatchURL().String())
+}
+
+func TestCommitMatch_SplitBySourceRef(t *testing.T) {
+	cm := &CommitMatch{SourceRefs: []string{"refs/heads/main", "refs/heads/release/1.0"}}
+
+	split := cm.SplitBySourceRef()
+	require.Len(t, split, 2)
+	require.Equal(t, "refs/heads/main", split[0].Ref)
+	require.Equal(t, "refs/heads/release/1.0", split[1].Ref)
+	require.NotEqual(t, split[0].Key(), split[1].Key())
+
+	decorated := (&CommitMatch{SourceRefs: []string{"tag: refs/tags/v1.0.0"}}).SplitBySourceRef()
+	require.Len(t, decorated, 1)
+	require.Equal(t, "refs/tags/v1.0.0", decorated[0].Ref)
+
+	// Commits without source refs are reported once, and kept when selecting
+	// per-ref results.
+	single := &CommitMatch{SourceRefs: []string{""}}
+	require.Equal(t, []*CommitMatch{single}, single.SplitBySourceRef())
+	require.Equal(t, single, single.Select(filter.SelectPath{filter.Commit, "ref"}))
+}
+
+func TestCommitMatch_SplitBySourceRefCopies(t *testing.T) {
+	r := func(offset int) Range {
+		return Range{Start: Location{Offset: offset}, End: Location{Offset: offset + 1}}
+	}
+	cm := &CommitMatch{
+		SourceRefs:  []string{"refs/heads/main", "refs/heads/release/1.0"},
+		DiffPreview: &MatchedString{Content: "a.go b.go\n@@ -1 +1 @@\n+abc", MatchedRanges: Ranges{r(0), r(5), r(24)}},
+		Diff: []DiffFile{{
+			OrigName: "a.go",
+			NewName:  "b.go",
+			Hunks: []Hunk{{
+				Header:     "@@ -1 +1 @@",
+				Lines:      []string{"+abc"},
+				LineRanges: []Ranges{{r(1)}},
+			}},
+			NameRanges: Ranges{r(0), r(5)},
+		}},
+	}
+
+	split := cm.SplitBySourceRef()
+	require.Len(t, split, 2)
+
+	// Limiting or editing one ref doesn't change the other refs.
+	require.Equal(t, 0, split[0].Limit(1))
+	split[0].Diff[0].NameRanges[0] = r(7)
+	split[0].Diff[0].Hunks[0].LineRanges[0][0] = r(2)
+
+	require.Len(t, split[0].DiffPreview.MatchedRanges, 1)
+	require.Len(t, split[1].DiffPreview.MatchedRanges, 3)
+	require.Len(t, cm.DiffPreview.MatchedRanges, 3)
+	require.Equal(t, r(0), split[1].Diff[0].NameRanges[0])
+	require.Equal(t, r(1), split[1].Diff[0].Hunks[0].LineRanges[0][0])
+	require.Equal(t, r(1), cm.Diff[0].Hunks[0].LineRanges[0][0])
+}
+
+func TestCommitMatch_SelectAuthor(t *testing.T) {
+	repo := types.MinimalRepo{ID: 1, Name: "github.com/sourcegraph/sourcegraph"}
+	commit := func(id, email string, date time.Time) *CommitMatch {
+		return &CommitMatch{
+			Repo: repo,
+			Commit: gitdomain.Commit{
+				ID:     api.CommitID(id),
+				Author: gitdomain.Signature{Name: "Alice", Email: email, Date: date},
+			},
+			MessagePreview: &MatchedString{Content: "fix"},
+		}
+	}
+	morning := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	evening := time.Date(2023, 5, 1, 20, 0, 0, 0, time.FixedZone("PDT", -7*60*60))
+
+	author := filter.SelectPath{filter.Commit, "author"}
+	require.Equal(t, &CommitAuthorMatch{Repo: repo, Name: "Alice", Email: "alice@example.com"},
+		commit("a", "alice@example.com", morning).Select(author))
+
+	// Commits by the same author share a key, so that they are deduplicated.
+	require.Equal(t,
+		commit("a", "alice@example.com", morning).Select(author).Key(),
+		commit("b", "alice@example.com", evening).Select(author).Key())
+	require.NotEqual(t,
+		commit("a", "alice@example.com", morning).Select(author).Key(),
+		commit("c", "alice@other.com", morning).Select(author).Key())
+
+	// Dates are truncated to the UTC day.
+	date := filter.SelectPath{filter.Commit, "date"}
+	require.Equal(t, &CommitAuthorMatch{Repo: repo, Date: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)},
+		commit("a", "alice@example.com", morning).Select(date))
+	require.Equal(t, &CommitAuthorMatch{Repo: repo, Date: time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC)},
+		commit("b", "alice@example.com", evening).Select(date))
+
+	// Selecting again is idempotent.
+	selected := commit("a", "alice@example.com", morning).Select(author)
+	require.Equal(t, selected, selected.Select(author))
+	require.Nil(t, selected.Select(date))
+	require.Equal(t, &RepoMatch{ID: 1, Name: repo.Name}, selected.Select(filter.SelectPath{filter.Repository}))
+}
+
+func TestCommitMatch_AppendMatchesDiff(t *testing.T) {
+	fileA := "a.go a.go\n@@ -1,1 +1,1 @@\n-foo\n+bar\n"
+	fileB := "b.go b.go\n@@ -1,1 +1,1 @@\n-baz\n+qux\n"
+	foo := Range{Start: Location{Offset: 27, Line: 2, Column: 1}, End: Location{Offset: 30, Line: 2, Column: 4}}
+	bar := Range{Start: Location{Offset: 32, Line: 3, Column: 1}, End: Location{Offset: 35, Line: 3, Column: 4}}
+	qux := Range{Start: Location{Offset: 32, Line: 3, Column: 1}, End: Location{Offset: 35, Line: 3, Column: 4}}
+
+	newMatch := func(content string, ranges ...Range) *CommitMatch {
+		preview := MatchedString{Content: content, MatchedRanges: ranges}
+		diff, err := ParseDiffPreview(preview)
+		require.NoError(t, err)
+		return &CommitMatch{DiffPreview: &preview, Diff: diff}
+	}
+
+	t.Run("same preview", func(t *testing.T) {
+		cm := newMatch(fileA, foo)
+		cm.AppendMatches(newMatch(fileA, bar))
+		require.Equal(t, &MatchedString{Content: fileA, MatchedRanges: Ranges{foo, bar}}, cm.DiffPreview)
+		// The structured diff is merged too.
+		require.Equal(t, newMatch(fileA, foo, bar).Diff, cm.Diff)
+	})
+
+	t.Run("different files", func(t *testing.T) {
+		cm := newMatch(fileA, foo)
+		src := newMatch(fileB, qux)
+		cm.AppendMatches(src)
+
+		quxMerged := Range{Start: Location{Offset: 68, Line: 7, Column: 1}, End: Location{Offset: 71, Line: 7, Column: 4}}
+		require.Equal(t, &MatchedString{Content: fileA + fileB, MatchedRanges: Ranges{foo, quxMerged}}, cm.DiffPreview)
+		require.Len(t, cm.Diff, 2)
+		// The source match is left untouched.
+		require.Equal(t, &MatchedString{Content: fileB, MatchedRanges: Ranges{qux}}, src.DiffPreview)
+	})
+
+	t.Run("file names", func(t *testing.T) {
+		name := Range{Start: Location{Offset: 0, Line: 0, Column: 0}, End: Location{Offset: 4, Line: 0, Column: 4}}
+		cm := newMatch(fileA, name)
+		cm.AppendMatches(newMatch(fileB, qux))
+
+		quxMerged := Range{Start: Location{Offset: 68, Line: 7, Column: 1}, End: Location{Offset: 71, Line: 7, Column: 4}}
+		require.Equal(t, &MatchedString{Content: fileA + fileB, MatchedRanges: Ranges{name, quxMerged}}, cm.DiffPreview)
+	})
+
+	t.Run("same hunk", func(t *testing.T) {
+		cm := newMatch(fileA+fileB, foo)
+		cm.AppendMatches(newMatch(fileA, bar))
+		require.Equal(t, &MatchedString{Content: fileA + fileB, MatchedRanges: Ranges{foo, bar}}, cm.DiffPreview)
+	})
+}
@@ -2,6 +2,10 @@ package result
 
 // Deduper deduplicates matches added to it with Add(). Matches are deduplicated by their key,
 // and the return value of Results() is ordered in the same order results are added with Add().
+//
+// The changes of a commit can be reported both by a commit match with a diff and by the diff
+// matches of its files. Diff matches of files already reported by a commit match are
+// duplicates, and files already reported by diff matches are removed from commit matches.
 type Deduper struct {
 	results Matches
 	seen    map[Key]Match
@@ -22,17 +26,69 @@ func (d *Deduper) Add(m Match) {
 		case *FileMatch:
 			prevMatch.AppendMatches(m.(*FileMatch))
 		case *CommitMatch:
-			prevMatch.AppendMatches(m.(*CommitMatch))
+			// The key can also be the key of a diff match reporting a
+			// file of the commit match, which is a duplicate.
+			if cm, ok := m.(*CommitMatch); ok {
+				d.appendCommitMatch(prevMatch, cm)
+			}
 		}
 		return
 	}
 
+	if cm, ok := m.(*CommitMatch); ok {
+		if keys := cm.diffFileKeys(); len(keys) > 0 {
+			if !cm.removeDiffFiles(d.seenKey) {
+				return
+			}
+			for _, key := range cm.diffFileKeys() {
+				d.seen[key] = cm
+			}
+		}
+	}
+
 	d.results = append(d.results, m)
 	d.seen[m.Key()] = m
 }
 
+// appendCommitMatch merges cm into prev, which has the same key. Files of the
+// diff of cm that were reported by other matches are removed first, and the
+// files it adds to prev are registered as reported by prev.
+func (d *Deduper) appendCommitMatch(prev, cm *CommitMatch) {
+	if len(cm.diffFileKeys()) > 0 {
+		reportedElsewhere := func(key Key) bool {
+			m, ok := d.seen[key]
+			return ok && m != Match(prev)
+		}
+		if !cm.removeDiffFiles(reportedElsewhere) {
+			cm.Diff, cm.DiffPreview = nil, nil
+		}
+	}
+
+	prev.AppendMatches(cm)
+	for _, key := range prev.diffFileKeys() {
+		d.seen[key] = prev
+	}
+}
+
 func (d *Deduper) Seen(m Match) bool {
-	_, ok := d.seen[m.Key()]
+	if d.seenKey(m.Key()) {
+		return true
+	}
+	// A commit match is seen if all the files of its diff were seen.
+	if cm, ok := m.(*CommitMatch); ok {
+		keys := cm.diffFileKeys()
+		for _, key := range keys {
+			if !d.seenKey(key) {
+				return false
+			}
+		}
+		return len(keys) > 0
+	}
+	return false
+}
+
+func (d *Deduper) seenKey(key Key) bool {
+	_, ok := d.seen[key]
 	return ok
 }
 
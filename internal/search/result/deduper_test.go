@@ -34,6 +34,32 @@ func TestDeduper(t *testing.T) {
 		}
 	}
 
+	const (
+		fileA = "a.go a.go\n@@ -1,1 +1,1 @@\n-foo\n+bar\n"
+		fileB = "b.go b.go\n@@ -1,1 +1,1 @@\n-baz\n+qux\n"
+		fileC = "c.go c.go\n@@ -1,1 +1,1 @@\n-quux\n+corge\n"
+	)
+
+	diffFiles := func(repo, id, content string) *CommitMatch {
+		cm := diff(repo, id)
+		cm.DiffPreview.Content = content
+		cm.Diff, _ = ParseDiffString(content)
+		return cm
+	}
+
+	diffFile := func(repo, id, content string) *CommitDiffMatch {
+		diffFiles, _ := ParseDiffString(content)
+		return &CommitDiffMatch{
+			Repo: types.MinimalRepo{
+				Name: api.RepoName(repo),
+			},
+			Commit: gitdomain.Commit{
+				ID: api.CommitID(id),
+			},
+			DiffFile: &diffFiles[0],
+		}
+	}
+
 	repo := func(name, rev string) *RepoMatch {
 		return &RepoMatch{
 			Name: api.RepoName(name),
@@ -101,6 +127,62 @@ func TestDeduper(t *testing.T) {
 				diff("a", "b"),
 			},
 		},
+		{
+			name: "diff matches of files of commit diffs are duplicates",
+			input: []Match{
+				diffFiles("a", "b", fileA+fileB),
+				diffFile("a", "b", fileB),
+				diffFile("a", "c", fileB),
+			},
+			expected: []Match{
+				diffFiles("a", "b", fileA+fileB),
+				diffFile("a", "c", fileB),
+			},
+		},
+		{
+			name: "files of diff matches are removed from commit diffs",
+			input: []Match{
+				diffFile("a", "b", fileA),
+				diffFiles("a", "b", fileA+fileB),
+			},
+			expected: []Match{
+				diffFile("a", "b", fileA),
+				diffFiles("a", "b", fileB),
+			},
+		},
+		{
+			name: "commit diffs of files of diff matches are duplicates",
+			input: []Match{
+				diffFile("a", "b", fileA),
+				diffFiles("a", "b", fileA),
+			},
+			expected: []Match{
+				diffFile("a", "b", fileA),
+			},
+		},
+		{
+			name: "files added by merged commit diffs are registered",
+			input: []Match{
+				diffFiles("a", "b", fileA),
+				diffFiles("a", "b", fileB),
+				diffFile("a", "b", fileB),
+			},
+			expected: []Match{
+				diffFiles("a", "b", fileA+fileB),
+			},
+		},
+		{
+			name: "files of diff matches are removed from merged commit diffs",
+			input: []Match{
+				diffFiles("a", "b", fileA),
+				diffFile("a", "b", fileB),
+				diffFiles("a", "b", fileB+fileC),
+			},
+			expected: []Match{
+				diffFiles("a", "b", fileA+fileC),
+				diffFile("a", "b", fileB),
+			},
+		},
 		{
 			name: "different revs not deduped",
 			input: []Match{
@@ -35,17 +35,21 @@ var (
 	_ Match = (*CommitMatch)(nil)
 	_ Match = (*CommitDiffMatch)(nil)
 	_ Match = (*OwnerMatch)(nil)
+	_ Match = (*CommitGroupMatch)(nil)
+	_ Match = (*CommitAuthorMatch)(nil)
 )
 
 // Match ranks are used for sorting the different match types.
 // Match types with lower ranks will be sorted before match types
 // with higher ranks.
 const (
-	rankFileMatch   = 0
-	rankCommitMatch = 1
-	rankDiffMatch   = 2
-	rankRepoMatch   = 3
-	rankOwnerMatch  = 4
+	rankFileMatch         = 0
+	rankCommitMatch       = 1
+	rankDiffMatch         = 2
+	rankRepoMatch         = 3
+	rankOwnerMatch        = 4
+	rankCommitGroupMatch  = 5
+	rankCommitAuthorMatch = 6
 )
 
 // Key is a sorting or deduplicating key for a Match. It contains all the
@@ -80,6 +84,10 @@ type Key struct {
 	// Empty if this is not a Key for an OwnerMatch.
 	OwnerMetadata string
 
+	// Author identifies the author of a commit.
+	// Empty if this is not a Key for a CommitAuthorMatch selecting authors.
+	Author string
+
 	// TypeRank is the sorting rank of the type this key belongs to.
 	TypeRank int
 }
@@ -110,6 +118,10 @@ func (k Key) Less(other Key) bool {
 		return k.OwnerMetadata < other.OwnerMetadata
 	}
 
+	if k.Author != other.Author {
+		return k.Author < other.Author
+	}
+
 	return k.TypeRank < other.TypeRank
 }
 
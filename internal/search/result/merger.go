@@ -91,6 +91,8 @@ func (lm *merger) addMatch(m Match, source int) Match {
 		prev.match.(*CommitMatch).AppendMatches(v)
 	case *RepoMatch:
 		prev.match.(*RepoMatch).AppendMatches(v)
+	case *CommitGroupMatch:
+		prev.match.(*CommitGroupMatch).AppendMatches(v)
 	}
 
 	// Mark the key as seen by this source
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
 type MatchedString struct {
@@ -12,9 +13,21 @@ type MatchedString struct {
 	MatchedRanges Ranges `json:"matchedRanges"`
 }
 
+// Normalize returns a copy of m with its ranges normalized against its
+// content. See Ranges.Normalize.
+func (m MatchedString) Normalize() MatchedString {
+	return MatchedString{
+		Content:       m.Content,
+		MatchedRanges: m.MatchedRanges.Normalize(m.Content),
+	}
+}
+
 func (m MatchedString) ToHighlightedString() HighlightedString {
-	highlights := make([]HighlightedRange, 0, len(m.MatchedRanges))
-	for _, r := range m.MatchedRanges {
+	// Ranges merged from several matches can overlap, and ranges outside of
+	// the content can't be highlighted, so we normalize them first.
+	ranges := m.MatchedRanges.Normalize(m.Content)
+	highlights := make([]HighlightedRange, 0, len(ranges))
+	for _, r := range ranges {
 		highlights = append(highlights, rangeToHighlights(m.Content, r)...)
 	}
 	return HighlightedString{Value: m.Content, Highlights: highlights}
@@ -137,6 +150,58 @@ func (r Ranges) Merge(other Ranges) Ranges {
 	return r
 }
 
+// Normalize returns the ranges of r that fall within content, sorted by
+// offset, with overlapping and duplicate ranges merged into one. Ranges
+// extending past the end of content are clamped to it. r is not modified.
+func (r Ranges) Normalize(content string) Ranges {
+	res := make(Ranges, 0, len(r))
+	for _, rr := range r {
+		if rr.End.Offset < rr.Start.Offset || rr.Start.Offset > len(content) || rr.End.Offset < 0 {
+			continue
+		}
+		if rr.Start.Offset < 0 {
+			rr.Start = Location{}
+		}
+		if rr.End.Offset > len(content) {
+			rr.End = locationAt(content, len(content))
+		}
+		res = append(res, rr)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Start.Offset != res[j].Start.Offset {
+			return res[i].Start.Offset < res[j].Start.Offset
+		}
+		return res[i].End.Offset < res[j].End.Offset
+	})
+
+	merged := res[:0]
+	for _, rr := range res {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if rr == *last || rr.Start.Offset < last.End.Offset {
+				if rr.End.Offset > last.End.Offset {
+					last.End = rr.End
+				}
+				continue
+			}
+		}
+		merged = append(merged, rr)
+	}
+	return merged
+}
+
+// locationAt returns the Location of the byte at offset in content.
+func locationAt(content string, offset int) Location {
+	before := content[:offset]
+	lineStart := strings.LastIndexByte(before, '\n') + 1
+	return Location{
+		Offset: offset,
+		Line:   strings.Count(before, "\n"),
+		Column: utf8.RuneCountInString(before[lineStart:]),
+	}
+}
+
 func (r Ranges) Add(amount Location) Ranges {
 	res := make(Ranges, 0, len(r))
 	for _, oldRange := range r {
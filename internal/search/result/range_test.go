@@ -1,8 +1,11 @@
 package result
 
 import (
+	"math/rand"
+	"reflect"
 	"strconv"
 	"testing"
+	"testing/quick"
 
 	"github.com/stretchr/testify/require"
 )
@@ -72,3 +75,152 @@ func Test_searchRangeToHighlights(t *testing.T) {
 		})
 	}
 }
+
+func TestRanges_Normalize(t *testing.T) {
+	content := "abc\ndef"
+	r := func(start, end int) Range {
+		return Range{Start: locationAt(content, start), End: locationAt(content, end)}
+	}
+
+	cases := []struct {
+		name   string
+		input  Ranges
+		output Ranges
+	}{{
+		name:   "sorts",
+		input:  Ranges{r(4, 5), r(0, 1)},
+		output: Ranges{r(0, 1), r(4, 5)},
+	}, {
+		name:   "merges duplicates",
+		input:  Ranges{r(1, 2), r(1, 2)},
+		output: Ranges{r(1, 2)},
+	}, {
+		name:   "merges overlaps",
+		input:  Ranges{r(0, 2), r(1, 5), r(4, 6)},
+		output: Ranges{r(0, 6)},
+	}, {
+		name:   "keeps adjacent ranges",
+		input:  Ranges{r(0, 1), r(1, 2)},
+		output: Ranges{r(0, 1), r(1, 2)},
+	}, {
+		name:   "clamps to content",
+		input:  Ranges{{Start: locationAt(content, 5), End: Location{Offset: 20, Line: 3, Column: 2}}},
+		output: Ranges{r(5, 7)},
+	}, {
+		name:   "drops ranges outside of content",
+		input:  Ranges{{Start: Location{Offset: 10}, End: Location{Offset: 12}}, {Start: Location{Offset: 3}, End: Location{Offset: 1}}},
+		output: Ranges{},
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := append(Ranges(nil), tc.input...)
+			require.Equal(t, tc.output, tc.input.Normalize(content))
+			require.Equal(t, input, tc.input, "input was modified")
+		})
+	}
+}
+
+// normalizeInput is a MatchedString with random, possibly overlapping or out
+// of bounds ranges. It implements quick.Generator.
+type normalizeInput MatchedString
+
+func (normalizeInput) Generate(rand *rand.Rand, size int) reflect.Value {
+	const alphabet = "ab\n"
+	content := make([]byte, rand.Intn(size+1))
+	for i := range content {
+		content[i] = alphabet[rand.Intn(len(alphabet))]
+	}
+
+	offset := func() int {
+		// Allow offsets a bit outside of the content.
+		return rand.Intn(len(content)+5) - 2
+	}
+	location := func(offset int) Location {
+		if offset < 0 || offset > len(content) {
+			return Location{Offset: offset}
+		}
+		return locationAt(string(content), offset)
+	}
+
+	ranges := make(Ranges, rand.Intn(size+1))
+	for i := range ranges {
+		start, end := offset(), offset()
+		if start > end && rand.Intn(4) > 0 {
+			start, end = end, start
+		}
+		ranges[i] = Range{Start: location(start), End: location(end)}
+	}
+
+	return reflect.ValueOf(normalizeInput{Content: string(content), MatchedRanges: ranges})
+}
+
+func TestRanges_Normalize_Properties(t *testing.T) {
+	// covered returns which bytes of content are within a range that is
+	// valid, i.e. does not end before it starts.
+	covered := func(in normalizeInput, ranges Ranges) []bool {
+		res := make([]bool, len(in.Content))
+		for _, r := range ranges {
+			if r.End.Offset < r.Start.Offset {
+				continue
+			}
+			for i := r.Start.Offset; i < r.End.Offset; i++ {
+				if i >= 0 && i < len(res) {
+					res[i] = true
+				}
+			}
+		}
+		return res
+	}
+
+	t.Run("sorted, disjoint and within bounds", func(t *testing.T) {
+		f := func(in normalizeInput) bool {
+			ranges := in.MatchedRanges.Normalize(in.Content)
+			for i, r := range ranges {
+				if r.Start.Offset < 0 || r.Start.Offset > r.End.Offset || r.End.Offset > len(in.Content) {
+					return false
+				}
+				if r.Start != locationAt(in.Content, r.Start.Offset) || r.End != locationAt(in.Content, r.End.Offset) {
+					return false
+				}
+				if i > 0 && (r.Start.Offset < ranges[i-1].End.Offset || r == ranges[i-1]) {
+					return false
+				}
+			}
+			return true
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("preserves the highlighted bytes", func(t *testing.T) {
+		f := func(in normalizeInput) bool {
+			return reflect.DeepEqual(covered(in, in.MatchedRanges), covered(in, in.MatchedRanges.Normalize(in.Content)))
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("idempotent", func(t *testing.T) {
+		f := func(in normalizeInput) bool {
+			once := in.MatchedRanges.Normalize(in.Content)
+			return reflect.DeepEqual(once, once.Normalize(in.Content))
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("can be highlighted", func(t *testing.T) {
+		f := func(in normalizeInput) bool {
+			// Panics if a range is out of bounds.
+			MatchedString(in).ToHighlightedString()
+			return true
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
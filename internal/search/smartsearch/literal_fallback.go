@@ -0,0 +1,65 @@
+package smartsearch
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	alertobserver "github.com/sourcegraph/sourcegraph/internal/search/alert"
+	"github.com/sourcegraph/sourcegraph/internal/search/job"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+)
+
+// NewLiteralFallbackJob wraps the job of a query whose patterns failed to
+// parse as regular expressions with regexpErr, and are searched literally
+// instead. plan is the literal interpretation of the query. The job notifies
+// the user of the literal interpretation.
+func NewLiteralFallbackJob(child job.Job, plan query.Plan, regexpErr error) job.Job {
+	return &literalFallbackJob{
+		child:     child,
+		query:     query.OmitField(plan.ToQ(), query.FieldPatternType),
+		regexpErr: regexpErr,
+	}
+}
+
+type literalFallbackJob struct {
+	child     job.Job
+	query     string
+	regexpErr error
+}
+
+func (l *literalFallbackJob) Run(ctx context.Context, clients job.RuntimeClients, stream streaming.Sender) (*search.Alert, error) {
+	alert, err := l.child.Run(ctx, clients, stream)
+	return alert, errors.Append(err, &alertobserver.ErrLiteralFallback{
+		Query: l.query,
+		Err:   l.regexpErr,
+	})
+}
+
+func (l *literalFallbackJob) Name() string {
+	return "LiteralFallbackJob"
+}
+
+func (l *literalFallbackJob) Attributes(v job.Verbosity) (res []attribute.KeyValue) {
+	switch v {
+	case job.VerbosityMax:
+		fallthrough
+	case job.VerbosityBasic:
+		res = append(res,
+			attribute.String("query", l.query),
+			attribute.String("regexpErr", l.regexpErr.Error()),
+		)
+	}
+	return res
+}
+
+func (l *literalFallbackJob) Children() []job.Describer { return []job.Describer{l.child} }
+
+func (l *literalFallbackJob) MapChildren(fn job.MapFunc) job.Job {
+	cp := *l
+	cp.child = job.Map(l.child, fn)
+	return &cp
+}
@@ -0,0 +1,124 @@
+package smartsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/regexp"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/search/searchcontexts"
+)
+
+const (
+	// maxRecentRepositories is the maximum number of recently viewed repositories
+	// a generated query is restricted to.
+	maxRecentRepositories = 5
+
+	// maxRecentViewEvents is the number of most recent file views of the user that
+	// are considered to find recently viewed repositories.
+	maxRecentViewEvents = 200
+)
+
+// recentRepositories returns the names of the repositories the current user most
+// recently viewed files in, most recent first. Anonymous users have no recent
+// repositories.
+func recentRepositories(ctx context.Context, db database.DB) ([]string, error) {
+	a := actor.FromContext(ctx)
+	if !a.IsAuthenticated() {
+		return nil, nil
+	}
+
+	eventName := "ViewBlob"
+	events, err := db.EventLogs().ListAll(ctx, database.EventLogsListOptions{
+		UserID:      a.UID,
+		EventName:   &eventName,
+		LimitOffset: &database.LimitOffset{Limit: maxRecentViewEvents},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recentRepositoryNames(events), nil
+}
+
+// recentRepositoryNames returns the distinct repository names of the given
+// "ViewBlob" events, which are ordered from most to least recent, up to
+// maxRecentRepositories.
+func recentRepositoryNames(events []*database.Event) []string {
+	var names []string
+	seen := map[string]struct{}{}
+	for _, event := range events {
+		var arg struct {
+			RepoName string `json:"repoName"`
+		}
+		if err := json.Unmarshal(event.PublicArgument, &arg); err != nil || arg.RepoName == "" {
+			continue
+		}
+		if _, ok := seen[arg.RepoName]; ok {
+			continue
+		}
+		seen[arg.RepoName] = struct{}{}
+		names = append(names, arg.RepoName)
+		if len(names) == maxRecentRepositories {
+			break
+		}
+	}
+	return names
+}
+
+// recentRepositoriesRules returns rules that restrict a query to the given
+// repositories after applying a widening rule. Restricting a query to a subset
+// of repositories alone can't find results the query didn't find, so the
+// restriction is only combined with the widening rules.
+func recentRepositoriesRules(repos []string) []rule {
+	restrict := restrictToRepositories(repos)
+	rules := make([]rule, 0, len(rulesWiden))
+	for _, w := range rulesWiden {
+		rules = append(rules, rule{
+			description: w.description + " ⚬ search recently viewed repositories",
+			transform:   append(append([]transform{}, w.transform...), restrict),
+		})
+	}
+	return rules
+}
+
+// restrictToRepositories returns a transform that adds a repo filter matching
+// exactly the given repositories. It does not apply to queries that already
+// specify which repositories to search, other than with the global search
+// context.
+func restrictToRepositories(repos []string) transform {
+	quoted := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		quoted = append(quoted, regexp.QuoteMeta(repo))
+	}
+	value := fmt.Sprintf("^(?:%s)$", strings.Join(quoted, "|"))
+
+	return func(b query.Basic) *query.Basic {
+		if len(repos) == 0 {
+			return nil
+		}
+		for _, param := range b.Parameters {
+			if param.Field == query.FieldRepo {
+				return nil
+			}
+			if param.Field == query.FieldContext && !searchcontexts.IsGlobalSearchContextSpec(param.Value) {
+				return nil
+			}
+		}
+
+		newParams := make([]query.Parameter, 0, len(b.Parameters)+1)
+		newParams = append(newParams, b.Parameters...)
+		newParams = append(newParams, query.Parameter{
+			Field:      query.FieldRepo,
+			Value:      value,
+			Annotation: query.Annotation{},
+		})
+		newQuery := b.MapParameters(newParams)
+		return &newQuery
+	}
+}
@@ -0,0 +1,50 @@
+package smartsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/database"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+)
+
+func TestRecentRepositoryNames(t *testing.T) {
+	event := func(arg string) *database.Event {
+		return &database.Event{Name: "ViewBlob", PublicArgument: []byte(arg)}
+	}
+
+	names := recentRepositoryNames([]*database.Event{
+		event(`{"repoName": "github.com/a/a", "filePath": "main.go"}`),
+		event(`{"repoName": "github.com/b/b", "filePath": "main.go"}`),
+		event(`{"repoName": "github.com/a/a", "filePath": "README.md"}`),
+		event(`{"filePath": "main.go"}`),
+		event(`not json`),
+		event(`{"repoName": "github.com/c/c"}`),
+		event(`{"repoName": "github.com/d/d"}`),
+		event(`{"repoName": "github.com/e/e"}`),
+		event(`{"repoName": "github.com/f/f"}`),
+	})
+	require.Equal(t, []string{"github.com/a/a", "github.com/b/b", "github.com/c/c", "github.com/d/d", "github.com/e/e"}, names)
+}
+
+func TestRecentRepositoriesRules(t *testing.T) {
+	generate := func(input string) []want {
+		q, err := query.ParseStandard(input)
+		require.NoError(t, err)
+		b, err := query.ToBasicQuery(q)
+		require.NoError(t, err)
+		return generateAll(NewGenerator(b, nil, recentRepositoriesRules([]string{"github.com/a/a", "github.com/b/b"})), input)
+	}
+
+	repoFilter := `repo:^(?:github\.com/a/a|github\.com/b/b)$`
+
+	generated := generate(`context:global foo bar`)
+	require.Len(t, generated, 1)
+	require.Equal(t, "AND patterns together ⚬ search recently viewed repositories", generated[0].Description)
+	require.Contains(t, generated[0].Query, repoFilter)
+
+	// Queries that already restrict repositories are left alone.
+	require.Empty(t, generate(`repo:foo foo bar`))
+	require.Empty(t, generate(`context:@alice foo bar`))
+}
@@ -39,6 +39,10 @@ var rulesNarrow = []rule{
 		description: "apply symbol select for pattern",
 		transform:   []transform{symbolPatterns},
 	},
+	{
+		description: "apply file filter for file name pattern",
+		transform:   []transform{filePatterns},
+	},
 	{
 		description: "expand URL to filters",
 		transform:   []transform{patternsToCodeHostFilters},
@@ -484,6 +488,88 @@ func langPatterns(b query.Basic) *query.Basic {
 	}
 }
 
+// fileNamePattern matches patterns that look like a file name with an
+// extension, like `foo.go`, or a bare extension, like `.tsx`.
+var fileNamePattern = regexp.MustCompile(`^[\w-]*\.\w+$`)
+
+// looksLikeFileName returns true if value looks like a file name or file
+// extension of a language we know about. Values that are code host domains
+// like `github.com` are excluded since they are handled by
+// patternsToCodeHostFilters.
+func looksLikeFileName(value string) bool {
+	if !fileNamePattern.MatchString(value) {
+		return false
+	}
+	if _, ok := lookup[strings.ToLower(value)]; ok {
+		return false
+	}
+	return len(enry.GetLanguagesByExtension(value, nil, nil)) > 0
+}
+
+// filePatterns converts a trailing pattern that looks like a file name (e.g.,
+// `foo.go`) or file extension (e.g., `.tsx`) into a `file:` filter anchored at
+// the end of the path. Users frequently append file names to their search,
+// which rarely match as literal content. File names elsewhere in the query,
+// like `parse foo.go error`, are more likely part of the content being
+// searched for and are left alone.
+func filePatterns(b query.Basic) *query.Basic {
+	rawPatternTree, err := query.Parse(query.StringHuman([]query.Node{b.Pattern}), query.SearchTypeStandard)
+	if err != nil {
+		return nil
+	}
+
+	count := 0
+	query.VisitPattern(rawPatternTree, func(string, bool, query.Annotation) {
+		count++
+	})
+
+	changed := false
+	var fileValue string // store the trailing pattern if it looks like a file name.
+	isNegated := false
+	i := 0
+	newPattern := query.MapPattern(rawPatternTree, func(value string, negated bool, annotation query.Annotation) query.Node {
+		i++
+		if i < count || annotation.Labels.IsSet(query.Regexp) || !looksLikeFileName(value) {
+			return query.Pattern{
+				Value:      value,
+				Negated:    negated,
+				Annotation: annotation,
+			}
+		}
+		changed = true
+		fileValue = regexp.QuoteMeta(value) + "$"
+		isNegated = negated
+		// remove this node
+		return nil
+	})
+
+	if !changed {
+		return nil
+	}
+
+	fileParam := query.Parameter{
+		Field:      query.FieldFile,
+		Value:      fileValue,
+		Negated:    isNegated,
+		Annotation: query.Annotation{},
+	}
+
+	var pattern query.Node
+	if len(newPattern) > 0 {
+		// Process concat nodes
+		nodes, err := query.Sequence(query.For(query.SearchTypeStandard))(newPattern)
+		if err != nil {
+			return nil
+		}
+		pattern = nodes[0] // guaranteed root at first node
+	}
+
+	return &query.Basic{
+		Parameters: append(b.Parameters, fileParam),
+		Pattern:    pattern,
+	}
+}
+
 func typePatterns(b query.Basic) *query.Basic {
 	rawPatternTree, err := query.Parse(query.StringHuman([]query.Node{b.Pattern}), query.SearchTypeStandard)
 	if err != nil {
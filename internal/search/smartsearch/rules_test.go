@@ -103,6 +103,28 @@ func Test_symbolPatterns(t *testing.T) {
 
 }
 
+func Test_filePatterns(t *testing.T) {
+	rule := []transform{filePatterns}
+	test := func(input string) string {
+		return apply(input, rule)
+	}
+
+	cases := []string{
+		`context:global .tsx`,
+		`context:global parse foo.go`,
+		`context:global my.yaml.conf`,
+		`context:global github.com`,
+		`context:global parse foo.go error`,
+	}
+
+	for _, c := range cases {
+		t.Run("file patterns", func(t *testing.T) {
+			autogold.ExpectFile(t, autogold.Raw(test(c)))
+		})
+	}
+
+}
+
 func Test_typePatterns(t *testing.T) {
 	rule := []transform{typePatterns}
 	test := func(input string) string {
@@ -4,14 +4,17 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/sourcegraph/log"
 	searchrepos "github.com/sourcegraph/sourcegraph/internal/search/repos"
 	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	alertobserver "github.com/sourcegraph/sourcegraph/internal/search/alert"
 	"github.com/sourcegraph/sourcegraph/internal/search/job"
 	"github.com/sourcegraph/sourcegraph/internal/search/limits"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/search/quota"
 	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
@@ -55,6 +58,7 @@ func NewSmartSearchJob(initialJob job.Job, newJob newJob, plan query.Plan) *Feel
 
 	return &FeelingLuckySearchJob{
 		initialJob:      initialJob,
+		plan:            plan,
 		generators:      generators,
 		newGeneratedJob: newGeneratedJob,
 	}
@@ -66,6 +70,7 @@ func NewSmartSearchJob(initialJob job.Job, newJob newJob, plan query.Plan) *Feel
 // flow) with static inputs (search inputs), while not exposing static inputs.
 type FeelingLuckySearchJob struct {
 	initialJob      job.Job
+	plan            query.Plan
 	generators      []next
 	newGeneratedJob func(*autoQuery) job.Job
 }
@@ -108,8 +113,14 @@ func (f *FeelingLuckySearchJob) Run(ctx context.Context, clients job.RuntimeClie
 		luckyAlertType = alertobserver.LuckyAlertAdded
 	}
 	generated := &alertobserver.ErrLuckyQueries{Type: luckyAlertType, ProposedQueries: []*search.QueryDescription{}}
+	generators := f.generators
+	if conf.ExperimentalFeatures().SmartSearchRecentRepositories {
+		// Queries restricted to the user's recent repositories are more likely to
+		// be what they're looking for, so they're tried first.
+		generators = append(f.recentRepositoriesGenerators(ctx, clients), generators...)
+	}
 	var autoQ *autoQuery
-	for _, next := range f.generators {
+	for _, next := range generators {
 		for next != nil {
 			autoQ, next = next()
 			j := f.newGeneratedJob(autoQ)
@@ -117,6 +128,19 @@ func (f *FeelingLuckySearchJob) Run(ctx context.Context, clients job.RuntimeClie
 				// Generated an invalid job with this query, just continue.
 				continue
 			}
+
+			// Generated queries count towards the user's daily quota. Once it is
+			// exceeded, we stop and only return what we have so far. Failing to
+			// update the quota doesn't fail the search.
+			var exceeded *quota.ExceededError
+			if _, qErr := quota.DefaultStore.Reserve(ctx, quota.KindSmartSearchQueries, 1); errors.As(qErr, &exceeded) {
+				maxAlerter.Add(exceeded.Alert())
+				if len(generated.ProposedQueries) > 0 {
+					errs = errors.Append(errs, generated)
+				}
+				return maxAlerter.Alert, errs
+			}
+
 			alert, err = j.Run(ctx, clients, stream)
 			if stream.Count()-originalResultSetSize >= RESULT_THRESHOLD {
 				// We've sent additional results up to the maximum bound. Let's stop here.
@@ -148,6 +172,27 @@ func (f *FeelingLuckySearchJob) Run(ctx context.Context, clients job.RuntimeClie
 	return maxAlerter.Alert, errs
 }
 
+// recentRepositoriesGenerators returns generators for queries restricted to the
+// repositories the current user recently viewed. Failing to look them up doesn't
+// fail the search.
+func (f *FeelingLuckySearchJob) recentRepositoriesGenerators(ctx context.Context, clients job.RuntimeClients) []next {
+	repos, err := recentRepositories(ctx, clients.DB)
+	if err != nil {
+		clients.Logger.Warn("failed to look up recently viewed repositories for smart search", log.Error(err))
+		return nil
+	}
+	if len(repos) == 0 {
+		return nil
+	}
+
+	rules := recentRepositoriesRules(repos)
+	generators := make([]next, 0, len(f.plan))
+	for _, b := range f.plan {
+		generators = append(generators, NewGenerator(b, nil, rules))
+	}
+	return generators
+}
+
 func (f *FeelingLuckySearchJob) Name() string {
 	return "FeelingLuckySearchJob"
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	"github.com/sourcegraph/sourcegraph/internal/search/result"
 	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
 func TestNewSmartSearchJob_Run(t *testing.T) {
@@ -129,3 +130,22 @@ func TestNewSmartSearchJob_ResultCount(t *testing.T) {
 		require.Equal(t, RESULT_THRESHOLD, len(sent))
 	})
 }
+
+func TestLiteralFallbackJob(t *testing.T) {
+	mockJob := mockjob.NewMockJob()
+	mockJob.RunFunc.SetDefaultHook(func(context.Context, job.RuntimeClients, streaming.Sender) (*search.Alert, error) {
+		return nil, nil
+	})
+
+	plan, err := query.Pipeline(query.InitLiteral("foo( patterntype:regexp"))
+	require.NoError(t, err)
+	regexpErr := errors.New("missing closing )")
+
+	j := NewLiteralFallbackJob(mockJob, plan, regexpErr)
+	_, err = j.Run(context.Background(), job.RuntimeClients{}, streaming.NewNullStream())
+
+	var fErr *alertobserver.ErrLiteralFallback
+	require.True(t, errors.As(err, &fErr))
+	require.Equal(t, `content:"foo("`, fErr.Query)
+	require.Equal(t, regexpErr, fErr.Err)
+}
@@ -39,6 +39,7 @@ type FrontendStreamDecoder struct {
 	OnFilters  func([]*EventFilter)
 	OnAlert    func(*EventAlert)
 	OnError    func(*EventError)
+	OnDone     func(*EventDone)
 	OnUnknown  func(event, data []byte)
 }
 
@@ -100,6 +101,13 @@ func (rr FrontendStreamDecoder) ReadAll(r io.Reader) error {
 			rr.OnError(&d)
 		} else if bytes.Equal(event, []byte("done")) {
 			// Always the last event
+			if rr.OnDone != nil {
+				var d EventDone
+				if err := json.Unmarshal(data, &d); err != nil {
+					return errors.Errorf("failed to decode done payload: %w", err)
+				}
+				rr.OnDone(&d)
+			}
 			break
 		} else {
 			if rr.OnUnknown == nil {
@@ -135,6 +143,10 @@ func (r *eventMatchUnmarshaller) UnmarshalJSON(b []byte) error {
 		r.EventMatch = &EventSymbolMatch{}
 	case CommitMatchType:
 		r.EventMatch = &EventCommitMatch{}
+	case CommitGroupMatchType:
+		r.EventMatch = &EventCommitGroupMatch{}
+	case CommitAuthorMatchType:
+		r.EventMatch = &EventCommitAuthorMatch{}
 	default:
 		return errors.Errorf("unknown MatchType %v", typeU.Type)
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"time"
 
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/lib/errors"
 )
 
@@ -167,10 +168,97 @@ type EventCommitMatch struct {
 	Content         string     `json:"content"`
 	// [line, character, length]
 	Ranges [][3]int32 `json:"ranges"`
+	// Languages maps each detected language to the number of files of that
+	// language modified by the commit. It is omitted when no modified files
+	// are known.
+	Languages map[string]int `json:"languages,omitempty"`
+	// PatchURL is the URL of the commit in the format of git format-patch. It
+	// is only set if the client asked for it.
+	PatchURL string `json:"patchURL,omitempty"`
+	// Diff is the structured form of the diff in Content, so that clients
+	// don't have to parse it. It is only set for diff matches if the client
+	// asked for it.
+	Diff []EventDiffFile `json:"diff,omitempty"`
 }
 
 func (e *EventCommitMatch) eventMatch() {}
 
+// EventDiffFile is a file modified by the diff of a commit match.
+type EventDiffFile struct {
+	// OrigName and NewName are /dev/null for added and deleted files
+	// respectively.
+	OrigName string          `json:"origName"`
+	NewName  string          `json:"newName"`
+	Hunks    []EventDiffHunk `json:"hunks"`
+}
+
+type EventDiffHunk struct {
+	OldStart int32           `json:"oldStart"`
+	OldCount int32           `json:"oldCount"`
+	NewStart int32           `json:"newStart"`
+	NewCount int32           `json:"newCount"`
+	Header   string          `json:"header,omitempty"`
+	Lines    []EventDiffLine `json:"lines"`
+	// Symbols are the symbols enclosing the changes of the hunk. They are
+	// omitted unless the symbols of the changed file were resolved.
+	Symbols []EventDiffSymbol `json:"symbols,omitempty"`
+}
+
+type EventDiffSymbol struct {
+	Name string `json:"name"`
+	// Kind is the kind of the symbol as used by select:symbol, e.g.
+	// "function" or "class".
+	Kind string `json:"kind"`
+}
+
+type EventDiffLine struct {
+	// Kind is "added", "removed" or "context".
+	Kind string `json:"kind"`
+	// Content is the line without its "+", "-" or " " prefix.
+	Content string `json:"content"`
+	// [character, length] of the matches in Content
+	Ranges [][2]int32 `json:"ranges,omitempty"`
+}
+
+// EventCommitGroupMatch summarizes the commit and diff matches in a repository
+// when a search groups them by repository. A later event for the same
+// repository replaces the earlier one.
+type EventCommitGroupMatch struct {
+	// Type is always CommitGroupMatchType. Included here for marshalling.
+	Type MatchType `json:"type"`
+
+	RepositoryID    int32      `json:"repositoryID"`
+	Repository      string     `json:"repository"`
+	RepoStars       int        `json:"repoStars,omitempty"`
+	RepoLastFetched *time.Time `json:"repoLastFetched,omitempty"`
+	CommitCount     int        `json:"commitCount"`
+	DiffCount       int        `json:"diffCount"`
+	// Query lists the grouped matches when searched.
+	Query string `json:"query"`
+}
+
+func (e *EventCommitGroupMatch) eventMatch() {}
+
+// EventCommitAuthorMatch is the author or the authoring day of commits in a
+// repository, as selected by select:commit.author or select:commit.date.
+type EventCommitAuthorMatch struct {
+	// Type is always CommitAuthorMatchType. Included here for marshalling.
+	Type MatchType `json:"type"`
+
+	RepositoryID    int32      `json:"repositoryID"`
+	Repository      string     `json:"repository"`
+	RepoStars       int        `json:"repoStars,omitempty"`
+	RepoLastFetched *time.Time `json:"repoLastFetched,omitempty"`
+	// AuthorName and AuthorEmail are only set for select:commit.author.
+	AuthorName  string `json:"authorName,omitempty"`
+	AuthorEmail string `json:"authorEmail,omitempty"`
+	// Date is the UTC day in the format YYYY-MM-DD. It is only set for
+	// select:commit.date.
+	Date string `json:"date,omitempty"`
+}
+
+func (e *EventCommitAuthorMatch) eventMatch() {}
+
 type EventPersonMatch struct {
 	// Type is always PersonMatchType. Included here for marshalling.
 	Type MatchType `json:"type"`
@@ -241,6 +329,13 @@ type EventError struct {
 	Message string `json:"message"`
 }
 
+// EventDone is the last event of a stream.
+type EventDone struct {
+	// Watermarks are the commit watermarks to search after next time. They
+	// are only set if the search was run after watermarks.
+	Watermarks map[api.RepoID][]string `json:"watermarks,omitempty"`
+}
+
 type MatchType int
 
 const (
@@ -251,6 +346,8 @@ const (
 	PathMatchType
 	PersonMatchType
 	TeamMatchType
+	CommitGroupMatchType
+	CommitAuthorMatchType
 )
 
 func (t MatchType) MarshalJSON() ([]byte, error) {
@@ -269,6 +366,10 @@ func (t MatchType) MarshalJSON() ([]byte, error) {
 		return []byte(`"person"`), nil
 	case TeamMatchType:
 		return []byte(`"team"`), nil
+	case CommitGroupMatchType:
+		return []byte(`"commitGroup"`), nil
+	case CommitAuthorMatchType:
+		return []byte(`"commitAuthor"`), nil
 	default:
 		return nil, errors.Errorf("unknown MatchType: %d", t)
 	}
@@ -289,6 +390,10 @@ func (t *MatchType) UnmarshalJSON(b []byte) error {
 		*t = PersonMatchType
 	} else if bytes.Equal(b, []byte(`"team"`)) {
 		*t = TeamMatchType
+	} else if bytes.Equal(b, []byte(`"commitGroup"`)) {
+		*t = CommitGroupMatchType
+	} else if bytes.Equal(b, []byte(`"commitAuthor"`)) {
+		*t = CommitAuthorMatchType
 	} else {
 		return errors.Errorf("unknown MatchType: %s", b)
 	}
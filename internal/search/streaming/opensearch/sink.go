@@ -0,0 +1,428 @@
+// Package opensearch exports streamed search results to an OpenSearch or
+// Elasticsearch index with the bulk API.
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	streamhttp "github.com/sourcegraph/sourcegraph/internal/search/streaming/http"
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+const (
+	defaultBatchSize = 500
+
+	// maxInflightRequests is the number of bulk requests sent concurrently.
+	// Once reached, Send drops full batches instead of waiting for a request
+	// to finish.
+	maxInflightRequests = 2
+)
+
+// Options configures a Sink.
+type Options struct {
+	// URL is the base URL of the cluster, e.g. https://opensearch.example.com:9200.
+	URL string
+
+	// Index is the index matches are written to, unless their mapping sets
+	// another one.
+	Index string
+
+	// Username and Password are used for basic authentication if set.
+	Username string
+	Password string
+
+	// Mappings configures how matches are indexed by their type in the
+	// streaming API, e.g. "content", "path", "symbol", "repo" or "commit".
+	// Matches of types without a mapping are indexed as their streaming API
+	// event in Index.
+	Mappings map[string]Mapping
+
+	// BatchSize is the number of matches indexed per bulk request. Defaults
+	// to 500.
+	BatchSize int
+}
+
+// OptionsFromConfig returns the options configured in the site configuration.
+func OptionsFromConfig(c *schema.SearchExportOpensearch) Options {
+	opts := Options{
+		URL:       c.Url,
+		Index:     c.Index,
+		Username:  c.Username,
+		Password:  c.Password,
+		Mappings:  map[string]Mapping{},
+		BatchSize: c.BatchSize,
+	}
+	for typ, index := range c.Indexes {
+		opts.Mappings[typ] = Mapping{Index: index}
+	}
+	for _, typ := range c.Exclude {
+		m := opts.Mappings[typ]
+		m.Exclude = true
+		opts.Mappings[typ] = m
+	}
+	return opts
+}
+
+// Mapping configures how the matches of a type are indexed.
+type Mapping struct {
+	// Index, if set, overrides Options.Index.
+	Index string
+
+	// Exclude skips the matches of the type.
+	Exclude bool
+
+	// Document, if set, returns the document indexed for a match instead of
+	// its streaming API event.
+	Document func(result.Match) any
+}
+
+// Sink is a streaming.Sender that bulk indexes the matches sent to it. Matches
+// are buffered and indexed in batches in the background, so a slow cluster
+// never slows down the search: batches filled while maxInflightRequests
+// batches are in flight are dropped. Matches are indexed with an ID derived
+// from their key and the actor of the search, so indexing the same match for
+// the same actor twice overwrites the first document. Once indexing fails,
+// the following matches are dropped and Flush returns the error.
+type Sink struct {
+	ctx   context.Context
+	doer  httpcli.Doer
+	opts  Options
+	actor documentActor
+
+	inflight chan struct{}
+	wg       sync.WaitGroup
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	pending int
+	dropped int
+	err     error
+}
+
+var _ streaming.Sender = (*Sink)(nil)
+
+// NewSink returns a Sink that indexes matches with requests sent by doer. The
+// documents record the actor of ctx as the actor who found the matches.
+func NewSink(ctx context.Context, doer httpcli.Doer, opts Options) (*Sink, error) {
+	if opts.URL == "" {
+		return nil, errors.New("OpenSearch URL is required")
+	}
+	if opts.Index == "" {
+		return nil, errors.New("OpenSearch index is required")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	a := actor.FromContext(ctx)
+	return &Sink{
+		ctx:  ctx,
+		doer: doer,
+		opts: opts,
+		actor: documentActor{
+			UserID:       a.UID,
+			AnonymousUID: a.AnonymousUID,
+			Internal:     a.IsInternal(),
+		},
+		inflight: make(chan struct{}, maxInflightRequests),
+	}, nil
+}
+
+func (s *Sink) Send(event streaming.SearchEvent) {
+	for _, match := range event.Results {
+		s.mu.Lock()
+		if s.err != nil {
+			s.mu.Unlock()
+			return
+		}
+		if s.err = s.append(match); s.err != nil {
+			s.mu.Unlock()
+			return
+		}
+		var batch []byte
+		var n int
+		if s.pending >= s.opts.BatchSize {
+			batch, n = s.takeBatch()
+		}
+		s.mu.Unlock()
+
+		if batch == nil {
+			continue
+		}
+
+		select {
+		case s.inflight <- struct{}{}:
+			s.flushAsync(batch)
+		default:
+			s.mu.Lock()
+			s.dropped += n
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Flush indexes the buffered matches and waits for all bulk requests to
+// finish. It returns the first error indexing failed with, or an error
+// reporting the number of matches dropped because too many bulk requests were
+// in flight.
+func (s *Sink) Flush() error {
+	s.mu.Lock()
+	var batch []byte
+	if s.err == nil {
+		batch, _ = s.takeBatch()
+	}
+	s.mu.Unlock()
+
+	if batch != nil {
+		// Flushing happens after the search finished, so it can wait for a
+		// request to finish.
+		s.inflight <- struct{}{}
+		s.flushAsync(batch)
+	}
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil && s.dropped > 0 {
+		return errors.Errorf("dropped %d matches while %d bulk requests were in flight", s.dropped, maxInflightRequests)
+	}
+	return s.err
+}
+
+// takeBatch returns the buffered actions and their number, and resets the
+// buffer. It returns nil if nothing is buffered. The caller must hold s.mu.
+func (s *Sink) takeBatch() ([]byte, int) {
+	if s.pending == 0 {
+		return nil, 0
+	}
+	batch := bytes.Clone(s.buf.Bytes())
+	n := s.pending
+	s.buf.Reset()
+	s.pending = 0
+	return batch, n
+}
+
+// flushAsync sends batch in a bulk request in the background. The caller must
+// have acquired a slot in s.inflight, which is released once the request
+// finished.
+func (s *Sink) flushAsync(batch []byte) {
+	s.wg.Add(1)
+	go func() {
+		defer func() {
+			<-s.inflight
+			s.wg.Done()
+		}()
+
+		if err := s.bulk(batch); err != nil {
+			s.mu.Lock()
+			if s.err == nil {
+				s.err = err
+			}
+			s.mu.Unlock()
+		}
+	}()
+}
+
+type bulkAction struct {
+	Index struct {
+		Index string `json:"_index"`
+		ID    string `json:"_id"`
+	} `json:"index"`
+}
+
+// document is the document indexed for a match. Search results are already
+// filtered by the repository permissions of the actor who searched, but the
+// index isn't, so documents record the actor and the repository to let
+// consumers of the index enforce permissions.
+type document struct {
+	// Match is the streaming API event of the match, or the document returned
+	// by Mapping.Document.
+	Match any `json:"match"`
+
+	// RepositoryID is the ID of the repository of the match. It is unset for
+	// matches outside of repositories, e.g. owners.
+	RepositoryID int32 `json:"repositoryID,omitempty"`
+
+	// Actor is the actor whose search found the match.
+	Actor documentActor `json:"actor"`
+}
+
+type documentActor struct {
+	// UserID is the ID of the user, unset for anonymous and internal actors.
+	UserID int32 `json:"userID,omitempty"`
+	// AnonymousUID identifies anonymous users across requests.
+	AnonymousUID string `json:"anonymousUID,omitempty"`
+	Internal     bool   `json:"internal,omitempty"`
+}
+
+// append adds the bulk action indexing match to the buffer. Matches that
+// can't be converted to a streaming API event are skipped.
+func (s *Sink) append(match result.Match) error {
+	switch match.(type) {
+	case *result.FileMatch, *result.RepoMatch, *result.CommitMatch, *result.CommitGroupMatch, *result.CommitAuthorMatch, *result.OwnerMatch:
+	default:
+		// Other matches, e.g. CommitDiffMatch, aren't sent by the streaming
+		// API and FromMatch panics on them.
+		return nil
+	}
+	event := search.FromMatch(match, nil, true) // chunk matches enabled
+	if event == nil {
+		return nil
+	}
+	mapping := s.opts.Mappings[eventType(event)]
+	if mapping.Exclude {
+		return nil
+	}
+
+	var action bulkAction
+	action.Index.Index = s.opts.Index
+	if mapping.Index != "" {
+		action.Index.Index = mapping.Index
+	}
+	action.Index.ID = documentID(match.Key(), s.actor)
+	doc := document{
+		Match:        event,
+		RepositoryID: int32(match.RepoName().ID),
+		Actor:        s.actor,
+	}
+	if mapping.Document != nil {
+		doc.Match = mapping.Document(match)
+	}
+
+	oldLen := s.buf.Len()
+	enc := json.NewEncoder(&s.buf)
+	for _, v := range []any{action, doc} {
+		if err := enc.Encode(v); err != nil {
+			// Reset the buffer to where it was before failing to marshal
+			s.buf.Truncate(oldLen)
+			return err
+		}
+	}
+	s.pending++
+	return nil
+}
+
+// documentID returns the ID of the document indexed for the match with the
+// given key found by the given actor. Matches found by different actors are
+// different documents, so that each keeps the actor who may see it.
+func documentID(key result.Key, a documentActor) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s", key.TypeRank, key.Repo, key.Rev, key.Commit, key.Path, key.OwnerMetadata, key.Author)
+	fmt.Fprintf(h, "\x00%d\x00%s\x00%t", a.UserID, a.AnonymousUID, a.Internal)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// bulk sends the actions in batch in a bulk request.
+func (s *Sink) bulk(batch []byte) error {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, strings.TrimSuffix(s.opts.URL, "/")+"/_bulk", bytes.NewReader(batch))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.opts.Username != "" {
+		req.SetBasicAuth(s.opts.Username, s.opts.Password)
+	}
+
+	resp, err := s.doer.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "bulk indexing matches")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "reading bulk response")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("bulk indexing matches: unexpected status %d: %s", resp.StatusCode, truncate(string(body), 200))
+	}
+
+	var bulk bulkResponse
+	if err := json.Unmarshal(body, &bulk); err != nil {
+		return errors.Wrap(err, "decoding bulk response")
+	}
+	if !bulk.Errors {
+		return nil
+	}
+
+	// Report the first failed item, the others most likely failed the same
+	// way.
+	failed := 0
+	var first string
+	for _, item := range bulk.Items {
+		for _, r := range item {
+			if r.Error == nil {
+				continue
+			}
+			if failed == 0 {
+				first = r.Error.Type + ": " + r.Error.Reason
+			}
+			failed++
+		}
+	}
+	return errors.Errorf("bulk indexing matches: %d of %d failed, first error: %s", failed, len(bulk.Items), first)
+}
+
+// eventType returns the type of the event as named in the streaming API.
+func eventType(event streamhttp.EventMatch) string {
+	var t streamhttp.MatchType
+	switch v := event.(type) {
+	case *streamhttp.EventContentMatch:
+		t = v.Type
+	case *streamhttp.EventPathMatch:
+		t = v.Type
+	case *streamhttp.EventRepoMatch:
+		t = v.Type
+	case *streamhttp.EventSymbolMatch:
+		t = v.Type
+	case *streamhttp.EventCommitMatch:
+		t = v.Type
+	case *streamhttp.EventCommitGroupMatch:
+		t = v.Type
+	case *streamhttp.EventCommitAuthorMatch:
+		t = v.Type
+	case *streamhttp.EventPersonMatch:
+		t = v.Type
+	case *streamhttp.EventTeamMatch:
+		t = v.Type
+	default:
+		return ""
+	}
+	b, err := t.MarshalJSON()
+	if err != nil {
+		return ""
+	}
+	return strings.Trim(string(b), `"`)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
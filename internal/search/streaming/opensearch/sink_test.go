@@ -0,0 +1,224 @@
+package opensearch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/gitdomain"
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming"
+	"github.com/sourcegraph/sourcegraph/internal/types"
+)
+
+func TestSink(t *testing.T) {
+	repo := types.MinimalRepo{ID: 1, Name: "github.com/sourcegraph/sourcegraph"}
+	repoMatch := &result.RepoMatch{ID: repo.ID, Name: repo.Name}
+	pathMatch := &result.FileMatch{File: result.File{Repo: repo, Path: "README.md"}}
+	signature := gitdomain.Signature{Name: "alice", Email: "alice@example.com", Date: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	commitMatch := &result.CommitMatch{
+		Repo: repo,
+		Commit: gitdomain.Commit{
+			ID:        api.CommitID("abc"),
+			Author:    signature,
+			Committer: &signature,
+			Message:   "fix",
+		},
+		MessagePreview: &result.MatchedString{Content: "fix"},
+	}
+
+	var (
+		mu       sync.Mutex
+		requests [][]map[string]any
+		response = `{"took": 1, "errors": false, "items": []}`
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		require.Equal(t, "/_bulk", r.URL.Path)
+		require.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		user, password, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "user", user)
+		require.Equal(t, "secret", password)
+
+		var lines []map[string]any
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var line map[string]any
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			lines = append(lines, line)
+		}
+		requests = append(requests, lines)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(ts.Close)
+	sentRequests := func() [][]map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([][]map[string]any(nil), requests...)
+	}
+
+	ctx := actor.WithActor(context.Background(), actor.FromUser(42))
+	sink, err := NewSink(ctx, http.DefaultClient, Options{
+		URL:      ts.URL + "/",
+		Index:    "search-results",
+		Username: "user",
+		Password: "secret",
+		Mappings: map[string]Mapping{
+			"repo": {Exclude: true},
+			"commit": {
+				Index: "search-commits",
+				Document: func(m result.Match) any {
+					return map[string]any{"oid": string(m.(*result.CommitMatch).Commit.ID)}
+				},
+			},
+		},
+		BatchSize: 2,
+	})
+	require.NoError(t, err)
+
+	alice := documentActor{UserID: 42}
+	pathID := documentID(pathMatch.Key(), alice)
+	commitID := documentID(commitMatch.Key(), alice)
+	require.NotEqual(t, pathID, commitID)
+	// The same match found by another actor is another document.
+	require.NotEqual(t, pathID, documentID(pathMatch.Key(), documentActor{AnonymousUID: "anon"}))
+
+	// The batch is full, so it is indexed in the background. Flushing waits
+	// for it without sending another request.
+	sink.Send(streaming.SearchEvent{Results: result.Matches{repoMatch, pathMatch, commitMatch}})
+	require.NoError(t, sink.Flush())
+	require.Len(t, sentRequests(), 1)
+
+	sink.Send(streaming.SearchEvent{Results: result.Matches{pathMatch}})
+	require.Len(t, sentRequests(), 1)
+	require.NoError(t, sink.Flush())
+	require.Len(t, sentRequests(), 2)
+	got := sentRequests()
+
+	// The repo match is excluded.
+	require.Len(t, got[0], 4)
+	require.Equal(t, map[string]any{"index": map[string]any{"_index": "search-results", "_id": pathID}}, got[0][0])
+	pathDoc := got[0][1]["match"].(map[string]any)
+	require.Equal(t, "path", pathDoc["type"])
+	require.Equal(t, "README.md", pathDoc["path"])
+	require.Equal(t, "github.com/sourcegraph/sourcegraph", pathDoc["repository"])
+	require.Equal(t, float64(1), got[0][1]["repositoryID"])
+	require.Equal(t, map[string]any{"userID": float64(42)}, got[0][1]["actor"])
+	require.Equal(t, map[string]any{"index": map[string]any{"_index": "search-commits", "_id": commitID}}, got[0][2])
+	require.Equal(t, map[string]any{
+		"match":        map[string]any{"oid": "abc"},
+		"repositoryID": float64(1),
+		"actor":        map[string]any{"userID": float64(42)},
+	}, got[0][3])
+
+	// Indexing the same match again overwrites its document.
+	require.Len(t, got[1], 2)
+	require.Equal(t, map[string]any{"index": map[string]any{"_index": "search-results", "_id": pathID}}, got[1][0])
+
+	// Nothing left to index.
+	require.NoError(t, sink.Flush())
+	require.Len(t, sentRequests(), 2)
+
+	t.Run("incomplete and unsupported matches", func(t *testing.T) {
+		// Commit matches without a committer or previews are indexed
+		// without them.
+		incomplete := &result.CommitMatch{Repo: repo, Commit: gitdomain.Commit{ID: api.CommitID("def"), Message: "wip"}}
+		// Diff matches aren't sent by the streaming API, so they are skipped.
+		diff := &result.CommitDiffMatch{Commit: gitdomain.Commit{ID: api.CommitID("def")}, Repo: repo}
+		sink.Send(streaming.SearchEvent{Results: result.Matches{incomplete, diff}})
+		require.NoError(t, sink.Flush())
+		require.Len(t, sentRequests(), 3)
+		last := sentRequests()[2]
+		require.Len(t, last, 2)
+		require.Equal(t, map[string]any{"oid": "def"}, last[1]["match"])
+	})
+
+	t.Run("item errors", func(t *testing.T) {
+		mu.Lock()
+		response = `{"errors": true, "items": [
+			{"index": {"status": 201}},
+			{"index": {"status": 400, "error": {"type": "mapper_parsing_exception", "reason": "failed to parse field [path]"}}}
+		]}`
+		mu.Unlock()
+		sink.Send(streaming.SearchEvent{Results: result.Matches{pathMatch, pathMatch}})
+		err := sink.Flush()
+		require.Error(t, err)
+		require.True(t, strings.Contains(err.Error(), "1 of 2 failed, first error: mapper_parsing_exception: failed to parse field [path]"), err.Error())
+
+		// Matches are dropped after a failure.
+		sent := len(sentRequests())
+		sink.Send(streaming.SearchEvent{Results: result.Matches{pathMatch, pathMatch}})
+		require.Len(t, sentRequests(), sent)
+		require.Equal(t, err, sink.Flush())
+	})
+}
+
+func TestSinkDropsBatchesWhileRequestsInflight(t *testing.T) {
+	pathMatch := &result.FileMatch{File: result.File{Repo: types.MinimalRepo{ID: 1, Name: "a"}, Path: "README.md"}}
+
+	release := make(chan struct{})
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		<-release
+		_, _ = w.Write([]byte(`{"errors": false, "items": []}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	sink, err := NewSink(context.Background(), http.DefaultClient, Options{
+		URL:       ts.URL,
+		Index:     "search-results",
+		BatchSize: 1,
+	})
+	require.NoError(t, err)
+
+	// Sending doesn't block while the cluster doesn't respond. Batches filled
+	// while maxInflightRequests requests are in flight are dropped.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < maxInflightRequests+3; i++ {
+			sink.Send(streaming.SearchEvent{Results: result.Matches{pathMatch}})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Send blocked on a slow cluster")
+	}
+
+	close(release)
+	err = sink.Flush()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "dropped 3 matches")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, maxInflightRequests, requests)
+}
+
+func TestNewSink(t *testing.T) {
+	_, err := NewSink(context.Background(), http.DefaultClient, Options{Index: "results"})
+	require.Error(t, err)
+
+	_, err = NewSink(context.Background(), http.DefaultClient, Options{URL: "http://localhost:9200"})
+	require.Error(t, err)
+}
@@ -229,6 +229,12 @@ func (s *SearchFilters) Update(event SearchEvent) {
 
 			// =========== TODO: Jason Repo Metadata filters ============
 			// file paths are in v.ModifiedFiles which is a []string
+		case *result.CommitGroupMatch:
+			addRepoFilter(v.Repo.Name, "", int32(v.ResultCount()))
+			s.Dirty = true
+		case *result.CommitAuthorMatch:
+			addRepoFilter(v.Repo.Name, "", int32(v.ResultCount()))
+			s.Dirty = true
 		}
 	}
 }
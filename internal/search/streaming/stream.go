@@ -1,6 +1,7 @@
 package streaming
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -201,3 +202,57 @@ func (s *batchingStream) flush() {
 		s.dirty = false
 	}
 }
+
+// NewOrderingStream returns a stream that collects the events sent to it, so
+// that they can be sent as a single event with the results sorted by
+// repository, revision, commit and path once the search is done. The sorted
+// event can be retrieved with Event().
+//
+// At most limit results are kept: the first limit results in that order, so
+// that the output is still reproducible when results are dropped. Memory is
+// bounded by twice the limit.
+func NewOrderingStream(limit int) *orderingStream {
+	return &orderingStream{limit: limit}
+}
+
+type orderingStream struct {
+	limit int
+
+	mu      sync.Mutex
+	event   SearchEvent
+	dropped bool
+}
+
+func (o *orderingStream) Send(event SearchEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.event.Results = append(o.event.Results, event.Results...)
+	o.event.Stats.Update(&event.Stats)
+	if len(o.event.Results) > 2*o.limit {
+		o.truncate()
+	}
+}
+
+// truncate sorts the results and drops all but the first limit results.
+func (o *orderingStream) truncate() {
+	sort.Stable(o.event.Results)
+	if len(o.event.Results) > o.limit {
+		// Clear the dropped matches so they can be garbage collected.
+		clear(o.event.Results[o.limit:])
+		o.event.Results = o.event.Results[:o.limit]
+		o.dropped = true
+	}
+}
+
+// Event returns the sorted event. If results were dropped because of the
+// limit, its stats report that the limit was hit.
+func (o *orderingStream) Event() SearchEvent {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.truncate()
+	event := o.event
+	event.Stats.IsLimitHit = event.Stats.IsLimitHit || o.dropped
+	return event
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
 
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/search/result"
 )
 
@@ -148,3 +149,33 @@ func TestDedupingStream(t *testing.T) {
 
 	require.Equal(t, 1, len(sent))
 }
+
+func TestOrderingStream(t *testing.T) {
+	paths := []string{"e", "b", "g", "a", "f", "c", "d"}
+
+	s := NewOrderingStream(3)
+	for _, path := range paths {
+		s.Send(SearchEvent{
+			Results: []result.Match{&result.FileMatch{File: result.File{Path: path}}},
+			Stats:   Stats{Repos: map[api.RepoID]struct{}{1: {}}},
+		})
+	}
+
+	event := s.Event()
+	var got []string
+	for _, match := range event.Results {
+		got = append(got, match.(*result.FileMatch).Path)
+	}
+	require.Equal(t, []string{"a", "b", "c"}, got)
+	require.True(t, event.Stats.IsLimitHit)
+	require.Len(t, event.Stats.Repos, 1)
+
+	// Below the limit every result is kept.
+	s = NewOrderingStream(10)
+	for _, path := range paths {
+		s.Send(SearchEvent{Results: []result.Match{&result.FileMatch{File: result.File{Path: path}}}})
+	}
+	event = s.Event()
+	require.Len(t, event.Results, len(paths))
+	require.False(t, event.Stats.IsLimitHit)
+}
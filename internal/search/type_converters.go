@@ -18,6 +18,10 @@ func FromMatch(match result.Match, repoCache map[api.RepoID]*types.SearchedRepo,
 		return fromRepository(v, repoCache)
 	case *result.CommitMatch:
 		return fromCommit(v, repoCache)
+	case *result.CommitGroupMatch:
+		return fromCommitGroup(v, repoCache)
+	case *result.CommitAuthorMatch:
+		return fromCommitAuthor(v, repoCache)
 	case *result.OwnerMatch:
 		return fromOwner(v)
 	default:
@@ -222,20 +226,25 @@ func fromCommit(commit *result.CommitMatch, repoCache map[api.RepoID]*types.Sear
 	}
 
 	commitEvent := &http.EventCommitMatch{
-		Type:          http.CommitMatchType,
-		Label:         commit.Label(),
-		URL:           commit.URL().String(),
-		Detail:        commit.Detail(),
-		Repository:    string(commit.Repo.Name),
-		RepositoryID:  int32(commit.Repo.ID),
-		OID:           string(commit.Commit.ID),
-		Message:       string(commit.Commit.Message),
-		AuthorName:    commit.Commit.Author.Name,
-		AuthorDate:    commit.Commit.Author.Date,
-		CommitterName: commit.Commit.Committer.Name,
-		CommitterDate: commit.Commit.Committer.Date,
-		Content:       hls.Value,
-		Ranges:        ranges,
+		Type:         http.CommitMatchType,
+		Label:        commit.Label(),
+		URL:          commit.URL().String(),
+		Detail:       commit.Detail(),
+		Repository:   string(commit.Repo.Name),
+		RepositoryID: int32(commit.Repo.ID),
+		OID:          string(commit.Commit.ID),
+		Message:      string(commit.Commit.Message),
+		AuthorName:   commit.Commit.Author.Name,
+		AuthorDate:   commit.Commit.Author.Date,
+		Content:      hls.Value,
+		Ranges:       ranges,
+		Languages:    commit.ModifiedLanguages(),
+	}
+
+	// The committer is unset if the commit was only partially loaded.
+	if committer := commit.Commit.Committer; committer != nil {
+		commitEvent.CommitterName = committer.Name
+		commitEvent.CommitterDate = committer.Date
 	}
 
 	if r, ok := repoCache[commit.Repo.ID]; ok {
@@ -246,6 +255,135 @@ func fromCommit(commit *result.CommitMatch, repoCache map[api.RepoID]*types.Sear
 	return commitEvent
 }
 
+// FromDiffMatch returns the structured form of the diff of match, which is a
+// diff match, or nil if it has no diff. The files of a CommitDiffMatch are
+// limited to the file it matches.
+func FromDiffMatch(match result.Match) []http.EventDiffFile {
+	var files []result.DiffFile
+	switch v := match.(type) {
+	case *result.CommitMatch:
+		if v.DiffPreview == nil {
+			return nil
+		}
+		files = v.Diff
+	case *result.CommitDiffMatch:
+		if v.DiffFile == nil {
+			return nil
+		}
+		files = []result.DiffFile{*v.DiffFile}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	res := make([]http.EventDiffFile, 0, len(files))
+	for _, file := range files {
+		eventFile := http.EventDiffFile{
+			OrigName: file.OrigName,
+			NewName:  file.NewName,
+			Hunks:    make([]http.EventDiffHunk, 0, len(file.Hunks)),
+		}
+		for _, hunk := range file.Hunks {
+			eventHunk := http.EventDiffHunk{
+				OldStart: int32(hunk.OldStart),
+				OldCount: int32(hunk.OldCount),
+				NewStart: int32(hunk.NewStart),
+				NewCount: int32(hunk.NewCount),
+				Header:   hunk.Header,
+				Lines:    make([]http.EventDiffLine, 0, len(hunk.Lines)),
+			}
+			for i, line := range hunk.Lines {
+				var ranges result.Ranges
+				if hunk.LineRanges != nil {
+					ranges = hunk.LineRanges[i]
+				}
+				eventHunk.Lines = append(eventHunk.Lines, fromDiffLine(line, ranges))
+			}
+			if hunk.EnclosingSymbol != nil {
+				eventHunk.Symbols = []http.EventDiffSymbol{{
+					Name: hunk.EnclosingSymbol.Name,
+					Kind: hunk.EnclosingSymbol.Kind,
+				}}
+			}
+			eventFile.Hunks = append(eventFile.Hunks, eventHunk)
+		}
+		res = append(res, eventFile)
+	}
+	return res
+}
+
+// fromDiffLine converts a line of a hunk with the ranges matched on it. The
+// ranges are shifted to be relative to the line without its prefix.
+func fromDiffLine(line string, ranges result.Ranges) http.EventDiffLine {
+	if line == "" {
+		return http.EventDiffLine{Kind: "context"}
+	}
+	kind := "context"
+	switch line[0] {
+	case '+':
+		kind = "added"
+	case '-':
+		kind = "removed"
+	}
+
+	var eventRanges [][2]int32
+	for _, r := range ranges {
+		character, length := r.Start.Column-1, r.End.Column-r.Start.Column
+		if character < 0 {
+			// Don't highlight the prefix
+			length += character
+			character = 0
+		}
+		if length > 0 {
+			eventRanges = append(eventRanges, [2]int32{int32(character), int32(length)})
+		}
+	}
+
+	return http.EventDiffLine{
+		Kind:    kind,
+		Content: line[1:],
+		Ranges:  eventRanges,
+	}
+}
+
+func fromCommitGroup(group *result.CommitGroupMatch, repoCache map[api.RepoID]*types.SearchedRepo) *http.EventCommitGroupMatch {
+	groupEvent := &http.EventCommitGroupMatch{
+		Type:         http.CommitGroupMatchType,
+		RepositoryID: int32(group.Repo.ID),
+		Repository:   string(group.Repo.Name),
+		CommitCount:  group.CommitCount,
+		DiffCount:    group.DiffCount,
+		Query:        group.Query,
+	}
+
+	if r, ok := repoCache[group.Repo.ID]; ok {
+		groupEvent.RepoStars = r.Stars
+		groupEvent.RepoLastFetched = r.LastFetched
+	}
+
+	return groupEvent
+}
+
+func fromCommitAuthor(author *result.CommitAuthorMatch, repoCache map[api.RepoID]*types.SearchedRepo) *http.EventCommitAuthorMatch {
+	authorEvent := &http.EventCommitAuthorMatch{
+		Type:         http.CommitAuthorMatchType,
+		RepositoryID: int32(author.Repo.ID),
+		Repository:   string(author.Repo.Name),
+		AuthorName:   author.Name,
+		AuthorEmail:  author.Email,
+	}
+	if !author.Date.IsZero() {
+		authorEvent.Date = author.Date.Format("2006-01-02")
+	}
+
+	if r, ok := repoCache[author.Repo.ID]; ok {
+		authorEvent.RepoStars = r.Stars
+		authorEvent.RepoLastFetched = r.LastFetched
+	}
+
+	return authorEvent
+}
+
 func fromOwner(owner *result.OwnerMatch) http.EventMatch {
 	switch v := owner.ResolvedOwner.(type) {
 	case *result.OwnerPerson:
@@ -0,0 +1,95 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/internal/search/result"
+	"github.com/sourcegraph/sourcegraph/internal/search/streaming/http"
+)
+
+func TestFromDiffMatch(t *testing.T) {
+	content := "a.go a.go\n" +
+		"@@ -1,2 +1,2 @@ func main()\n" +
+		" package main\n" +
+		"-var x = foo\n" +
+		"+var x = bar\n" +
+		"/dev/null b.go\n" +
+		"@@ -0,0 +1,1 @@\n" +
+		"+bar\n"
+	// Matches "bar" on lines 4 and 7.
+	preview := &result.MatchedString{
+		Content: content,
+		MatchedRanges: result.Ranges{{
+			Start: result.Location{Offset: 74, Line: 4, Column: 9},
+			End:   result.Location{Offset: 77, Line: 4, Column: 12},
+		}, {
+			Start: result.Location{Offset: 110, Line: 7, Column: 1},
+			End:   result.Location{Offset: 113, Line: 7, Column: 4},
+		}},
+	}
+	files, err := result.ParseDiffPreview(*preview)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileA := http.EventDiffFile{
+		OrigName: "a.go",
+		NewName:  "a.go",
+		Hunks: []http.EventDiffHunk{{
+			OldStart: 1, OldCount: 2, NewStart: 1, NewCount: 2,
+			Header: "func main()",
+			Lines: []http.EventDiffLine{
+				{Kind: "context", Content: "package main"},
+				{Kind: "removed", Content: "var x = foo"},
+				{Kind: "added", Content: "var x = bar", Ranges: [][2]int32{{8, 3}}},
+			},
+		}},
+	}
+	fileB := http.EventDiffFile{
+		OrigName: "/dev/null",
+		NewName:  "b.go",
+		Hunks: []http.EventDiffHunk{{
+			OldStart: 0, OldCount: 0, NewStart: 1, NewCount: 1,
+			Lines: []http.EventDiffLine{
+				{Kind: "added", Content: "bar", Ranges: [][2]int32{{0, 3}}},
+			},
+		}},
+	}
+
+	t.Run("commit match", func(t *testing.T) {
+		got := FromDiffMatch(&result.CommitMatch{DiffPreview: preview, Diff: files})
+		if diff := cmp.Diff([]http.EventDiffFile{fileA, fileB}, got); diff != "" {
+			t.Fatalf("unexpected diff (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("commit diff match", func(t *testing.T) {
+		got := FromDiffMatch(&result.CommitDiffMatch{Preview: preview, DiffFile: &files[1]})
+		if diff := cmp.Diff([]http.EventDiffFile{fileB}, got); diff != "" {
+			t.Fatalf("unexpected diff (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("enclosing symbols", func(t *testing.T) {
+		diffMatch := &result.CommitDiffMatch{Preview: preview, DiffFile: &files[0]}
+		diffMatch.SetEnclosingSymbols(result.Symbols{{Name: "main", Kind: "func", Line: 1}})
+
+		withSymbols := fileA
+		withSymbols.Hunks = []http.EventDiffHunk{fileA.Hunks[0]}
+		withSymbols.Hunks[0].Symbols = []http.EventDiffSymbol{{Name: "main", Kind: "function"}}
+
+		got := FromDiffMatch(diffMatch)
+		if diff := cmp.Diff([]http.EventDiffFile{withSymbols}, got); diff != "" {
+			t.Fatalf("unexpected diff (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("commit match without diff", func(t *testing.T) {
+		got := FromDiffMatch(&result.CommitMatch{MessagePreview: &result.MatchedString{Content: "bar"}})
+		if got != nil {
+			t.Fatalf("expected no files, got %v", got)
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/grafana/regexp"
@@ -40,6 +41,72 @@ type Inputs struct {
 	Protocol               Protocol
 	ContextLines           int32
 	SanitizeSearchPatterns []*regexp.Regexp
+
+	// RegexpParseError is the error parsing the patterns of a Smart Search
+	// query as regular expressions, if they are searched literally instead.
+	// Plan is then the literal interpretation of the query.
+	RegexpParseError error
+
+	// CommitWatermarks, if set, restricts commit and diff searches to commits
+	// that are new since a previous search.
+	CommitWatermarks *CommitWatermarks
+
+	// Ordering, if set, makes the search send its results as a single sorted
+	// event once it completes.
+	Ordering *ResultOrdering
+}
+
+// ResultOrdering sorts the results of a search by repository, revision, commit
+// and path, so that searches over the same corpus produce the same results in
+// the same order. Results are held in memory until the search completes, so
+// only the first Limit results in that order are kept. The limit being hit is
+// reported in the stats of the event.
+type ResultOrdering struct {
+	Limit int
+}
+
+// CommitWatermarks restricts commit and diff searches to commits that are new
+// since a previous search, and collects the watermarks for the next search. A
+// repo's watermark is the set of commit hashes its revisions resolved to.
+type CommitWatermarks struct {
+	// After are the watermarks of the previous search. Only commits not
+	// reachable from a repo's watermark are searched.
+	After map[api.RepoID][]string
+
+	mu   sync.Mutex
+	next map[api.RepoID][]string
+}
+
+// NewCommitWatermarks returns CommitWatermarks that search after the given
+// watermarks. after may be empty on the first search.
+func NewCommitWatermarks(after map[api.RepoID][]string) *CommitWatermarks {
+	if after == nil {
+		after = map[api.RepoID][]string{}
+	}
+	next := make(map[api.RepoID][]string, len(after))
+	for repo, watermark := range after {
+		next[repo] = watermark
+	}
+	return &CommitWatermarks{After: after, next: next}
+}
+
+// Advance records the new watermark of repo. It is safe to call concurrently.
+func (w *CommitWatermarks) Advance(repo api.RepoID, watermark []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.next[repo] = watermark
+}
+
+// Next returns the watermarks to search after next time. Repos that weren't
+// searched keep their previous watermark.
+func (w *CommitWatermarks) Next() map[api.RepoID][]string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	next := make(map[api.RepoID][]string, len(w.next))
+	for repo, watermark := range w.next {
+		next[repo] = watermark
+	}
+	return next
 }
 
 // MaxResults computes the limit for the query.
@@ -388,6 +455,11 @@ type Features struct {
 	// options. This should be used for quick interactive experiments only. An
 	// invalid JSON string or unknown fields will be ignored.
 	ZoektSearchOptionsOverride string
+
+	// GroupCommitsByRepo when true will stream one summary per repository
+	// instead of individual commit and diff matches. It is set per request by
+	// clients of the streaming API rather than by a feature flag.
+	GroupCommitsByRepo bool
 }
 
 func (f *Features) String() string {
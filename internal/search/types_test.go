@@ -7,6 +7,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/sourcegraph/zoekt"
 
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/search/filter"
 	"github.com/sourcegraph/sourcegraph/internal/search/limits"
@@ -196,3 +197,26 @@ func TestZoektParameters(t *testing.T) {
 		})
 	}
 }
+
+func TestCommitWatermarks(t *testing.T) {
+	w := NewCommitWatermarks(map[api.RepoID][]string{
+		1: {"a"},
+		2: {"b"},
+	})
+	w.Advance(2, []string{"c", "d"})
+	w.Advance(3, []string{"e"})
+
+	want := map[api.RepoID][]string{
+		1: {"a"}, // not searched, keeps its watermark
+		2: {"c", "d"},
+		3: {"e"},
+	}
+	if diff := cmp.Diff(want, w.Next()); diff != "" {
+		t.Errorf("unexpected watermarks (-want +got):\n%s", diff)
+	}
+
+	// The watermarks being searched after are unchanged.
+	if diff := cmp.Diff([]string{"b"}, w.After[2]); diff != "" {
+		t.Errorf("unexpected previous watermark (-want +got):\n%s", diff)
+	}
+}
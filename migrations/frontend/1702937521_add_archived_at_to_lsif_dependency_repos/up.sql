@@ -0,0 +1 @@
+ALTER TABLE lsif_dependency_repos ADD COLUMN IF NOT EXISTS archived_at timestamp with time zone;
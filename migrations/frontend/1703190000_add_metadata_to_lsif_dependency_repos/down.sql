@@ -0,0 +1,3 @@
+ALTER TABLE lsif_dependency_repos DROP COLUMN IF EXISTS published_at;
+ALTER TABLE lsif_dependency_repos DROP COLUMN IF EXISTS description;
+ALTER TABLE lsif_dependency_repos DROP COLUMN IF EXISTS license;
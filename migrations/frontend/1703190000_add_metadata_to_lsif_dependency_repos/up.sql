@@ -0,0 +1,3 @@
+ALTER TABLE lsif_dependency_repos ADD COLUMN IF NOT EXISTS published_at timestamp with time zone;
+ALTER TABLE lsif_dependency_repos ADD COLUMN IF NOT EXISTS description text NOT NULL DEFAULT '';
+ALTER TABLE lsif_dependency_repos ADD COLUMN IF NOT EXISTS license text NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE package_repo_versions DROP COLUMN IF EXISTS license;
@@ -0,0 +1 @@
+ALTER TABLE package_repo_versions ADD COLUMN IF NOT EXISTS license text NOT NULL DEFAULT '';
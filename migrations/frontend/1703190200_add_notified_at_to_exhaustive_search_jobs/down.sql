@@ -0,0 +1 @@
+ALTER TABLE exhaustive_search_jobs DROP COLUMN IF EXISTS notified_at;
@@ -0,0 +1,5 @@
+ALTER TABLE exhaustive_search_jobs ADD COLUMN IF NOT EXISTS notified_at timestamp with time zone;
+
+-- Jobs that already exist predate notifications, don't email their initiators
+-- about them retroactively.
+UPDATE exhaustive_search_jobs SET notified_at = now() WHERE notified_at IS NULL;
@@ -0,0 +1,3 @@
+-- The spelling a version was first referenced by, e.g. v1.2.3 for 1.2.3, if it
+-- differs from the normalized version.
+ALTER TABLE package_repo_versions ADD COLUMN IF NOT EXISTS original_version text NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE lsif_dependency_repos DROP COLUMN IF EXISTS private;
@@ -0,0 +1,3 @@
+-- Private package repos mirror internal registries. Their existence is only
+-- disclosed to site admins.
+ALTER TABLE lsif_dependency_repos ADD COLUMN IF NOT EXISTS private boolean NOT NULL DEFAULT false;
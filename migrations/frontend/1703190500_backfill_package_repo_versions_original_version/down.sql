@@ -0,0 +1 @@
+-- Nothing to do: the original spellings are kept in original_version.
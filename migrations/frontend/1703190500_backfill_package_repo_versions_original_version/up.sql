@@ -0,0 +1,56 @@
+-- Versions inserted before 1703190300 were stored verbatim, so the same semantic
+-- version can exist under multiple spellings (v1.2.3 and 1.2.3). Normalize them
+-- the same way versions.Normalize does for semver ecosystems, keep the spelling
+-- they were referenced by in original_version, and drop the duplicates.
+--
+-- PEP 440 versions are left alone. Their normalization (epochs, the many
+-- spellings of pre-, post- and dev-releases, implicit separators, local version
+-- labels, leading zeros) can't be reproduced faithfully with regular
+-- expressions, and a partial reimplementation would disagree with
+-- versions.Normalize and create new duplicates instead of removing them. Legacy
+-- PEP 440 rows keep their verbatim spelling. The same version referenced again
+-- is inserted in normalized form next to them, and lookups resolve to that row.
+-- Maven and RubyGems versions are only trimmed by versions.Normalize, and the
+-- registries require them verbatim, so they are left alone as well.
+CREATE TEMPORARY TABLE normalized_package_repo_versions ON COMMIT DROP AS
+SELECT
+    prv.id,
+    prv.package_id,
+    prv.version,
+    CASE
+        WHEN lr.scheme IN ('go', 'gomod') THEN 'v' || regexp_replace(btrim(prv.version), '^[vV=]', '')
+        WHEN lr.scheme = 'npm' THEN regexp_replace(regexp_replace(btrim(prv.version), '^[vV=]', ''), '\+[0-9A-Za-z.-]+$', '')
+        ELSE regexp_replace(btrim(prv.version), '^[vV=]', '')
+    END AS normalized
+FROM package_repo_versions prv
+JOIN lsif_dependency_repos lr ON lr.id = prv.package_id
+WHERE
+    lr.scheme NOT IN ('python', 'pip', 'pypi', 'semanticdb', 'maven', 'jvm-dependencies', 'scip-ruby', 'rubygems', 'gem') AND
+    btrim(prv.version) ~ '^[vV=]?[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$';
+
+-- Keep a single row per normalized version, preferring the one that is already
+-- normalized, then the oldest.
+DELETE FROM package_repo_versions prv
+USING normalized_package_repo_versions n
+WHERE
+    prv.id = n.id AND
+    EXISTS (
+        SELECT 1
+        FROM package_repo_versions other
+        LEFT JOIN normalized_package_repo_versions o ON o.id = other.id
+        WHERE
+            other.package_id = n.package_id AND
+            other.id != n.id AND
+            COALESCE(o.normalized, other.version) = n.normalized AND
+            (
+                other.version = n.normalized OR
+                (n.version != n.normalized AND other.id < n.id)
+            )
+    );
+
+UPDATE package_repo_versions prv
+SET
+    version = n.normalized,
+    original_version = CASE WHEN prv.original_version = '' THEN prv.version ELSE prv.original_version END
+FROM normalized_package_repo_versions n
+WHERE prv.id = n.id AND prv.version != n.normalized;
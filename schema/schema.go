@@ -915,6 +915,8 @@ type ExperimentalFeatures struct {
 	CustomGitFetch []*CustomGitFetchMapping `json:"customGitFetch,omitempty"`
 	// DebugLog description: Turns on debug logging for specific debugging scenarios.
 	DebugLog *DebugLog `json:"debug.log,omitempty"`
+	// DependenciesSlowQueryLogThresholdMs description: Logs the queries of the package dependencies store that take longer than this many milliseconds, together with their query plan. Bound parameters are redacted to their type, size and a short hash. Intended for debugging, since explaining a query runs it again. Setting it to 0 disables the logging.
+	DependenciesSlowQueryLogThresholdMs int `json:"dependencies.slowQueryLogThresholdMs,omitempty"`
 	// EnableGithubInternalRepoVisibility description: Enable support for visibility of internal Github repositories
 	EnableGithubInternalRepoVisibility bool `json:"enableGithubInternalRepoVisibility,omitempty"`
 	// EnablePermissionsWebhooks description: DEPRECATED: No longer has any effect.
@@ -957,6 +959,8 @@ type ExperimentalFeatures struct {
 	RubyPackages string `json:"rubyPackages,omitempty"`
 	// RustPackages description: Allow adding Rust package code host connections
 	RustPackages string `json:"rustPackages,omitempty"`
+	// SearchExportOpensearch description: Exports the results of streamed searches to an OpenSearch or Elasticsearch index with the bulk API, e.g. to build analytics on search results. Results are indexed in the background and indexing failures are logged, but don't fail the search. The index contains the results of every user's searches, including the contents of private repositories, and the cluster does not enforce repository permissions. Each document records the ID of its repository (`repositoryID`) and the user whose search found it (`actor`), so consumers can restrict results to what a user may see. Otherwise, only site admins should be given access to the index.
+	SearchExportOpensearch *SearchExportOpensearch `json:"search.export.opensearch,omitempty"`
 	// SearchIndexBranches description: A map from repository name to a list of extra revs (branch, ref, tag, commit sha, etc) to index for a repository. We always index the default branch ("HEAD") and revisions in version contexts. This allows specifying additional revisions. Sourcegraph can index up to 64 branches per repository.
 	SearchIndexBranches map[string][]string `json:"search.index.branches,omitempty"`
 	// SearchIndexQueryContexts description: Enables indexing of revisions of repos matching any query defined in search contexts.
@@ -967,6 +971,8 @@ type ExperimentalFeatures struct {
 	SearchSanitization *SearchSanitization `json:"search.sanitization,omitempty"`
 	// SearchJobs description: Enables search jobs (long-running exhaustive) search feature and its UI
 	SearchJobs *bool `json:"searchJobs,omitempty"`
+	// SmartSearchRecentRepositories description: Enables smart search to also try interpretations of a query restricted to the repositories the user recently viewed files in. These are proposed before interpretations over all repositories.
+	SmartSearchRecentRepositories bool `json:"smartSearchRecentRepositories,omitempty"`
 	// StructuralSearch description: Enables structural search.
 	StructuralSearch   string              `json:"structuralSearch,omitempty"`
 	SubRepoPermissions *SubRepoPermissions `json:"subRepoPermissions,omitempty"`
@@ -1008,6 +1014,7 @@ func (v *ExperimentalFeatures) UnmarshalJSON(data []byte) error {
 	delete(m, "batchChanges.enablePerforce")
 	delete(m, "customGitFetch")
 	delete(m, "debug.log")
+	delete(m, "dependencies.slowQueryLogThresholdMs")
 	delete(m, "enableGithubInternalRepoVisibility")
 	delete(m, "enablePermissionsWebhooks")
 	delete(m, "enableStorm")
@@ -1029,11 +1036,13 @@ func (v *ExperimentalFeatures) UnmarshalJSON(data []byte) error {
 	delete(m, "rateLimitAnonymous")
 	delete(m, "rubyPackages")
 	delete(m, "rustPackages")
+	delete(m, "search.export.opensearch")
 	delete(m, "search.index.branches")
 	delete(m, "search.index.query.contexts")
 	delete(m, "search.index.revisions")
 	delete(m, "search.sanitization")
 	delete(m, "searchJobs")
+	delete(m, "smartSearchRecentRepositories")
 	delete(m, "structuralSearch")
 	delete(m, "subRepoPermissions")
 	delete(m, "tls.external")
@@ -2245,6 +2254,24 @@ type SMTPServerConfig struct {
 	// Username description: The username to use when communicating with the SMTP server.
 	Username string `json:"username,omitempty"`
 }
+
+// SearchExportOpensearch description: Exports the results of streamed searches to an OpenSearch or Elasticsearch index with the bulk API, e.g. to build analytics on search results. Results are indexed in the background and indexing failures are logged, but don't fail the search. The index contains the results of every user's searches, including the contents of private repositories, and the cluster does not enforce repository permissions. Each document records the ID of its repository (`repositoryID`) and the user whose search found it (`actor`), so consumers can restrict results to what a user may see. Otherwise, only site admins should be given access to the index.
+type SearchExportOpensearch struct {
+	// BatchSize description: The number of results indexed per bulk request.
+	BatchSize int `json:"batchSize,omitempty"`
+	// Exclude description: Result types in the streaming API that are not exported.
+	Exclude []string `json:"exclude,omitempty"`
+	// Index description: The index results are written to, unless `indexes` sets another one for their type.
+	Index string `json:"index"`
+	// Indexes description: Maps result types in the streaming API (e.g. "content", "path", "symbol", "repo" or "commit") to the index their results are written to.
+	Indexes map[string]string `json:"indexes,omitempty"`
+	// Password description: The password used for basic authentication.
+	Password string `json:"password,omitempty"`
+	// Url description: The base URL of the cluster.
+	Url string `json:"url"`
+	// Username description: The username used for basic authentication.
+	Username string `json:"username,omitempty"`
+}
 type SearchIndexRevisionsRule struct {
 	// Name description: Regular expression which matches against the name of a repository (e.g. "^github\.com/owner/name$").
 	Name string `json:"name,omitempty"`
@@ -2262,6 +2289,10 @@ type SearchLimits struct {
 	MaxRepos int `json:"maxRepos,omitempty"`
 	// MaxTimeoutSeconds description: The maximum value for "timeout:" that search will respect. "timeout:" values larger than maxTimeoutSeconds are capped at maxTimeoutSeconds. Note: You need to ensure your load balancer / reverse proxy in front of Sourcegraph won't timeout the request for larger values. Note: Too many large rearch requests may harm Soucregraph for other users. Note: Experimental search jobs do not respect this limit. Defaults to 1 minute.
 	MaxTimeoutSeconds int `json:"maxTimeoutSeconds,omitempty"`
+	// PerUserDailyComputeResults description: The maximum number of compute results a user can retrieve per day. Compute queries stop with an alert once the quota is exceeded. Any value less than or equal to zero means unlimited. Anonymous users are only limited, by IP address, if Cloudflare headers are trusted (SRC_USE_CLOUDFLARE_HEADERS).
+	PerUserDailyComputeResults int `json:"perUserDailyComputeResults,omitempty"`
+	// PerUserDailySmartSearchQueries description: The maximum number of queries smart search generates and runs for a user per day. Once the quota is exceeded, smart search only runs the original query. Any value less than or equal to zero means unlimited. Anonymous users are only limited, by IP address, if Cloudflare headers are trusted (SRC_USE_CLOUDFLARE_HEADERS).
+	PerUserDailySmartSearchQueries int `json:"perUserDailySmartSearchQueries,omitempty"`
 }
 
 // SearchSanitization description: Allows site admins to specify a list of regular expressions representing matched content that should be omitted from search results. Also allows admins to specify the name of an organization within their Sourcegraph instance whose members are trusted and will not have their search results sanitized. Enable this feature by adding at least one valid regular expression to the value of the `sanitizePatterns` field on this object. Site admins will not have their searches sanitized.
@@ -3133,6 +3164,8 @@ type Step struct {
 type SubRepoPermissions struct {
 	// Enabled description: Enables sub-repo permission checking
 	Enabled bool `json:"enabled,omitempty"`
+	// FailClosed description: Aborts searches with an alert if sub-repo permissions can't be checked, for example because the authorization provider is unreachable, instead of returning the results that could be checked
+	FailClosed bool `json:"failClosed,omitempty"`
 	// UserCacheSize description: The number of user permissions to cache
 	UserCacheSize int `json:"userCacheSize,omitempty"`
 	// UserCacheTTLSeconds description: The TTL in seconds for cached user permissions